@@ -0,0 +1,20 @@
+package main
+
+// The engines available to the node register themselves on import. Engines
+// of other packages are added by importing them here.
+import (
+	_ "github.com/anthdm/consenter/pkg/consensus/algorand"
+	_ "github.com/anthdm/consenter/pkg/consensus/avalanche"
+	_ "github.com/anthdm/consenter/pkg/consensus/dag"
+	_ "github.com/anthdm/consenter/pkg/consensus/fbft"
+	_ "github.com/anthdm/consenter/pkg/consensus/honeybadger"
+	_ "github.com/anthdm/consenter/pkg/consensus/hotstuff"
+	_ "github.com/anthdm/consenter/pkg/consensus/paxos"
+	_ "github.com/anthdm/consenter/pkg/consensus/pbft"
+	_ "github.com/anthdm/consenter/pkg/consensus/poa"
+	_ "github.com/anthdm/consenter/pkg/consensus/pos"
+	_ "github.com/anthdm/consenter/pkg/consensus/pow"
+	_ "github.com/anthdm/consenter/pkg/consensus/raft"
+	_ "github.com/anthdm/consenter/pkg/consensus/solo"
+	_ "github.com/anthdm/consenter/pkg/consensus/tendermint"
+)
@@ -2,22 +2,24 @@ package main
 
 import (
 	"crypto/ecdsa"
-	"crypto/elliptic"
-	"crypto/rand"
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/anthdm/consenter/pkg/chain"
+	"github.com/anthdm/consenter/pkg/common"
 	"github.com/anthdm/consenter/pkg/consensus"
-	"github.com/anthdm/consenter/pkg/consensus/solo"
+	"github.com/anthdm/consenter/pkg/consensus/casper"
+	"github.com/anthdm/consenter/pkg/mempool"
 	"github.com/anthdm/consenter/pkg/network"
+	"github.com/anthdm/consenter/pkg/storage"
 	"github.com/urfave/cli"
 )
 
-var (
-	errMissingPrivateKey = errors.New("missing private key for consensus node")
-)
+var errMissingPrivateKey = errors.New("missing private key for consensus node")
 
 func main() {
 	ctl := cli.NewApp()
@@ -25,6 +27,7 @@ func main() {
 	ctl.Usage = "Pluggable blockchain consensus simulation framework"
 	ctl.Commands = []cli.Command{
 		newNodeCommand(),
+		newEnginesCommand(),
 	}
 	ctl.Run(os.Args)
 }
@@ -40,36 +43,77 @@ func newNodeCommand() cli.Command {
 			cli.BoolFlag{Name: "consensus"},
 			cli.StringFlag{Name: "privkey"},
 			cli.StringFlag{Name: "engine"},
+			cli.StringFlag{Name: "validators"},
+			cli.StringSliceFlag{Name: "config"},
+			cli.BoolFlag{Name: "casper"},
+			cli.StringFlag{Name: "datadir"},
+			cli.StringFlag{Name: "forkchoice", Value: "longest"},
+			cli.IntFlag{Name: "mempoolsize", Value: mempool.DefaultMaxTxs},
+			cli.StringFlag{Name: "mempoolorder", Value: "fee"},
 		},
 	}
 }
 
+func newEnginesCommand() cli.Command {
+	return cli.Command{
+		Name:   "engines",
+		Usage:  "List the available consensus engines and their config",
+		Action: listEngines,
+	}
+}
+
+func listEngines(ctx *cli.Context) error {
+	for _, f := range consensus.Factories() {
+		fmt.Printf("%s\t%s\n", f.Name, f.Description)
+		for _, field := range f.Schema() {
+			fmt.Printf("\t%s %s (default: %s)\n", field.Name, field.Type, field.Default)
+		}
+	}
+	return nil
+}
+
 func startServer(ctx *cli.Context) error {
 	var (
 		isConsensusNode = ctx.Bool("consensus")
 		privKey         *ecdsa.PrivateKey
-		err             error
 		engine          consensus.Engine
+		validators      = consensus.NewValidators(parseSeeds(ctx.String("validators")))
 	)
+	forkChoice, err := parseForkChoice(ctx.String("forkchoice"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	order, err := mempool.ParseOrder(ctx.String("mempoolorder"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
 	if isConsensusNode {
 		if len(ctx.String("privkey")) == 0 {
 			return cli.NewExitError(errMissingPrivateKey, 1)
 		}
-		// For simulation we dont need the right (bitcoin) elliptic curve points.
-		// The default P256 curve that comes with the Go stdlib will do.
-		privKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		// The private key is derived from the given seed, so that nodes can know
+		// each others public keys by their seeds (see the validators flag).
+		privKey = common.NewPrivateKey([]byte(ctx.String("privkey")))
+		if len(ctx.String("engine")) == 0 {
+			return cli.NewExitError("engine cannot be empty if running a consensus node", 1)
+		}
+		params, err := parseParams(ctx.StringSlice("config"))
 		if err != nil {
 			return cli.NewExitError(err, 1)
 		}
-		if len(ctx.String("engine")) == 0 {
-			return cli.NewExitError("engine cannot be empty if running a consensus node", 1)
+		engine, err = consensus.NewEngine(ctx.String("engine"), params, validators)
+		if err != nil {
+			return cli.NewExitError(err, 1)
 		}
-		switch ctx.String("engine") {
-		case "solo":
-			// block generation 15 seconds.
-			engine = solo.NewEngine(15 * time.Second)
-		default:
-			return cli.NewExitError("invalid engine option", 1)
+		if ctx.Bool("casper") {
+			if len(validators) == 0 {
+				return cli.NewExitError(consensus.ErrMissingValidators, 1)
+			}
+			// Checkpoints every 10 blocks.
+			engine = casper.NewEngine(engine, casper.Config{
+				Validators:  validators,
+				EpochLength: 10,
+			})
 		}
 	}
 	cfg := network.ServerConfig{
@@ -78,11 +122,56 @@ func startServer(ctx *cli.Context) error {
 		BootstrapNodes: parseSeeds(ctx.String("seed")),
 		Consensus:      isConsensusNode,
 		PrivateKey:     privKey,
+		Validators:     validators,
+		ForkChoice:     forkChoice,
+		Mempool: mempool.Config{
+			MaxTxs: ctx.Int("mempoolsize"),
+			Order:  order,
+		},
+	}
+	// The chain is persisted to the data directory, if any, so the node can
+	// resume from its last block.
+	if dir := ctx.String("datadir"); len(dir) > 0 {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		store, err := storage.NewFileStore(filepath.Join(dir, "chain.db"))
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		defer store.Close()
+		cfg.Store = store
 	}
 	srv := network.NewServer(cfg, engine)
 	return cli.NewExitError(srv.Start(), 1)
 }
 
+func parseForkChoice(name string) (chain.ForkChoice, error) {
+	switch name {
+	case "longest":
+		return chain.LongestChain{}, nil
+	case "work":
+		return chain.HeaviestWork{}, nil
+	case "ghost":
+		return chain.GHOST{}, nil
+	default:
+		return nil, fmt.Errorf("invalid fork choice option %s", name)
+	}
+}
+
+// parseParams parses the engine config given as key=value pairs.
+func parseParams(pairs []string) (map[string]string, error) {
+	params := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid engine config %s, expected key=value", pair)
+		}
+		params[parts[0]] = parts[1]
+	}
+	return params, nil
+}
+
 func parseSeeds(str string) []string {
 	if len(str) == 0 {
 		return nil
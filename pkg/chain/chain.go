@@ -0,0 +1,426 @@
+package chain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/anthdm/consenter/pkg/storage"
+	proto "github.com/golang/protobuf/proto"
+)
+
+var (
+	errMissingHeader = errors.New("block without header")
+	errUnknownParent = errors.New("unknown parent")
+	errUnknownBlock  = errors.New("unknown block")
+)
+
+// Prefixes of the keys in the store.
+var (
+	headKey      = []byte("head")
+	blockPrefix  = []byte("b")
+	heightPrefix = []byte("h")
+)
+
+// Reorg describes a switch of the head to another branch of the tree.
+type Reorg struct {
+	// Blocks removed from the chain, from the old head down to the common
+	// ancestor of both heads.
+	Detached []*pb.Block
+	// Blocks added to the chain, from the common ancestor up to the new
+	// head.
+	Attached []*pb.Block
+}
+
+// Orphaned returns the transactions of the detached blocks that are not
+// part of the attached blocks, which are pending again.
+func (r *Reorg) Orphaned() []*pb.Transaction {
+	attached := make(map[string]bool)
+	for _, b := range r.Attached {
+		for _, tx := range b.Transactions {
+			attached[string(tx.Hash())] = true
+		}
+	}
+	var orphaned []*pb.Transaction
+	for _, b := range r.Detached {
+		for _, tx := range b.Transactions {
+			if !attached[string(tx.Hash())] {
+				orphaned = append(orphaned, tx)
+			}
+		}
+	}
+	return orphaned
+}
+
+// Chain is an ordered chain of blocks persisted to a store. Blocks are
+// identified by the hash of their header. Competing blocks are kept in a
+// tree, the fork choice rule selects the branch of the tree the chain
+// follows. Finalizing a block prunes the tree down to the descendants of the
+// block, hence finalized blocks are never reverted. The chain is resumed from
+// the store, hence a node that restarts continues at its last block.
+// Competing branches and finality are not resumed.
+type Chain struct {
+	store storage.Store
+	rule  ForkChoice
+
+	lock sync.RWMutex
+	tree *tree
+	// Block of the head of the tree, nil if the chain is empty.
+	head *pb.Block
+}
+
+// New returns the chain persisted to the given store, which is empty if the
+// store holds no chain. The chain follows the longest chain if no fork
+// choice rule is given.
+func New(store storage.Store, rule ForkChoice) (*Chain, error) {
+	if rule == nil {
+		rule = LongestChain{}
+	}
+	c := &Chain{
+		store: store,
+		rule:  rule,
+		tree:  newTree(),
+	}
+	if !store.Has(headKey) {
+		return c, nil
+	}
+	hash, err := store.Get(headKey)
+	if err != nil {
+		return nil, err
+	}
+	head, err := c.getBlock(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load head of the chain: %s", err)
+	}
+	if err := c.resume(head); err != nil {
+		return nil, err
+	}
+	c.head = head
+	return c, nil
+}
+
+// resume rebuilds the chain from the head down. Blocks are followed by their
+// PrevHash, by the height index if they have none. Entries of the height
+// index that do not match the chain, left by a crash during a reorg, are
+// repaired.
+func (c *Chain) resume(head *pb.Block) error {
+	chain := []*pb.Block{head}
+	for b := head; b.Header.Index > 1; {
+		var (
+			parent *pb.Block
+			err    error
+		)
+		if len(b.Header.PrevHash) > 0 {
+			parent, err = c.getBlock(b.Header.PrevHash)
+		} else {
+			parent, err = c.getBlockByHeight(b.Header.Index - 1)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load block %d: %s", b.Header.Index-1, err)
+		}
+		if parent.Header.Index != b.Header.Index-1 {
+			return fmt.Errorf("block %d does not follow its parent %d",
+				b.Header.Index, parent.Header.Index)
+		}
+		chain = append(chain, parent)
+		b = parent
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		h := chain[i].Header
+		c.tree.insert(h, c.tree.head)
+		c.tree.head = c.tree.nodes[string(h.Hash())]
+		hash, err := c.store.Get(heightKey(h.Index))
+		if err != nil || !bytes.Equal(hash, c.tree.head.Hash) {
+			if err := c.store.Put(heightKey(h.Index), c.tree.head.Hash); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Head returns the last block of the chain, nil if the chain is empty.
+func (c *Chain) Head() *pb.Block {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.head
+}
+
+// Height returns the index of the last block of the chain, 0 if the chain
+// is empty.
+func (c *Chain) Height() uint32 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.tree.head.Height()
+}
+
+// Add adds the block to the tree and returns the reorg if the fork choice
+// rule switches to another branch because of it. The parent of the block is
+// referred to by its PrevHash. Engines that do not link their blocks leave it
+// empty, those blocks extend the head. The blocks with index 1 are the
+// children of the genesis of the engine, which is not part of the chain.
+// Adding a block of the tree is a no-op.
+func (c *Chain) Add(b *pb.Block) (*Reorg, error) {
+	if b == nil || b.Header == nil {
+		return nil, errMissingHeader
+	}
+	hash := b.Header.Hash()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, ok := c.tree.nodes[string(hash)]; ok {
+		return nil, nil
+	}
+	parent, err := c.parent(b.Header)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := proto.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.store.Put(blockKey(hash), buf); err != nil {
+		return nil, err
+	}
+	c.tree.insert(b.Header, parent)
+	return c.updateHead()
+}
+
+// Finalize marks the block with the given hash as finalized. The block
+// becomes the root of the tree, branches that do not descend from it are
+// pruned and blocks following them are rejected. Blocks conflicting with the
+// previously finalized block are already pruned and unknown to the tree. It
+// returns the reorg if the head switches to another branch because of it.
+func (c *Chain) Finalize(hash []byte) (*Reorg, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	n, ok := c.tree.nodes[string(hash)]
+	if !ok {
+		return nil, errUnknownBlock
+	}
+	c.tree.root = n
+	reorg, err := c.updateHead()
+	// The old head is needed to find the detached blocks of the reorg.
+	c.tree.prune()
+	return reorg, err
+}
+
+// GetBlockByHash returns the block with the given header hash, which might
+// be part of a competing branch.
+func (c *Chain) GetBlockByHash(hash []byte) (*pb.Block, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.getBlock(hash)
+}
+
+// GetBlockByHeight returns the block of the chain with the given index.
+func (c *Chain) GetBlockByHeight(height uint32) (*pb.Block, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if height == 0 || height > c.tree.head.Height() {
+		return nil, storage.ErrNotFound
+	}
+	return c.getBlockByHeight(height)
+}
+
+// HasBlock reports whether the block with the given header hash is stored,
+// either as part of the chain or of a competing branch.
+func (c *Chain) HasBlock(hash []byte) bool {
+	return c.store.Has(blockKey(hash))
+}
+
+// parent returns the node of the parent of the block.
+func (c *Chain) parent(h *pb.Header) (*Node, error) {
+	var parent *Node
+	switch {
+	case h.Index == 1 && c.tree.root.Height() == 0:
+		parent = c.tree.root
+	case len(h.PrevHash) > 0:
+		parent = c.tree.nodes[string(h.PrevHash)]
+	default:
+		parent = c.tree.head
+	}
+	if parent == nil {
+		return nil, fmt.Errorf("block %d: %s", h.Index, errUnknownParent)
+	}
+	if h.Index != parent.Height()+1 {
+		return nil, fmt.Errorf("block %d does not follow its parent %d", h.Index, parent.Height())
+	}
+	return parent, nil
+}
+
+// updateHead applies the fork choice rule and moves the chain to the new
+// head. The lock needs to be held by the caller.
+func (c *Chain) updateHead() (*Reorg, error) {
+	var (
+		old  = c.tree.head
+		head = c.rule.Head(c.tree)
+	)
+	if head == old {
+		return nil, nil
+	}
+	var detached, attached []*Node
+	for a, b := old, head; a != b; {
+		if a.Height() >= b.Height() {
+			detached = append(detached, a)
+			a = a.Parent
+		} else {
+			attached = append(attached, b)
+			b = b.Parent
+		}
+	}
+	reorg := &Reorg{}
+	for _, n := range detached {
+		b, err := c.getBlock(n.Hash)
+		if err != nil {
+			return nil, err
+		}
+		reorg.Detached = append(reorg.Detached, b)
+	}
+	// The height index is written before the head, a crash in between
+	// leaves the chain at its old head, see resume.
+	for i := len(attached) - 1; i >= 0; i-- {
+		n := attached[i]
+		b, err := c.getBlock(n.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.store.Put(heightKey(n.Height()), n.Hash); err != nil {
+			return nil, err
+		}
+		reorg.Attached = append(reorg.Attached, b)
+	}
+	switch {
+	case head.Height() == 0:
+		c.head = nil
+	case len(attached) > 0:
+		c.head = reorg.Attached[len(reorg.Attached)-1]
+	default:
+		// The head moved back to one of its ancestors.
+		b, err := c.getBlock(head.Hash)
+		if err != nil {
+			return nil, err
+		}
+		c.head = b
+	}
+	if err := c.store.Put(headKey, head.Hash); err != nil {
+		return nil, err
+	}
+	c.tree.head = head
+	if len(detached) == 0 {
+		return nil, nil
+	}
+	return reorg, nil
+}
+
+func (c *Chain) getBlockByHeight(height uint32) (*pb.Block, error) {
+	hash, err := c.store.Get(heightKey(height))
+	if err != nil {
+		return nil, err
+	}
+	return c.getBlock(hash)
+}
+
+func (c *Chain) getBlock(hash []byte) (*pb.Block, error) {
+	buf, err := c.store.Get(blockKey(hash))
+	if err != nil {
+		return nil, err
+	}
+	b := &pb.Block{}
+	if err := proto.Unmarshal(buf, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// tree implements the Tree interface. Its root is the last finalized block,
+// the genesis of the engine until a block is finalized. The genesis is
+// represented by an empty header.
+type tree struct {
+	root  *Node
+	head  *Node
+	nodes map[string]*Node
+	// Blocks without children, in the order they were added.
+	tips []*Node
+}
+
+func newTree() *tree {
+	root := &Node{Header: &pb.Header{}, Weight: 1}
+	return &tree{
+		root:  root,
+		head:  root,
+		nodes: make(map[string]*Node),
+		tips:  []*Node{root},
+	}
+}
+
+func (t *tree) Root() *Node { return t.root }
+func (t *tree) Head() *Node { return t.head }
+
+func (t *tree) Tips() []*Node { return t.tips }
+
+// insert adds the header as child of the parent. The weights of the
+// ancestors are only updated up to the root.
+func (t *tree) insert(h *pb.Header, parent *Node) {
+	work := h.Difficulty
+	if work == 0 {
+		work = 1
+	}
+	n := &Node{
+		Hash:   h.Hash(),
+		Header: h,
+		Parent: parent,
+		Work:   parent.Work + work,
+		Weight: 1,
+	}
+	if n.Work < parent.Work {
+		n.Work = math.MaxUint64
+	}
+	parent.Children = append(parent.Children, n)
+	for a := parent; a != nil; a = a.Parent {
+		a.Weight++
+		if a == t.root {
+			break
+		}
+	}
+	for i, tip := range t.tips {
+		if tip == parent {
+			t.tips = append(t.tips[:i], t.tips[i+1:]...)
+			break
+		}
+	}
+	t.tips = append(t.tips, n)
+	t.nodes[string(n.Hash)] = n
+}
+
+// prune removes the blocks that do not descend from the root from the tree.
+// The blocks stay in the store.
+func (t *tree) prune() {
+	for hash, n := range t.nodes {
+		if !t.root.IsAncestorOf(n) {
+			delete(t.nodes, hash)
+		}
+	}
+	tips := t.tips[:0]
+	for _, tip := range t.tips {
+		if t.root.IsAncestorOf(tip) {
+			tips = append(tips, tip)
+		}
+	}
+	t.tips = tips
+	t.root.Parent = nil
+}
+
+func blockKey(hash []byte) []byte {
+	return append(append([]byte{}, blockPrefix...), hash...)
+}
+
+func heightKey(height uint32) []byte {
+	key := make([]byte, len(heightPrefix)+4)
+	copy(key, heightPrefix)
+	binary.BigEndian.PutUint32(key[len(heightPrefix):], height)
+	return key
+}
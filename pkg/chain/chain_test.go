@@ -0,0 +1,102 @@
+package chain
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/anthdm/consenter/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestBlocks returns n blocks linked to each other.
+func newTestBlocks(n int) []*pb.Block {
+	blocks := make([]*pb.Block, n)
+	for i := range blocks {
+		blocks[i] = pb.NewBlock(uint32(i))
+		if i > 0 {
+			blocks[i].Header.PrevHash = blocks[i-1].Header.Hash()
+		}
+	}
+	return blocks
+}
+
+func TestChainAdd(t *testing.T) {
+	c, err := New(storage.NewMemStore(), nil)
+	assert.Nil(t, err)
+	assert.Nil(t, c.Head())
+	assert.Equal(t, uint32(0), c.Height())
+
+	blocks := newTestBlocks(10)
+	// Blocks need to follow the head.
+	_, err = c.Add(blocks[1])
+	assert.NotNil(t, err)
+	for _, b := range blocks {
+		_, err = c.Add(b)
+		assert.Nil(t, err)
+	}
+	_, err = c.Add(blocks[5])
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(10), c.Height())
+	assert.Equal(t, blocks[9], c.Head())
+
+	for _, b := range blocks {
+		found, err := c.GetBlockByHeight(b.Header.Index)
+		assert.Nil(t, err)
+		assert.Equal(t, b.Header.Hash(), found.Header.Hash())
+
+		found, err = c.GetBlockByHash(b.Header.Hash())
+		assert.Nil(t, err)
+		assert.Equal(t, b.Header.Index, found.Header.Index)
+		assert.True(t, c.HasBlock(b.Header.Hash()))
+	}
+	_, err = c.GetBlockByHeight(11)
+	assert.Equal(t, storage.ErrNotFound, err)
+}
+
+func TestChainAddInvalidLink(t *testing.T) {
+	c, err := New(storage.NewMemStore(), nil)
+	assert.Nil(t, err)
+	blocks := newTestBlocks(2)
+	_, err = c.Add(blocks[0])
+	assert.Nil(t, err)
+
+	b := pb.NewBlock(1)
+	b.Header.PrevHash = []byte("unknown")
+	_, err = c.Add(b)
+	assert.NotNil(t, err)
+
+	// Engines that do not link their blocks leave the PrevHash empty.
+	_, err = c.Add(pb.NewBlock(1))
+	assert.Nil(t, err)
+}
+
+func TestChainResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chain")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "chain.db")
+
+	store, err := storage.NewFileStore(path)
+	assert.Nil(t, err)
+	c, err := New(store, nil)
+	assert.Nil(t, err)
+	blocks := newTestBlocks(5)
+	for _, b := range blocks {
+		_, err = c.Add(b)
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, store.Close())
+
+	store, err = storage.NewFileStore(path)
+	assert.Nil(t, err)
+	defer store.Close()
+	c, err = New(store, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(5), c.Height())
+	assert.Equal(t, blocks[4].Header.Hash(), c.Head().Header.Hash())
+	_, err = c.Add(pb.NewBlock(5))
+	assert.Nil(t, err)
+}
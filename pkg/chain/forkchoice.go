@@ -0,0 +1,112 @@
+package chain
+
+import pb "github.com/anthdm/consenter/pkg/protos"
+
+// Node is a block in the block tree.
+type Node struct {
+	Hash   []byte
+	Header *pb.Header
+	Parent *Node
+	// Children in the order they were added.
+	Children []*Node
+	// Total work of the branch up to and including the block, which is the
+	// sum of the difficulties of its blocks. Blocks without difficulty count
+	// as 1. The sum saturates at the maximum uint64.
+	Work uint64
+	// Amount of blocks in the subtree of the block, including itself.
+	// Blocks of pruned branches are not counted.
+	Weight uint64
+}
+
+// Height returns the index of the block.
+func (n *Node) Height() uint32 {
+	return n.Header.Index
+}
+
+// IsAncestorOf reports whether the block is an ancestor of d or d itself.
+func (n *Node) IsAncestorOf(d *Node) bool {
+	for d != nil && d.Height() > n.Height() {
+		d = d.Parent
+	}
+	return d == n
+}
+
+// Tree is the view of the block tree fork choice rules select the head from.
+type Tree interface {
+	// Root returns the block every candidate for the head descends from,
+	// which is the last finalized block or the genesis.
+	Root() *Node
+	// Head returns the current head.
+	Head() *Node
+	// Tips returns the blocks of the tree without children.
+	Tips() []*Node
+}
+
+// ForkChoice selects the head of the chain among the blocks of the tree.
+type ForkChoice interface {
+	Head(Tree) *Node
+}
+
+// LongestChain follows the branch with the most blocks.
+type LongestChain struct{}
+
+// Head implements the ForkChoice interface.
+func (LongestChain) Head(t Tree) *Node {
+	return heaviestTip(t, func(n *Node) uint64 {
+		return uint64(n.Height())
+	})
+}
+
+// HeaviestWork follows the branch with the most total work, as in proof of
+// work. Without difficulties it follows the longest chain. The difficulties
+// are claimed by the blocks, the rule is only sound if each of them is
+// validated before the block is added, see consensus.DifficultyValidator.
+type HeaviestWork struct{}
+
+// Head implements the ForkChoice interface.
+func (HeaviestWork) Head(t Tree) *Node {
+	return heaviestTip(t, func(n *Node) uint64 {
+		return n.Work
+	})
+}
+
+// heaviestTip returns the tip with the highest score. The current head is
+// kept on a tie, so the head only switches to branches that are better.
+func heaviestTip(t Tree, score func(*Node) uint64) *Node {
+	var (
+		root = t.Root()
+		best = root
+	)
+	if head := t.Head(); root.IsAncestorOf(head) {
+		best = head
+	}
+	for _, tip := range t.Tips() {
+		if score(tip) > score(best) && root.IsAncestorOf(tip) {
+			best = tip
+		}
+	}
+	return best
+}
+
+// GHOST (greedy heaviest observed subtree) descends from the root into the
+// child with the most blocks in its subtree, hence blocks of competing
+// branches still count towards the branch they build upon.
+type GHOST struct{}
+
+// Head implements the ForkChoice interface.
+func (GHOST) Head(t Tree) *Node {
+	var (
+		head = t.Head()
+		n    = t.Root()
+	)
+	for len(n.Children) > 0 {
+		best := n.Children[0]
+		for _, c := range n.Children[1:] {
+			if c.Weight > best.Weight || (c.Weight == best.Weight && c.IsAncestorOf(head)) {
+				best = c
+			}
+		}
+		n = best
+	}
+	return n
+}
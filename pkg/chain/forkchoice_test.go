@@ -0,0 +1,194 @@
+package chain
+
+import (
+	"math"
+	"testing"
+
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/anthdm/consenter/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// newChild returns a block with a single transaction following the parent,
+// the genesis if the parent is nil.
+func newChild(parent *pb.Block, difficulty uint64) *pb.Block {
+	var b *pb.Block
+	if parent == nil {
+		b = pb.NewBlock(0)
+	} else {
+		b = pb.NewBlock(parent.Header.Index)
+		b.Header.PrevHash = parent.Header.Hash()
+	}
+	b.Header.Difficulty = difficulty
+	b.Transactions = []*pb.Transaction{pb.NewTransaction()}
+	return b
+}
+
+// newBranch returns n blocks following the parent.
+func newBranch(parent *pb.Block, n int, difficulty uint64) []*pb.Block {
+	branch := make([]*pb.Block, n)
+	for i := range branch {
+		branch[i] = newChild(parent, difficulty)
+		parent = branch[i]
+	}
+	return branch
+}
+
+func newTestChain(t *testing.T, rule ForkChoice) *Chain {
+	c, err := New(storage.NewMemStore(), rule)
+	assert.Nil(t, err)
+	return c
+}
+
+func addBlocks(t *testing.T, c *Chain, blocks []*pb.Block) *Reorg {
+	var reorg *Reorg
+	for _, b := range blocks {
+		r, err := c.Add(b)
+		assert.Nil(t, err)
+		if r != nil {
+			reorg = r
+		}
+	}
+	return reorg
+}
+
+func TestLongestChainReorg(t *testing.T) {
+	var (
+		c      = newTestChain(t, LongestChain{})
+		a      = newBranch(nil, 3, 0)
+		b      = newBranch(a[0], 3, 0)
+		shared = b[1].Transactions[0]
+	)
+	// The tx of the 2nd block of the old branch is part of the new branch.
+	a[1].Transactions = append(a[1].Transactions, shared)
+	assert.Nil(t, addBlocks(t, c, a))
+
+	// Branches of equal length do not switch the head.
+	assert.Nil(t, addBlocks(t, c, b[:2]))
+	assert.Equal(t, a[2].Header.Hash(), c.Head().Header.Hash())
+
+	reorg := addBlocks(t, c, b[2:])
+	assert.NotNil(t, reorg)
+	assert.Equal(t, b[2].Header.Hash(), c.Head().Header.Hash())
+	assert.Equal(t, 2, len(reorg.Detached))
+	assert.Equal(t, a[2].Header.Hash(), reorg.Detached[0].Header.Hash())
+	assert.Equal(t, 3, len(reorg.Attached))
+	assert.Equal(t, b[0].Header.Hash(), reorg.Attached[0].Header.Hash())
+	assert.Equal(t, []*pb.Transaction{a[2].Transactions[0], a[1].Transactions[0]}, reorg.Orphaned())
+
+	for i, blk := range append([]*pb.Block{a[0]}, b...) {
+		found, err := c.GetBlockByHeight(uint32(i + 1))
+		assert.Nil(t, err)
+		assert.Equal(t, blk.Header.Hash(), found.Header.Hash())
+	}
+}
+
+func TestHeaviestWork(t *testing.T) {
+	var (
+		c = newTestChain(t, HeaviestWork{})
+		a = newBranch(nil, 3, 1)
+		b = newBranch(nil, 2, 10)
+	)
+	addBlocks(t, c, a)
+	assert.NotNil(t, addBlocks(t, c, b))
+	assert.Equal(t, b[1].Header.Hash(), c.Head().Header.Hash())
+}
+
+func TestGHOST(t *testing.T) {
+	var (
+		longest = newTestChain(t, LongestChain{})
+		ghost   = newTestChain(t, GHOST{})
+		first   = newChild(nil, 0)
+		// A subtree of 4 blocks with a height of 3 against a chain of 3
+		// blocks with a height of 4.
+		uncle = newChild(first, 0)
+		heavy = []*pb.Block{uncle, newChild(uncle, 0), newChild(uncle, 0), newChild(uncle, 0)}
+		long  = newBranch(first, 3, 0)
+	)
+	for _, c := range []*Chain{longest, ghost} {
+		addBlocks(t, c, []*pb.Block{first})
+		addBlocks(t, c, heavy)
+		addBlocks(t, c, long)
+	}
+	assert.Equal(t, long[2].Header.Hash(), longest.Head().Header.Hash())
+	assert.Equal(t, heavy[1].Header.Hash(), ghost.Head().Header.Hash())
+}
+
+func TestFinalize(t *testing.T) {
+	var (
+		c = newTestChain(t, LongestChain{})
+		a = newBranch(nil, 2, 0)
+		b = newBranch(a[0], 3, 0)
+	)
+	addBlocks(t, c, a)
+	addBlocks(t, c, b[:1])
+	_, err := c.Finalize(a[1].Header.Hash())
+	assert.Nil(t, err)
+
+	// The branch forking off below the finalized block is pruned, the
+	// longer branch would revert it.
+	for _, blk := range b[1:] {
+		_, err = c.Add(blk)
+		assert.NotNil(t, err)
+	}
+	assert.Equal(t, a[1].Header.Hash(), c.Head().Header.Hash())
+
+	_, err = c.Finalize(b[0].Header.Hash())
+	assert.Equal(t, errUnknownBlock, err)
+	_, err = c.Finalize([]byte("unknown"))
+	assert.Equal(t, errUnknownBlock, err)
+}
+
+func TestFinalizeReorg(t *testing.T) {
+	var (
+		c = newTestChain(t, LongestChain{})
+		a = newBranch(nil, 3, 0)
+		b = newBranch(nil, 2, 0)
+	)
+	addBlocks(t, c, a)
+	addBlocks(t, c, b)
+	// Finalizing a block of the shorter branch switches to it.
+	reorg, err := c.Finalize(b[1].Header.Hash())
+	assert.Nil(t, err)
+	assert.NotNil(t, reorg)
+	assert.Equal(t, 3, len(reorg.Detached))
+	assert.Equal(t, b[1].Header.Hash(), c.Head().Header.Hash())
+}
+
+func TestFinalizePrune(t *testing.T) {
+	var (
+		c     = newTestChain(t, GHOST{})
+		a     = newBranch(nil, 3, 0)
+		b     = newBranch(a[0], 2, 0)
+		uncle = newChild(a[1], 0)
+	)
+	addBlocks(t, c, a)
+	addBlocks(t, c, b)
+	addBlocks(t, c, []*pb.Block{uncle})
+	_, err := c.Finalize(a[1].Header.Hash())
+	assert.Nil(t, err)
+
+	// Only the descendants of the finalized block are left in the tree, and
+	// only their weight counts.
+	assert.Equal(t, 3, len(c.tree.nodes))
+	assert.Equal(t, 2, len(c.tree.Tips()))
+	assert.Equal(t, uint64(3), c.tree.Root().Weight)
+	addBlocks(t, c, []*pb.Block{newChild(uncle, 0)})
+	assert.Equal(t, uint64(4), c.tree.Root().Weight)
+	assert.Nil(t, c.tree.Root().Parent)
+
+	// Blocks of the old head are still stored.
+	found, err := c.GetBlockByHeight(1)
+	assert.Nil(t, err)
+	assert.Equal(t, a[0].Header.Hash(), found.Header.Hash())
+}
+
+func TestHeaviestWorkSaturates(t *testing.T) {
+	var (
+		c = newTestChain(t, HeaviestWork{})
+		a = newBranch(nil, 3, math.MaxUint64/2)
+	)
+	addBlocks(t, c, a)
+	assert.Equal(t, uint64(math.MaxUint64), c.tree.Head().Work)
+	assert.Equal(t, a[2].Header.Hash(), c.Head().Header.Hash())
+}
@@ -28,9 +28,11 @@ func EncodeProto(w io.Writer, msg *pb.Message) error {
 	if err != nil {
 		return err
 	}
-	buf := make([]byte, 4)
+	// The frame is written at once, so a failing write of the length prefix
+	// can not be followed by a message without one.
+	buf := make([]byte, 4+len(b))
 	binary.LittleEndian.PutUint32(buf, uint32(len(b)))
+	copy(buf[4:], b)
 	_, err = w.Write(buf)
-	_, err = w.Write(b)
 	return err
 }
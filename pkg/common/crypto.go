@@ -0,0 +1,130 @@
+package common
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+)
+
+var (
+	errInvalidPublicKey = errors.New("invalid public key")
+	errCiphertextShort  = errors.New("ciphertext too short")
+)
+
+// Curve is the elliptic curve used for all keys in the simulation. For
+// simulation we dont need the right (bitcoin) elliptic curve points, the
+// default P256 curve that comes with the Go stdlib will do.
+var Curve = elliptic.P256()
+
+// NewPrivateKey deterministically derives a private key from the given seed.
+// This makes it possible to bootstrap a set of consensus nodes that know each
+// others public keys upfront, which is all we need for simulation.
+func NewPrivateKey(seed []byte) *ecdsa.PrivateKey {
+	var (
+		n = new(big.Int).Sub(Curve.Params().N, big.NewInt(1))
+		d = new(big.Int).SetBytes(Hash256(seed))
+	)
+	d.Mod(d, n)
+	d.Add(d, big.NewInt(1))
+
+	priv := &ecdsa.PrivateKey{D: d}
+	priv.PublicKey.Curve = Curve
+	priv.PublicKey.X, priv.PublicKey.Y = Curve.ScalarBaseMult(padBytes(d.Bytes(), 32))
+	return priv
+}
+
+// MarshalPublicKey encodes the given public key into its uncompressed form.
+func MarshalPublicKey(pub *ecdsa.PublicKey) []byte {
+	return elliptic.Marshal(Curve, pub.X, pub.Y)
+}
+
+// UnmarshalPublicKey decodes a public key encoded with MarshalPublicKey.
+func UnmarshalPublicKey(b []byte) (*ecdsa.PublicKey, error) {
+	x, y := elliptic.Unmarshal(Curve, b)
+	if x == nil {
+		return nil, errInvalidPublicKey
+	}
+	return &ecdsa.PublicKey{Curve: Curve, X: x, Y: y}, nil
+}
+
+// Sign signs the given hash with priv. The signature is returned as the
+// fixed size concatenation of r and s.
+func Sign(priv *ecdsa.PrivateKey, hash []byte) ([]byte, error) {
+	r, s, err := ecdsa.Sign(rand.Reader, priv, hash)
+	if err != nil {
+		return nil, err
+	}
+	sig := make([]byte, 64)
+	copy(sig[:32], padBytes(r.Bytes(), 32))
+	copy(sig[32:], padBytes(s.Bytes(), 32))
+	return sig, nil
+}
+
+// Verify reports whether sig is a valid signature of hash by pub.
+func Verify(pub *ecdsa.PublicKey, hash, sig []byte) bool {
+	if pub == nil || len(sig) != 64 {
+		return false
+	}
+	var (
+		r = new(big.Int).SetBytes(sig[:32])
+		s = new(big.Int).SetBytes(sig[32:])
+	)
+	return ecdsa.Verify(pub, hash, r, s)
+}
+
+// SharedSecret computes the ECDH shared secret between priv and pub, hashed
+// so it can be used directly as a symmetric key.
+func SharedSecret(priv *ecdsa.PrivateKey, pub *ecdsa.PublicKey) []byte {
+	x, _ := Curve.ScalarMult(pub.X, pub.Y, padBytes(priv.D.Bytes(), 32))
+	return Hash256(padBytes(x.Bytes(), 32))[:32]
+}
+
+// Encrypt encrypts plaintext with AES-GCM using the given 32 byte key. The
+// random nonce is prepended to the returned ciphertext.
+func Encrypt(key, plaintext []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Decrypt decrypts a ciphertext produced by Encrypt.
+func Decrypt(key, ciphertext []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errCiphertextShort
+	}
+	nonce := ciphertext[:aead.NonceSize()]
+	return aead.Open(nil, nonce, ciphertext[aead.NonceSize():], nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// padBytes left pads b with zeros up to size.
+func padBytes(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	buf := make([]byte, size)
+	copy(buf[size-len(b):], b)
+	return buf
+}
@@ -0,0 +1,115 @@
+package common
+
+import (
+	"bytes"
+	"errors"
+)
+
+var errLeafIndex = errors.New("merkle leaf index out of range")
+
+// Prefixes of the hashed leaves and inner nodes of a Merkle tree, which
+// prevent an inner node from being passed off as a leaf.
+const (
+	merkleLeaf  byte = 0x00
+	merkleInner byte = 0x01
+)
+
+// MerkleProof proves that a leaf is part of the Merkle tree with a given
+// root.
+type MerkleProof struct {
+	// Index of the leaf and the amount of leaves of the tree.
+	Index int
+	Size  int
+	// Hashes of the siblings on the path from the leaf up to the root.
+	Hashes [][]byte
+}
+
+// MerkleRoot computes the root of the Merkle tree of the given leaves, which
+// is nil if there are none. Nodes are hashed with Hash256 in pairs, a node
+// without sibling moves up to the next level as is.
+func MerkleRoot(leaves [][]byte) []byte {
+	if len(leaves) == 0 {
+		return nil
+	}
+	level := hashLeaves(leaves)
+	for len(level) > 1 {
+		level = nextLevel(level)
+	}
+	return level[0]
+}
+
+// NewMerkleProof returns the proof that the leaf at the given index is part
+// of the Merkle tree of the leaves.
+func NewMerkleProof(leaves [][]byte, index int) (*MerkleProof, error) {
+	if index < 0 || index >= len(leaves) {
+		return nil, errLeafIndex
+	}
+	proof := &MerkleProof{
+		Index: index,
+		Size:  len(leaves),
+	}
+	level := hashLeaves(leaves)
+	for i := index; len(level) > 1; i /= 2 {
+		if sibling := i ^ 1; sibling < len(level) {
+			proof.Hashes = append(proof.Hashes, level[sibling])
+		}
+		level = nextLevel(level)
+	}
+	return proof, nil
+}
+
+// VerifyMerkleProof reports whether the proof proves that the leaf is part of
+// the Merkle tree with the given root.
+func VerifyMerkleProof(root, leaf []byte, proof *MerkleProof) bool {
+	if proof == nil || proof.Index < 0 || proof.Index >= proof.Size {
+		return false
+	}
+	var (
+		hash   = hashNode(merkleLeaf, leaf)
+		hashes = proof.Hashes
+	)
+	for i, n := proof.Index, proof.Size; n > 1; i, n = i/2, (n+1)/2 {
+		if i^1 >= n {
+			// The node has no sibling and moves up as is.
+			continue
+		}
+		if len(hashes) == 0 {
+			return false
+		}
+		if i%2 == 0 {
+			hash = hashNode(merkleInner, hash, hashes[0])
+		} else {
+			hash = hashNode(merkleInner, hashes[0], hash)
+		}
+		hashes = hashes[1:]
+	}
+	return len(hashes) == 0 && bytes.Equal(hash, root)
+}
+
+func hashLeaves(leaves [][]byte) [][]byte {
+	level := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		level[i] = hashNode(merkleLeaf, leaf)
+	}
+	return level
+}
+
+func nextLevel(level [][]byte) [][]byte {
+	next := make([][]byte, 0, (len(level)+1)/2)
+	for i := 0; i < len(level); i += 2 {
+		if i+1 == len(level) {
+			next = append(next, level[i])
+		} else {
+			next = append(next, hashNode(merkleInner, level[i], level[i+1]))
+		}
+	}
+	return next
+}
+
+func hashNode(prefix byte, parts ...[]byte) []byte {
+	b := []byte{prefix}
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return Hash256(b)
+}
@@ -0,0 +1,70 @@
+package common
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testLeaves(n int) [][]byte {
+	leaves := make([][]byte, n)
+	for i := range leaves {
+		leaves[i] = []byte(fmt.Sprintf("leaf %d", i))
+	}
+	return leaves
+}
+
+func TestMerkleRoot(t *testing.T) {
+	assert.Nil(t, MerkleRoot(nil))
+
+	leaves := testLeaves(5)
+	root := MerkleRoot(leaves)
+	assert.Equal(t, root, MerkleRoot(testLeaves(5)))
+	assert.NotEqual(t, root, MerkleRoot(leaves[:4]))
+
+	// Swapping leaves changes the root.
+	leaves[0], leaves[1] = leaves[1], leaves[0]
+	assert.NotEqual(t, root, MerkleRoot(leaves))
+}
+
+func TestMerkleProof(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		var (
+			leaves = testLeaves(n)
+			root   = MerkleRoot(leaves)
+		)
+		for i, leaf := range leaves {
+			proof, err := NewMerkleProof(leaves, i)
+			assert.Nil(t, err)
+			assert.True(t, VerifyMerkleProof(root, leaf, proof), "leaf %d of %d", i, n)
+			assert.False(t, VerifyMerkleProof(root, []byte("other"), proof))
+		}
+	}
+	_, err := NewMerkleProof(testLeaves(3), 3)
+	assert.Equal(t, errLeafIndex, err)
+}
+
+func TestMerkleProofTampered(t *testing.T) {
+	var (
+		leaves = testLeaves(6)
+		root   = MerkleRoot(leaves)
+	)
+	proof, err := NewMerkleProof(leaves, 2)
+	assert.Nil(t, err)
+
+	proof.Index = 3
+	assert.False(t, VerifyMerkleProof(root, leaves[2], proof))
+	proof.Index = 2
+	proof.Hashes = proof.Hashes[1:]
+	assert.False(t, VerifyMerkleProof(root, leaves[2], proof))
+	assert.False(t, VerifyMerkleProof(root, leaves[2], nil))
+
+	// An inner node can not be passed off as a leaf.
+	inner := hashNode(merkleInner, hashNode(merkleLeaf, leaves[0]), hashNode(merkleLeaf, leaves[1]))
+	proof, err = NewMerkleProof(leaves, 0)
+	assert.Nil(t, err)
+	proof.Size = 3
+	proof.Hashes = proof.Hashes[1:]
+	assert.False(t, VerifyMerkleProof(root, inner, proof))
+}
@@ -0,0 +1,92 @@
+package common
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+// ShareSize is the size in bytes of an encoded secret share.
+const ShareSize = 32
+
+var errInvalidThreshold = errors.New("invalid secret sharing threshold")
+
+// fieldPrime is the prime of the finite field all secret sharing arithmetic
+// happens in. We reuse the order of the curve which is a 256 bit prime.
+var fieldPrime = Curve.Params().N
+
+// Share is a single point (X, Y) on a secret sharing polynomial.
+type Share struct {
+	X int
+	Y *big.Int
+}
+
+// Bytes returns the fixed size encoding of the share its Y coordinate.
+func (s Share) Bytes() []byte {
+	return padBytes(s.Y.Bytes(), ShareSize)
+}
+
+// NewSecret returns a random secret that can be split with SplitSecret.
+func NewSecret() (*big.Int, error) {
+	return rand.Int(rand.Reader, fieldPrime)
+}
+
+// SplitSecret splits secret into n shares using Shamir's secret sharing,
+// where any k of them are sufficient to reconstruct the secret. The X
+// coordinates of the shares range from 1 to n.
+func SplitSecret(secret *big.Int, n, k int) ([]Share, error) {
+	if k < 1 || k > n {
+		return nil, errInvalidThreshold
+	}
+	coeffs := make([]*big.Int, k)
+	coeffs[0] = new(big.Int).Mod(secret, fieldPrime)
+	for i := 1; i < k; i++ {
+		c, err := rand.Int(rand.Reader, fieldPrime)
+		if err != nil {
+			return nil, err
+		}
+		coeffs[i] = c
+	}
+	shares := make([]Share, n)
+	for i := 0; i < n; i++ {
+		var (
+			x = big.NewInt(int64(i + 1))
+			y = new(big.Int)
+		)
+		// Horner's method.
+		for j := k - 1; j >= 0; j-- {
+			y.Mul(y, x)
+			y.Add(y, coeffs[j])
+			y.Mod(y, fieldPrime)
+		}
+		shares[i] = Share{X: i + 1, Y: y}
+	}
+	return shares, nil
+}
+
+// CombineShares reconstructs the secret from the given shares by Lagrange
+// interpolation at zero. The caller is responsible for passing at least the
+// threshold amount of distinct shares, otherwise the result is garbage.
+func CombineShares(shares []Share) *big.Int {
+	secret := new(big.Int)
+	for i, si := range shares {
+		var (
+			num = big.NewInt(1)
+			den = big.NewInt(1)
+		)
+		for j, sj := range shares {
+			if i == j {
+				continue
+			}
+			num.Mul(num, big.NewInt(int64(-sj.X)))
+			num.Mod(num, fieldPrime)
+			den.Mul(den, big.NewInt(int64(si.X-sj.X)))
+			den.Mod(den, fieldPrime)
+		}
+		term := new(big.Int).Mul(si.Y, num)
+		term.Mul(term, new(big.Int).ModInverse(den, fieldPrime))
+		secret.Add(secret, term)
+		secret.Mod(secret, fieldPrime)
+	}
+	return secret
+}
@@ -0,0 +1,115 @@
+package common
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+// VRFProofSize is the size in bytes of a VRF proof, which is the marshaled
+// gamma point followed by the challenge and the response.
+const VRFProofSize = 65 + 32 + 32
+
+var errInvalidVRFProof = errors.New("invalid vrf proof")
+
+// HashToCurve maps data to a point on the curve of which nobody knows the
+// discrete logarithm, by trying x coordinates until one is on the curve.
+func HashToCurve(data []byte) (x, y *big.Int) {
+	var (
+		params = Curve.Params()
+		three  = big.NewInt(3)
+		buf    = make([]byte, len(data)+4)
+	)
+	copy(buf, data)
+	for ctr := uint32(0); ; ctr++ {
+		binary.BigEndian.PutUint32(buf[len(data):], ctr)
+		x = new(big.Int).SetBytes(Hash256(buf))
+		x.Mod(x, params.P)
+		// y² = x³ - 3x + b
+		y2 := new(big.Int).Exp(x, three, params.P)
+		y2.Sub(y2, new(big.Int).Mul(three, x))
+		y2.Add(y2, params.B)
+		y2.Mod(y2, params.P)
+		if y = new(big.Int).ModSqrt(y2, params.P); y != nil {
+			return x, y
+		}
+	}
+}
+
+// VRFProve evaluates the verifiable random function of priv on msg. The
+// output is unique for the key and the message and looks random to anyone
+// without the private key, the proof lets anyone with the public key verify
+// the output. See VRFVerify.
+func VRFProve(priv *ecdsa.PrivateKey, msg []byte) (output, proof []byte) {
+	var (
+		n      = Curve.Params().N
+		d      = padBytes(priv.D.Bytes(), 32)
+		hx, hy = HashToCurve(append(MarshalPublicKey(&priv.PublicKey), msg...))
+		gx, gy = Curve.ScalarMult(hx, hy, d)
+	)
+	// The nonce is derived from the key and the message, like in RFC 6979,
+	// so the proof does not depend on a source of randomness.
+	k := new(big.Int).SetBytes(Hash256(append(d, elliptic.Marshal(Curve, hx, hy)...)))
+	k.Mod(k, n)
+	var (
+		ux, uy = Curve.ScalarBaseMult(padBytes(k.Bytes(), 32))
+		vx, vy = Curve.ScalarMult(hx, hy, padBytes(k.Bytes(), 32))
+		c      = vrfChallenge(hx, hy, gx, gy, ux, uy, vx, vy)
+		s      = new(big.Int).Mul(c, priv.D)
+	)
+	s.Add(s, k)
+	s.Mod(s, n)
+
+	proof = make([]byte, 0, VRFProofSize)
+	proof = append(proof, elliptic.Marshal(Curve, gx, gy)...)
+	proof = append(proof, padBytes(c.Bytes(), 32)...)
+	proof = append(proof, padBytes(s.Bytes(), 32)...)
+	return vrfOutput(gx, gy), proof
+}
+
+// VRFVerify verifies the proof of the output of the verifiable random
+// function of pub on msg and returns the output.
+func VRFVerify(pub *ecdsa.PublicKey, msg, proof []byte) ([]byte, error) {
+	if pub == nil || len(proof) != VRFProofSize {
+		return nil, errInvalidVRFProof
+	}
+	gx, gy := elliptic.Unmarshal(Curve, proof[:65])
+	if gx == nil {
+		return nil, errInvalidVRFProof
+	}
+	var (
+		p      = Curve.Params().P
+		c      = new(big.Int).SetBytes(proof[65:97])
+		s      = proof[97:]
+		hx, hy = HashToCurve(append(MarshalPublicKey(pub), msg...))
+	)
+	// u = sG - c*pub and v = sH - c*gamma equal the commitments of the
+	// prover if the proof is valid.
+	var (
+		sgx, sgy = Curve.ScalarBaseMult(s)
+		cpx, cpy = Curve.ScalarMult(pub.X, pub.Y, c.Bytes())
+		ux, uy   = Curve.Add(sgx, sgy, cpx, new(big.Int).Sub(p, cpy))
+		shx, shy = Curve.ScalarMult(hx, hy, s)
+		cgx, cgy = Curve.ScalarMult(gx, gy, c.Bytes())
+		vx, vy   = Curve.Add(shx, shy, cgx, new(big.Int).Sub(p, cgy))
+	)
+	if c.Cmp(vrfChallenge(hx, hy, gx, gy, ux, uy, vx, vy)) != 0 {
+		return nil, errInvalidVRFProof
+	}
+	return vrfOutput(gx, gy), nil
+}
+
+func vrfChallenge(coords ...*big.Int) *big.Int {
+	var b []byte
+	for i := 0; i < len(coords); i += 2 {
+		b = append(b, elliptic.Marshal(Curve, coords[i], coords[i+1])...)
+	}
+	c := new(big.Int).SetBytes(Hash256(b))
+	return c.Mod(c, Curve.Params().N)
+}
+
+func vrfOutput(gx, gy *big.Int) []byte {
+	return Hash256(elliptic.Marshal(Curve, gx, gy))
+}
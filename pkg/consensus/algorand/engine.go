@@ -0,0 +1,599 @@
+package algorand
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"math"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/common"
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/mempool"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// maxFuture is the maximum amount of messages of the next round that are
+	// buffered while the current round is not decided yet.
+	maxFuture = 1024
+
+	// maxSteps is the highest step votes are accepted for.
+	maxSteps = 180
+)
+
+// The steps of a round. Votes are only cast in the reduction and binary
+// steps, the binary steps continue from stepBinary onwards.
+const (
+	stepProposal uint32 = iota
+	stepReduction1
+	stepReduction2
+	stepBinary
+
+	// The validator decided and waits for the decided block to arrive.
+	stepDecided uint32 = math.MaxUint32 - 1
+	// The validator committed a block and waits for the next round.
+	stepCommit uint32 = math.MaxUint32
+)
+
+// Config holds the configuration of the Algorand engine.
+type Config struct {
+	// The validators participating in sortition.
+	Validators consensus.Validators
+
+	// The weight of each validator in sortition, which would be its stake.
+	// Validators without a weight have a weight of 1.
+	Weights []uint64
+
+	// The expected amount of proposers of each round.
+	ProposerSize uint64
+
+	// The expected size of the committee of each step. A step is decided
+	// once more than 2/3 of it voted for the same value.
+	CommitteeSize uint64
+
+	// The time a validator waits after committing a block before it starts
+	// the next round, giving transactions time to arrive.
+	BlockInterval time.Duration
+
+	// The time a validator waits for proposals and for the votes of a step.
+	StepTimeout time.Duration
+}
+
+// timeout is fired when a step of a round timed out.
+type timeout struct {
+	round uint64
+	step  uint32
+}
+
+// stepVotes holds the votes received for a single step.
+type stepVotes struct {
+	voters map[uint32]bool
+	// The summed weight of the votes for each block hash.
+	weights map[string]uint64
+	// The lowest priority of the voters, which is the common coin of the
+	// step.
+	lowest []byte
+}
+
+// Engine is an Algorand consensus engine. Proposers and the committees of
+// each step are selected by VRF sortition, hence the amount of messages per
+// round depends on the committee size rather than the amount of validators.
+// Rounds are decided by BA*, which reduces the proposals to a single block
+// hash and then runs a binary agreement on it or the empty block.
+type Engine struct {
+	Config
+	consensus.Lifecycle
+
+	privKey   *ecdsa.PrivateKey
+	relayCh   chan<- *pb.Message
+	msgCh     chan *pb.Message
+	timeoutCh chan timeout
+
+	// Index of this node in the validator set, -1 if this node only follows
+	// the consensus.
+	index int
+
+	// Pending transactions, shared with the server if it has a mempool.
+	pool *mempool.Pool
+
+	round uint64
+	step  uint32
+	// Hash of the last committed block, which seeds the sortition.
+	seed []byte
+	// The proposals of the round by block hash, and the one with the lowest
+	// priority seen in the proposal step.
+	proposals    map[string]*pb.AlgorandProposal
+	best         string
+	bestPriority []byte
+	votes        map[uint32]*stepVotes
+	// The outcome of the reduction and the decided block hash.
+	blockHash string
+	decision  string
+	// Messages of the next round that arrived early.
+	future []*pb.Message
+}
+
+func init() {
+	consensus.Register(consensus.Factory{
+		Name:        "algorand",
+		Description: "Algorand VRF sortition with BA* agreement",
+		Config: func() interface{} {
+			return &Config{
+				ProposerSize:  20,
+				CommitteeSize: 100,
+				BlockInterval: 5 * time.Second,
+				StepTimeout:   3 * time.Second,
+			}
+		},
+		New: func(c interface{}) (consensus.Engine, error) {
+			cfg := c.(*Config)
+			if len(cfg.Validators) == 0 {
+				return nil, consensus.ErrMissingValidators
+			}
+			return NewEngine(*cfg), nil
+		},
+	})
+}
+
+// NewEngine returns a new Algorand consensus engine.
+func NewEngine(cfg Config) *Engine {
+	genesis := &pb.Block{Header: &pb.Header{}}
+	return &Engine{
+		Config:    cfg,
+		msgCh:     make(chan *pb.Message, 1024),
+		timeoutCh: make(chan timeout),
+		pool:      mempool.New(mempool.Config{}),
+		step:      stepCommit,
+		seed:      genesis.Header.Hash(),
+	}
+}
+
+// Configurate implements the Engine interface.
+func (e *Engine) Configurate(relayCh chan<- *pb.Message, priv *ecdsa.PrivateKey) {
+	e.privKey = priv
+	e.relayCh = relayCh
+	e.index = -1
+	if priv != nil {
+		e.index = e.Validators.Index(&priv.PublicKey)
+	}
+}
+
+// Start implements the Engine interface.
+func (e *Engine) Start(ctx context.Context) error {
+	return e.Run(ctx, e.run)
+}
+
+// AddTransaction implements the Engine interface.
+func (e *Engine) AddTransaction(tx *pb.Transaction) {
+	e.pool.Add(tx)
+}
+
+// UseMempool implements the consensus.MempoolUser interface.
+func (e *Engine) UseMempool(pool *mempool.Pool) {
+	e.pool = pool
+}
+
+// HandleMessage implements the consensus.Handler interface.
+func (e *Engine) HandleMessage(from consensus.Peer, msg *pb.Message) error {
+	switch msg.Payload.(type) {
+	case *pb.Message_AlgorandProposal, *pb.Message_AlgorandVote:
+		select {
+		case e.msgCh <- msg:
+		case <-e.Done():
+		}
+	}
+	return nil
+}
+
+func (e *Engine) run(ctx context.Context) error {
+	e.scheduleTimeout(e.BlockInterval)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case t := <-e.timeoutCh:
+			e.handleTimeout(t)
+		case msg := <-e.msgCh:
+			e.handleMessage(msg)
+		}
+	}
+}
+
+// newRound starts the next round and proposes a block if this validator was
+// selected as a proposer.
+func (e *Engine) newRound() {
+	e.round++
+	e.step = stepProposal
+	e.proposals = make(map[string]*pb.AlgorandProposal)
+	e.best = ""
+	e.bestPriority = nil
+	e.votes = make(map[uint32]*stepVotes)
+	e.blockHash = ""
+	e.decision = ""
+	e.scheduleTimeout(e.StepTimeout)
+
+	if e.index >= 0 {
+		output, proof := common.VRFProve(e.privKey, sortitionMessage(e.seed, roleProposer, e.round, stepProposal))
+		if sortition(output, e.ProposerSize, e.weight(e.index), e.totalWeight()) > 0 {
+			e.propose(proof)
+		}
+	}
+
+	future := e.future
+	e.future = nil
+	for _, msg := range future {
+		e.handleMessage(msg)
+	}
+}
+
+func (e *Engine) propose(proof []byte) {
+	txs := e.pool.Batch(consensus.MaxBlockTxs, consensus.MaxBlockBytes)
+
+	proposal := &pb.AlgorandProposal{
+		Round: e.round,
+		Block: &pb.Block{
+			Header: &pb.Header{
+				Index:     uint32(e.round),
+				PrevHash:  e.seed,
+				Timestamp: time.Now().UnixNano(),
+				Proposer:  common.MarshalPublicKey(&e.privKey.PublicKey),
+				TxRoot:    pb.TxRoot(txs),
+			},
+			Transactions: txs,
+		},
+		Proof:    proof,
+		Proposer: uint32(e.index),
+	}
+	if err := proposal.Block.Sign(e.privKey); err != nil {
+		log.Errorf("algorand: failed to sign block: %s", err)
+		return
+	}
+	if err := sign(proposal, e.privKey); err != nil {
+		log.Errorf("algorand: failed to sign proposal: %s", err)
+		return
+	}
+	log.WithFields(log.Fields{
+		"round": e.round,
+		"txs":   len(txs),
+	}).Info("algorand: proposed block")
+	e.send(&pb.Message{
+		Payload: &pb.Message_AlgorandProposal{
+			AlgorandProposal: proposal,
+		},
+	})
+}
+
+// vote casts a vote for the block hash in the given step if this validator
+// was selected into its committee.
+func (e *Engine) vote(step uint32, blockHash string) {
+	if e.index < 0 {
+		return
+	}
+	output, proof := common.VRFProve(e.privKey, sortitionMessage(e.seed, roleCommittee, e.round, step))
+	if sortition(output, e.CommitteeSize, e.weight(e.index), e.totalWeight()) == 0 {
+		return
+	}
+	vote := &pb.AlgorandVote{
+		Round:     e.round,
+		Step:      step,
+		BlockHash: []byte(blockHash),
+		Proof:     proof,
+		Voter:     uint32(e.index),
+	}
+	if err := sign(vote, e.privKey); err != nil {
+		log.Errorf("algorand: failed to sign vote: %s", err)
+		return
+	}
+	e.send(&pb.Message{
+		Payload: &pb.Message_AlgorandVote{
+			AlgorandVote: vote,
+		},
+	})
+}
+
+func (e *Engine) handleMessage(msg *pb.Message) {
+	var round uint64
+	switch p := msg.Payload.(type) {
+	case *pb.Message_AlgorandProposal:
+		round = p.AlgorandProposal.Round
+	case *pb.Message_AlgorandVote:
+		round = p.AlgorandVote.Round
+	}
+	// Messages of the next round are buffered until this validator catches
+	// up, it might still be deciding or waiting for the block interval.
+	if round == e.round+1 {
+		if len(e.future) < maxFuture {
+			e.future = append(e.future, msg)
+		}
+		return
+	}
+	if round != e.round || e.step == stepCommit {
+		return
+	}
+	switch p := msg.Payload.(type) {
+	case *pb.Message_AlgorandProposal:
+		e.handleProposal(p.AlgorandProposal)
+	case *pb.Message_AlgorandVote:
+		e.handleVote(p.AlgorandVote)
+	}
+}
+
+func (e *Engine) handleProposal(p *pb.AlgorandProposal) {
+	pub := e.Validators.Get(int(p.Proposer))
+	if pub == nil || !verify(p, pub) {
+		log.Warnf("algorand: proposal from %d: %s", p.Proposer, errInvalidSignature)
+		return
+	}
+	output, err := common.VRFVerify(pub, sortitionMessage(e.seed, roleProposer, e.round, stepProposal), p.Proof)
+	if err != nil {
+		log.Warnf("algorand: proposal from %d: %s", p.Proposer, err)
+		return
+	}
+	j := sortition(output, e.ProposerSize, e.weight(int(p.Proposer)), e.totalWeight())
+	if j == 0 {
+		log.Warnf("algorand: proposal from %d that was not selected", p.Proposer)
+		return
+	}
+	b := p.Block
+	if b == nil || b.Header == nil || uint64(b.Header.Index) != e.round || !bytes.Equal(b.Header.PrevHash, e.seed) {
+		log.Warnf("algorand: invalid block proposed by %d", p.Proposer)
+		return
+	}
+	if signer, err := b.Verify(); err != nil || e.Validators.Index(signer) != int(p.Proposer) {
+		log.Warnf("algorand: block proposed by %d not signed by it", p.Proposer)
+		return
+	}
+	hash := string(b.Hash())
+	if _, ok := e.proposals[hash]; ok {
+		return
+	}
+	e.proposals[hash] = p
+
+	// Proposals that arrive after the proposal step are only kept in case
+	// they get decided.
+	if prio := priority(output, j); e.step == stepProposal && (e.bestPriority == nil || bytes.Compare(prio, e.bestPriority) < 0) {
+		e.best = hash
+		e.bestPriority = prio
+	}
+	if e.step == stepDecided && hash == e.decision {
+		e.commit()
+	}
+}
+
+func (e *Engine) handleVote(v *pb.AlgorandVote) {
+	if v.Step < stepReduction1 || v.Step > maxSteps {
+		return
+	}
+	pub := e.Validators.Get(int(v.Voter))
+	if pub == nil || !verify(v, pub) {
+		log.Warnf("algorand: vote from %d: %s", v.Voter, errInvalidSignature)
+		return
+	}
+	sv, ok := e.votes[v.Step]
+	if !ok {
+		sv = &stepVotes{
+			voters:  make(map[uint32]bool),
+			weights: make(map[string]uint64),
+		}
+		e.votes[v.Step] = sv
+	}
+	if sv.voters[v.Voter] {
+		return
+	}
+	output, err := common.VRFVerify(pub, sortitionMessage(e.seed, roleCommittee, e.round, v.Step), v.Proof)
+	if err != nil {
+		log.Warnf("algorand: vote from %d: %s", v.Voter, err)
+		return
+	}
+	j := sortition(output, e.CommitteeSize, e.weight(int(v.Voter)), e.totalWeight())
+	if j == 0 {
+		return
+	}
+	sv.voters[v.Voter] = true
+	sv.weights[string(v.BlockHash)] += j
+	if prio := priority(output, j); sv.lowest == nil || bytes.Compare(prio, sv.lowest) < 0 {
+		sv.lowest = prio
+	}
+	e.checkStep()
+}
+
+// checkStep ends the current step once more than 2/3 of the expected
+// committee voted for the same block hash.
+func (e *Engine) checkStep() {
+	if e.step < stepReduction1 || e.step >= stepDecided {
+		return
+	}
+	sv, ok := e.votes[e.step]
+	if !ok {
+		return
+	}
+	for hash, w := range sv.weights {
+		if 3*w > 2*e.committeeSize() {
+			e.endStep(false, hash)
+			return
+		}
+	}
+}
+
+func (e *Engine) handleTimeout(t timeout) {
+	if t.round != e.round || t.step != e.step {
+		return
+	}
+	switch e.step {
+	case stepDecided:
+	case stepCommit:
+		e.newRound()
+	default:
+		e.endStep(true, "")
+	}
+}
+
+// endStep moves to the next step of BA* given the outcome of the current
+// one.
+func (e *Engine) endStep(timedOut bool, result string) {
+	switch e.step {
+	case stepProposal:
+		e.next(stepReduction1, e.best)
+	case stepReduction1:
+		e.next(stepReduction2, result)
+	case stepReduction2:
+		e.blockHash = result
+		e.next(stepBinary, result)
+	default:
+		r := result
+		switch (e.step - stepBinary) % 3 {
+		case 0:
+			if timedOut {
+				r = e.blockHash
+			} else if r != "" {
+				e.decide(r)
+				return
+			}
+		case 1:
+			if timedOut {
+				r = ""
+			} else if r == "" {
+				e.decide(r)
+				return
+			}
+		case 2:
+			if timedOut {
+				r = ""
+				if lowest := e.votes[e.step].lowestPriority(); len(lowest) > 0 && lowest[len(lowest)-1]&1 == 1 {
+					r = e.blockHash
+				}
+			}
+		}
+		if e.step+1 > maxSteps {
+			log.Warnf("algorand: round %d did not decide within %d steps", e.round, maxSteps)
+			return
+		}
+		e.next(e.step+1, r)
+	}
+}
+
+// next moves to the given step and votes for value.
+func (e *Engine) next(step uint32, value string) {
+	e.step = step
+	e.scheduleTimeout(e.StepTimeout)
+	e.vote(step, value)
+	e.checkStep()
+}
+
+// decide decides the round on the block hash. The vote is repeated for the
+// next three steps, so that validators that are behind decide as well.
+func (e *Engine) decide(hash string) {
+	step := e.step
+	e.step = stepDecided
+	e.decision = hash
+	for s := step + 1; s <= step+3; s++ {
+		e.vote(s, hash)
+	}
+	if hash == "" {
+		e.commit()
+		return
+	}
+	if _, ok := e.proposals[hash]; ok {
+		e.commit()
+	}
+}
+
+// commit commits the decided block and waits for the next round.
+func (e *Engine) commit() {
+	var (
+		block *pb.Block
+		// Empty blocks are signed and relayed by a single validator.
+		relayer = int(e.round % uint64(len(e.Validators)))
+	)
+	if e.decision == "" {
+		block = &pb.Block{
+			Header: &pb.Header{
+				Index:    uint32(e.round),
+				PrevHash: e.seed,
+				Proposer: common.MarshalPublicKey(e.Validators.Get(relayer)),
+			},
+		}
+	} else {
+		block = e.proposals[e.decision].Block
+	}
+	e.seed = block.Header.Hash()
+	e.step = stepCommit
+	e.pool.Remove(block.Transactions)
+
+	log.WithFields(log.Fields{
+		"round": e.round,
+		"hash":  hex.EncodeToString(e.seed),
+		"txs":   len(block.Transactions),
+		"empty": e.decision == "",
+	}).Info("algorand: committed block")
+
+	// The proposer relays its block.
+	relay := e.index >= 0 && e.index == relayer
+	if p, ok := e.proposals[e.decision]; ok {
+		relay = int(p.Proposer) == e.index
+	} else if relay {
+		if err := block.Sign(e.privKey); err != nil {
+			log.Errorf("algorand: failed to sign block: %s", err)
+			relay = false
+		}
+	}
+	if relay {
+		e.Relay(e.relayCh, &pb.Message{
+			Payload: &pb.Message_Block{
+				Block: block,
+			},
+		})
+	}
+	e.scheduleTimeout(e.BlockInterval)
+}
+
+func (sv *stepVotes) lowestPriority() []byte {
+	if sv == nil {
+		return nil
+	}
+	return sv.lowest
+}
+
+func (e *Engine) weight(i int) uint64 {
+	if i < len(e.Weights) {
+		return e.Weights[i]
+	}
+	return 1
+}
+
+// committeeSize returns the expected size of the committee. A committee
+// larger than the total weight selects every validator with its full weight.
+func (e *Engine) committeeSize() uint64 {
+	if total := e.totalWeight(); total < e.CommitteeSize {
+		return total
+	}
+	return e.CommitteeSize
+}
+
+func (e *Engine) totalWeight() uint64 {
+	var total uint64
+	for i := range e.Validators {
+		total += e.weight(i)
+	}
+	return total
+}
+
+// scheduleTimeout fires a timeout for the current step after d.
+func (e *Engine) scheduleTimeout(d time.Duration) {
+	t := timeout{round: e.round, step: e.step}
+	time.AfterFunc(d, func() {
+		select {
+		case e.timeoutCh <- t:
+		case <-e.Done():
+		}
+	})
+}
+
+// send gossips the message and processes it like the ones of the others.
+func (e *Engine) send(msg *pb.Message) {
+	e.Relay(e.relayCh, msg)
+	e.handleMessage(msg)
+}
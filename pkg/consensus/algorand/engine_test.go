@@ -0,0 +1,104 @@
+package algorand
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/consensus/consensustest"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestNetwork returns a network of n engines with the given config, which
+// are not started.
+func newTestNetwork(cfg Config, n int) *consensustest.Network {
+	return consensustest.NewNetwork(n, func(validators consensus.Validators) consensus.Engine {
+		cfg.Validators = validators
+		return NewEngine(cfg)
+	})
+}
+
+// testConfig returns the config of the engines of a test network of n
+// validators.
+func testConfig(n int) Config {
+	return Config{
+		ProposerSize:  uint64(n),
+		CommitteeSize: uint64(n),
+		BlockInterval: 20 * time.Millisecond,
+		StepTimeout:   200 * time.Millisecond,
+	}
+}
+
+// receiveIndex receives blocks until a block at the given index or above. The
+// blocks of Byzantine proposers are not received.
+func receiveIndex(t *testing.T, net *consensustest.Network, index uint32) *pb.Block {
+	for {
+		if b := net.Receive(t.Fatalf).Block; b.Header.Index >= index {
+			return b
+		}
+	}
+}
+
+func TestEngineCommit(t *testing.T) {
+	net := newTestNetwork(testConfig(4), 4)
+	net.Start()
+	defer net.Stop()
+	b := receiveIndex(t, net, 1)
+	assert.Equal(t, uint32(1), b.Header.Index)
+	assert.Equal(t, 1, len(b.Transactions))
+}
+
+func TestEngineCrashedValidator(t *testing.T) {
+	net := newTestNetwork(testConfig(4), 4)
+	net.Start(1)
+	defer net.Stop()
+	receiveIndex(t, net, 3)
+}
+
+func TestEngineDefaultCommittee(t *testing.T) {
+	f, ok := consensus.Lookup("algorand")
+	assert.True(t, ok)
+	// The default committee is larger than the total weight of 4 validators.
+	cfg := *f.Config().(*Config)
+	cfg.BlockInterval = 20 * time.Millisecond
+	cfg.StepTimeout = 200 * time.Millisecond
+	net := newTestNetwork(cfg, 4)
+	net.Start()
+	defer net.Stop()
+	receiveIndex(t, net, 1)
+}
+
+func TestEngineEquivocatingProposer(t *testing.T) {
+	net := newTestNetwork(testConfig(4), 4)
+	// A proposer sends each validator another block.
+	net.SetByzantine(0, func(to int, msg *pb.Message) *pb.Message {
+		if p := msg.GetAlgorandProposal(); p != nil {
+			p.Block.Header.Nonce = uint64(to)
+			if err := sign(p, consensustest.PrivateKey(0)); err != nil {
+				panic(err)
+			}
+		}
+		return msg
+	})
+	net.Start()
+	defer net.Stop()
+	receiveIndex(t, net, 3)
+}
+
+func TestEngineEquivocatingVotes(t *testing.T) {
+	net := newTestNetwork(testConfig(4), 4)
+	// A validator votes for another block at each validator.
+	net.SetByzantine(3, func(to int, msg *pb.Message) *pb.Message {
+		if v := msg.GetAlgorandVote(); v != nil {
+			v.BlockHash = pb.NewBlock(uint32(to)).Hash()
+			if err := sign(v, consensustest.PrivateKey(3)); err != nil {
+				panic(err)
+			}
+		}
+		return msg
+	})
+	net.Start()
+	defer net.Stop()
+	receiveIndex(t, net, 3)
+}
@@ -0,0 +1,76 @@
+package algorand
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+
+	"github.com/anthdm/consenter/pkg/common"
+)
+
+// The roles validators are selected for by sortition.
+const (
+	roleProposer  = "proposer"
+	roleCommittee = "committee"
+)
+
+// sortitionMessage returns the message the VRF is evaluated on for selecting
+// the validators of the given role in a step of a round.
+func sortitionMessage(seed []byte, role string, round uint64, step uint32) []byte {
+	b := make([]byte, 12)
+	binary.BigEndian.PutUint64(b, round)
+	binary.BigEndian.PutUint32(b[8:], step)
+	return append(append(append([]byte{}, seed...), role...), b...)
+}
+
+// sortition returns how many of the w sub-users of a validator are selected
+// given its VRF output, where total is the weight of all validators and tau
+// the expected amount of selected sub-users. Each sub-user is selected with
+// probability tau/total, hence the result is binomially distributed.
+func sortition(output []byte, tau, w, total uint64) uint64 {
+	if total == 0 {
+		return 0
+	}
+	p := float64(tau) / float64(total)
+	if p >= 1 {
+		return w
+	}
+	var (
+		ratio = float64(binary.BigEndian.Uint64(output)) / math.Pow(2, 64)
+		cdf   float64
+	)
+	for j := uint64(0); j < w; j++ {
+		cdf += binomial(j, w, p)
+		if ratio < cdf {
+			return j
+		}
+	}
+	return w
+}
+
+// binomial returns the probability of exactly k successes out of n trials
+// with success probability p.
+func binomial(k, n uint64, p float64) float64 {
+	var (
+		a, _ = math.Lgamma(float64(n + 1))
+		b, _ = math.Lgamma(float64(k + 1))
+		c, _ = math.Lgamma(float64(n - k + 1))
+	)
+	return math.Exp(a - b - c + float64(k)*math.Log(p) + float64(n-k)*math.Log1p(-p))
+}
+
+// priority returns the lowest hash of the j selected sub-users of a
+// validator with the given VRF output. The proposal with the lowest priority
+// wins and the lowest priority of a step is its common coin.
+func priority(output []byte, j uint64) []byte {
+	var lowest []byte
+	for i := uint64(1); i <= j; i++ {
+		b := make([]byte, len(output)+8)
+		copy(b, output)
+		binary.BigEndian.PutUint64(b[len(output):], i)
+		if h := common.Hash256(b); lowest == nil || bytes.Compare(h, lowest) < 0 {
+			lowest = h
+		}
+	}
+	return lowest
+}
@@ -0,0 +1,40 @@
+package algorand
+
+import (
+	"testing"
+
+	"github.com/anthdm/consenter/pkg/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortition(t *testing.T) {
+	priv := common.NewPrivateKey([]byte("node"))
+	var selected uint64
+	for round := uint64(0); round < 1000; round++ {
+		msg := sortitionMessage([]byte("seed"), roleCommittee, round, stepReduction1)
+		output, proof := common.VRFProve(priv, msg)
+		verified, err := common.VRFVerify(&priv.PublicKey, msg, proof)
+		assert.Nil(t, err)
+		assert.Equal(t, output, verified)
+
+		// A validator with 10 of 100 weight and a committee of 20 is
+		// expected to have 2 selected sub-users.
+		selected += sortition(output, 20, 10, 100)
+	}
+	assert.InDelta(t, 2000, selected, 200)
+
+	// Everybody is selected if the committee is larger than the total weight.
+	assert.Equal(t, uint64(3), sortition(make([]byte, 32), 10, 3, 5))
+}
+
+func TestVRFVerifyInvalid(t *testing.T) {
+	var (
+		priv     = common.NewPrivateKey([]byte("node"))
+		other    = common.NewPrivateKey([]byte("other"))
+		_, proof = common.VRFProve(priv, []byte("msg"))
+	)
+	_, err := common.VRFVerify(&other.PublicKey, []byte("msg"), proof)
+	assert.NotNil(t, err)
+	_, err = common.VRFVerify(&priv.PublicKey, []byte("other msg"), proof)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,373 @@
+package avalanche
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/mempool"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxQueryTransactions is the maximum amount of conflict sets asked for in a
+// single query.
+const maxQueryTransactions = 256
+
+// Config holds the configuration of the Avalanche engine.
+type Config struct {
+	// The validators that are sampled in queries.
+	Validators consensus.Validators
+
+	// The amount of validators sampled in each query. If there are fewer
+	// other validators, all of them are sampled and Alpha is scaled down
+	// accordingly.
+	K int
+
+	// The amount of sampled validators that need to prefer a transaction for
+	// a query to be successful. It should be more than half of K.
+	Alpha int
+
+	// The amount of consecutive successful queries for the same transaction
+	// after which it is accepted.
+	Beta int
+
+	// The time a validator waits for the responses of a query. Validators
+	// that did not respond count as not preferring any transaction.
+	QueryTimeout time.Duration
+}
+
+// query is a query of this node waiting for responses.
+type query struct {
+	id  uint64
+	txs []*pb.Transaction
+	// The sampled validators and their responses.
+	sample    map[uint32]bool
+	responses map[uint32]*pb.AvalancheResponse
+}
+
+// Engine is an Avalanche consensus engine that runs Snowball over sets of
+// conflicting transactions. Validators repeatedly ask small random samples
+// of the validators for their preferences, until the network tips over to
+// one of the transactions.
+type Engine struct {
+	Config
+	consensus.Lifecycle
+
+	privKey *ecdsa.PrivateKey
+	relayCh chan<- *pb.Message
+	msgCh   chan *pb.Message
+
+	// Index of this node in the validator set, -1 if this node only follows
+	// the consensus.
+	index int
+
+	// Pending transactions, shared with the server if it has a mempool.
+	pool *mempool.Pool
+
+	lock sync.Mutex
+	// Hashes of the accepted transactions.
+	accepted map[string]bool
+
+	sets map[string]*conflictSet
+	// Conflict sets that are not accepted yet, in the order they were seen.
+	undecided  []*conflictSet
+	query      *query
+	queryTimer *time.Timer
+}
+
+func init() {
+	consensus.Register(consensus.Factory{
+		Name:        "avalanche",
+		Description: "Avalanche Snowball over conflicting transactions",
+		Config: func() interface{} {
+			return &Config{
+				K:            20,
+				Alpha:        15,
+				Beta:         20,
+				QueryTimeout: 1 * time.Second,
+			}
+		},
+		New: func(c interface{}) (consensus.Engine, error) {
+			cfg := c.(*Config)
+			if len(cfg.Validators) == 0 {
+				return nil, consensus.ErrMissingValidators
+			}
+			return NewEngine(*cfg), nil
+		},
+	})
+}
+
+// NewEngine returns a new Avalanche consensus engine.
+func NewEngine(cfg Config) *Engine {
+	return &Engine{
+		Config:   cfg,
+		msgCh:    make(chan *pb.Message, 1024),
+		pool:     mempool.New(mempool.Config{}),
+		accepted: make(map[string]bool),
+		sets:     make(map[string]*conflictSet),
+	}
+}
+
+// Configurate implements the Engine interface.
+func (e *Engine) Configurate(relayCh chan<- *pb.Message, priv *ecdsa.PrivateKey) {
+	e.privKey = priv
+	e.relayCh = relayCh
+	e.index = -1
+	if priv != nil {
+		e.index = e.Validators.Index(&priv.PublicKey)
+	}
+}
+
+// Start implements the Engine interface.
+func (e *Engine) Start(ctx context.Context) error {
+	return e.Run(ctx, e.run)
+}
+
+// AddTransaction implements the Engine interface.
+func (e *Engine) AddTransaction(tx *pb.Transaction) {
+	e.pool.Add(tx)
+}
+
+// UseMempool implements the consensus.MempoolUser interface.
+func (e *Engine) UseMempool(pool *mempool.Pool) {
+	e.pool = pool
+}
+
+// HandleMessage implements the consensus.Handler interface.
+func (e *Engine) HandleMessage(from consensus.Peer, msg *pb.Message) error {
+	switch msg.Payload.(type) {
+	case *pb.Message_AvalancheQuery, *pb.Message_AvalancheResponse:
+		select {
+		case e.msgCh <- msg:
+		case <-e.Done():
+		}
+	}
+	return nil
+}
+
+func (e *Engine) run(ctx context.Context) error {
+	e.queryTimer = time.NewTimer(e.QueryTimeout)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-e.queryTimer.C:
+			// The outstanding query timed out, or there was nothing to query.
+			e.finishQuery()
+			e.startQuery()
+		case msg := <-e.msgCh:
+			switch p := msg.Payload.(type) {
+			case *pb.Message_AvalancheQuery:
+				e.handleQuery(p.AvalancheQuery)
+			case *pb.Message_AvalancheResponse:
+				e.handleResponse(p.AvalancheResponse)
+			}
+		}
+	}
+}
+
+// addTransactions moves the pending transactions to their conflict sets.
+func (e *Engine) addTransactions() {
+	txs := e.pool.Batch(0, 0)
+	e.pool.Remove(txs)
+	for _, tx := range txs {
+		e.addTransaction(tx)
+	}
+}
+
+// addTransaction adds the transaction to its conflict set and returns the
+// set.
+func (e *Engine) addTransaction(tx *pb.Transaction) *conflictSet {
+	key := conflictKey(tx)
+	cs, ok := e.sets[key]
+	if !ok {
+		cs = newConflictSet(tx)
+		e.sets[key] = cs
+		e.undecided = append(e.undecided, cs)
+		return cs
+	}
+	cs.add(tx)
+	return cs
+}
+
+// startQuery multicasts a query for the undecided conflict sets to a random
+// sample of the validators.
+func (e *Engine) startQuery() {
+	if !e.queryTimer.Stop() {
+		select {
+		case <-e.queryTimer.C:
+		default:
+		}
+	}
+	e.queryTimer.Reset(e.QueryTimeout)
+	if e.index < 0 || e.query != nil {
+		return
+	}
+	e.addTransactions()
+	if len(e.undecided) == 0 {
+		return
+	}
+	q := &query{
+		id:        rand.Uint64(),
+		sample:    make(map[uint32]bool),
+		responses: make(map[uint32]*pb.AvalancheResponse),
+	}
+	for _, cs := range e.undecided {
+		if len(q.txs) == maxQueryTransactions {
+			break
+		}
+		q.txs = append(q.txs, cs.txs[cs.preference])
+	}
+	sample := make([]uint32, 0, e.sampleSize())
+	for _, i := range rand.Perm(len(e.Validators)) {
+		if len(sample) == cap(sample) {
+			break
+		}
+		if i != e.index {
+			sample = append(sample, uint32(i))
+			q.sample[uint32(i)] = true
+		}
+	}
+	msg := &pb.AvalancheQuery{
+		Id:           q.id,
+		Transactions: q.txs,
+		Sample:       sample,
+		Replica:      uint32(e.index),
+	}
+	if err := sign(msg, e.privKey); err != nil {
+		log.Errorf("avalanche: failed to sign query: %s", err)
+		return
+	}
+	e.query = q
+	e.Relay(e.relayCh, &pb.Message{
+		Payload: &pb.Message_AvalancheQuery{
+			AvalancheQuery: msg,
+		},
+	})
+}
+
+// finishQuery records the outcome of the outstanding query in the queried
+// conflict sets.
+func (e *Engine) finishQuery() {
+	q := e.query
+	if q == nil {
+		return
+	}
+	e.query = nil
+
+	alpha := e.Alpha
+	if k := e.sampleSize(); k < e.K {
+		alpha = (e.Alpha*k + e.K - 1) / e.K
+	}
+	for i, tx := range q.txs {
+		votes := make(map[string]int)
+		for _, resp := range q.responses {
+			votes[string(resp.Preferences[i])]++
+		}
+		cs := e.sets[conflictKey(tx)]
+		if cs.record(votes, alpha, e.Beta) {
+			e.accept(cs)
+		}
+	}
+}
+
+// accept marks the preferred transaction of the conflict set as accepted.
+func (e *Engine) accept(cs *conflictSet) {
+	for i, s := range e.undecided {
+		if s == cs {
+			e.undecided = append(e.undecided[:i], e.undecided[i+1:]...)
+			break
+		}
+	}
+	e.lock.Lock()
+	e.accepted[cs.preference] = true
+	e.lock.Unlock()
+
+	log.WithFields(log.Fields{
+		"hash":      hex.EncodeToString([]byte(cs.preference)),
+		"conflicts": len(cs.txs) - 1,
+		"pending":   len(e.undecided),
+	}).Info("avalanche: accepted transaction")
+}
+
+func (e *Engine) handleQuery(q *pb.AvalancheQuery) {
+	if int(q.Replica) >= len(e.Validators) || len(q.Transactions) > maxQueryTransactions {
+		return
+	}
+	if !verify(q, e.Validators[q.Replica]) {
+		log.Warnf("avalanche: query from %d: %s", q.Replica, errInvalidSignature)
+		return
+	}
+	// Every validator learns about the transactions of a query, not only the
+	// sampled ones.
+	sets := make([]*conflictSet, len(q.Transactions))
+	for i, tx := range q.Transactions {
+		sets[i] = e.addTransaction(tx)
+	}
+	sampled := false
+	for _, i := range q.Sample {
+		if int(i) == e.index {
+			sampled = true
+		}
+	}
+	if !sampled {
+		return
+	}
+	resp := &pb.AvalancheResponse{
+		Id:          q.Id,
+		Preferences: make([][]byte, len(sets)),
+		Replica:     uint32(e.index),
+	}
+	for i, cs := range sets {
+		resp.Preferences[i] = []byte(cs.preference)
+	}
+	if err := sign(resp, e.privKey); err != nil {
+		log.Errorf("avalanche: failed to sign response: %s", err)
+		return
+	}
+	e.Relay(e.relayCh, &pb.Message{
+		Payload: &pb.Message_AvalancheResponse{
+			AvalancheResponse: resp,
+		},
+	})
+}
+
+func (e *Engine) handleResponse(resp *pb.AvalancheResponse) {
+	q := e.query
+	if q == nil || resp.Id != q.id || !q.sample[resp.Replica] {
+		return
+	}
+	if _, ok := q.responses[resp.Replica]; ok || len(resp.Preferences) != len(q.txs) {
+		return
+	}
+	if !verify(resp, e.Validators[resp.Replica]) {
+		log.Warnf("avalanche: response from %d: %s", resp.Replica, errInvalidSignature)
+		return
+	}
+	q.responses[resp.Replica] = resp
+	if len(q.responses) == len(q.sample) {
+		e.finishQuery()
+		e.startQuery()
+	}
+}
+
+// sampleSize returns the amount of validators sampled in a query.
+func (e *Engine) sampleSize() int {
+	if k := len(e.Validators) - 1; k < e.K {
+		return k
+	}
+	return e.K
+}
+
+// isAccepted reports whether the transaction with the given hash is
+// accepted.
+func (e *Engine) isAccepted(hash []byte) bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.accepted[string(hash)]
+}
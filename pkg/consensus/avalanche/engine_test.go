@@ -0,0 +1,83 @@
+package avalanche
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/consensus/consensustest"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestNetwork returns a network of n engines, which are not started.
+func newTestNetwork(n int) *consensustest.Network {
+	return consensustest.NewNetwork(n, func(validators consensus.Validators) consensus.Engine {
+		return NewEngine(Config{
+			Validators:   validators,
+			K:            4,
+			Alpha:        3,
+			Beta:         10,
+			QueryTimeout: 50 * time.Millisecond,
+		})
+	})
+}
+
+func TestEngineConflictingTransactions(t *testing.T) {
+	var (
+		net = newTestNetwork(6)
+		a   = pb.NewTransaction()
+		b   = &pb.Transaction{Payload: []byte("b")}
+	)
+	net.Start()
+	defer net.Stop()
+	// Both transactions spend the same nonce, half of the validators see a
+	// first and the other half b.
+	b.Nonce = a.Nonce
+	for i := 0; i < 6; i++ {
+		if i%2 == 0 {
+			net.Engine(i).AddTransaction(a)
+		} else {
+			net.Engine(i).AddTransaction(b)
+		}
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		var nA, nB int
+		for i := 0; i < 6; i++ {
+			e := net.Engine(i).(*Engine)
+			if e.isAccepted(a.Hash()) {
+				nA++
+			}
+			if e.isAccepted(b.Hash()) {
+				nB++
+			}
+		}
+		if nA+nB == 6 {
+			assert.True(t, nA == 0 || nB == 0, "validators accepted conflicting transactions")
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("transaction not accepted by all validators")
+}
+
+func TestConflictSetRecord(t *testing.T) {
+	var (
+		a  = pb.NewTransaction()
+		b  = pb.NewTransaction()
+		cs = newConflictSet(a)
+	)
+	cs.add(b)
+	hashA, hashB := string(a.Hash()), string(b.Hash())
+
+	assert.False(t, cs.record(map[string]int{hashB: 3}, 3, 2))
+	assert.Equal(t, hashB, cs.preference)
+	// An unsuccessful query resets the counter.
+	assert.False(t, cs.record(map[string]int{hashA: 2, hashB: 2}, 3, 2))
+	assert.False(t, cs.record(map[string]int{hashB: 3}, 3, 2))
+	assert.True(t, cs.record(map[string]int{hashB: 4}, 3, 2))
+	assert.True(t, cs.accepted)
+	assert.Equal(t, hashB, cs.preference)
+}
@@ -0,0 +1,55 @@
+package avalanche
+
+import (
+	"crypto/ecdsa"
+	"errors"
+
+	"github.com/anthdm/consenter/pkg/common"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	proto "github.com/golang/protobuf/proto"
+)
+
+var errInvalidSignature = errors.New("invalid signature")
+
+// sign signs the given Avalanche message with priv and sets its signature.
+func sign(msg proto.Message, priv *ecdsa.PrivateKey) error {
+	sig, err := common.Sign(priv, signatureHash(msg))
+	if err != nil {
+		return err
+	}
+	switch m := msg.(type) {
+	case *pb.AvalancheQuery:
+		m.Signature = sig
+	case *pb.AvalancheResponse:
+		m.Signature = sig
+	}
+	return nil
+}
+
+// verify reports whether the given Avalanche message is signed by pub.
+func verify(msg proto.Message, pub *ecdsa.PublicKey) bool {
+	var sig []byte
+	switch m := msg.(type) {
+	case *pb.AvalancheQuery:
+		sig = m.Signature
+	case *pb.AvalancheResponse:
+		sig = m.Signature
+	}
+	return common.Verify(pub, signatureHash(msg), sig)
+}
+
+// signatureHash returns the hash of the message without its signature.
+func signatureHash(msg proto.Message) []byte {
+	msg = proto.Clone(msg)
+	switch m := msg.(type) {
+	case *pb.AvalancheQuery:
+		m.Signature = nil
+	case *pb.AvalancheResponse:
+		m.Signature = nil
+	}
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+	return common.Hash256(b)
+}
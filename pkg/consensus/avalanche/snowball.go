@@ -0,0 +1,85 @@
+package avalanche
+
+import (
+	"strconv"
+
+	pb "github.com/anthdm/consenter/pkg/protos"
+)
+
+// conflictKey returns the key of the conflict set of the transaction.
+// Transactions with the same nonce conflict with each other, which models a
+// double spend.
+func conflictKey(tx *pb.Transaction) string {
+	return strconv.FormatUint(tx.Nonce, 10)
+}
+
+// conflictSet is a set of conflicting transactions of which at most one is
+// accepted. Each set runs its own instance of Snowball.
+type conflictSet struct {
+	// The transactions of the set by their hash.
+	txs map[string]*pb.Transaction
+	// Hash of the preferred transaction.
+	preference string
+	// Hash of the transaction that received an alpha majority in the last
+	// successful query, and the amount of consecutive successful queries for
+	// it.
+	last  string
+	count int
+	// The amount of successful queries for each transaction.
+	confidence map[string]int
+	accepted   bool
+}
+
+func newConflictSet(tx *pb.Transaction) *conflictSet {
+	hash := string(tx.Hash())
+	return &conflictSet{
+		txs:        map[string]*pb.Transaction{hash: tx},
+		preference: hash,
+		confidence: make(map[string]int),
+	}
+}
+
+// add adds the transaction to the set. The first transaction seen stays
+// preferred until the network decides otherwise.
+func (cs *conflictSet) add(tx *pb.Transaction) {
+	hash := string(tx.Hash())
+	if _, ok := cs.txs[hash]; !ok {
+		cs.txs[hash] = tx
+	}
+}
+
+// record records the outcome of a query, votes holds the amount of sampled
+// validators preferring each transaction. It reports whether the set got
+// accepted.
+func (cs *conflictSet) record(votes map[string]int, alpha, beta int) bool {
+	if cs.accepted {
+		return false
+	}
+	for hash, n := range votes {
+		if n < alpha {
+			continue
+		}
+		if _, ok := cs.txs[hash]; !ok {
+			// The majority prefers a transaction this node has not seen yet,
+			// it will arrive with a query of one of them.
+			break
+		}
+		cs.confidence[hash]++
+		if cs.confidence[hash] > cs.confidence[cs.preference] {
+			cs.preference = hash
+		}
+		if hash != cs.last {
+			cs.last = hash
+			cs.count = 1
+		} else {
+			cs.count++
+		}
+		if cs.count >= beta {
+			cs.preference = hash
+			cs.accepted = true
+		}
+		return cs.accepted
+	}
+	cs.count = 0
+	return false
+}
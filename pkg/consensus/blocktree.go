@@ -0,0 +1,166 @@
+package consensus
+
+import (
+	"fmt"
+
+	"github.com/anthdm/consenter/pkg/chain"
+	"github.com/anthdm/consenter/pkg/mempool"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/anthdm/consenter/pkg/storage"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxOrphans is the maximum amount of blocks with an unknown parent a block
+// tree keeps around until their parent arrives.
+const maxOrphans = 256
+
+// BlockTree is the tree of known blocks of engines that extend the chain
+// they follow with their own blocks, like proof of work. The head is selected
+// by the fork choice rule of the tree. Blocks of which the parent is not
+// known yet are kept until it arrives. The transactions of the blocks the
+// head switches to are removed from the mempool, the ones of the blocks it
+// switches away from are pending again. It is not safe for concurrent use.
+type BlockTree struct {
+	chain    *chain.Chain
+	genesis  *pb.Block
+	depth    uint32
+	validate func(b, parent *pb.Block) error
+
+	// Height of the last finalized block.
+	finalized uint32
+	// Blocks with an unknown parent, by the hash of their parent.
+	orphans  map[string][]*pb.Block
+	nOrphans int
+}
+
+// NewBlockTree returns the tree of the blocks following the genesis block,
+// which is not part of the chain of the tree. Blocks more than depth below
+// the head are finalized, hence branches forking off further down are
+// rejected. A depth of zero never finalizes blocks. Each block is checked
+// against its parent by validate before it is added.
+func NewBlockTree(genesis *pb.Block, rule chain.ForkChoice, depth uint32, validate func(b, parent *pb.Block) error) *BlockTree {
+	c, err := chain.New(storage.NewMemStore(), rule)
+	if err != nil {
+		// An empty store holds no chain to resume.
+		panic(err)
+	}
+	return &BlockTree{
+		chain:    c,
+		genesis:  genesis,
+		depth:    depth,
+		validate: validate,
+		orphans:  make(map[string][]*pb.Block),
+	}
+}
+
+// Head returns the head of the chain, the genesis block if it is empty.
+func (t *BlockTree) Head() *pb.Block {
+	if head := t.chain.Head(); head != nil {
+		return head
+	}
+	return t.genesis
+}
+
+// GetBlockByHash implements the BlockReader interface. Blocks of pruned
+// branches and the genesis block are found as well.
+func (t *BlockTree) GetBlockByHash(hash []byte) (*pb.Block, error) {
+	if string(hash) == string(t.genesis.Header.Hash()) {
+		return t.genesis, nil
+	}
+	return t.chain.GetBlockByHash(hash)
+}
+
+// Finalized returns the height of the last finalized block.
+func (t *BlockTree) Finalized() uint32 {
+	return t.finalized
+}
+
+// Add adds the block to the tree, along with the orphans waiting for it, and
+// updates the mempool if the head switches. Blocks with an unknown parent are
+// kept as orphans. Adding a known block is a no-op.
+func (t *BlockTree) Add(b *pb.Block, pool *mempool.Pool) error {
+	if b == nil || b.Header == nil {
+		return errMissingHeader
+	}
+	hash := b.Header.Hash()
+	if t.chain.HasBlock(hash) {
+		return nil
+	}
+	h := b.Header
+	if h.Index <= t.finalized {
+		return fmt.Errorf("block %d forks off below the finalized block %d", h.Index, t.finalized)
+	}
+	parent, err := t.GetBlockByHash(h.PrevHash)
+	if err == storage.ErrNotFound {
+		if t.nOrphans < maxOrphans {
+			t.orphans[string(h.PrevHash)] = append(t.orphans[string(h.PrevHash)], b)
+			t.nOrphans++
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if h.Index != parent.Header.Index+1 {
+		return fmt.Errorf("block %d does not follow its parent %d", h.Index, parent.Header.Index)
+	}
+	if err := t.validate(b, parent); err != nil {
+		return err
+	}
+	head := t.chain.Head()
+	reorg, err := t.chain.Add(b)
+	if err != nil {
+		return err
+	}
+	if reorg != nil {
+		log.WithFields(log.Fields{
+			"depth":  len(reorg.Detached),
+			"height": t.chain.Height(),
+		}).Warn("chain reorganization")
+		for _, blk := range reorg.Attached {
+			pool.Remove(blk.Transactions)
+		}
+		for _, tx := range reorg.Orphaned() {
+			pool.Add(tx)
+		}
+	} else if t.chain.Head() != head {
+		pool.Remove(b.Transactions)
+	}
+	if err := t.finalize(); err != nil {
+		return err
+	}
+
+	children := t.orphans[string(hash)]
+	delete(t.orphans, string(hash))
+	t.nOrphans -= len(children)
+	for _, child := range children {
+		if err := t.Add(child, pool); err != nil {
+			log.Warnf("failed processing orphan block: %s", err)
+		}
+	}
+	return nil
+}
+
+// finalize finalizes the block depth below the head and drops the orphans
+// that can no longer be added.
+func (t *BlockTree) finalize() error {
+	height := t.chain.Height()
+	if t.depth == 0 || height <= t.finalized+t.depth {
+		return nil
+	}
+	b, err := t.chain.GetBlockByHeight(height - t.depth)
+	if err != nil {
+		return err
+	}
+	if _, err := t.chain.Finalize(b.Header.Hash()); err != nil {
+		return err
+	}
+	t.finalized = b.Header.Index
+	for hash, blocks := range t.orphans {
+		if blocks[0].Header.Index <= t.finalized+1 {
+			delete(t.orphans, hash)
+			t.nOrphans -= len(blocks)
+		}
+	}
+	return nil
+}
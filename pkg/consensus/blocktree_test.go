@@ -0,0 +1,104 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/anthdm/consenter/pkg/chain"
+	"github.com/anthdm/consenter/pkg/mempool"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBlockTree(depth uint32) *BlockTree {
+	genesis := &pb.Block{Header: &pb.Header{}}
+	return NewBlockTree(genesis, chain.LongestChain{}, depth, func(b, parent *pb.Block) error {
+		if b.Header.Nonce == 0 {
+			return errRejected
+		}
+		return nil
+	})
+}
+
+func childBlock(parent *pb.Block, nonce uint64, txs ...*pb.Transaction) *pb.Block {
+	return &pb.Block{
+		Header: &pb.Header{
+			Index:    parent.Header.Index + 1,
+			PrevHash: parent.Header.Hash(),
+			Nonce:    nonce,
+			TxRoot:   pb.TxRoot(txs),
+		},
+		Transactions: txs,
+	}
+}
+
+func TestBlockTreeOrphans(t *testing.T) {
+	var (
+		tree = newTestBlockTree(0)
+		pool = mempool.New(mempool.Config{})
+		b1   = childBlock(tree.Head(), 1)
+		b2   = childBlock(b1, 1)
+		b3   = childBlock(b2, 1)
+	)
+	assert.Nil(t, tree.Add(b3, pool))
+	assert.Nil(t, tree.Add(b2, pool))
+	assert.Equal(t, uint32(0), tree.Head().Header.Index)
+	assert.Nil(t, tree.Add(b1, pool))
+	assert.Equal(t, b3.Header.Hash(), tree.Head().Header.Hash())
+	assert.Equal(t, 0, tree.nOrphans)
+
+	assert.Equal(t, errRejected, tree.Add(childBlock(b3, 0), pool))
+	assert.Equal(t, errMissingHeader, tree.Add(&pb.Block{}, pool))
+}
+
+func TestBlockTreeReorg(t *testing.T) {
+	var (
+		tree = newTestBlockTree(0)
+		pool = mempool.New(mempool.Config{})
+		tx1  = pb.NewTransaction()
+		tx2  = pb.NewTransaction()
+		b1   = childBlock(tree.Head(), 1)
+		a2   = childBlock(b1, 1, tx1)
+		c2   = childBlock(b1, 2, tx2)
+		c3   = childBlock(c2, 2)
+	)
+	pool.Add(tx1)
+	pool.Add(tx2)
+	assert.Nil(t, tree.Add(b1, pool))
+	assert.Nil(t, tree.Add(a2, pool))
+	assert.False(t, pool.Has(tx1.Hash()))
+
+	assert.Nil(t, tree.Add(c2, pool))
+	assert.Equal(t, a2.Header.Hash(), tree.Head().Header.Hash())
+	assert.True(t, pool.Has(tx2.Hash()))
+	assert.Nil(t, tree.Add(c3, pool))
+	assert.Equal(t, c3.Header.Hash(), tree.Head().Header.Hash())
+	assert.True(t, pool.Has(tx1.Hash()))
+	assert.False(t, pool.Has(tx2.Hash()))
+}
+
+func TestBlockTreeFinalize(t *testing.T) {
+	var (
+		tree = newTestBlockTree(2)
+		pool = mempool.New(mempool.Config{})
+		b    = tree.Head()
+		fork = childBlock(b, 2)
+	)
+	assert.Nil(t, tree.Add(fork, pool))
+	// An orphan of a block that never arrives.
+	assert.Nil(t, tree.Add(&pb.Block{
+		Header: &pb.Header{Index: 3, PrevHash: []byte("unknown"), Nonce: 1},
+	}, pool))
+	assert.Equal(t, 1, tree.nOrphans)
+	for i := 0; i < 4; i++ {
+		b = childBlock(b, 1)
+		assert.Nil(t, tree.Add(b, pool))
+	}
+	assert.Equal(t, uint32(2), tree.Finalized())
+	assert.Equal(t, 0, tree.nOrphans)
+	assert.NotNil(t, tree.Add(childBlock(fork, 1), pool))
+
+	// Blocks of the pruned branch are still found.
+	f, err := tree.GetBlockByHash(fork.Header.Hash())
+	assert.Nil(t, err)
+	assert.Equal(t, fork.Header.Hash(), f.Header.Hash())
+}
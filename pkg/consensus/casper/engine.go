@@ -0,0 +1,430 @@
+package casper
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"sync"
+
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/mempool"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	log "github.com/sirupsen/logrus"
+)
+
+// Config holds the configuration of the Casper FFG finality gadget.
+type Config struct {
+	// The validators voting on checkpoints, which do not need to be the ones
+	// of the wrapped engine.
+	Validators consensus.Validators
+
+	// The amount of blocks per epoch. The block at the start of each epoch,
+	// which is every EpochLength-th block, is a checkpoint.
+	EpochLength uint32
+}
+
+// checkpoint is a block at the start of an epoch.
+type checkpoint struct {
+	hash  []byte
+	epoch uint64
+}
+
+// link holds the votes for the link between two checkpoints.
+type link struct {
+	source checkpoint
+	target checkpoint
+	voters map[uint32]bool
+}
+
+// Engine is a Casper FFG finality gadget that wraps another consensus
+// engine. The wrapped engine produces the blocks, while the validators vote
+// on links between the checkpoints of the chain. A checkpoint is justified
+// once 2/3 of the validators voted for a link to it from a justified
+// checkpoint, and a justified checkpoint is finalized once its direct child
+// checkpoint is justified.
+type Engine struct {
+	Config
+	consensus.Lifecycle
+
+	engine  consensus.Engine
+	privKey *ecdsa.PrivateKey
+	relayCh chan<- *pb.Message
+	msgCh   chan *pb.Message
+	// The relay channel of the wrapped engine.
+	innerCh chan *pb.Message
+
+	// Index of this node in the validator set, -1 if this node only follows
+	// the finality of the chain.
+	index int
+
+	lock sync.Mutex
+	// The last justified and finalized checkpoints.
+	justified checkpoint
+	finalized checkpoint
+	slashed   map[uint32]bool
+
+	blocks map[string]*pb.Block
+	// Justified checkpoints by hash.
+	justifiedSet map[string]bool
+	// Links by the hashes of their source and target.
+	links map[string]*link
+	// The votes of each validator, used for detecting slashing conditions.
+	history map[uint32][]*pb.CasperVote
+	// The target epoch of the last vote of this validator.
+	lastVoted uint64
+}
+
+// NewEngine returns a new Casper FFG finality gadget wrapping the given
+// engine.
+func NewEngine(engine consensus.Engine, cfg Config) *Engine {
+	// The genesis is justified and finalized, its hash is unknown to the
+	// gadget as the wrapped engine does not relay it.
+	genesis := checkpoint{hash: []byte{}}
+	return &Engine{
+		Config:       cfg,
+		engine:       engine,
+		msgCh:        make(chan *pb.Message, 1024),
+		innerCh:      make(chan *pb.Message),
+		justified:    genesis,
+		finalized:    genesis,
+		blocks:       make(map[string]*pb.Block),
+		justifiedSet: map[string]bool{string(genesis.hash): true},
+		links:        make(map[string]*link),
+		history:      make(map[uint32][]*pb.CasperVote),
+		slashed:      make(map[uint32]bool),
+	}
+}
+
+// Configurate implements the Engine interface. The wrapped engine relays
+// into the gadget, which observes the blocks it produces.
+func (e *Engine) Configurate(relayCh chan<- *pb.Message, priv *ecdsa.PrivateKey) {
+	e.privKey = priv
+	e.relayCh = relayCh
+	e.index = -1
+	if priv != nil {
+		e.index = e.Validators.Index(&priv.PublicKey)
+	}
+	e.engine.Configurate(e.innerCh, priv)
+}
+
+// Start implements the Engine interface. The wrapped engine is started and
+// stopped along with the gadget.
+func (e *Engine) Start(ctx context.Context) error {
+	return e.Run(ctx, e.run, e.forward, e.runEngine)
+}
+
+// AddTransaction implements the Engine interface.
+func (e *Engine) AddTransaction(tx *pb.Transaction) {
+	e.engine.AddTransaction(tx)
+}
+
+// UseMempool implements the consensus.MempoolUser interface by passing the
+// mempool to the wrapped engine, if it uses one.
+func (e *Engine) UseMempool(pool *mempool.Pool) {
+	if u, ok := e.engine.(consensus.MempoolUser); ok {
+		u.UseMempool(pool)
+	}
+}
+
+// ValidatesBlocks implements the consensus.BlockValidator interface by
+// asking the wrapped engine, if it validates blocks.
+func (e *Engine) ValidatesBlocks() bool {
+	return consensus.ValidatesBlocks(e.engine)
+}
+
+// ValidateBlock implements the consensus.BlockValidator interface by
+// passing the block to the wrapped engine.
+func (e *Engine) ValidateBlock(b *pb.Block) error {
+	return e.engine.(consensus.BlockValidator).ValidateBlock(b)
+}
+
+// ValidatesDifficulty implements the consensus.DifficultyValidator interface
+// by asking the wrapped engine, if it validates difficulties.
+func (e *Engine) ValidatesDifficulty() bool {
+	return consensus.ValidatesDifficulty(e.engine)
+}
+
+// ValidateDifficulty implements the consensus.DifficultyValidator interface
+// by passing the block to the wrapped engine.
+func (e *Engine) ValidateDifficulty(b *pb.Block, chain consensus.BlockReader) error {
+	return e.engine.(consensus.DifficultyValidator).ValidateDifficulty(b, chain)
+}
+
+// SignsBlocks implements the consensus.BlockSigner interface by asking the
+// wrapped engine, if it signs blocks.
+func (e *Engine) SignsBlocks() bool {
+	if s, ok := e.engine.(consensus.BlockSigner); ok {
+		return s.SignsBlocks()
+	}
+	return false
+}
+
+// HandleMessage implements the consensus.Handler interface. Messages other
+// than the ones of the gadget are passed on to the wrapped engine.
+func (e *Engine) HandleMessage(from consensus.Peer, msg *pb.Message) error {
+	switch msg.Payload.(type) {
+	case *pb.Message_CasperVote, *pb.Message_CasperSlashing:
+		e.observe(msg)
+		return nil
+	case *pb.Message_Block:
+		e.observe(msg)
+	}
+	if h, ok := e.engine.(consensus.Handler); ok {
+		return h.HandleMessage(from, msg)
+	}
+	return nil
+}
+
+// Finalized returns the hash of the header of the last finalized checkpoint
+// and its epoch.
+func (e *Engine) Finalized() ([]byte, uint64) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.finalized.hash, e.finalized.epoch
+}
+
+// observe passes the message to the run loop of the gadget.
+func (e *Engine) observe(msg *pb.Message) {
+	select {
+	case e.msgCh <- msg:
+	case <-e.Done():
+	}
+}
+
+// runEngine runs the wrapped engine until the gadget is stopped.
+func (e *Engine) runEngine(ctx context.Context) error {
+	if err := e.engine.Start(ctx); err != nil {
+		return err
+	}
+	defer e.engine.Stop()
+	select {
+	case err := <-e.engine.Err():
+		return err
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+// forward relays the messages of the wrapped engine and passes its blocks to
+// the gadget.
+func (e *Engine) forward(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg := <-e.innerCh:
+			if _, ok := msg.Payload.(*pb.Message_Block); ok {
+				e.observe(msg)
+			}
+			select {
+			case e.relayCh <- msg:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+func (e *Engine) run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg := <-e.msgCh:
+			switch p := msg.Payload.(type) {
+			case *pb.Message_Block:
+				e.addBlock(p.Block)
+			case *pb.Message_CasperVote:
+				e.handleVote(p.CasperVote)
+			case *pb.Message_CasperSlashing:
+				e.handleSlashing(p.CasperSlashing)
+			}
+		}
+	}
+}
+
+// addBlock records the block and votes for it if it is the checkpoint of a
+// new epoch.
+func (e *Engine) addBlock(b *pb.Block) {
+	if b == nil || b.Header == nil {
+		return
+	}
+	hash := b.Header.Hash()
+	if _, ok := e.blocks[string(hash)]; ok {
+		return
+	}
+	e.blocks[string(hash)] = b
+
+	if e.index < 0 || e.EpochLength == 0 || b.Header.Index == 0 || b.Header.Index%e.EpochLength != 0 {
+		return
+	}
+	target := checkpoint{hash: hash, epoch: uint64(b.Header.Index / e.EpochLength)}
+	source := e.justifiedCheckpoint()
+	// Voting twice for the same epoch is a double vote, hence only the first
+	// checkpoint seen of an epoch gets a vote.
+	if target.epoch <= e.lastVoted || target.epoch <= source.epoch || !e.descends(b, source) {
+		return
+	}
+	e.lastVoted = target.epoch
+	vote := &pb.CasperVote{
+		Source:      source.hash,
+		SourceEpoch: source.epoch,
+		Target:      target.hash,
+		TargetEpoch: target.epoch,
+		Validator:   uint32(e.index),
+	}
+	if err := sign(vote, e.privKey); err != nil {
+		log.Errorf("casper: failed to sign vote: %s", err)
+		return
+	}
+	e.Relay(e.relayCh, &pb.Message{
+		Payload: &pb.Message_CasperVote{
+			CasperVote: vote,
+		},
+	})
+	e.handleVote(vote)
+}
+
+// descends reports whether the block descends from the checkpoint. Chains of
+// engines that do not link their blocks, or with unknown blocks in between,
+// are assumed to descend from it.
+func (e *Engine) descends(b *pb.Block, cp checkpoint) bool {
+	if cp.epoch == 0 {
+		return true
+	}
+	height := uint32(cp.epoch) * e.EpochLength
+	for b.Header.Index > height {
+		parent, ok := e.blocks[string(b.Header.PrevHash)]
+		if !ok {
+			return true
+		}
+		b = parent
+	}
+	return bytes.Equal(b.Header.Hash(), cp.hash)
+}
+
+func (e *Engine) handleVote(v *pb.CasperVote) {
+	pub := e.Validators.Get(int(v.Validator))
+	if pub == nil || !verify(v, pub) {
+		log.Warnf("casper: vote from %d: %s", v.Validator, errInvalidSignature)
+		return
+	}
+	if v.TargetEpoch <= v.SourceEpoch || e.isSlashed(v.Validator) {
+		return
+	}
+	for _, prev := range e.history[v.Validator] {
+		if prev.SourceEpoch == v.SourceEpoch && prev.TargetEpoch == v.TargetEpoch &&
+			bytes.Equal(prev.Source, v.Source) && bytes.Equal(prev.Target, v.Target) {
+			return
+		}
+		if err := checkSlashing(prev, v); err != nil {
+			e.slash(v.Validator, err)
+			e.Relay(e.relayCh, &pb.Message{
+				Payload: &pb.Message_CasperSlashing{
+					CasperSlashing: &pb.CasperSlashing{
+						Vote1: prev,
+						Vote2: v,
+					},
+				},
+			})
+			return
+		}
+	}
+	e.history[v.Validator] = append(e.history[v.Validator], v)
+
+	key := string(v.Source) + string(v.Target)
+	l, ok := e.links[key]
+	if !ok {
+		l = &link{
+			source: checkpoint{hash: v.Source, epoch: v.SourceEpoch},
+			target: checkpoint{hash: v.Target, epoch: v.TargetEpoch},
+			voters: make(map[uint32]bool),
+		}
+		e.links[key] = l
+	}
+	l.voters[v.Validator] = true
+	e.checkLink(l)
+}
+
+// checkLink justifies the target of the link once 2/3 of the validators
+// voted for it from a justified source.
+func (e *Engine) checkLink(l *link) {
+	if !e.justifiedSet[string(l.source.hash)] || e.justifiedSet[string(l.target.hash)] {
+		return
+	}
+	if len(l.voters) < e.quorum() {
+		return
+	}
+	e.justifiedSet[string(l.target.hash)] = true
+	log.WithFields(log.Fields{
+		"epoch": l.target.epoch,
+		"hash":  hex.EncodeToString(l.target.hash),
+	}).Info("casper: justified checkpoint")
+
+	e.lock.Lock()
+	if l.target.epoch > e.justified.epoch {
+		e.justified = l.target
+	}
+	finalized := l.target.epoch == l.source.epoch+1 && l.source.epoch >= e.finalized.epoch
+	if finalized {
+		e.finalized = l.source
+	}
+	e.lock.Unlock()
+
+	if finalized {
+		log.WithFields(log.Fields{
+			"epoch": l.source.epoch,
+			"hash":  hex.EncodeToString(l.source.hash),
+		}).Info("casper: finalized checkpoint")
+	}
+	// Links from the newly justified checkpoint might have their votes
+	// already.
+	for _, next := range e.links {
+		if bytes.Equal(next.source.hash, l.target.hash) {
+			e.checkLink(next)
+		}
+	}
+}
+
+func (e *Engine) handleSlashing(s *pb.CasperSlashing) {
+	v1, v2 := s.Vote1, s.Vote2
+	if v1 == nil || v2 == nil || v1.Validator != v2.Validator || e.isSlashed(v1.Validator) {
+		return
+	}
+	pub := e.Validators.Get(int(v1.Validator))
+	if pub == nil || !verify(v1, pub) || !verify(v2, pub) {
+		log.Warnf("casper: slashing evidence against %d: %s", v1.Validator, errInvalidSignature)
+		return
+	}
+	if err := checkSlashing(v1, v2); err != nil {
+		e.slash(v1.Validator, err)
+	}
+}
+
+// slash excludes the validator from voting.
+func (e *Engine) slash(validator uint32, reason error) {
+	e.lock.Lock()
+	e.slashed[validator] = true
+	e.lock.Unlock()
+	log.WithFields(log.Fields{
+		"validator": validator,
+		"reason":    reason,
+	}).Warn("casper: slashing condition violated")
+}
+
+func (e *Engine) isSlashed(validator uint32) bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.slashed[validator]
+}
+
+func (e *Engine) justifiedCheckpoint() checkpoint {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.justified
+}
+
+func (e *Engine) quorum() int {
+	return 2*len(e.Validators)/3 + 1
+}
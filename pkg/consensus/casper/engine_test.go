@@ -0,0 +1,168 @@
+package casper
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"testing"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/consensus/consensustest"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/stretchr/testify/assert"
+)
+
+// testEngine is a wrapped engine that does not produce any blocks, the
+// blocks are handed to the gadgets by the tests.
+type testEngine struct{}
+
+func (testEngine) Configurate(chan<- *pb.Message, *ecdsa.PrivateKey) {}
+func (testEngine) Start(context.Context) error                       { return nil }
+func (testEngine) Stop()                                             {}
+func (testEngine) Err() <-chan error                                 { return nil }
+func (testEngine) AddTransaction(*pb.Transaction)                    {}
+
+// newTestNetwork returns a network of n gadgets, which are not started.
+func newTestNetwork(n int) *consensustest.Network {
+	return consensustest.NewNetwork(n, func(validators consensus.Validators) consensus.Engine {
+		return NewEngine(testEngine{}, Config{
+			Validators:  validators,
+			EpochLength: 2,
+		})
+	})
+}
+
+// gadgets returns the gadgets of the network at the given indexes.
+func gadgets(net *consensustest.Network, indexes ...int) []*Engine {
+	engines := make([]*Engine, len(indexes))
+	for i, index := range indexes {
+		engines[i] = net.Engine(index).(*Engine)
+	}
+	return engines
+}
+
+// newTestChain returns a chain of n linked blocks.
+func newTestChain(n int) []*pb.Block {
+	var (
+		chain = make([]*pb.Block, n)
+		prev  *pb.Block
+	)
+	for i := range chain {
+		b := pb.NewBlock(uint32(i))
+		if prev != nil {
+			b.Header.PrevHash = prev.Header.Hash()
+		}
+		chain[i] = b
+		prev = b
+	}
+	return chain
+}
+
+// addBlocks hands the blocks to the gadgets. Each checkpoint is handed out
+// once the previous one is justified, otherwise the gadgets would vote from
+// an older justified checkpoint.
+func addBlocks(t *testing.T, engines []*Engine, blocks []*pb.Block) {
+	for _, b := range blocks {
+		for _, e := range engines {
+			e.HandleMessage(nil, &pb.Message{
+				Payload: &pb.Message_Block{
+					Block: b,
+				},
+			})
+		}
+		if b.Header.Index%2 != 0 {
+			continue
+		}
+		epoch := uint64(b.Header.Index / 2)
+		for _, e := range engines {
+			wait(t, func() bool { return e.justifiedCheckpoint().epoch >= epoch })
+		}
+	}
+}
+
+func wait(t *testing.T, cond func() bool) {
+	deadline := time.After(5 * time.Second)
+	for !cond() {
+		select {
+		case <-deadline:
+			t.Fatal("timeout")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestEngineFinalize(t *testing.T) {
+	var (
+		net     = newTestNetwork(4)
+		engines = gadgets(net, 0, 1, 2, 3)
+		chain   = newTestChain(6)
+	)
+	net.Start()
+	defer net.Stop()
+	addBlocks(t, engines, chain)
+	for _, e := range engines {
+		// Epoch 2 (block 4) is finalized by the justification of epoch 3.
+		hash, epoch := e.Finalized()
+		assert.Equal(t, uint64(2), epoch)
+		assert.Equal(t, chain[3].Header.Hash(), hash)
+	}
+}
+
+func TestEngineFinalizeCrashed(t *testing.T) {
+	var (
+		net     = newTestNetwork(4)
+		engines = gadgets(net, 0, 1, 2)
+		chain   = newTestChain(4)
+	)
+	net.Start(3)
+	defer net.Stop()
+	addBlocks(t, engines, chain)
+	hash, epoch := engines[0].Finalized()
+	assert.Equal(t, uint64(1), epoch)
+	assert.Equal(t, chain[1].Header.Hash(), hash)
+}
+
+func TestEngineNoQuorum(t *testing.T) {
+	var (
+		net     = newTestNetwork(4)
+		engines = gadgets(net, 0, 1)
+		chain   = newTestChain(6)
+	)
+	net.Start(2, 3)
+	defer net.Stop()
+	for _, b := range chain {
+		for _, e := range engines {
+			e.HandleMessage(nil, &pb.Message{
+				Payload: &pb.Message_Block{
+					Block: b,
+				},
+			})
+		}
+	}
+	time.Sleep(200 * time.Millisecond)
+	_, epoch := engines[0].Finalized()
+	assert.Equal(t, uint64(0), epoch)
+}
+
+func TestEngineSlashing(t *testing.T) {
+	var (
+		net     = newTestNetwork(4)
+		engines = gadgets(net, 0, 1)
+		priv    = consensustest.PrivateKey(3)
+		vote1   = &pb.CasperVote{Target: []byte("a"), TargetEpoch: 1, Validator: 3}
+		vote2   = &pb.CasperVote{Target: []byte("b"), TargetEpoch: 1, Validator: 3}
+	)
+	net.Start()
+	defer net.Stop()
+	assert.Nil(t, sign(vote1, priv))
+	assert.Nil(t, sign(vote2, priv))
+	for _, v := range []*pb.CasperVote{vote1, vote2} {
+		engines[0].HandleMessage(nil, &pb.Message{
+			Payload: &pb.Message_CasperVote{
+				CasperVote: v,
+			},
+		})
+	}
+	// The evidence is relayed to the other validators.
+	wait(t, func() bool { return engines[1].isSlashed(3) })
+}
@@ -0,0 +1,38 @@
+package casper
+
+import (
+	"crypto/ecdsa"
+	"errors"
+
+	"github.com/anthdm/consenter/pkg/common"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	proto "github.com/golang/protobuf/proto"
+)
+
+var errInvalidSignature = errors.New("invalid signature")
+
+// sign signs the vote with priv and sets its signature.
+func sign(v *pb.CasperVote, priv *ecdsa.PrivateKey) error {
+	sig, err := common.Sign(priv, signatureHash(v))
+	if err != nil {
+		return err
+	}
+	v.Signature = sig
+	return nil
+}
+
+// verify reports whether the vote is signed by pub.
+func verify(v *pb.CasperVote, pub *ecdsa.PublicKey) bool {
+	return common.Verify(pub, signatureHash(v), v.Signature)
+}
+
+// signatureHash returns the hash of the vote without its signature.
+func signatureHash(v *pb.CasperVote) []byte {
+	v = proto.Clone(v).(*pb.CasperVote)
+	v.Signature = nil
+	b, err := proto.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return common.Hash256(b)
+}
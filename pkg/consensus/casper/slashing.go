@@ -0,0 +1,33 @@
+package casper
+
+import (
+	"bytes"
+	"errors"
+
+	pb "github.com/anthdm/consenter/pkg/protos"
+)
+
+var (
+	errDoubleVote   = errors.New("double vote")
+	errSurroundVote = errors.New("surround vote")
+)
+
+// checkSlashing returns the slashing condition the two votes of the same
+// validator violate, or nil if they do not violate any.
+func checkSlashing(v1, v2 *pb.CasperVote) error {
+	// A validator must not vote for two different targets of the same epoch.
+	if v1.TargetEpoch == v2.TargetEpoch && !bytes.Equal(v1.Target, v2.Target) {
+		return errDoubleVote
+	}
+	// A validator must not vote for a link within the span of another one of
+	// its links.
+	if surrounds(v1, v2) || surrounds(v2, v1) {
+		return errSurroundVote
+	}
+	return nil
+}
+
+// surrounds reports whether the link of v1 surrounds the link of v2.
+func surrounds(v1, v2 *pb.CasperVote) bool {
+	return v1.SourceEpoch < v2.SourceEpoch && v2.TargetEpoch < v1.TargetEpoch
+}
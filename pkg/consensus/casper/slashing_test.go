@@ -0,0 +1,26 @@
+package casper
+
+import (
+	"testing"
+
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckSlashing(t *testing.T) {
+	var (
+		vote = &pb.CasperVote{Source: []byte("a"), SourceEpoch: 1, Target: []byte("b"), TargetEpoch: 2}
+		same = &pb.CasperVote{Source: []byte("a"), SourceEpoch: 1, Target: []byte("b"), TargetEpoch: 2}
+		next = &pb.CasperVote{Source: []byte("b"), SourceEpoch: 2, Target: []byte("c"), TargetEpoch: 3}
+	)
+	assert.Nil(t, checkSlashing(vote, same))
+	assert.Nil(t, checkSlashing(vote, next))
+
+	double := &pb.CasperVote{Source: []byte("a"), SourceEpoch: 1, Target: []byte("x"), TargetEpoch: 2}
+	assert.Equal(t, errDoubleVote, checkSlashing(vote, double))
+
+	// 0 -> 3 surrounds 1 -> 2, in both orders.
+	surround := &pb.CasperVote{SourceEpoch: 0, Target: []byte("c"), TargetEpoch: 3}
+	assert.Equal(t, errSurroundVote, checkSlashing(vote, surround))
+	assert.Equal(t, errSurroundVote, checkSlashing(surround, vote))
+}
@@ -0,0 +1,272 @@
+// Package consensustest simulates a network of consensus engines for the
+// tests of the engines.
+package consensustest
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/common"
+	"github.com/anthdm/consenter/pkg/consensus"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	proto "github.com/golang/protobuf/proto"
+)
+
+// PrivateKey returns the private key of the validator at index i.
+func PrivateKey(i int) *ecdsa.PrivateKey {
+	return common.NewPrivateKey([]byte(fmt.Sprintf("node_%d", i)))
+}
+
+// Validators returns the validators of a network of n engines.
+func Validators(n int) consensus.Validators {
+	v := make(consensus.Validators, n)
+	for i := range v {
+		v[i] = &PrivateKey(i).PublicKey
+	}
+	return v
+}
+
+// Block is a block relayed by the engine at index From.
+type Block struct {
+	From  int
+	Block *pb.Block
+}
+
+// Tamper returns the message a Byzantine engine sends to the engine at index
+// to instead of the given one, or nil if it sends none. The message is a copy
+// that can be modified.
+type Tamper func(to int, msg *pb.Message) *pb.Message
+
+// Network connects engines with each other, like the servers of their nodes
+// would. The blocks relayed by the engines are collected by the network.
+type Network struct {
+	// How long Receive waits for the next block.
+	Timeout time.Duration
+
+	engines    []consensus.Engine
+	validators consensus.Validators
+	blocks     chan Block
+	quit       chan struct{}
+	// The first block received at each index.
+	committed map[uint32]*pb.Block
+
+	lock    sync.Mutex
+	started map[int]bool
+	// Engines that neither send nor receive messages.
+	down map[int]bool
+	// Reports whether the message from an engine to another is lost.
+	drop      func(from, to int, msg *pb.Message) bool
+	byzantine map[int]Tamper
+}
+
+// NewNetwork returns a network of n engines, created by newEngine with the
+// validators of the network. The engines are started by Start.
+func NewNetwork(n int, newEngine func(consensus.Validators) consensus.Engine) *Network {
+	net := &Network{
+		Timeout:    5 * time.Second,
+		engines:    make([]consensus.Engine, n),
+		validators: Validators(n),
+		blocks:     make(chan Block, 100),
+		quit:       make(chan struct{}),
+		committed:  make(map[uint32]*pb.Block),
+		started:    make(map[int]bool),
+		down:       make(map[int]bool),
+		byzantine:  make(map[int]Tamper),
+	}
+	for i := range net.engines {
+		net.engines[i] = newEngine(net.validators)
+	}
+	return net
+}
+
+// Engine returns the engine at index i.
+func (net *Network) Engine(i int) consensus.Engine {
+	return net.engines[i]
+}
+
+// Start starts the engines, except the ones at the given indexes which are
+// considered to be crashed. Each engine starts with a transaction of its own.
+func (net *Network) Start(crashed ...int) {
+	for _, i := range crashed {
+		net.SetDown(i, true)
+	}
+	// All engines are configured before any is started, the messages of the
+	// first engines are queued by the others until they are started.
+	var engines []int
+	for i, e := range net.engines {
+		if net.isDown(i) {
+			continue
+		}
+		relayCh := make(chan *pb.Message)
+		go net.relay(i, relayCh)
+		e.Configurate(relayCh, PrivateKey(i))
+		engines = append(engines, i)
+	}
+	net.lock.Lock()
+	for _, i := range engines {
+		net.started[i] = true
+	}
+	net.lock.Unlock()
+	for _, i := range engines {
+		net.engines[i].Start(context.Background())
+		net.engines[i].AddTransaction(pb.NewTransaction())
+	}
+}
+
+// Stop stops the started engines.
+func (net *Network) Stop() {
+	close(net.quit)
+	for i, e := range net.engines {
+		if net.isStarted(i) {
+			e.Stop()
+		}
+	}
+}
+
+// SetDown cuts the engine at index i off the network, or connects it again.
+func (net *Network) SetDown(i int, down bool) {
+	net.lock.Lock()
+	defer net.lock.Unlock()
+	net.down[i] = down
+}
+
+// SetDrop sets the function that reports whether the message from an engine
+// to another is lost.
+func (net *Network) SetDrop(drop func(from, to int, msg *pb.Message) bool) {
+	net.lock.Lock()
+	defer net.lock.Unlock()
+	net.drop = drop
+}
+
+// SetByzantine makes the engine at index i send the messages returned by
+// tamper instead of its own. The blocks it relays are ignored, Byzantine
+// engines might claim to commit anything.
+func (net *Network) SetByzantine(i int, tamper Tamper) {
+	net.lock.Lock()
+	defer net.lock.Unlock()
+	net.byzantine[i] = tamper
+}
+
+// Sender returns the sender of the engine at index i, which delivers direct
+// messages like a server. Direct messages are flagged so.
+func (net *Network) Sender(i int) consensus.Sender {
+	return sender{net: net, from: i}
+}
+
+type sender struct {
+	net  *Network
+	from int
+}
+
+// SendTo implements the consensus.Sender interface.
+func (s sender) SendTo(pub *ecdsa.PublicKey, msg *pb.Message) {
+	msg = proto.Clone(msg).(*pb.Message)
+	to := s.net.validators.Index(pub)
+	if to < 0 {
+		s.net.broadcast(s.from, msg)
+		return
+	}
+	msg.Flag = pb.Flag_direct
+	s.net.deliver(s.from, to, msg)
+}
+
+// Receive returns the next block relayed by the engines. Blocks relayed at
+// the same index, which are committed by different replicas, have to be the
+// same. Failures are reported to fatalf, which is usually the Fatalf method
+// of the running test.
+func (net *Network) Receive(fatalf func(format string, args ...interface{})) Block {
+	select {
+	case b := <-net.blocks:
+		index := b.Block.Header.Index
+		if prev, ok := net.committed[index]; !ok {
+			net.committed[index] = b.Block
+		} else if !bytes.Equal(prev.Hash(), b.Block.Hash()) {
+			fatalf("replicas committed different blocks at index %d", index)
+		}
+		return b
+	case <-time.After(net.Timeout):
+		fatalf("no block committed")
+		return Block{}
+	}
+}
+
+// ReceiveNone checks that no block is relayed for the given duration.
+func (net *Network) ReceiveNone(fatalf func(format string, args ...interface{}), d time.Duration) {
+	select {
+	case b := <-net.blocks:
+		fatalf("block %d committed by %d", b.Block.Header.Index, b.From)
+	case <-time.After(d):
+	}
+}
+
+// relay passes the messages relayed by the engine at index from to the other
+// engines.
+func (net *Network) relay(from int, relayCh <-chan *pb.Message) {
+	for msg := range relayCh {
+		b, ok := msg.Payload.(*pb.Message_Block)
+		if !ok {
+			net.broadcast(from, msg)
+			continue
+		}
+		if net.isDown(from) || net.isByzantine(from) {
+			continue
+		}
+		select {
+		case net.blocks <- Block{From: from, Block: b.Block}:
+		case <-net.quit:
+		}
+	}
+}
+
+func (net *Network) broadcast(from int, msg *pb.Message) {
+	for to := range net.engines {
+		if to != from {
+			net.deliver(from, to, msg)
+		}
+	}
+}
+
+func (net *Network) deliver(from, to int, msg *pb.Message) {
+	net.lock.Lock()
+	var (
+		lost   = net.down[from] || net.down[to] || !net.started[to]
+		tamper = net.byzantine[from]
+	)
+	if net.drop != nil && net.drop(from, to, msg) {
+		lost = true
+	}
+	net.lock.Unlock()
+	if lost {
+		return
+	}
+	if tamper != nil {
+		if msg = tamper(to, proto.Clone(msg).(*pb.Message)); msg == nil {
+			return
+		}
+	}
+	if h, ok := net.engines[to].(consensus.Handler); ok {
+		h.HandleMessage(nil, msg)
+	}
+}
+
+func (net *Network) isDown(i int) bool {
+	net.lock.Lock()
+	defer net.lock.Unlock()
+	return net.down[i]
+}
+
+func (net *Network) isStarted(i int) bool {
+	net.lock.Lock()
+	defer net.lock.Unlock()
+	return net.started[i]
+}
+
+func (net *Network) isByzantine(i int) bool {
+	net.lock.Lock()
+	defer net.lock.Unlock()
+	return net.byzantine[i] != nil
+}
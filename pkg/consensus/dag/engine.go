@@ -0,0 +1,333 @@
+package dag
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"math/rand"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/common"
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/mempool"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxPending is the maximum amount of events buffered because one of their
+// parents is not known yet.
+const maxPending = 4096
+
+// Config holds the configuration of the DAG engine.
+type Config struct {
+	// The nodes creating events. Virtual voting tolerates f faulty nodes out
+	// of 3f+1.
+	Validators consensus.Validators
+
+	// The interval in which a node creates a new event, holding its pending
+	// transactions.
+	EventInterval time.Duration
+}
+
+// Engine is a hashgraph consensus engine. Instead of agreeing on a chain of
+// blocks proposed by a leader, nodes multicast events that reference the
+// last event of their creator and the last event received from another node.
+// The order of the events follows from virtual voting over the resulting
+// graph, without sending any votes. The transactions of the events received
+// in a round are committed as a block.
+type Engine struct {
+	Config
+	consensus.Lifecycle
+
+	privKey *ecdsa.PrivateKey
+	relayCh chan<- *pb.Message
+	msgCh   chan *pb.Message
+
+	// Index of this node in the validator set, -1 if this node only follows
+	// the graph.
+	index int
+
+	// Pending transactions, shared with the server if it has a mempool.
+	pool *mempool.Pool
+
+	graph *hashgraph
+	// Events waiting for the parent with the given hash.
+	pending      map[string][]*pb.DagEvent
+	pendingCount int
+	// Hashes of the committed transactions, transactions are part of the
+	// events of every node that received them.
+	committed map[string]bool
+	height    uint32
+	prevHash  []byte
+}
+
+func init() {
+	consensus.Register(consensus.Factory{
+		Name:        "dag",
+		Description: "Hashgraph events ordered by virtual voting",
+		Config: func() interface{} {
+			return &Config{
+				EventInterval: 1 * time.Second,
+			}
+		},
+		New: func(c interface{}) (consensus.Engine, error) {
+			cfg := c.(*Config)
+			if len(cfg.Validators) == 0 {
+				return nil, consensus.ErrMissingValidators
+			}
+			return NewEngine(*cfg), nil
+		},
+	})
+}
+
+// NewEngine returns a new DAG consensus engine.
+func NewEngine(cfg Config) *Engine {
+	return &Engine{
+		Config:    cfg,
+		msgCh:     make(chan *pb.Message, 1024),
+		pool:      mempool.New(mempool.Config{}),
+		graph:     newHashgraph(len(cfg.Validators)),
+		pending:   make(map[string][]*pb.DagEvent),
+		committed: make(map[string]bool),
+	}
+}
+
+// Configurate implements the Engine interface.
+func (e *Engine) Configurate(relayCh chan<- *pb.Message, priv *ecdsa.PrivateKey) {
+	e.privKey = priv
+	e.relayCh = relayCh
+	e.index = -1
+	if priv != nil {
+		e.index = e.Validators.Index(&priv.PublicKey)
+	}
+}
+
+// Start implements the Engine interface.
+func (e *Engine) Start(ctx context.Context) error {
+	return e.Run(ctx, e.run)
+}
+
+// AddTransaction implements the Engine interface.
+func (e *Engine) AddTransaction(tx *pb.Transaction) {
+	e.pool.Add(tx)
+}
+
+// UseMempool implements the consensus.MempoolUser interface.
+func (e *Engine) UseMempool(pool *mempool.Pool) {
+	e.pool = pool
+}
+
+// HandleMessage implements the consensus.Handler interface.
+func (e *Engine) HandleMessage(from consensus.Peer, msg *pb.Message) error {
+	if _, ok := msg.Payload.(*pb.Message_DagEvent); ok {
+		select {
+		case e.msgCh <- msg:
+		case <-e.Done():
+		}
+	}
+	return nil
+}
+
+func (e *Engine) run(ctx context.Context) error {
+	ticker := time.NewTicker(e.EventInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if e.index >= 0 {
+				e.createEvent()
+			}
+		case msg := <-e.msgCh:
+			if p, ok := msg.Payload.(*pb.Message_DagEvent); ok {
+				e.handleEvent(p.DagEvent)
+			}
+		}
+	}
+}
+
+// createEvent creates an event holding the pending transactions. Events
+// without transactions are only created if there is a new event of another
+// node to reference, to not grow the graph without progress.
+func (e *Engine) createEvent() {
+	var (
+		self  = e.graph.last(e.index)
+		other = e.otherParent(self)
+	)
+	// The transactions stay pending until the event is inserted.
+	txs := e.pool.Batch(consensus.MaxBlockTxs, consensus.MaxBlockBytes)
+	if self != nil && other == nil && len(txs) == 0 {
+		return
+	}
+
+	ev := &pb.DagEvent{
+		Creator:      uint32(e.index),
+		Timestamp:    time.Now().UnixNano(),
+		Transactions: txs,
+	}
+	if self != nil {
+		ev.SelfParent = self.hash
+	}
+	if other != nil {
+		ev.OtherParent = other.hash
+	}
+	if err := sign(ev, e.privKey); err != nil {
+		log.Errorf("dag: failed to sign event: %s", err)
+		return
+	}
+	e.Relay(e.relayCh, &pb.Message{
+		Payload: &pb.Message_DagEvent{
+			DagEvent: ev,
+		},
+	})
+	e.handleEvent(ev)
+}
+
+// otherParent returns the last event of a random other node that is not an
+// ancestor of the self parent yet, nil if there is none. Picking a random
+// node, like the random peer of a gossip sync in hashgraph, spreads the
+// knowledge of events over all nodes.
+func (e *Engine) otherParent(self *event) *event {
+	var candidates []*event
+	for c := range e.Validators {
+		last := e.graph.last(c)
+		if c == e.index || last == nil {
+			continue
+		}
+		if self == nil || !e.graph.sees(self, last) {
+			candidates = append(candidates, last)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	return candidates[rand.Intn(len(candidates))]
+}
+
+func (e *Engine) handleEvent(ev *pb.DagEvent) {
+	hash := signatureHash(ev)
+	if e.graph.has(hash) {
+		return
+	}
+	pub := e.Validators.Get(int(ev.Creator))
+	if pub == nil || !verify(ev, pub) {
+		log.Warnf("dag: event from %d: %s", ev.Creator, errInvalidSignature)
+		return
+	}
+	if parent := e.missingParent(ev); parent != nil {
+		e.buffer(ev, parent)
+		return
+	}
+	if !e.insert(ev, hash) {
+		return
+	}
+	if e.graph.decideFame() {
+		for _, events := range e.graph.order() {
+			e.commit(events)
+		}
+	}
+}
+
+// insert inserts the event into the graph, followed by the buffered events
+// that were waiting for it.
+func (e *Engine) insert(ev *pb.DagEvent, hash []byte) bool {
+	if _, err := e.graph.insert(ev, hash); err != nil {
+		log.Warnf("dag: event from %d: %s", ev.Creator, err)
+		return false
+	}
+	e.pool.Remove(ev.Transactions)
+
+	children := e.pending[string(hash)]
+	delete(e.pending, string(hash))
+	e.pendingCount -= len(children)
+	for _, child := range children {
+		childHash := signatureHash(child)
+		if e.graph.has(childHash) {
+			continue
+		}
+		// The other parent of the child might still be missing.
+		if parent := e.missingParent(child); parent != nil {
+			e.buffer(child, parent)
+			continue
+		}
+		e.insert(child, childHash)
+	}
+	return true
+}
+
+// missingParent returns the hash of a parent of the event that is not part
+// of the graph yet, nil if both are.
+func (e *Engine) missingParent(ev *pb.DagEvent) []byte {
+	for _, parent := range [][]byte{ev.SelfParent, ev.OtherParent} {
+		if len(parent) > 0 && !e.graph.has(parent) {
+			return parent
+		}
+	}
+	return nil
+}
+
+// buffer buffers the event until its parent with the given hash is inserted.
+func (e *Engine) buffer(ev *pb.DagEvent, parent []byte) {
+	if e.pendingCount >= maxPending {
+		return
+	}
+	e.pending[string(parent)] = append(e.pending[string(parent)], ev)
+	e.pendingCount++
+}
+
+// commit commits the transactions of the events received in a round as a
+// block, in consensus order. Every node orders the events the same, hence all
+// of them commit the same block. The validators take turns signing and
+// relaying it.
+func (e *Engine) commit(events []*event) {
+	var (
+		txs       []*pb.Transaction
+		timestamp int64
+	)
+	for _, x := range events {
+		for _, tx := range x.Transactions {
+			if !e.committed[string(tx.Hash())] {
+				e.committed[string(tx.Hash())] = true
+				txs = append(txs, tx)
+			}
+		}
+		timestamp = x.timestamp
+	}
+	if len(txs) == 0 {
+		return
+	}
+	e.height++
+	relayer := int((e.height - 1) % uint32(len(e.Validators)))
+	block := &pb.Block{
+		Header: &pb.Header{
+			Index:     e.height,
+			PrevHash:  e.prevHash,
+			Timestamp: timestamp,
+			Proposer:  common.MarshalPublicKey(e.Validators.Get(relayer)),
+			TxRoot:    pb.TxRoot(txs),
+		},
+		Transactions: txs,
+	}
+	e.prevHash = block.Header.Hash()
+
+	log.WithFields(log.Fields{
+		"index": block.Header.Index,
+		"hash":  hex.EncodeToString(block.Hash()),
+		"txs":   len(block.Transactions),
+		"round": events[0].roundReceived,
+	}).Info("dag: committed block")
+
+	if e.index != relayer {
+		return
+	}
+	if err := block.Sign(e.privKey); err != nil {
+		log.Errorf("dag: failed to sign block: %s", err)
+		return
+	}
+	e.Relay(e.relayCh, &pb.Message{
+		Payload: &pb.Message_Block{
+			Block: block,
+		},
+	})
+}
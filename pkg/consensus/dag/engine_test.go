@@ -0,0 +1,66 @@
+package dag
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/consensus/consensustest"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestNetwork returns a network of n engines, which are not started.
+func newTestNetwork(n int) *consensustest.Network {
+	return consensustest.NewNetwork(n, func(validators consensus.Validators) consensus.Engine {
+		return NewEngine(Config{
+			Validators:    validators,
+			EventInterval: 10 * time.Millisecond,
+		})
+	})
+}
+
+// receiveFirst waits for the first block, which every node commits and a
+// single one relays. It has to be signed by one of the validators.
+func receiveFirst(t *testing.T, net *consensustest.Network, validators consensus.Validators) *pb.Block {
+	b := net.Receive(t.Fatalf).Block
+	assert.Equal(t, uint32(1), b.Header.Index)
+	pub, err := b.Verify()
+	assert.Nil(t, err)
+	assert.True(t, validators.Index(pub) >= 0)
+	return b
+}
+
+func TestEngineCommit(t *testing.T) {
+	net := newTestNetwork(4)
+	net.Start()
+	defer net.Stop()
+	b := receiveFirst(t, net, consensustest.Validators(4))
+	assert.True(t, len(b.Transactions) > 0)
+}
+
+func TestEngineCrashedNode(t *testing.T) {
+	net := newTestNetwork(4)
+	net.Start(2)
+	defer net.Stop()
+	receiveFirst(t, net, consensustest.Validators(4))
+}
+
+func TestEngineForkedEvents(t *testing.T) {
+	net := newTestNetwork(4)
+	// A node sends node 1 other events than the rest, which forks its chain
+	// of events. The events of node 1 reference the fork and are never
+	// inserted by the others, which still agree on the blocks.
+	net.SetByzantine(3, func(to int, msg *pb.Message) *pb.Message {
+		if ev := msg.GetDagEvent(); ev != nil && to == 1 {
+			ev.Timestamp++
+			if err := sign(ev, consensustest.PrivateKey(3)); err != nil {
+				panic(err)
+			}
+		}
+		return msg
+	})
+	net.Start()
+	defer net.Stop()
+	receiveFirst(t, net, consensustest.Validators(4))
+}
@@ -0,0 +1,331 @@
+package dag
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	pb "github.com/anthdm/consenter/pkg/protos"
+)
+
+// coinRound is the frequency of the rounds in which undecided witnesses vote
+// by coin flip, which guarantees termination of the fame election.
+const coinRound = 10
+
+var (
+	errUnknownCreator = errors.New("unknown creator")
+	errFork           = errors.New("event forks the chain of its creator")
+)
+
+type fame int
+
+const (
+	undecided fame = iota
+	famous
+	notFamous
+)
+
+// event is an event inserted into the hashgraph.
+type event struct {
+	*pb.DagEvent
+	hash []byte
+	// Position of the event in the chain of its creator.
+	seq int
+	// The highest position in the chain of each creator of the ancestors of
+	// the event, including the event itself. -1 if no event of the creator is
+	// an ancestor.
+	lastAncestors []int
+
+	round   int
+	witness bool
+	fame    fame
+	// Votes of the witness on the fame of the witnesses of earlier rounds, by
+	// their hash.
+	votes map[string]bool
+
+	// The round the event is received in and its consensus timestamp, only
+	// set once the event is ordered.
+	roundReceived int
+	timestamp     int64
+}
+
+// hashgraph holds the events of all nodes and orders them by virtual voting.
+// Nodes do not send votes, instead each node computes how every other node
+// would have voted from the events it knows.
+type hashgraph struct {
+	n int
+	// Events by hash and the chain of events of each creator.
+	events map[string]*event
+	chains [][]*event
+	// Witnesses by round.
+	witnesses map[int][]*event
+	maxRound  int
+	// The last round with the fame of all its witnesses decided and the last
+	// round its received events are ordered in.
+	decidedRound int
+	orderedRound int
+	// Events that are not ordered yet, in insertion order.
+	unordered []*event
+}
+
+func newHashgraph(n int) *hashgraph {
+	return &hashgraph{
+		n:            n,
+		events:       make(map[string]*event),
+		chains:       make([][]*event, n),
+		witnesses:    make(map[int][]*event),
+		decidedRound: -1,
+		orderedRound: -1,
+	}
+}
+
+// has reports whether the event with the given hash is part of the graph.
+func (g *hashgraph) has(hash []byte) bool {
+	_, ok := g.events[string(hash)]
+	return ok
+}
+
+// last returns the last event of the creator, nil if it has none.
+func (g *hashgraph) last(creator int) *event {
+	chain := g.chains[creator]
+	if len(chain) == 0 {
+		return nil
+	}
+	return chain[len(chain)-1]
+}
+
+// insert adds the event to the graph, its parents need to be part of the
+// graph already.
+func (g *hashgraph) insert(ev *pb.DagEvent, hash []byte) (*event, error) {
+	c := int(ev.Creator)
+	if c >= g.n {
+		return nil, errUnknownCreator
+	}
+	x := &event{
+		DagEvent:      ev,
+		hash:          hash,
+		lastAncestors: make([]int, g.n),
+		votes:         make(map[string]bool),
+	}
+	for i := range x.lastAncestors {
+		x.lastAncestors[i] = -1
+	}
+	// The self parent needs to be the last event of the creator, anything
+	// else means the creator created two events with the same self parent.
+	self := g.last(c)
+	if (self == nil) != (len(ev.SelfParent) == 0) ||
+		(self != nil && !bytes.Equal(self.hash, ev.SelfParent)) {
+		return nil, errFork
+	}
+	var parents []*event
+	if self != nil {
+		parents = append(parents, self)
+		x.seq = self.seq + 1
+	}
+	if len(ev.OtherParent) > 0 {
+		parents = append(parents, g.events[string(ev.OtherParent)])
+	}
+	for _, p := range parents {
+		for i, s := range p.lastAncestors {
+			if s > x.lastAncestors[i] {
+				x.lastAncestors[i] = s
+			}
+		}
+		if p.round > x.round {
+			x.round = p.round
+		}
+	}
+	x.lastAncestors[c] = x.seq
+	g.events[string(hash)] = x
+	g.chains[c] = append(g.chains[c], x)
+
+	// The event starts a new round if it strongly sees a supermajority of
+	// the witnesses of the round of its parents.
+	if len(parents) > 0 {
+		count := 0
+		for _, w := range g.witnesses[x.round] {
+			if g.stronglySees(x, w) {
+				count++
+			}
+		}
+		if g.supermajority(count) {
+			x.round++
+		}
+	}
+	x.witness = self == nil || self.round < x.round
+	if x.witness {
+		// Witnesses of decided rounds can not be famous anymore.
+		if x.round <= g.decidedRound {
+			x.fame = notFamous
+		}
+		g.witnesses[x.round] = append(g.witnesses[x.round], x)
+	}
+	if x.round > g.maxRound {
+		g.maxRound = x.round
+	}
+	g.unordered = append(g.unordered, x)
+	return x, nil
+}
+
+// sees reports whether y is an ancestor of x.
+func (g *hashgraph) sees(x, y *event) bool {
+	return x.lastAncestors[y.Creator] >= y.seq
+}
+
+// stronglySees reports whether x sees y through events of a supermajority of
+// the creators.
+func (g *hashgraph) stronglySees(x, y *event) bool {
+	count := 0
+	for c, s := range x.lastAncestors {
+		// The last event of the creator seen by x is the one most likely to
+		// see y.
+		if s >= 0 && g.sees(g.chains[c][s], y) {
+			count++
+		}
+	}
+	return g.supermajority(count)
+}
+
+// decideFame runs the virtual election on the fame of the witnesses of the
+// undecided rounds and returns whether any round got decided.
+func (g *hashgraph) decideFame() bool {
+	decided := false
+	for r := g.decidedRound + 1; r < g.maxRound; r++ {
+		for _, y := range g.witnesses[r] {
+			if y.fame == undecided {
+				g.elect(y)
+			}
+		}
+		for _, y := range g.witnesses[r] {
+			if y.fame == undecided {
+				return decided
+			}
+		}
+		g.decidedRound = r
+		decided = true
+	}
+	return decided
+}
+
+// elect lets the witnesses of the later rounds vote on the fame of y.
+func (g *hashgraph) elect(y *event) {
+	key := string(y.hash)
+	for r := y.round + 1; r <= g.maxRound; r++ {
+		for _, x := range g.witnesses[r] {
+			d := r - y.round
+			if d == 1 {
+				x.votes[key] = g.sees(x, y)
+				continue
+			}
+			// Collect the votes of the witnesses of the previous round that
+			// are strongly seen.
+			var yes, no int
+			for _, w := range g.witnesses[r-1] {
+				if !g.stronglySees(x, w) {
+					continue
+				}
+				if w.votes[key] {
+					yes++
+				} else {
+					no++
+				}
+			}
+			vote, count := yes >= no, yes
+			if !vote {
+				count = no
+			}
+			switch {
+			case d%coinRound != 0 && g.supermajority(count):
+				y.fame = notFamous
+				if vote {
+					y.fame = famous
+				}
+				return
+			case d%coinRound != 0 || g.supermajority(count):
+				x.votes[key] = vote
+			default:
+				x.votes[key] = coin(x)
+			}
+		}
+	}
+}
+
+// order returns the events received in each of the rounds decided since the
+// last call, in consensus order. An event is received in the first round in
+// which all famous witnesses see it.
+func (g *hashgraph) order() [][]*event {
+	var ordered [][]*event
+	for g.orderedRound < g.decidedRound {
+		g.orderedRound++
+		var fam []*event
+		for _, w := range g.witnesses[g.orderedRound] {
+			if w.fame == famous {
+				fam = append(fam, w)
+			}
+		}
+		if len(fam) == 0 {
+			continue
+		}
+		var (
+			received []*event
+			pending  = g.unordered[:0]
+		)
+		for _, x := range g.unordered {
+			if g.seenByAll(x, fam) {
+				x.roundReceived = g.orderedRound
+				x.timestamp = g.consensusTimestamp(x, fam)
+				received = append(received, x)
+			} else {
+				pending = append(pending, x)
+			}
+		}
+		g.unordered = pending
+		sort.Slice(received, func(i, j int) bool {
+			if received[i].timestamp != received[j].timestamp {
+				return received[i].timestamp < received[j].timestamp
+			}
+			return bytes.Compare(received[i].hash, received[j].hash) < 0
+		})
+		if len(received) > 0 {
+			ordered = append(ordered, received)
+		}
+	}
+	return ordered
+}
+
+func (g *hashgraph) seenByAll(x *event, witnesses []*event) bool {
+	for _, w := range witnesses {
+		if !g.sees(w, x) {
+			return false
+		}
+	}
+	return true
+}
+
+// consensusTimestamp returns the median of the times at which the creators
+// of the famous witnesses first learned of x.
+func (g *hashgraph) consensusTimestamp(x *event, fam []*event) int64 {
+	times := make([]int64, 0, len(fam))
+	for _, w := range fam {
+		chain := g.chains[w.Creator][:w.seq+1]
+		i := sort.Search(len(chain), func(i int) bool {
+			return g.sees(chain[i], x)
+		})
+		times = append(times, chain[i].Timestamp)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	return times[len(times)/2]
+}
+
+func (g *hashgraph) supermajority(count int) bool {
+	return 3*count > 2*g.n
+}
+
+// coin returns a pseudo random bit derived from the signature of the event,
+// which nobody can predict before the event is created.
+func coin(x *event) bool {
+	if len(x.Signature) == 0 {
+		return false
+	}
+	return x.Signature[len(x.Signature)/2]&1 == 1
+}
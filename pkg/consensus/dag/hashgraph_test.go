@@ -0,0 +1,71 @@
+package dag
+
+import (
+	"testing"
+
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/stretchr/testify/assert"
+)
+
+// gossip lets each of the n creators create an event in turn, referencing the
+// last event of the previous creator.
+func gossip(g *hashgraph, n, events int) {
+	for i := 0; i < events; i++ {
+		var (
+			c  = i % n
+			ev = &pb.DagEvent{Creator: uint32(c), Timestamp: int64(i)}
+		)
+		if self := g.last(c); self != nil {
+			ev.SelfParent = self.hash
+		}
+		if other := g.last((c + n - 1) % n); other != nil {
+			ev.OtherParent = other.hash
+		}
+		if _, err := g.insert(ev, signatureHash(ev)); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func TestHashgraphOrder(t *testing.T) {
+	g := newHashgraph(4)
+	gossip(g, 4, 100)
+
+	assert.True(t, g.decideFame())
+	var (
+		rounds        = g.order()
+		ordered       = make(map[string]bool)
+		last    int64 = -1
+	)
+	assert.NotEmpty(t, rounds)
+	for _, events := range rounds {
+		for _, x := range events {
+			assert.False(t, ordered[string(x.hash)])
+			ordered[string(x.hash)] = true
+			assert.True(t, x.timestamp >= last)
+			last = x.timestamp
+		}
+	}
+	// The first events of all creators are received in the first decided
+	// round.
+	for c := 0; c < 4; c++ {
+		assert.True(t, ordered[string(g.chains[c][0].hash)])
+	}
+}
+
+func TestHashgraphFork(t *testing.T) {
+	g := newHashgraph(4)
+	gossip(g, 4, 8)
+
+	// An event with the same self parent as the last event of its creator.
+	var (
+		last = g.last(0)
+		ev   = &pb.DagEvent{Creator: 0, SelfParent: last.SelfParent, Timestamp: 100}
+	)
+	_, err := g.insert(ev, signatureHash(ev))
+	assert.Equal(t, errFork, err)
+
+	ev = &pb.DagEvent{Creator: 4}
+	_, err = g.insert(ev, signatureHash(ev))
+	assert.Equal(t, errUnknownCreator, err)
+}
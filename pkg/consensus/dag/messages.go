@@ -0,0 +1,39 @@
+package dag
+
+import (
+	"crypto/ecdsa"
+	"errors"
+
+	"github.com/anthdm/consenter/pkg/common"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	proto "github.com/golang/protobuf/proto"
+)
+
+var errInvalidSignature = errors.New("invalid signature")
+
+// sign signs the event with priv and sets its signature.
+func sign(ev *pb.DagEvent, priv *ecdsa.PrivateKey) error {
+	sig, err := common.Sign(priv, signatureHash(ev))
+	if err != nil {
+		return err
+	}
+	ev.Signature = sig
+	return nil
+}
+
+// verify reports whether the event is signed by pub.
+func verify(ev *pb.DagEvent, pub *ecdsa.PublicKey) bool {
+	return common.Verify(pub, signatureHash(ev), ev.Signature)
+}
+
+// signatureHash returns the hash of the event without its signature, which
+// is also used to reference the event.
+func signatureHash(ev *pb.DagEvent) []byte {
+	ev = proto.Clone(ev).(*pb.DagEvent)
+	ev.Signature = nil
+	b, err := proto.Marshal(ev)
+	if err != nil {
+		panic(err)
+	}
+	return common.Hash256(b)
+}
@@ -1,18 +1,94 @@
 package consensus
 
 import (
+	"context"
 	"crypto/ecdsa"
 
+	"github.com/anthdm/consenter/pkg/mempool"
 	pb "github.com/anthdm/consenter/pkg/protos"
 )
 
+// Limits of the batch of transactions engines pull from the mempool for a
+// block.
+const (
+	MaxBlockTxs   = 4096
+	MaxBlockBytes = 1 << 20
+)
+
 // Engine is an interface abstraction for an algorithm agnostic consensus engine.
 type Engine interface {
 	// Configurate will be called on server startup, where the server will pass
 	// its relay channel, which can be used to relay generated blocks and
 	// consensus messages into the network and the private key.
 	Configurate(chan<- *pb.Message, *ecdsa.PrivateKey)
+	// Start will be called once the server is running, after Configurate.
+	// The engine runs in the background until the context is done or Stop
+	// is called.
+	Start(context.Context) error
+	// Stop stops the engine and waits for it to shut down. A stopped engine
+	// can be started again.
+	Stop()
+	// Err returns a channel on which the engine reports the errors it can
+	// not recover from, after which it stops.
+	Err() <-chan error
 	// AddTransaction will be called each time the server sees a tx for the
 	// first time.
 	AddTransaction(*pb.Transaction)
 }
+
+// Handler can optionally be implemented by engines that need to receive the
+// consensus messages of other nodes in the network.
+type Handler interface {
+	// HandleMessage will be called each time the server sees a message, other
+	// than a transaction, for the first time, along with the peer it was
+	// received from. Implementations should not block.
+	HandleMessage(Peer, *pb.Message) error
+}
+
+// Peer is the remote node in the network a message is received from.
+type Peer interface {
+	// Endpoint returns the address of the remote node.
+	Endpoint() string
+}
+
+// Finalizer can optionally be implemented by engines that finalize blocks,
+// like finality gadgets. Finalized blocks are never reverted by the chain,
+// see chain.Chain.Finalize.
+type Finalizer interface {
+	// Finalized returns the header hash of the last finalized block and its
+	// epoch.
+	Finalized() ([]byte, uint64)
+}
+
+// MempoolUser can optionally be implemented by engines that pull the
+// transactions of their blocks from a mempool instead of buffering them. The
+// server shares its mempool with the engine before calling Configurate, the
+// transactions passed to AddTransaction are already part of it then.
+type MempoolUser interface {
+	UseMempool(*mempool.Pool)
+}
+
+// Resumer can optionally be implemented by engines that continue the chain
+// of the node. If the chain already holds blocks when the node starts, like
+// after a restart, the server passes the head of the chain to the engine
+// before calling Start.
+type Resumer interface {
+	Resume(head *pb.Block)
+}
+
+// Sender sends consensus messages to single validators instead of relaying
+// them into the network. It is implemented by the server.
+type Sender interface {
+	// SendTo sends the message to the validator with the given public key.
+	// The message is relayed into the network if the validator is not a
+	// connected peer. Implementations may block until the message is
+	// handed to the network.
+	SendTo(*ecdsa.PublicKey, *pb.Message)
+}
+
+// SenderUser can optionally be implemented by engines that address consensus
+// messages to single validators, like votes to the next leader. The server
+// passes itself to the engine before calling Configurate.
+type SenderUser interface {
+	UseSender(Sender)
+}
@@ -1,6 +1,8 @@
-package fbft
-
 // Package fbft (Fast Byzantine Fault Tolerance) implements a consensus Engine
 // based on its corresponding technical paper; Scalable Byzantine Consensus via
 // Hardware-assisted Secret Sharing. Which can be found in the link below.
 // https://arxiv.org/pdf/1612.04997.pdf
+//
+// The trusted hardware each replica relies on is simulated in software, see
+// enclave.go.
+package fbft
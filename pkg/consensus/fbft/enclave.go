@@ -0,0 +1,169 @@
+package fbft
+
+import (
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/anthdm/consenter/pkg/common"
+	"github.com/anthdm/consenter/pkg/consensus"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	proto "github.com/golang/protobuf/proto"
+)
+
+var (
+	errInvalidView      = errors.New("prepare does not match the current view")
+	errInvalidCounter   = errors.New("prepare does not match the next counter value")
+	errInvalidSignature = errors.New("invalid signature")
+	errInvalidShare     = errors.New("invalid secret share")
+)
+
+// enclave simulates the trusted execution environment (TEE) every FBFT
+// replica is equipped with. On real hardware the TEE guarantees that this
+// code runs unmodified and that its state can not be tampered with. In this
+// simulation we have to trust the replica not to reach into it.
+//
+// The enclave of the leader binds each proposed block to a unique monotonic
+// counter value, which makes it impossible for a faulty leader to equivocate.
+// For each counter value it generates a fresh secret, which is split into
+// shares for every replica. The enclave of a replica only releases its share
+// after it verified that the prepare carries the next counter value.
+type enclave struct {
+	priv       *ecdsa.PrivateKey
+	validators consensus.Validators
+	threshold  int
+
+	// The view the enclave is operating in. Counters restart in each view.
+	view uint64
+	// Last counter value assigned by this enclave while being the leader.
+	counter uint64
+	// Last counter value accepted from the leader.
+	lastSeen uint64
+	// Shares of the secrets created by this enclave, keyed by counter.
+	shares map[uint64][]common.Share
+}
+
+func newEnclave(priv *ecdsa.PrivateKey, validators consensus.Validators, threshold int) *enclave {
+	return &enclave{
+		priv:       priv,
+		validators: validators,
+		threshold:  threshold,
+		shares:     make(map[uint64][]common.Share),
+	}
+}
+
+// setView moves the enclave to the given view. Enclaves never move back to an
+// older view.
+func (e *enclave) setView(view uint64) {
+	if view <= e.view {
+		return
+	}
+	e.view = view
+	e.counter = 0
+	e.lastSeen = 0
+	e.shares = make(map[uint64][]common.Share)
+}
+
+// prepare binds the given block to the next counter value and creates the
+// secret shares needed to commit it.
+func (e *enclave) prepare(block *pb.Block) (*pb.FbftPrepare, error) {
+	secret, err := common.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+	shares, err := common.SplitSecret(secret, len(e.validators), e.threshold)
+	if err != nil {
+		return nil, err
+	}
+	e.counter++
+	p := &pb.FbftPrepare{
+		View:       e.view,
+		Counter:    e.counter,
+		Block:      block,
+		Commitment: commitment(secret, e.view, e.counter),
+	}
+	for i, pub := range e.validators {
+		ct, err := common.Encrypt(common.SharedSecret(e.priv, pub), shares[i].Bytes())
+		if err != nil {
+			return nil, err
+		}
+		p.Shares = append(p.Shares, ct)
+	}
+	sig, err := common.Sign(e.priv, prepareHash(p))
+	if err != nil {
+		return nil, err
+	}
+	p.Signature = sig
+	e.shares[e.counter] = shares
+	return p, nil
+}
+
+// accept verifies the prepare of the given leader and releases the share of
+// the replica at index.
+func (e *enclave) accept(p *pb.FbftPrepare, leader *ecdsa.PublicKey, index int) (common.Share, error) {
+	if p.View != e.view {
+		return common.Share{}, errInvalidView
+	}
+	if p.Counter != e.lastSeen+1 {
+		return common.Share{}, errInvalidCounter
+	}
+	if !common.Verify(leader, prepareHash(p), p.Signature) {
+		return common.Share{}, errInvalidSignature
+	}
+	if index < 0 || index >= len(p.Shares) {
+		return common.Share{}, errInvalidShare
+	}
+	b, err := common.Decrypt(common.SharedSecret(e.priv, leader), p.Shares[index])
+	if err != nil {
+		return common.Share{}, err
+	}
+	e.lastSeen = p.Counter
+	return common.Share{X: index + 1, Y: new(big.Int).SetBytes(b)}, nil
+}
+
+// verifyShare reports whether y is the share this enclave created for the
+// replica at index for the given counter value.
+func (e *enclave) verifyShare(counter uint64, index int, y []byte) bool {
+	shares, ok := e.shares[counter]
+	if !ok || index < 0 || index >= len(shares) {
+		return false
+	}
+	return shares[index].Y.Cmp(new(big.Int).SetBytes(y)) == 0
+}
+
+// commitment returns the hash commitment of a secret bound to the given view
+// and counter value.
+func commitment(secret *big.Int, view, counter uint64) []byte {
+	buf := make([]byte, common.ShareSize+16)
+	copy(buf, common.Share{Y: secret}.Bytes())
+	binary.BigEndian.PutUint64(buf[common.ShareSize:], view)
+	binary.BigEndian.PutUint64(buf[common.ShareSize+8:], counter)
+	return common.Hash256(buf)
+}
+
+func prepareHash(p *pb.FbftPrepare) []byte {
+	msg := *p
+	msg.Signature = nil
+	return hashProto(&msg)
+}
+
+func viewChangeHash(vc *pb.FbftViewChange) []byte {
+	msg := *vc
+	msg.Signature = nil
+	return hashProto(&msg)
+}
+
+func revealHash(rev *pb.FbftReveal) []byte {
+	msg := *rev
+	msg.Signature = nil
+	return hashProto(&msg)
+}
+
+func hashProto(msg proto.Message) []byte {
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+	return common.Hash256(b)
+}
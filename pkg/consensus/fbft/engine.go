@@ -0,0 +1,667 @@
+package fbft
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/common"
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/mempool"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	log "github.com/sirupsen/logrus"
+)
+
+// window is the amount of heights above the last committed block for which
+// early reveals and certificates are kept, and the amount of heights below
+// it for which certificates are served to replicas that fell behind.
+const window = 64
+
+var errInvalidCertificate = errors.New("invalid commit certificate")
+
+// Config holds the configuration of the FBFT engine.
+type Config struct {
+	// The validators participating in consensus. FBFT tolerates f faulty
+	// validators out of 2f+1.
+	Validators consensus.Validators
+
+	// The interval in which the leader proposes new blocks.
+	BlockInterval time.Duration
+
+	// The time a replica waits for a block to be committed before it
+	// suspects the leader and requests a view change.
+	ViewTimeout time.Duration
+}
+
+// Engine is a FBFT consensus engine.
+type Engine struct {
+	Config
+	consensus.Lifecycle
+
+	privKey *ecdsa.PrivateKey
+	relayCh chan<- *pb.Message
+	msgCh   chan *pb.Message
+
+	// Index of this node in the validator set, -1 if this node only follows
+	// the consensus.
+	index   int
+	enclave *enclave
+
+	// Pending transactions, shared with the server if it has a mempool.
+	pool *mempool.Pool
+
+	view uint64
+	// Highest view this replica requested a view change for.
+	requested uint64
+	height    uint32
+	// Hash of the last committed block, along with its prepare and revealed
+	// secret, which prove that the block is committed.
+	head      []byte
+	certified *pb.FbftPrepare
+	secret    []byte
+	// Last prepare accepted from a leader.
+	prepared *pb.FbftPrepare
+	// Block the leader proposes first in a new view, see enterView.
+	carried *pb.Block
+	// Last counter value committed in the current view.
+	committed   uint64
+	rounds      map[uint64]*round
+	reveals     map[uint64]*pb.FbftReveal
+	viewChanges map[uint64]map[uint32]*pb.FbftViewChange
+	viewTimer   *time.Timer
+	// Certificates of the recently committed blocks and of the blocks
+	// committed above the height by other replicas, by height.
+	certificates map[uint32]*certificate
+	pending      map[uint32]*pb.FbftCertificate
+}
+
+// certificate is the certificate of a committed block and the last time it
+// was served to a replica that fell behind.
+type certificate struct {
+	*pb.FbftCertificate
+	served time.Time
+}
+
+// round holds the state of a single counter value.
+type round struct {
+	prepare  *pb.FbftPrepare
+	shares   map[uint32]common.Share
+	revealed bool
+	secret   []byte
+}
+
+func init() {
+	consensus.Register(consensus.Factory{
+		Name:        "fbft",
+		Description: "FBFT byzantine fault tolerant consensus",
+		Config: func() interface{} {
+			return &Config{
+				BlockInterval: 5 * time.Second,
+				ViewTimeout:   15 * time.Second,
+			}
+		},
+		New: func(c interface{}) (consensus.Engine, error) {
+			cfg := c.(*Config)
+			if len(cfg.Validators) == 0 {
+				return nil, consensus.ErrMissingValidators
+			}
+			return NewEngine(*cfg), nil
+		},
+	})
+}
+
+// NewEngine returns a new FBFT consensus engine.
+func NewEngine(cfg Config) *Engine {
+	return &Engine{
+		Config:       cfg,
+		msgCh:        make(chan *pb.Message, 1024),
+		pool:         mempool.New(mempool.Config{}),
+		rounds:       make(map[uint64]*round),
+		reveals:      make(map[uint64]*pb.FbftReveal),
+		viewChanges:  make(map[uint64]map[uint32]*pb.FbftViewChange),
+		certificates: make(map[uint32]*certificate),
+		pending:      make(map[uint32]*pb.FbftCertificate),
+	}
+}
+
+// Configurate implements the Engine interface.
+func (e *Engine) Configurate(relayCh chan<- *pb.Message, priv *ecdsa.PrivateKey) {
+	e.privKey = priv
+	e.relayCh = relayCh
+	e.index = -1
+	if priv != nil {
+		e.index = e.Validators.Index(&priv.PublicKey)
+	}
+	e.enclave = newEnclave(priv, e.Validators, e.threshold())
+}
+
+// Start implements the Engine interface.
+func (e *Engine) Start(ctx context.Context) error {
+	return e.Run(ctx, e.run)
+}
+
+// AddTransaction implements the Engine interface.
+func (e *Engine) AddTransaction(tx *pb.Transaction) {
+	e.pool.Add(tx)
+}
+
+// UseMempool implements the consensus.MempoolUser interface.
+func (e *Engine) UseMempool(pool *mempool.Pool) {
+	e.pool = pool
+}
+
+// HandleMessage implements the consensus.Handler interface.
+func (e *Engine) HandleMessage(from consensus.Peer, msg *pb.Message) error {
+	switch msg.Payload.(type) {
+	case *pb.Message_FbftPrepare, *pb.Message_FbftCommit,
+		*pb.Message_FbftReveal, *pb.Message_FbftViewChange,
+		*pb.Message_FbftCatchUp, *pb.Message_FbftCertificate:
+		select {
+		case e.msgCh <- msg:
+		case <-e.Done():
+		}
+	}
+	return nil
+}
+
+func (e *Engine) run(ctx context.Context) error {
+	var (
+		ticker = time.NewTicker(e.BlockInterval)
+	)
+	defer ticker.Stop()
+	e.viewTimer = time.NewTimer(e.ViewTimeout)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			// A leader that fell behind proposes once it caught up.
+			if e.isLeader() && e.enclave.counter == e.committed && len(e.pending) == 0 {
+				if err := e.propose(); err != nil {
+					log.Warnf("fbft: failed to propose block: %s", err)
+				}
+			}
+		case <-e.viewTimer.C:
+			e.requestViewChange()
+		case msg := <-e.msgCh:
+			if err := e.handleMessage(msg); err != nil {
+				log.Warnf("fbft: failed processing message: %s", err)
+			}
+		}
+	}
+}
+
+func (e *Engine) handleMessage(msg *pb.Message) error {
+	switch p := msg.Payload.(type) {
+	case *pb.Message_FbftPrepare:
+		return e.handlePrepare(p.FbftPrepare)
+	case *pb.Message_FbftCommit:
+		return e.handleCommit(p.FbftCommit)
+	case *pb.Message_FbftReveal:
+		return e.handleReveal(p.FbftReveal)
+	case *pb.Message_FbftViewChange:
+		return e.handleViewChange(p.FbftViewChange)
+	case *pb.Message_FbftCatchUp:
+		e.handleCatchUp(p.FbftCatchUp)
+	case *pb.Message_FbftCertificate:
+		return e.handleCertificate(p.FbftCertificate)
+	}
+	return nil
+}
+
+func (e *Engine) propose() error {
+	block := e.carried
+	e.carried = nil
+	if block == nil || block.Header.Index < e.height {
+		block = pb.NewBlock(e.height)
+		block.Transactions = e.pool.Batch(consensus.MaxBlockTxs, consensus.MaxBlockBytes)
+		block.Header.TxRoot = pb.TxRoot(block.Transactions)
+		if e.certified != nil {
+			block.Header.PrevHash = e.certified.Block.Header.Hash()
+		}
+		block.Header.Proposer = common.MarshalPublicKey(&e.privKey.PublicKey)
+		if err := block.Sign(e.privKey); err != nil {
+			return err
+		}
+	}
+
+	p, err := e.enclave.prepare(block)
+	if err != nil {
+		return err
+	}
+	return e.send(&pb.Message{
+		Payload: &pb.Message_FbftPrepare{FbftPrepare: p},
+	})
+}
+
+func (e *Engine) handlePrepare(p *pb.FbftPrepare) error {
+	if p.View != e.view || p.Block == nil || p.Block.Header == nil {
+		return nil
+	}
+	if _, ok := e.rounds[p.Counter]; ok {
+		return nil
+	}
+	if p.Counter <= e.committed {
+		return nil
+	}
+	// Prepares may arrive before the reveal of their predecessor, hence the
+	// expected index depends on the amount of uncommitted counter values. The
+	// first prepare of a view might be the last committed block, see
+	// enterView.
+	want := e.height + uint32(p.Counter-e.committed)
+	if p.Block.Header.Index != want && !e.isHead(p) {
+		return fmt.Errorf("invalid block index %d expected %d", p.Block.Header.Index, want)
+	}
+	// Blocks carried over from a previous view are signed by its leader.
+	if pub, err := p.Block.Verify(); err != nil || e.Validators.Index(pub) < 0 {
+		return fmt.Errorf("block %d not signed by a validator", p.Block.Header.Index)
+	}
+	var (
+		leader = e.Validators.Get(e.leader(p.View))
+		share  common.Share
+		err    error
+	)
+	if e.index < 0 {
+		if !common.Verify(leader, prepareHash(p), p.Signature) {
+			return errInvalidSignature
+		}
+	} else if share, err = e.enclave.accept(p, leader, e.index); err != nil {
+		return err
+	}
+	e.rounds[p.Counter] = &round{
+		prepare: p,
+		shares:  make(map[uint32]common.Share),
+	}
+	e.prepared = p
+	if e.index >= 0 {
+		err = e.send(&pb.Message{
+			Payload: &pb.Message_FbftCommit{
+				FbftCommit: &pb.FbftCommit{
+					View:    p.View,
+					Counter: p.Counter,
+					Replica: uint32(e.index),
+					Share:   share.Bytes(),
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if r, ok := e.reveals[p.Counter]; ok {
+		delete(e.reveals, p.Counter)
+		return e.handleReveal(r)
+	}
+	return nil
+}
+
+func (e *Engine) handleCommit(c *pb.FbftCommit) error {
+	if c.View != e.view || !e.isLeader() {
+		return nil
+	}
+	r, ok := e.rounds[c.Counter]
+	if !ok || r.revealed {
+		return nil
+	}
+	if !e.enclave.verifyShare(c.Counter, int(c.Replica), c.Share) {
+		return errInvalidShare
+	}
+	r.shares[c.Replica] = common.Share{
+		X: int(c.Replica) + 1,
+		Y: new(big.Int).SetBytes(c.Share),
+	}
+	if len(r.shares) < e.threshold() {
+		return nil
+	}
+	shares := make([]common.Share, 0, len(r.shares))
+	for _, s := range r.shares {
+		shares = append(shares, s)
+	}
+	rev := &pb.FbftReveal{
+		View:    c.View,
+		Counter: c.Counter,
+		Secret:  common.Share{Y: common.CombineShares(shares)}.Bytes(),
+	}
+	sig, err := common.Sign(e.privKey, revealHash(rev))
+	if err != nil {
+		return err
+	}
+	rev.Signature = sig
+	return e.send(&pb.Message{
+		Payload: &pb.Message_FbftReveal{FbftReveal: rev},
+	})
+}
+
+func (e *Engine) handleReveal(rev *pb.FbftReveal) error {
+	if rev.View != e.view || rev.Counter <= e.committed || rev.Counter > e.committed+window {
+		return nil
+	}
+	if !common.Verify(e.Validators.Get(e.leader(rev.View)), revealHash(rev), rev.Signature) {
+		return errInvalidSignature
+	}
+	r, ok := e.rounds[rev.Counter]
+	if !ok {
+		// We did not see the prepare yet.
+		e.reveals[rev.Counter] = rev
+		return nil
+	}
+	if r.revealed {
+		return nil
+	}
+	secret := new(big.Int).SetBytes(rev.Secret)
+	if !bytes.Equal(commitment(secret, rev.View, rev.Counter), r.prepare.Commitment) {
+		return fmt.Errorf("invalid secret revealed for counter %d", rev.Counter)
+	}
+	r.revealed = true
+	r.secret = rev.Secret
+	for {
+		next, ok := e.rounds[e.committed+1]
+		if !ok || !next.revealed {
+			break
+		}
+		e.commit(next.prepare, next.secret)
+		delete(e.rounds, e.committed+1)
+		e.committed++
+	}
+	return nil
+}
+
+// commit commits the block of the prepare, of which the secret is revealed.
+// The block might be committed already if it is the last committed block
+// proposed again, the leader relays it once more nonetheless.
+func (e *Engine) commit(p *pb.FbftPrepare, secret []byte) {
+	block := p.Block
+	if block.Header.Index > e.height {
+		e.setHead(p, secret)
+		log.WithFields(log.Fields{
+			"index": block.Header.Index,
+			"hash":  hex.EncodeToString(block.Hash()),
+			"txs":   len(block.Transactions),
+			"view":  e.view,
+		}).Info("fbft: committed block")
+	}
+	e.resetViewTimer()
+
+	if e.isLeader() {
+		e.Relay(e.relayCh, &pb.Message{
+			Payload: &pb.Message_Block{
+				Block: block,
+			},
+		})
+	}
+}
+
+// setHead makes the block of the prepare the last committed block.
+func (e *Engine) setHead(p *pb.FbftPrepare, secret []byte) {
+	e.height = p.Block.Header.Index
+	e.head = p.Block.Hash()
+	e.certified = p
+	e.secret = secret
+	e.pool.Remove(p.Block.Transactions)
+
+	e.certificates[e.height] = &certificate{
+		FbftCertificate: &pb.FbftCertificate{Prepare: p, Secret: secret},
+	}
+	for h := range e.certificates {
+		if h+window <= e.height {
+			delete(e.certificates, h)
+		}
+	}
+	for h := range e.pending {
+		if h <= e.height {
+			delete(e.pending, h)
+		}
+	}
+}
+
+// addCertificate commits the certified block if it follows the last
+// committed block, along with the pending blocks following it. Blocks
+// further up are pending until the blocks below them are fetched from other
+// replicas.
+func (e *Engine) addCertificate(c *pb.FbftCertificate) {
+	index := c.Prepare.Block.Header.Index
+	if _, ok := e.pending[index]; ok || index <= e.height {
+		return
+	}
+	if index > e.height+window {
+		// The certificates of the blocks in between are no longer served,
+		// the chain of the server syncs them.
+		log.WithFields(log.Fields{
+			"height": e.height,
+			"index":  index,
+		}).Warn("fbft: skipping blocks committed too far ahead")
+		e.setHead(c.Prepare, c.Secret)
+	} else {
+		e.pending[index] = c
+	}
+	for {
+		next, ok := e.pending[e.height+1]
+		if !ok {
+			break
+		}
+		e.setHead(next.Prepare, next.Secret)
+		log.WithFields(log.Fields{
+			"index": e.height,
+			"hash":  hex.EncodeToString(e.head),
+		}).Info("fbft: caught up with committed block")
+	}
+	if _, ok := e.pending[index]; ok {
+		e.catchUp()
+	}
+}
+
+// catchUp requests the certificates of the blocks above the height that
+// were committed by other replicas.
+func (e *Engine) catchUp() {
+	e.Relay(e.relayCh, &pb.Message{
+		Payload: &pb.Message_FbftCatchUp{
+			FbftCatchUp: &pb.FbftCatchUp{
+				Height: e.height + 1,
+			},
+		},
+	})
+}
+
+// handleCatchUp multicasts the certificates of the committed blocks from the
+// height of the request on. Each certificate is served at most once per
+// block interval, as every replica that fell behind requests it.
+func (e *Engine) handleCatchUp(c *pb.FbftCatchUp) {
+	for h := c.Height; h <= e.height; h++ {
+		cert, ok := e.certificates[h]
+		if !ok || time.Since(cert.served) < e.BlockInterval {
+			continue
+		}
+		cert.served = time.Now()
+		e.Relay(e.relayCh, &pb.Message{
+			Payload: &pb.Message_FbftCertificate{
+				FbftCertificate: cert.FbftCertificate,
+			},
+		})
+	}
+}
+
+func (e *Engine) handleCertificate(c *pb.FbftCertificate) error {
+	if !e.verifyCertificate(c.Prepare, c.Secret) {
+		return errInvalidCertificate
+	}
+	e.addCertificate(c)
+	return nil
+}
+
+// isHead reports whether the prepare proposes the last committed block again
+// as the first prepare of a view.
+func (e *Engine) isHead(p *pb.FbftPrepare) bool {
+	return p.Counter == e.committed+1 && e.head != nil &&
+		bytes.Equal(p.Block.Hash(), e.head)
+}
+
+func (e *Engine) requestViewChange() {
+	defer e.resetViewTimer()
+	if len(e.pending) > 0 {
+		// The certificates requested before might not have arrived.
+		e.catchUp()
+	}
+	if e.index < 0 {
+		return
+	}
+	view := e.view
+	if e.requested > view {
+		view = e.requested
+	}
+	view++
+	e.requested = view
+
+	vc := &pb.FbftViewChange{
+		View:      view,
+		Replica:   uint32(e.index),
+		Committed: e.certified,
+		Secret:    e.secret,
+	}
+	if e.prepared != nil && e.prepared.Block.Header.Index > e.height {
+		vc.Prepared = e.prepared
+	}
+	sig, err := common.Sign(e.privKey, viewChangeHash(vc))
+	if err != nil {
+		log.Warnf("fbft: failed to sign view change: %s", err)
+		return
+	}
+	vc.Signature = sig
+	log.WithFields(log.Fields{
+		"view": view,
+	}).Info("fbft: requesting view change")
+
+	if err := e.send(&pb.Message{
+		Payload: &pb.Message_FbftViewChange{FbftViewChange: vc},
+	}); err != nil {
+		log.Warnf("fbft: failed processing view change: %s", err)
+	}
+}
+
+func (e *Engine) handleViewChange(vc *pb.FbftViewChange) error {
+	if vc.View <= e.view {
+		return nil
+	}
+	pub := e.Validators.Get(int(vc.Replica))
+	if !common.Verify(pub, viewChangeHash(vc), vc.Signature) {
+		return errInvalidSignature
+	}
+	if vc.Committed != nil {
+		if !e.verifyCertificate(vc.Committed, vc.Secret) {
+			return errInvalidCertificate
+		}
+		// The replica committed blocks this replica missed, as the leader
+		// stopped relaying its reveals.
+		e.addCertificate(&pb.FbftCertificate{Prepare: vc.Committed, Secret: vc.Secret})
+	}
+	if vc.Prepared != nil && !e.verifyPrepare(vc.Prepared) {
+		return errInvalidSignature
+	}
+	if _, ok := e.viewChanges[vc.View]; !ok {
+		e.viewChanges[vc.View] = make(map[uint32]*pb.FbftViewChange)
+	}
+	e.viewChanges[vc.View][vc.Replica] = vc
+	if len(e.viewChanges[vc.View]) >= e.threshold() {
+		e.enterView(vc.View)
+	}
+	return nil
+}
+
+// enterView moves the replica to the given view. Uncommitted prepares of the
+// previous view are dropped, their transactions will be proposed again by
+// the new leader.
+//
+// Replicas that committed fewer blocks caught up with the certificates of
+// the view changes, but the last block might be committed by replicas that
+// did not request the view change. Its prepare was accepted by f+1 replicas
+// though, hence by at least one of the f+1 replicas that did. The leader
+// first proposes the prepared block of the highest view following its last
+// committed block, or its last committed block otherwise, so that the
+// heights of the replicas match again once it is committed.
+func (e *Engine) enterView(view uint64) {
+	e.carried = nil
+	if e.certified != nil {
+		e.carried = e.certified.Block
+	}
+	var carried *pb.FbftPrepare
+	for _, vc := range e.viewChanges[view] {
+		p := vc.Prepared
+		if p == nil || p.Block.Header.Index != e.height+1 {
+			continue
+		}
+		if carried == nil || p.View > carried.View {
+			carried = p
+		}
+	}
+	if carried != nil {
+		e.carried = carried.Block
+	}
+	e.view = view
+	e.committed = 0
+	e.rounds = make(map[uint64]*round)
+	e.reveals = make(map[uint64]*pb.FbftReveal)
+	e.enclave.setView(view)
+	for v := range e.viewChanges {
+		if v <= view {
+			delete(e.viewChanges, v)
+		}
+	}
+	e.resetViewTimer()
+
+	log.WithFields(log.Fields{
+		"view":   view,
+		"leader": e.leader(view),
+	}).Info("fbft: entered new view")
+}
+
+func (e *Engine) resetViewTimer() {
+	if !e.viewTimer.Stop() {
+		select {
+		case <-e.viewTimer.C:
+		default:
+		}
+	}
+	e.viewTimer.Reset(e.ViewTimeout)
+}
+
+// send processes the message locally and relays it into the network.
+func (e *Engine) send(msg *pb.Message) error {
+	e.Relay(e.relayCh, msg)
+	return e.handleMessage(msg)
+}
+
+// verifyPrepare verifies that the prepare is signed by the leader of its
+// view.
+func (e *Engine) verifyPrepare(p *pb.FbftPrepare) bool {
+	if p.Block == nil || p.Block.Header == nil {
+		return false
+	}
+	return common.Verify(e.Validators.Get(e.leader(p.View)), prepareHash(p), p.Signature)
+}
+
+// verifyCertificate verifies that the block of the prepare is committed, as
+// the revealed secret matches the commitment of the prepare.
+func (e *Engine) verifyCertificate(p *pb.FbftPrepare, secret []byte) bool {
+	if p == nil || !e.verifyPrepare(p) {
+		return false
+	}
+	return bytes.Equal(commitment(new(big.Int).SetBytes(secret), p.View, p.Counter), p.Commitment)
+}
+
+func (e *Engine) isLeader() bool {
+	return e.index >= 0 && e.leader(e.view) == e.index
+}
+
+func (e *Engine) leader(view uint64) int {
+	return int(view % uint64(len(e.Validators)))
+}
+
+// threshold returns the amount of shares needed to reconstruct a secret,
+// which is f+1 out of 2f+1 replicas.
+func (e *Engine) threshold() int {
+	return (len(e.Validators)-1)/2 + 1
+}
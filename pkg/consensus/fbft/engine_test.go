@@ -0,0 +1,145 @@
+package fbft
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/common"
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/consensus/consensustest"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestNetwork returns a network of n engines, which are not started.
+func newTestNetwork(n int) *consensustest.Network {
+	return consensustest.NewNetwork(n, func(validators consensus.Validators) consensus.Engine {
+		return NewEngine(Config{
+			Validators:    validators,
+			BlockInterval: 20 * time.Millisecond,
+			ViewTimeout:   200 * time.Millisecond,
+		})
+	})
+}
+
+func TestEngineCommit(t *testing.T) {
+	net := newTestNetwork(3)
+	net.Start()
+	defer net.Stop()
+	b := net.Receive(t.Fatalf).Block
+	assert.Equal(t, uint32(1), b.Header.Index)
+	assert.Equal(t, 1, len(b.Transactions))
+}
+
+func TestEngineViewChange(t *testing.T) {
+	// The leader of the first view is crashed.
+	net := newTestNetwork(3)
+	net.Start(0)
+	defer net.Stop()
+	assert.Equal(t, uint32(1), net.Receive(t.Fatalf).Block.Header.Index)
+}
+
+func TestEngineViewChangeHeights(t *testing.T) {
+	net := newTestNetwork(3)
+	// The reveals of the first leader do not reach replica 2, which falls
+	// behind, until the leader crashes.
+	net.SetDrop(func(from, to int, msg *pb.Message) bool {
+		_, reveal := msg.Payload.(*pb.Message_FbftReveal)
+		return reveal && to == 2
+	})
+	net.Start()
+	defer net.Stop()
+	first := net.Receive(t.Fatalf)
+	assert.Equal(t, 0, first.From)
+	net.SetDrop(nil)
+	net.SetDown(0, true)
+	// The next leader commits new blocks with the other replica, which only
+	// accepts their index once the heights match again.
+	for {
+		b := net.Receive(t.Fatalf)
+		if b.From != 0 && b.Block.Header.Index > first.Block.Header.Index {
+			break
+		}
+	}
+}
+
+func TestEngineCatchUp(t *testing.T) {
+	net := newTestNetwork(3)
+	net.SetDrop(func(from, to int, msg *pb.Message) bool {
+		_, reveal := msg.Payload.(*pb.Message_FbftReveal)
+		return reveal && to == 2
+	})
+	net.Start()
+	for {
+		if b := net.Receive(t.Fatalf); b.Block.Header.Index == 3 {
+			break
+		}
+	}
+	net.SetDrop(nil)
+	net.SetDown(0, true)
+	for {
+		// Either of the remaining replicas might lead the new view.
+		b := net.Receive(t.Fatalf)
+		if b.From != 0 && b.Block.Header.Index > 3 {
+			break
+		}
+	}
+	net.Stop()
+	// Replica 2 committed the blocks it missed instead of skipping them.
+	e := net.Engine(2).(*Engine)
+	for h := uint32(1); h <= 3; h++ {
+		assert.NotNil(t, e.certificates[h], "block %d", h)
+	}
+}
+
+func TestHandleReveal(t *testing.T) {
+	e := NewEngine(Config{Validators: consensustest.Validators(3)})
+	e.Configurate(make(chan *pb.Message, 16), consensustest.PrivateKey(1))
+	sign := func(rev *pb.FbftReveal, i int) *pb.FbftReveal {
+		sig, err := common.Sign(consensustest.PrivateKey(i), revealHash(rev))
+		assert.Nil(t, err)
+		rev.Signature = sig
+		return rev
+	}
+	// Only the leader of the view reveals secrets.
+	rev := sign(&pb.FbftReveal{Counter: 2, Secret: []byte{1}}, 2)
+	assert.Equal(t, errInvalidSignature, e.handleReveal(rev))
+	assert.Nil(t, e.handleReveal(sign(rev, 0)))
+	assert.Equal(t, 1, len(e.reveals))
+	// Reveals far ahead of the committed counter are dropped.
+	assert.Nil(t, e.handleReveal(sign(&pb.FbftReveal{Counter: window + 1}, 0)))
+	assert.Equal(t, 1, len(e.reveals))
+}
+
+func TestEngineForgedPrepare(t *testing.T) {
+	net := newTestNetwork(3)
+	// The host of the first leader swaps the blocks of its prepares, which it
+	// can not sign without its enclave.
+	net.SetByzantine(0, func(to int, msg *pb.Message) *pb.Message {
+		if p := msg.GetFbftPrepare(); p != nil {
+			p.Block.Header.Nonce++
+		}
+		return msg
+	})
+	net.Start()
+	defer net.Stop()
+	assert.Equal(t, 1, net.Receive(t.Fatalf).From)
+}
+
+func TestEngineForgedShares(t *testing.T) {
+	net := newTestNetwork(3)
+	// A replica releases shares that do not belong to it. The leader
+	// reconstructs the secrets from its own share and the one of the other
+	// replica.
+	net.SetByzantine(2, func(to int, msg *pb.Message) *pb.Message {
+		if c := msg.GetFbftCommit(); c != nil {
+			c.Share[0]++
+		}
+		return msg
+	})
+	net.Start()
+	defer net.Stop()
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, 0, net.Receive(t.Fatalf).From)
+	}
+}
@@ -0,0 +1,244 @@
+package honeybadger
+
+import (
+	"encoding/binary"
+
+	"github.com/anthdm/consenter/pkg/common"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxRoundsAhead is the maximum amount of rounds messages are accepted for
+// ahead of the current round of an agreement.
+const maxRoundsAhead = 16
+
+// agreementRound holds the messages of a single round of a binary agreement.
+type agreementRound struct {
+	bvals     [2]map[uint32]bool
+	sentBval  [2]bool
+	binValues [2]bool
+	// The value of the aux message of each validator.
+	aux     map[uint32]bool
+	sentAux bool
+	// The verified shares of the common coin.
+	shares   map[int]point
+	sentCoin bool
+}
+
+func newAgreementRound() *agreementRound {
+	return &agreementRound{
+		bvals:  [2]map[uint32]bool{make(map[uint32]bool), make(map[uint32]bool)},
+		aux:    make(map[uint32]bool),
+		shares: make(map[int]point),
+	}
+}
+
+// agreement is the randomized binary agreement of Mostefaoui et al. on
+// whether the batch of a single proposer is part of the epoch. Rounds that
+// do not decide are resolved by a common coin, so that the agreement
+// terminates with probability 1 without relying on any timing assumptions.
+type agreement struct {
+	epoch    uint64
+	proposer uint32
+	n, f     int
+	key      *thresholdKey
+	// Index of this node in the validator set.
+	index int
+
+	round   uint64
+	rounds  map[uint64]*agreementRound
+	est     bool
+	started bool
+	decided bool
+	value   bool
+	// Validators that decided on either value. Once 2f+1 of them decided, the
+	// agreement is done and this validator stops participating.
+	terms [2]map[uint32]bool
+	done  bool
+}
+
+func newAgreement(epoch uint64, proposer uint32, n, f int, key *thresholdKey, index int) *agreement {
+	return &agreement{
+		epoch:    epoch,
+		proposer: proposer,
+		n:        n,
+		f:        f,
+		key:      key,
+		index:    index,
+		rounds:   map[uint64]*agreementRound{0: newAgreementRound()},
+		terms:    [2]map[uint32]bool{make(map[uint32]bool), make(map[uint32]bool)},
+	}
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// coinBase returns the point the coin shares of the round are computed on.
+func (a *agreement) coinBase(round uint64) point {
+	b := make([]byte, 20)
+	binary.BigEndian.PutUint64(b, a.epoch)
+	binary.BigEndian.PutUint32(b[8:], a.proposer)
+	binary.BigEndian.PutUint64(b[12:], round)
+	return hashToPoint(b)
+}
+
+// input starts the agreement with the given value of this validator and
+// returns the messages to multicast.
+func (a *agreement) input(v bool) []*pb.HoneybadgerAgreement {
+	if a.started {
+		return nil
+	}
+	a.started = true
+	a.est = v
+	return a.step()
+}
+
+// handle records the message and returns the messages to multicast in
+// response.
+func (a *agreement) handle(msg *pb.HoneybadgerAgreement) []*pb.HoneybadgerAgreement {
+	if a.done {
+		return nil
+	}
+	if msg.Type == pb.HoneybadgerAgreementType_term {
+		a.terms[b2i(msg.Value)][msg.Sender] = true
+		return a.step()
+	}
+	if msg.Round < a.round || msg.Round > a.round+maxRoundsAhead {
+		return nil
+	}
+	r, ok := a.rounds[msg.Round]
+	if !ok {
+		r = newAgreementRound()
+		a.rounds[msg.Round] = r
+	}
+	switch msg.Type {
+	case pb.HoneybadgerAgreementType_bval:
+		r.bvals[b2i(msg.Value)][msg.Sender] = true
+	case pb.HoneybadgerAgreementType_aux:
+		if _, ok := r.aux[msg.Sender]; !ok {
+			r.aux[msg.Sender] = msg.Value
+		}
+	case pb.HoneybadgerAgreementType_coin:
+		if _, ok := r.shares[int(msg.Sender)]; ok {
+			return nil
+		}
+		s, err := unmarshalPoint(msg.Share)
+		if err != nil || !a.key.verifyShare(int(msg.Sender), a.coinBase(msg.Round), s, msg.Proof) {
+			log.Warnf("honeybadger: invalid coin share from %d", msg.Sender)
+			return nil
+		}
+		r.shares[int(msg.Sender)] = s
+	}
+	return a.step()
+}
+
+// step applies the rules of the agreement until no more progress can be
+// made.
+func (a *agreement) step() []*pb.HoneybadgerAgreement {
+	var out []*pb.HoneybadgerAgreement
+	for v := 0; v < 2; v++ {
+		// f+1 validators decided, at least one of them is honest.
+		if len(a.terms[v]) >= a.f+1 && !a.decided {
+			out = append(out, a.decide(v == 1))
+		}
+		if len(a.terms[v]) >= 2*a.f+1 {
+			a.done = true
+			return out
+		}
+	}
+	if !a.started {
+		return out
+	}
+	for {
+		r := a.rounds[a.round]
+		if !r.sentBval[b2i(a.est)] {
+			r.sentBval[b2i(a.est)] = true
+			out = append(out, a.message(pb.HoneybadgerAgreementType_bval, a.est))
+		}
+		for v := 0; v < 2; v++ {
+			if len(r.bvals[v]) >= a.f+1 && !r.sentBval[v] {
+				r.sentBval[v] = true
+				out = append(out, a.message(pb.HoneybadgerAgreementType_bval, v == 1))
+			}
+			if len(r.bvals[v]) >= 2*a.f+1 {
+				r.binValues[v] = true
+			}
+		}
+		if !r.sentAux && (r.binValues[0] || r.binValues[1]) {
+			r.sentAux = true
+			w := a.est
+			if !r.binValues[b2i(w)] {
+				w = !w
+			}
+			out = append(out, a.message(pb.HoneybadgerAgreementType_aux, w))
+		}
+		var (
+			vals  [2]bool
+			count int
+		)
+		for _, v := range r.aux {
+			if r.binValues[b2i(v)] {
+				vals[b2i(v)] = true
+				count++
+			}
+		}
+		if count < a.n-a.f {
+			return out
+		}
+		if !r.sentCoin {
+			r.sentCoin = true
+			s, proof, err := a.key.share(a.index, a.coinBase(a.round))
+			if err != nil {
+				log.Errorf("honeybadger: failed to compute coin share: %s", err)
+				return out
+			}
+			msg := a.message(pb.HoneybadgerAgreementType_coin, false)
+			msg.Share = s.bytes()
+			msg.Proof = proof
+			out = append(out, msg)
+		}
+		if len(r.shares) < a.f+1 {
+			return out
+		}
+		coin := common.Hash256(a.key.combine(r.shares).bytes())[0]&1 == 1
+		if vals[0] != vals[1] {
+			a.est = vals[1]
+			if a.est == coin && !a.decided {
+				out = append(out, a.decide(a.est))
+			}
+		} else {
+			a.est = coin
+		}
+		delete(a.rounds, a.round)
+		a.round++
+		if _, ok := a.rounds[a.round]; !ok {
+			a.rounds[a.round] = newAgreementRound()
+		}
+	}
+}
+
+// decide decides on the value and returns the term message announcing it.
+// The validator keeps participating until 2f+1 validators decided, so that
+// the others can decide too.
+func (a *agreement) decide(v bool) *pb.HoneybadgerAgreement {
+	a.decided = true
+	a.value = v
+	a.est = v
+	a.started = true
+	return a.message(pb.HoneybadgerAgreementType_term, v)
+}
+
+func (a *agreement) message(t pb.HoneybadgerAgreementType, v bool) *pb.HoneybadgerAgreement {
+	return &pb.HoneybadgerAgreement{
+		Type:     t,
+		Epoch:    a.epoch,
+		Proposer: a.proposer,
+		Round:    a.round,
+		Value:    v,
+		Sender:   uint32(a.index),
+	}
+}
@@ -0,0 +1,107 @@
+package honeybadger
+
+import (
+	"github.com/anthdm/consenter/pkg/common"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	proto "github.com/golang/protobuf/proto"
+)
+
+// broadcast is Bracha's reliable broadcast of the encrypted batch of a single
+// proposer. Either all honest validators deliver the same batch or none of
+// them does, even if the proposer is faulty.
+type broadcast struct {
+	// Echoed ciphertexts by their digest.
+	ciphertexts map[string]*pb.HoneybadgerCiphertext
+	echos       map[string]map[uint32]bool
+	readies     map[string]map[uint32]bool
+	// Validators only echo and ready a single value.
+	echoed    map[uint32]bool
+	readied   map[uint32]bool
+	sentEcho  bool
+	sentReady bool
+	// The delivered batch, nil until delivered.
+	delivered *pb.HoneybadgerCiphertext
+}
+
+func newBroadcast() *broadcast {
+	return &broadcast{
+		ciphertexts: make(map[string]*pb.HoneybadgerCiphertext),
+		echos:       make(map[string]map[uint32]bool),
+		readies:     make(map[string]map[uint32]bool),
+		echoed:      make(map[uint32]bool),
+		readied:     make(map[uint32]bool),
+	}
+}
+
+// ciphertextDigest returns the hash of the ciphertext.
+func ciphertextDigest(ct *pb.HoneybadgerCiphertext) []byte {
+	b, err := proto.Marshal(ct)
+	if err != nil {
+		panic(err)
+	}
+	return common.Hash256(b)
+}
+
+// handle processes a broadcast message and returns the messages this
+// validator has to multicast in response.
+func (b *broadcast) handle(msg *pb.HoneybadgerBroadcast, n, f int) []*pb.HoneybadgerBroadcast {
+	var out []*pb.HoneybadgerBroadcast
+	switch msg.Type {
+	case pb.HoneybadgerBroadcastType_val:
+		// Only the proposer sends its value, the echos carry the value too,
+		// hence validators that missed it can still deliver.
+		if msg.Sender != msg.Proposer || b.sentEcho || msg.Ciphertext == nil {
+			return nil
+		}
+		b.sentEcho = true
+		out = append(out, &pb.HoneybadgerBroadcast{
+			Type:       pb.HoneybadgerBroadcastType_echo,
+			Ciphertext: msg.Ciphertext,
+			Digest:     ciphertextDigest(msg.Ciphertext),
+		})
+	case pb.HoneybadgerBroadcastType_echo:
+		if msg.Ciphertext == nil || b.echoed[msg.Sender] {
+			return nil
+		}
+		b.echoed[msg.Sender] = true
+		digest := string(ciphertextDigest(msg.Ciphertext))
+		b.ciphertexts[digest] = msg.Ciphertext
+		if b.echos[digest] == nil {
+			b.echos[digest] = make(map[uint32]bool)
+		}
+		b.echos[digest][msg.Sender] = true
+		if len(b.echos[digest]) >= n-f && !b.sentReady {
+			b.sentReady = true
+			out = append(out, &pb.HoneybadgerBroadcast{
+				Type:   pb.HoneybadgerBroadcastType_ready,
+				Digest: []byte(digest),
+			})
+		}
+	case pb.HoneybadgerBroadcastType_ready:
+		if b.readied[msg.Sender] {
+			return nil
+		}
+		b.readied[msg.Sender] = true
+		digest := string(msg.Digest)
+		if b.readies[digest] == nil {
+			b.readies[digest] = make(map[uint32]bool)
+		}
+		b.readies[digest][msg.Sender] = true
+		// f+1 readies contain at least one honest validator.
+		if len(b.readies[digest]) >= f+1 && !b.sentReady {
+			b.sentReady = true
+			out = append(out, &pb.HoneybadgerBroadcast{
+				Type:   pb.HoneybadgerBroadcastType_ready,
+				Digest: []byte(digest),
+			})
+		}
+	}
+	if b.delivered == nil {
+		for digest, readies := range b.readies {
+			if ct, ok := b.ciphertexts[digest]; ok && len(readies) >= 2*f+1 {
+				b.delivered = ct
+			}
+		}
+	}
+	return out
+}
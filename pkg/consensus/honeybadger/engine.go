@@ -0,0 +1,514 @@
+package honeybadger
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"math/rand"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/common"
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/mempool"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	proto "github.com/golang/protobuf/proto"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxFuture is the maximum amount of messages of later epochs that are
+// buffered while the current epoch is not decided yet.
+const maxFuture = 4096
+
+// Config holds the configuration of the HoneyBadger engine.
+type Config struct {
+	// The validators participating in consensus. HoneyBadger tolerates f
+	// faulty validators out of 3f+1.
+	Validators consensus.Validators
+
+	// The time a validator waits after committing a block before it proposes
+	// its batch for the next epoch, giving transactions time to arrive. It is
+	// not needed for progress, the protocol makes no timing assumptions.
+	BlockInterval time.Duration
+
+	// The amount of pending transactions each validator picks its batch
+	// from. Each validator proposes a random BatchSize/n of them, so that the
+	// batches of different validators rarely overlap.
+	BatchSize int
+}
+
+// epoch holds the state of the asynchronous common subset of a single epoch.
+type epoch struct {
+	number     uint64
+	broadcasts []*broadcast
+	agreements []*agreement
+	// Indexes of the proposers whose batches are part of the epoch, nil until
+	// all agreements decided.
+	subset []int
+	// The verified decryption shares for the batch of each proposer, and the
+	// ones received before the batch was delivered.
+	shares        []map[int]point
+	pendingShares []map[uint32]*pb.HoneybadgerDecryption
+	sentShares    []bool
+	batches       [][]*pb.Transaction
+	decrypted     []bool
+}
+
+// Engine is a HoneyBadgerBFT consensus engine. In each epoch every
+// validator reliably broadcasts an encrypted batch of transactions, after
+// which a binary agreement per proposer decides the subset of batches that
+// is decrypted and committed. Progress does not depend on any timeouts,
+// hence it keeps committing blocks under arbitrary network delays.
+type Engine struct {
+	Config
+	consensus.Lifecycle
+
+	privKey *ecdsa.PrivateKey
+	relayCh chan<- *pb.Message
+	msgCh   chan *pb.Message
+	key     *thresholdKey
+
+	// Index of this node in the validator set, -1 if this node only follows
+	// the consensus.
+	index int
+
+	// Pending transactions, shared with the server if it has a mempool.
+	pool *mempool.Pool
+
+	epoch    *epoch
+	prevHash []byte
+	// Messages send by this node that still need to be processed locally.
+	local []*pb.Message
+	// Messages of later epochs that arrived early.
+	future []*pb.Message
+	// Fires when this node should propose its batch of the current epoch.
+	proposeTimer *time.Timer
+}
+
+func init() {
+	consensus.Register(consensus.Factory{
+		Name:        "honeybadger",
+		Description: "HoneyBadgerBFT asynchronous byzantine fault tolerance",
+		Config: func() interface{} {
+			return &Config{
+				BlockInterval: 5 * time.Second,
+				BatchSize:     1024,
+			}
+		},
+		New: func(c interface{}) (consensus.Engine, error) {
+			cfg := c.(*Config)
+			if len(cfg.Validators) == 0 {
+				return nil, consensus.ErrMissingValidators
+			}
+			return NewEngine(*cfg), nil
+		},
+	})
+}
+
+// NewEngine returns a new HoneyBadger consensus engine.
+func NewEngine(cfg Config) *Engine {
+	return &Engine{
+		Config: cfg,
+		msgCh:  make(chan *pb.Message, 1024),
+		key:    newThresholdKey(cfg.Validators, len(cfg.Validators)/3+1),
+		pool:   mempool.New(mempool.Config{}),
+	}
+}
+
+// Configurate implements the Engine interface.
+func (e *Engine) Configurate(relayCh chan<- *pb.Message, priv *ecdsa.PrivateKey) {
+	e.privKey = priv
+	e.relayCh = relayCh
+	e.index = -1
+	if priv != nil {
+		e.index = e.Validators.Index(&priv.PublicKey)
+	}
+}
+
+// Start implements the Engine interface.
+func (e *Engine) Start(ctx context.Context) error {
+	return e.Run(ctx, e.run)
+}
+
+// AddTransaction implements the Engine interface.
+func (e *Engine) AddTransaction(tx *pb.Transaction) {
+	e.pool.Add(tx)
+}
+
+// UseMempool implements the consensus.MempoolUser interface.
+func (e *Engine) UseMempool(pool *mempool.Pool) {
+	e.pool = pool
+}
+
+// HandleMessage implements the consensus.Handler interface.
+func (e *Engine) HandleMessage(from consensus.Peer, msg *pb.Message) error {
+	switch msg.Payload.(type) {
+	case *pb.Message_HoneybadgerBroadcast,
+		*pb.Message_HoneybadgerAgreement,
+		*pb.Message_HoneybadgerDecryption:
+		select {
+		case e.msgCh <- msg:
+		case <-e.Done():
+		}
+	}
+	return nil
+}
+
+func (e *Engine) run(ctx context.Context) error {
+	e.proposeTimer = time.NewTimer(e.BlockInterval)
+	e.newEpoch(1)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-e.proposeTimer.C:
+			e.propose()
+		case msg := <-e.msgCh:
+			e.handleMessage(msg)
+		}
+		// Messages of this node are processed like the ones of the others.
+		for len(e.local) > 0 {
+			msg := e.local[0]
+			e.local = e.local[1:]
+			e.handleMessage(msg)
+		}
+	}
+}
+
+func (e *Engine) f() int {
+	return (len(e.Validators) - 1) / 3
+}
+
+// newEpoch starts the given epoch and replays the buffered messages of it.
+func (e *Engine) newEpoch(number uint64) {
+	n := len(e.Validators)
+	ep := &epoch{
+		number:        number,
+		broadcasts:    make([]*broadcast, n),
+		agreements:    make([]*agreement, n),
+		shares:        make([]map[int]point, n),
+		pendingShares: make([]map[uint32]*pb.HoneybadgerDecryption, n),
+		sentShares:    make([]bool, n),
+		batches:       make([][]*pb.Transaction, n),
+		decrypted:     make([]bool, n),
+	}
+	for i := 0; i < n; i++ {
+		ep.broadcasts[i] = newBroadcast()
+		ep.agreements[i] = newAgreement(number, uint32(i), n, e.f(), e.key, e.index)
+		ep.shares[i] = make(map[int]point)
+		ep.pendingShares[i] = make(map[uint32]*pb.HoneybadgerDecryption)
+	}
+	e.epoch = ep
+
+	if !e.proposeTimer.Stop() {
+		select {
+		case <-e.proposeTimer.C:
+		default:
+		}
+	}
+	e.proposeTimer.Reset(e.BlockInterval)
+
+	future := e.future
+	e.future = nil
+	for _, msg := range future {
+		e.handleMessage(msg)
+	}
+}
+
+// propose encrypts a batch of the pending transactions and reliably
+// broadcasts it.
+func (e *Engine) propose() {
+	if e.index < 0 {
+		return
+	}
+	pending := e.pool.Batch(e.BatchSize, 0)
+	size := e.BatchSize / len(e.Validators)
+	if size < 1 {
+		size = 1
+	}
+	var txs []*pb.Transaction
+	for _, i := range rand.Perm(len(pending)) {
+		if len(txs) == size {
+			break
+		}
+		txs = append(txs, pending[i])
+	}
+
+	b, err := proto.Marshal(&pb.Block{Transactions: txs})
+	if err != nil {
+		log.Errorf("honeybadger: failed to encode batch: %s", err)
+		return
+	}
+	ct, err := e.key.encrypt(b)
+	if err != nil {
+		log.Errorf("honeybadger: failed to encrypt batch: %s", err)
+		return
+	}
+	log.WithFields(log.Fields{
+		"epoch": e.epoch.number,
+		"txs":   len(txs),
+	}).Info("honeybadger: proposed batch")
+
+	e.send(&pb.Message{
+		Payload: &pb.Message_HoneybadgerBroadcast{
+			HoneybadgerBroadcast: &pb.HoneybadgerBroadcast{
+				Type:       pb.HoneybadgerBroadcastType_val,
+				Epoch:      e.epoch.number,
+				Proposer:   uint32(e.index),
+				Ciphertext: ct,
+				Sender:     uint32(e.index),
+			},
+		},
+	})
+}
+
+func (e *Engine) handleMessage(msg *pb.Message) {
+	var (
+		ep     = e.epoch
+		epoch  uint64
+		sender uint32
+		signed proto.Message
+	)
+	switch p := msg.Payload.(type) {
+	case *pb.Message_HoneybadgerBroadcast:
+		epoch, sender, signed = p.HoneybadgerBroadcast.Epoch, p.HoneybadgerBroadcast.Sender, p.HoneybadgerBroadcast
+	case *pb.Message_HoneybadgerAgreement:
+		epoch, sender, signed = p.HoneybadgerAgreement.Epoch, p.HoneybadgerAgreement.Sender, p.HoneybadgerAgreement
+	case *pb.Message_HoneybadgerDecryption:
+		epoch, sender, signed = p.HoneybadgerDecryption.Epoch, p.HoneybadgerDecryption.Sender, p.HoneybadgerDecryption
+	default:
+		return
+	}
+	// Only validators take part in the protocol.
+	if e.index < 0 || epoch < ep.number {
+		return
+	}
+	if epoch > ep.number {
+		if len(e.future) < maxFuture {
+			e.future = append(e.future, msg)
+		}
+		return
+	}
+	if !verify(signed, e.Validators.Get(int(sender))) {
+		log.Warnf("honeybadger: message from %d: %s", sender, errInvalidSignature)
+		return
+	}
+
+	switch p := msg.Payload.(type) {
+	case *pb.Message_HoneybadgerBroadcast:
+		m := p.HoneybadgerBroadcast
+		if int(m.Proposer) >= len(ep.broadcasts) {
+			return
+		}
+		for _, out := range ep.broadcasts[m.Proposer].handle(m, len(e.Validators), e.f()) {
+			out.Epoch = ep.number
+			out.Proposer = m.Proposer
+			out.Sender = uint32(e.index)
+			e.send(&pb.Message{
+				Payload: &pb.Message_HoneybadgerBroadcast{HoneybadgerBroadcast: out},
+			})
+		}
+	case *pb.Message_HoneybadgerAgreement:
+		m := p.HoneybadgerAgreement
+		if int(m.Proposer) >= len(ep.agreements) {
+			return
+		}
+		e.sendAgreement(ep.agreements[m.Proposer].handle(m))
+	case *pb.Message_HoneybadgerDecryption:
+		m := p.HoneybadgerDecryption
+		if int(m.Proposer) >= len(ep.shares) {
+			return
+		}
+		ep.pendingShares[m.Proposer][m.Sender] = m
+	}
+	e.process()
+}
+
+// process drives the common subset of the current epoch forward and commits
+// the block once all batches of the subset are decrypted.
+func (e *Engine) process() {
+	var (
+		ep = e.epoch
+		n  = len(e.Validators)
+	)
+	// Agree on the batches that were delivered. Once n-f batches are part of
+	// the epoch, there is no need to wait for the others.
+	ones := 0
+	for j, ag := range ep.agreements {
+		if ep.broadcasts[j].delivered != nil {
+			e.sendAgreement(ag.input(true))
+		}
+		if ag.decided && ag.value {
+			ones++
+		}
+	}
+	if ones >= n-e.f() {
+		for _, ag := range ep.agreements {
+			e.sendAgreement(ag.input(false))
+		}
+	}
+	if ep.subset == nil {
+		for _, ag := range ep.agreements {
+			if !ag.decided {
+				return
+			}
+		}
+		ep.subset = []int{}
+		for j, ag := range ep.agreements {
+			if ag.value {
+				ep.subset = append(ep.subset, j)
+			}
+		}
+	}
+
+	// Decrypt the batches of the subset.
+	for _, j := range ep.subset {
+		ct := ep.broadcasts[j].delivered
+		if ct == nil || ep.decrypted[j] {
+			continue
+		}
+		u, err := unmarshalPoint(ct.U)
+		if err != nil {
+			// The ciphertext is invalid for everybody, hence the batch is
+			// empty.
+			ep.decrypted[j] = true
+			continue
+		}
+		if !ep.sentShares[j] {
+			ep.sentShares[j] = true
+			e.sendDecryptionShare(j, u)
+		}
+		shares := ep.shares[j]
+		for sender, m := range ep.pendingShares[j] {
+			delete(ep.pendingShares[j], sender)
+			if _, ok := shares[int(sender)]; ok || len(shares) > e.f() {
+				continue
+			}
+			s, err := unmarshalPoint(m.Share)
+			if err != nil || !e.key.verifyShare(int(sender), u, s, m.Proof) {
+				log.Warnf("honeybadger: invalid decryption share from %d", sender)
+				continue
+			}
+			shares[int(sender)] = s
+		}
+		if len(shares) <= e.f() {
+			continue
+		}
+		ep.decrypted[j] = true
+		b, err := decrypt(ct, e.key.combine(shares))
+		if err != nil {
+			log.Warnf("honeybadger: failed to decrypt batch of %d: %s", j, err)
+			continue
+		}
+		batch := &pb.Block{}
+		if err := proto.Unmarshal(b, batch); err != nil {
+			log.Warnf("honeybadger: failed to decode batch of %d: %s", j, err)
+			continue
+		}
+		ep.batches[j] = batch.Transactions
+	}
+	for _, j := range ep.subset {
+		if !ep.decrypted[j] {
+			return
+		}
+	}
+	e.commit()
+}
+
+// commit commits the union of the decrypted batches and starts the next
+// epoch.
+func (e *Engine) commit() {
+	var (
+		ep   = e.epoch
+		seen = make(map[string]bool)
+		txs  = []*pb.Transaction{}
+	)
+	for _, j := range ep.subset {
+		for _, tx := range ep.batches[j] {
+			if !seen[string(tx.Hash())] {
+				seen[string(tx.Hash())] = true
+				txs = append(txs, tx)
+			}
+		}
+	}
+	relayer := int(ep.number % uint64(len(e.Validators)))
+	block := &pb.Block{
+		Header: &pb.Header{
+			Index:    uint32(ep.number),
+			PrevHash: e.prevHash,
+			Proposer: common.MarshalPublicKey(e.Validators.Get(relayer)),
+			TxRoot:   pb.TxRoot(txs),
+		},
+		Transactions: txs,
+	}
+	e.prevHash = block.Header.Hash()
+	e.pool.Remove(txs)
+
+	log.WithFields(log.Fields{
+		"epoch":   ep.number,
+		"hash":    hex.EncodeToString(e.prevHash),
+		"txs":     len(txs),
+		"batches": len(ep.subset),
+	}).Info("honeybadger: committed block")
+
+	// Every validator commits the same block, a single one signs and relays
+	// it.
+	if e.index == relayer {
+		if err := block.Sign(e.privKey); err != nil {
+			log.Errorf("honeybadger: failed to sign block: %s", err)
+		} else {
+			e.Relay(e.relayCh, &pb.Message{
+				Payload: &pb.Message_Block{
+					Block: block,
+				},
+			})
+		}
+	}
+	e.newEpoch(ep.number + 1)
+}
+
+func (e *Engine) sendAgreement(msgs []*pb.HoneybadgerAgreement) {
+	for _, msg := range msgs {
+		e.send(&pb.Message{
+			Payload: &pb.Message_HoneybadgerAgreement{HoneybadgerAgreement: msg},
+		})
+	}
+}
+
+func (e *Engine) sendDecryptionShare(proposer int, u point) {
+	s, proof, err := e.key.share(e.index, u)
+	if err != nil {
+		log.Errorf("honeybadger: failed to compute decryption share: %s", err)
+		return
+	}
+	e.send(&pb.Message{
+		Payload: &pb.Message_HoneybadgerDecryption{
+			HoneybadgerDecryption: &pb.HoneybadgerDecryption{
+				Epoch:    e.epoch.number,
+				Proposer: uint32(proposer),
+				Share:    s.bytes(),
+				Proof:    proof,
+				Sender:   uint32(e.index),
+			},
+		},
+	})
+}
+
+// send signs and multicasts the message and queues it for processing by this
+// node.
+func (e *Engine) send(msg *pb.Message) {
+	var signed proto.Message
+	switch p := msg.Payload.(type) {
+	case *pb.Message_HoneybadgerBroadcast:
+		signed = p.HoneybadgerBroadcast
+	case *pb.Message_HoneybadgerAgreement:
+		signed = p.HoneybadgerAgreement
+	case *pb.Message_HoneybadgerDecryption:
+		signed = p.HoneybadgerDecryption
+	}
+	if err := sign(signed, e.privKey); err != nil {
+		log.Errorf("honeybadger: failed to sign message: %s", err)
+		return
+	}
+	e.Relay(e.relayCh, msg)
+	e.local = append(e.local, msg)
+}
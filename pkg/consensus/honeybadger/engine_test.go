@@ -0,0 +1,89 @@
+package honeybadger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/consensus/consensustest"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	proto "github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestNetwork returns a network of n engines, which are not started.
+func newTestNetwork(n int) *consensustest.Network {
+	net := consensustest.NewNetwork(n, func(validators consensus.Validators) consensus.Engine {
+		return NewEngine(Config{
+			Validators:    validators,
+			BlockInterval: 50 * time.Millisecond,
+			BatchSize:     8,
+		})
+	})
+	net.Timeout = 10 * time.Second
+	return net
+}
+
+// mustSign signs the message with the key of the validator at index i.
+func mustSign(msg proto.Message, i int) {
+	if err := sign(msg, consensustest.PrivateKey(i)); err != nil {
+		panic(err)
+	}
+}
+
+func TestEngineCommit(t *testing.T) {
+	net := newTestNetwork(4)
+	net.Start()
+	defer net.Stop()
+	b := net.Receive(t.Fatalf).Block
+	assert.Equal(t, uint32(1), b.Header.Index)
+	assert.Equal(t, 4, len(b.Transactions))
+}
+
+func TestEngineCrashedValidator(t *testing.T) {
+	// The batch of the crashed validator is left out of the epochs, which
+	// also means that it does not relay the block of every fourth epoch.
+	net := newTestNetwork(4)
+	net.Start(1)
+	defer net.Stop()
+	for _, i := range []uint32{2, 3, 4} {
+		assert.Equal(t, i, net.Receive(t.Fatalf).Block.Header.Index)
+	}
+}
+
+func TestEngineEquivocatingProposer(t *testing.T) {
+	net := newTestNetwork(4)
+	// A validator broadcasts a different batch to each validator, none of
+	// which is echoed by enough validators to be delivered.
+	net.SetByzantine(2, func(to int, msg *pb.Message) *pb.Message {
+		if b := msg.GetHoneybadgerBroadcast(); b != nil && b.Type == pb.HoneybadgerBroadcastType_val {
+			b.Ciphertext.Data = append(b.Ciphertext.Data, byte(to))
+			mustSign(b, 2)
+		}
+		return msg
+	})
+	net.Start()
+	defer net.Stop()
+	// The batch is left out, the batches of the others are committed.
+	b := net.Receive(t.Fatalf).Block
+	assert.Equal(t, uint32(1), b.Header.Index)
+	assert.Equal(t, 3, len(b.Transactions))
+}
+
+func TestEngineInvalidDecryptionShares(t *testing.T) {
+	net := newTestNetwork(4)
+	// A validator sends decryption shares with invalid proofs, the batches
+	// are decrypted with the shares of the others.
+	net.SetByzantine(2, func(to int, msg *pb.Message) *pb.Message {
+		if d := msg.GetHoneybadgerDecryption(); d != nil {
+			d.Proof[0]++
+			mustSign(d, 2)
+		}
+		return msg
+	})
+	net.Start()
+	defer net.Stop()
+	b := net.Receive(t.Fatalf).Block
+	assert.Equal(t, uint32(1), b.Header.Index)
+	assert.Equal(t, 4, len(b.Transactions))
+}
@@ -0,0 +1,234 @@
+package honeybadger
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"math/big"
+
+	"github.com/anthdm/consenter/pkg/common"
+	"github.com/anthdm/consenter/pkg/consensus"
+	pb "github.com/anthdm/consenter/pkg/protos"
+)
+
+var (
+	errInvalidPoint      = errors.New("invalid curve point")
+	errInvalidCiphertext = errors.New("invalid ciphertext")
+)
+
+// point is a point on common.Curve.
+type point struct {
+	x, y *big.Int
+}
+
+func (p point) bytes() []byte {
+	return elliptic.Marshal(common.Curve, p.x, p.y)
+}
+
+func unmarshalPoint(b []byte) (point, error) {
+	x, y := elliptic.Unmarshal(common.Curve, b)
+	if x == nil {
+		return point{}, errInvalidPoint
+	}
+	return point{x, y}, nil
+}
+
+func baseMult(k *big.Int) point {
+	x, y := common.Curve.ScalarBaseMult(k.Bytes())
+	return point{x, y}
+}
+
+func (p point) mult(k *big.Int) point {
+	x, y := common.Curve.ScalarMult(p.x, p.y, k.Bytes())
+	return point{x, y}
+}
+
+func (p point) add(q point) point {
+	x, y := common.Curve.Add(p.x, p.y, q.x, q.y)
+	return point{x, y}
+}
+
+func (p point) neg() point {
+	return point{p.x, new(big.Int).Sub(common.Curve.Params().P, p.y)}
+}
+
+// hashToPoint maps data to a point on the curve of which nobody knows the
+// discrete logarithm.
+func hashToPoint(data []byte) point {
+	x, y := common.HashToCurve(data)
+	return point{x, y}
+}
+
+// thresholdKey is a key of which any threshold amount of the validators can
+// jointly decrypt ciphertexts and compute the common coin, while fewer of
+// them learn nothing.
+//
+// There is no distributed key generation, a trusted dealer is simulated by
+// deriving the polynomial from the validator set. Hence every node could
+// compute every share, which is good enough for simulation only.
+type thresholdKey struct {
+	threshold int
+	public    point
+	// The verification key of each validator, which is its share times the
+	// base point.
+	verification []point
+	shares       []*big.Int
+}
+
+func newThresholdKey(validators consensus.Validators, threshold int) *thresholdKey {
+	var (
+		n    = common.Curve.Params().N
+		seed []byte
+	)
+	for _, pub := range validators {
+		seed = append(seed, common.MarshalPublicKey(pub)...)
+	}
+	coeffs := make([]*big.Int, threshold)
+	for i := range coeffs {
+		c := new(big.Int).SetBytes(common.Hash256(append(seed, byte(i))))
+		coeffs[i] = c.Mod(c, n)
+	}
+	k := &thresholdKey{
+		threshold:    threshold,
+		public:       baseMult(coeffs[0]),
+		verification: make([]point, len(validators)),
+		shares:       make([]*big.Int, len(validators)),
+	}
+	for i := range validators {
+		var (
+			x = big.NewInt(int64(i + 1))
+			y = new(big.Int)
+		)
+		for j := threshold - 1; j >= 0; j-- {
+			y.Mul(y, x)
+			y.Add(y, coeffs[j])
+			y.Mod(y, n)
+		}
+		k.shares[i] = y
+		k.verification[i] = baseMult(y)
+	}
+	return k
+}
+
+// share returns the share of validator i for base, which is its share of the
+// secret key times base, and a proof that it is correct.
+func (k *thresholdKey) share(i int, base point) (point, []byte, error) {
+	s := base.mult(k.shares[i])
+	proof, err := proveEqual(k.shares[i], k.verification[i], base, s)
+	if err != nil {
+		return point{}, nil, err
+	}
+	return s, proof, nil
+}
+
+// verifyShare reports whether s is the share of validator i for base.
+func (k *thresholdKey) verifyShare(i int, base, s point, proof []byte) bool {
+	if i < 0 || i >= len(k.verification) {
+		return false
+	}
+	return verifyEqual(k.verification[i], base, s, proof)
+}
+
+// combine interpolates the given shares by validator index, which yields the
+// secret key times base. At least threshold shares must be given.
+func (k *thresholdKey) combine(shares map[int]point) point {
+	var (
+		n      = common.Curve.Params().N
+		result *point
+	)
+	for i, s := range shares {
+		// Lagrange coefficient at zero with x coordinates starting at 1.
+		var (
+			num = big.NewInt(1)
+			den = big.NewInt(1)
+		)
+		for j := range shares {
+			if i == j {
+				continue
+			}
+			num.Mul(num, big.NewInt(int64(j+1)))
+			num.Mod(num, n)
+			den.Mul(den, big.NewInt(int64(j-i)))
+			den.Mod(den, n)
+		}
+		lambda := num.Mul(num, den.ModInverse(den, n))
+		term := s.mult(lambda.Mod(lambda, n))
+		if result == nil {
+			result = &term
+		} else {
+			sum := result.add(term)
+			result = &sum
+		}
+	}
+	return *result
+}
+
+// proveEqual proves that log_G(v) equals log_base(s), which is the secret x,
+// with a Chaum-Pedersen proof made non-interactive.
+func proveEqual(x *big.Int, v, base, s point) ([]byte, error) {
+	n := common.Curve.Params().N
+	w, err := rand.Int(rand.Reader, n)
+	if err != nil {
+		return nil, err
+	}
+	c := challenge(v, base, s, baseMult(w), base.mult(w))
+	z := new(big.Int).Mul(c, x)
+	z.Add(z, w)
+	z.Mod(z, n)
+
+	var (
+		proof  = make([]byte, 64)
+		cb, zb = c.Bytes(), z.Bytes()
+	)
+	copy(proof[32-len(cb):32], cb)
+	copy(proof[64-len(zb):], zb)
+	return proof, nil
+}
+
+func verifyEqual(v, base, s point, proof []byte) bool {
+	if len(proof) != 64 {
+		return false
+	}
+	var (
+		c = new(big.Int).SetBytes(proof[:32])
+		z = new(big.Int).SetBytes(proof[32:])
+		// zG - cv and z*base - cs equal the commitments of the prover.
+		a1 = baseMult(z).add(v.mult(c).neg())
+		a2 = base.mult(z).add(s.mult(c).neg())
+	)
+	return c.Cmp(challenge(v, base, s, a1, a2)) == 0
+}
+
+func challenge(points ...point) *big.Int {
+	var b []byte
+	for _, p := range points {
+		b = append(b, p.bytes()...)
+	}
+	c := new(big.Int).SetBytes(common.Hash256(b))
+	return c.Mod(c, common.Curve.Params().N)
+}
+
+// encrypt encrypts plaintext with the public key, such that it can only be
+// decrypted with the shares of threshold validators.
+func (k *thresholdKey) encrypt(plaintext []byte) (*pb.HoneybadgerCiphertext, error) {
+	r, err := rand.Int(rand.Reader, common.Curve.Params().N)
+	if err != nil {
+		return nil, err
+	}
+	data, err := common.Encrypt(common.Hash256(k.public.mult(r).bytes()), plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.HoneybadgerCiphertext{
+		U:    baseMult(r).bytes(),
+		Data: data,
+	}, nil
+}
+
+// decrypt decrypts the ciphertext with the combined decryption shares.
+func decrypt(ct *pb.HoneybadgerCiphertext, combined point) ([]byte, error) {
+	if ct == nil {
+		return nil, errInvalidCiphertext
+	}
+	return common.Decrypt(common.Hash256(combined.bytes()), ct.Data)
+}
@@ -0,0 +1,58 @@
+package honeybadger
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestKey(n int) *thresholdKey {
+	seeds := make([]string, n)
+	for i := range seeds {
+		seeds[i] = fmt.Sprintf("node_%d", i)
+	}
+	return newThresholdKey(consensus.NewValidators(seeds), n/3+1)
+}
+
+func TestThresholdDecrypt(t *testing.T) {
+	key := newTestKey(4)
+	ct, err := key.encrypt([]byte("batch"))
+	assert.Nil(t, err)
+	u, err := unmarshalPoint(ct.U)
+	assert.Nil(t, err)
+
+	// Any two of the four validators can decrypt.
+	for _, pair := range [][2]int{{0, 1}, {1, 3}, {2, 0}} {
+		shares := make(map[int]point)
+		for _, i := range pair {
+			s, proof, err := key.share(i, u)
+			assert.Nil(t, err)
+			assert.True(t, key.verifyShare(i, u, s, proof))
+			shares[i] = s
+		}
+		plaintext, err := decrypt(ct, key.combine(shares))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("batch"), plaintext)
+	}
+
+	// A single share is not enough.
+	s, _, _ := key.share(0, u)
+	_, err = decrypt(ct, key.combine(map[int]point{0: s}))
+	assert.NotNil(t, err)
+}
+
+func TestVerifyShare(t *testing.T) {
+	var (
+		key  = newTestKey(4)
+		base = hashToPoint([]byte("coin"))
+	)
+	s, proof, err := key.share(1, base)
+	assert.Nil(t, err)
+	assert.True(t, key.verifyShare(1, base, s, proof))
+	// The share of validator 1 is no valid share of validator 2.
+	assert.False(t, key.verifyShare(2, base, s, proof))
+	other, _, _ := key.share(2, base)
+	assert.False(t, key.verifyShare(1, base, other, proof))
+}
@@ -0,0 +1,619 @@
+package hotstuff
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/common"
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/mempool"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	log "github.com/sirupsen/logrus"
+)
+
+var errInvalidQC = errors.New("invalid quorum certificate")
+
+// Config holds the configuration of the HotStuff engine.
+type Config struct {
+	// The validators participating in consensus. HotStuff tolerates f
+	// faulty validators out of 3f+1.
+	Validators consensus.Validators
+
+	// The interval in which the leader of a view proposes a block, once it
+	// collected the votes of the previous view.
+	BlockInterval time.Duration
+
+	// The time a replica waits for a proposal before it moves on to the next
+	// view. It doubles with each view in a row that times out.
+	ViewTimeout time.Duration
+}
+
+// node is a proposal in the tree of proposals. The genesis node has no
+// proposal.
+type node struct {
+	hash     []byte
+	proposal *pb.HotstuffProposal
+	height   uint64
+}
+
+func (n *node) view() uint64 {
+	if n.proposal == nil {
+		return 0
+	}
+	return n.proposal.View
+}
+
+// Engine is a chained HotStuff consensus engine. Each proposal carries the
+// QC of its parent, so that the phases of consecutive proposals are
+// pipelined. A block is committed once it is the head of a three-chain of
+// proposals in consecutive views.
+type Engine struct {
+	Config
+	consensus.Lifecycle
+
+	privKey *ecdsa.PrivateKey
+	relayCh chan<- *pb.Message
+	msgCh   chan *pb.Message
+	// Sender of the votes and new views to the leader, they are relayed
+	// into the network if it is nil.
+	sender consensus.Sender
+
+	// Index of this node in the validator set, -1 if this node only follows
+	// the consensus.
+	index int
+
+	// Pending transactions, shared with the server if it has a mempool.
+	pool *mempool.Pool
+
+	view uint64
+	// The last view the replica voted in and proposed in.
+	voted    uint64
+	proposed uint64
+	nodes    map[string]*node
+	highQC   *pb.HotstuffQC
+	locked   *node
+	executed *node
+	// Votes collected by the leader, by the hash of the node voted for.
+	votes    map[string]map[uint32]*pb.HotstuffVote
+	newViews map[uint64]map[uint32]*pb.HotstuffNewView
+	// Number of views in a row that timed out.
+	attempts  uint
+	viewTimer *time.Timer
+}
+
+func init() {
+	consensus.Register(consensus.Factory{
+		Name:        "hotstuff",
+		Description: "Chained HotStuff with a rotating leader",
+		Config: func() interface{} {
+			return &Config{
+				BlockInterval: 5 * time.Second,
+				ViewTimeout:   15 * time.Second,
+			}
+		},
+		New: func(c interface{}) (consensus.Engine, error) {
+			cfg := c.(*Config)
+			if len(cfg.Validators) == 0 {
+				return nil, consensus.ErrMissingValidators
+			}
+			return NewEngine(*cfg), nil
+		},
+	})
+}
+
+// NewEngine returns a new HotStuff consensus engine.
+func NewEngine(cfg Config) *Engine {
+	genesis := &node{}
+	return &Engine{
+		Config:   cfg,
+		msgCh:    make(chan *pb.Message, 1024),
+		pool:     mempool.New(mempool.Config{}),
+		view:     1,
+		nodes:    map[string]*node{"": genesis},
+		highQC:   &pb.HotstuffQC{},
+		locked:   genesis,
+		executed: genesis,
+		votes:    make(map[string]map[uint32]*pb.HotstuffVote),
+		newViews: make(map[uint64]map[uint32]*pb.HotstuffNewView),
+	}
+}
+
+// Configurate implements the Engine interface.
+func (e *Engine) Configurate(relayCh chan<- *pb.Message, priv *ecdsa.PrivateKey) {
+	e.privKey = priv
+	e.relayCh = relayCh
+	e.index = -1
+	if priv != nil {
+		e.index = e.Validators.Index(&priv.PublicKey)
+	}
+}
+
+// Start implements the Engine interface.
+func (e *Engine) Start(ctx context.Context) error {
+	return e.Run(ctx, e.run)
+}
+
+// UseSender implements the consensus.SenderUser interface.
+func (e *Engine) UseSender(s consensus.Sender) {
+	e.sender = s
+}
+
+// AddTransaction implements the Engine interface.
+func (e *Engine) AddTransaction(tx *pb.Transaction) {
+	e.pool.Add(tx)
+}
+
+// UseMempool implements the consensus.MempoolUser interface.
+func (e *Engine) UseMempool(pool *mempool.Pool) {
+	e.pool = pool
+}
+
+// HandleMessage implements the consensus.Handler interface.
+func (e *Engine) HandleMessage(from consensus.Peer, msg *pb.Message) error {
+	switch msg.Payload.(type) {
+	case *pb.Message_HotstuffProposal, *pb.Message_HotstuffVote, *pb.Message_HotstuffNewView:
+		select {
+		case e.msgCh <- msg:
+		case <-e.Done():
+		}
+	}
+	return nil
+}
+
+func (e *Engine) run(ctx context.Context) error {
+	var (
+		ticker = time.NewTicker(e.BlockInterval)
+	)
+	defer ticker.Stop()
+	e.viewTimer = time.NewTimer(e.ViewTimeout)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if e.canPropose() {
+				if err := e.propose(); err != nil {
+					log.Warnf("hotstuff: failed to propose block: %s", err)
+				}
+			}
+		case <-e.viewTimer.C:
+			e.onViewTimeout()
+		case msg := <-e.msgCh:
+			if err := e.handleMessage(msg); err != nil {
+				log.Warnf("hotstuff: failed processing message: %s", err)
+			}
+		}
+	}
+}
+
+func (e *Engine) handleMessage(msg *pb.Message) error {
+	switch p := msg.Payload.(type) {
+	case *pb.Message_HotstuffProposal:
+		return e.handleProposal(p.HotstuffProposal)
+	case *pb.Message_HotstuffVote:
+		return e.handleVote(p.HotstuffVote)
+	case *pb.Message_HotstuffNewView:
+		return e.handleNewView(p.HotstuffNewView)
+	}
+	return nil
+}
+
+// canPropose reports whether this replica is the leader of the current view
+// and knows the QC of the previous view or the new views of 2f+1 replicas.
+func (e *Engine) canPropose() bool {
+	if e.index < 0 || e.leader(e.view) != e.index || e.proposed >= e.view {
+		return false
+	}
+	return e.highQC.View+1 == e.view || len(e.newViews[e.view]) >= e.quorum()
+}
+
+func (e *Engine) propose() error {
+	parent, ok := e.nodes[string(e.highQC.Node)]
+	if !ok {
+		return fmt.Errorf("unknown node %s", hex.EncodeToString(e.highQC.Node))
+	}
+	block := pb.NewBlock(uint32(parent.height))
+	block.Transactions = e.pendingTransactions(parent)
+	block.Header.TxRoot = pb.TxRoot(block.Transactions)
+	if parent.proposal != nil {
+		block.Header.PrevHash = parent.proposal.Block.Header.Hash()
+	}
+	block.Header.Proposer = common.MarshalPublicKey(&e.privKey.PublicKey)
+	if err := block.Sign(e.privKey); err != nil {
+		return err
+	}
+
+	e.proposed = e.view
+	p := &pb.HotstuffProposal{
+		View:    e.view,
+		Block:   block,
+		Justify: e.highQC,
+		Leader:  uint32(e.index),
+	}
+	if err := sign(p, e.privKey); err != nil {
+		return err
+	}
+	return e.send(&pb.Message{
+		Payload: &pb.Message_HotstuffProposal{HotstuffProposal: p},
+	})
+}
+
+func (e *Engine) handleProposal(p *pb.HotstuffProposal) error {
+	if int(p.Leader) != e.leader(p.View) {
+		return fmt.Errorf("proposal from non leader replica %d", p.Leader)
+	}
+	if !verify(p, e.Validators.Get(int(p.Leader))) {
+		return errInvalidSignature
+	}
+	if p.Justify == nil || p.Justify.View >= p.View {
+		return errInvalidQC
+	}
+	if err := e.verifyQC(p.Justify); err != nil {
+		return err
+	}
+	if p.Block == nil || p.Block.Header == nil {
+		return fmt.Errorf("invalid block in view %d", p.View)
+	}
+	if pub, err := p.Block.Verify(); err != nil || e.Validators.Index(pub) != int(p.Leader) {
+		return fmt.Errorf("block in view %d not signed by its leader", p.View)
+	}
+	n := &node{
+		hash:     nodeHash(p),
+		proposal: p,
+		height:   uint64(p.Block.Header.Index),
+	}
+	if parent := e.justified(n); parent != nil && n.height != parent.height+1 {
+		return fmt.Errorf("invalid block index in view %d", p.View)
+	}
+	if _, ok := e.nodes[string(n.hash)]; ok {
+		return nil
+	}
+	e.nodes[string(n.hash)] = n
+	e.update(n)
+
+	if p.View > e.voted && e.safeNode(n) {
+		e.voted = p.View
+		if err := e.vote(n); err != nil {
+			return err
+		}
+	}
+	if p.View >= e.view {
+		e.enterView(p.View + 1)
+	}
+	return nil
+}
+
+// update advances the high QC, the locked and the committed node based on
+// the chain of QCs the given node carries.
+func (e *Engine) update(n *node) {
+	e.updateHighQC(n.proposal.Justify)
+
+	b2 := e.justified(n)
+	if b2 == nil {
+		return
+	}
+	b1 := e.justified(b2)
+	if b1 == nil {
+		return
+	}
+	if b1.height > e.locked.height {
+		e.locked = b1
+	}
+	b0 := e.justified(b1)
+	if b0 == nil {
+		return
+	}
+	if b2.view() == b1.view()+1 && b1.view() == b0.view()+1 {
+		e.commit(b0)
+	}
+}
+
+// safeNode reports whether the replica can vote for the given node, which is
+// the case if it extends the locked node or if its QC is of a higher view
+// than the locked node.
+func (e *Engine) safeNode(n *node) bool {
+	if n.proposal.Justify.View > e.locked.view() {
+		return true
+	}
+	for b := n; b != nil; b = e.justified(b) {
+		if b.height <= e.locked.height {
+			return bytes.Equal(b.hash, e.locked.hash)
+		}
+	}
+	return false
+}
+
+func (e *Engine) vote(n *node) error {
+	if e.index < 0 {
+		return nil
+	}
+	v := &pb.HotstuffVote{
+		View:    n.proposal.View,
+		Node:    n.hash,
+		Replica: uint32(e.index),
+	}
+	if err := sign(v, e.privKey); err != nil {
+		return err
+	}
+	return e.sendTo(e.leader(v.View+1), &pb.Message{
+		Payload: &pb.Message_HotstuffVote{HotstuffVote: v},
+	})
+}
+
+// handleVote collects the votes addressed to this replica, which is the
+// leader of the view after the vote's view.
+func (e *Engine) handleVote(v *pb.HotstuffVote) error {
+	if e.index < 0 || e.leader(v.View+1) != e.index || v.View <= e.highQC.View {
+		return nil
+	}
+	if !verify(v, e.Validators.Get(int(v.Replica))) {
+		return errInvalidSignature
+	}
+	key := string(v.Node)
+	if _, ok := e.votes[key]; !ok {
+		e.votes[key] = make(map[uint32]*pb.HotstuffVote)
+	}
+	e.votes[key][v.Replica] = v
+	if len(e.votes[key]) < e.quorum() {
+		return nil
+	}
+	qc := &pb.HotstuffQC{
+		View: v.View,
+		Node: v.Node,
+	}
+	for _, vote := range e.votes[key] {
+		qc.Votes = append(qc.Votes, vote)
+	}
+	sort.Slice(qc.Votes, func(i, j int) bool { return qc.Votes[i].Replica < qc.Votes[j].Replica })
+	delete(e.votes, key)
+
+	e.updateHighQC(qc)
+	if v.View+1 > e.view {
+		e.enterView(v.View + 1)
+	}
+	return nil
+}
+
+func (e *Engine) onViewTimeout() {
+	defer e.resetViewTimer()
+	e.attempts++
+	e.view++
+
+	log.WithFields(log.Fields{
+		"view": e.view,
+	}).Info("hotstuff: view timed out")
+
+	if e.index < 0 {
+		return
+	}
+	nv := &pb.HotstuffNewView{
+		View:    e.view,
+		Justify: e.highQC,
+		Replica: uint32(e.index),
+	}
+	if err := sign(nv, e.privKey); err != nil {
+		log.Warnf("hotstuff: failed to sign new view: %s", err)
+		return
+	}
+	if err := e.sendTo(e.leader(nv.View), &pb.Message{
+		Payload: &pb.Message_HotstuffNewView{HotstuffNewView: nv},
+	}); err != nil {
+		log.Warnf("hotstuff: failed to send new view: %s", err)
+	}
+}
+
+// handleNewView collects the new views addressed to this replica, which is
+// the leader of the new view.
+func (e *Engine) handleNewView(nv *pb.HotstuffNewView) error {
+	if e.index < 0 || e.leader(nv.View) != e.index || nv.View < e.view {
+		return nil
+	}
+	if !verify(nv, e.Validators.Get(int(nv.Replica))) {
+		return errInvalidSignature
+	}
+	if nv.Justify == nil {
+		return errInvalidQC
+	}
+	if err := e.verifyQC(nv.Justify); err != nil {
+		return err
+	}
+	e.updateHighQC(nv.Justify)
+
+	if _, ok := e.newViews[nv.View]; !ok {
+		e.newViews[nv.View] = make(map[uint32]*pb.HotstuffNewView)
+	}
+	e.newViews[nv.View][nv.Replica] = nv
+	if len(e.newViews[nv.View]) >= e.quorum() && nv.View > e.view {
+		// Catch up without resetting the timeout, the view did not make
+		// progress yet.
+		e.view = nv.View
+		e.resetViewTimer()
+	}
+	return nil
+}
+
+// enterView moves the replica to the given view after the previous view made
+// progress.
+func (e *Engine) enterView(view uint64) {
+	e.view = view
+	e.attempts = 0
+	for v := range e.newViews {
+		if v < view {
+			delete(e.newViews, v)
+		}
+	}
+	e.resetViewTimer()
+}
+
+func (e *Engine) updateHighQC(qc *pb.HotstuffQC) {
+	if qc.View > e.highQC.View {
+		e.highQC = qc
+	}
+}
+
+// commit executes the given node and all its ancestors that are not executed
+// yet.
+func (e *Engine) commit(b *node) {
+	if b.height <= e.executed.height {
+		return
+	}
+	var (
+		chain []*node
+		n     = b
+	)
+	for n != nil && n.height > e.executed.height {
+		chain = append(chain, n)
+		n = e.justified(n)
+	}
+	if n == nil || !bytes.Equal(n.hash, e.executed.hash) {
+		log.Warnf("hotstuff: missing ancestors of block %d", b.height)
+		return
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		e.execute(chain[i])
+	}
+	e.executed = b
+
+	for key, n := range e.nodes {
+		if n.height < e.executed.height {
+			delete(e.nodes, key)
+		}
+	}
+	for key, votes := range e.votes {
+		for _, v := range votes {
+			if v.View < e.executed.view() {
+				delete(e.votes, key)
+			}
+			break
+		}
+	}
+}
+
+func (e *Engine) execute(n *node) {
+	block := n.proposal.Block
+	e.pool.Remove(block.Transactions)
+
+	log.WithFields(log.Fields{
+		"index": block.Header.Index,
+		"hash":  hex.EncodeToString(block.Hash()),
+		"txs":   len(block.Transactions),
+		"view":  n.proposal.View,
+	}).Info("hotstuff: committed block")
+
+	if int(n.proposal.Leader) == e.index {
+		e.Relay(e.relayCh, &pb.Message{
+			Payload: &pb.Message_Block{
+				Block: block,
+			},
+		})
+	}
+}
+
+// justified returns the node certified by the QC of the given node, which is
+// its parent, or nil if it is unknown.
+func (e *Engine) justified(n *node) *node {
+	if n.proposal == nil {
+		return nil
+	}
+	return e.nodes[string(n.proposal.Justify.Node)]
+}
+
+// verifyQC verifies that the QC holds valid votes of 2f+1 replicas. The QC
+// of the genesis node holds no votes.
+func (e *Engine) verifyQC(qc *pb.HotstuffQC) error {
+	if qc.View == 0 && len(qc.Node) == 0 {
+		return nil
+	}
+	seen := make(map[uint32]bool)
+	for _, v := range qc.Votes {
+		if v.View != qc.View || !bytes.Equal(v.Node, qc.Node) || seen[v.Replica] {
+			return errInvalidQC
+		}
+		if !verify(v, e.Validators.Get(int(v.Replica))) {
+			return errInvalidQC
+		}
+		seen[v.Replica] = true
+	}
+	if len(seen) < e.quorum() {
+		return errInvalidQC
+	}
+	return nil
+}
+
+// pendingTransactions returns the pending transactions that are not part of
+// the given node or its uncommitted ancestors.
+func (e *Engine) pendingTransactions(parent *node) []*pb.Transaction {
+	included := make(map[string]bool)
+	for n := parent; n != nil && n.height > e.executed.height; n = e.justified(n) {
+		for _, tx := range n.proposal.Block.Transactions {
+			included[string(tx.Hash())] = true
+		}
+	}
+	txs := []*pb.Transaction{}
+	for _, tx := range e.pool.Batch(consensus.MaxBlockTxs, consensus.MaxBlockBytes) {
+		if !included[string(tx.Hash())] {
+			txs = append(txs, tx)
+		}
+	}
+	return txs
+}
+
+func (e *Engine) resetViewTimer() {
+	if !e.viewTimer.Stop() {
+		select {
+		case <-e.viewTimer.C:
+		default:
+		}
+	}
+	attempts := e.attempts
+	if attempts > 6 {
+		attempts = 6
+	}
+	e.viewTimer.Reset(e.ViewTimeout << attempts)
+}
+
+// send processes the message locally and relays it into the network.
+func (e *Engine) send(msg *pb.Message) error {
+	e.Relay(e.relayCh, msg)
+	return e.handleMessage(msg)
+}
+
+// sendTo sends the message to the given replica only, which processes it
+// locally if it is this replica.
+func (e *Engine) sendTo(replica int, msg *pb.Message) error {
+	if replica == e.index {
+		return e.handleMessage(msg)
+	}
+	if e.sender == nil {
+		e.Relay(e.relayCh, msg)
+		return nil
+	}
+	go e.sender.SendTo(e.Validators.Get(replica), msg)
+	return nil
+}
+
+// leader returns the leader of the view, which changes each view. Blocks are
+// committed by a chain of QCs in three consecutive views, of which the
+// leaders and the leader collecting the votes of the last one need to be
+// correct. The leader is chosen by the hash of the view instead of in turns,
+// otherwise a faulty replica would be part of every such window of four
+// views if there are four replicas.
+func (e *Engine) leader(view uint64) int {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, view)
+	h := common.Hash256(b)
+	return int(binary.BigEndian.Uint64(h[:8]) % uint64(len(e.Validators)))
+}
+
+// quorum returns the amount of votes needed to form a QC, which is more than
+// 2/3 of the validators.
+func (e *Engine) quorum() int {
+	return 2*len(e.Validators)/3 + 1
+}
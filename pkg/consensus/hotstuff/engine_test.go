@@ -0,0 +1,135 @@
+package hotstuff
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/consensus/consensustest"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	proto "github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestNetwork returns a network of n engines, which are not started. If
+// direct is set, the engines send votes and new views to the leader only.
+func newTestNetwork(n int, direct bool) *consensustest.Network {
+	net := consensustest.NewNetwork(n, func(validators consensus.Validators) consensus.Engine {
+		return NewEngine(Config{
+			Validators:    validators,
+			BlockInterval: 20 * time.Millisecond,
+			ViewTimeout:   200 * time.Millisecond,
+		})
+	})
+	if direct {
+		for i := 0; i < n; i++ {
+			net.Engine(i).(*Engine).UseSender(net.Sender(i))
+		}
+	}
+	return net
+}
+
+// testLeader returns the leader of the view in a network of n engines.
+func testLeader(n int, view uint64) int {
+	return (&Engine{Config: Config{Validators: make(consensus.Validators, n)}}).leader(view)
+}
+
+// mustSign signs the message with the key of the validator at index i.
+func mustSign(msg proto.Message, i int) {
+	if err := sign(msg, consensustest.PrivateKey(i)); err != nil {
+		panic(err)
+	}
+}
+
+func TestEngineCommit(t *testing.T) {
+	net := newTestNetwork(4, false)
+	net.Start()
+	defer net.Stop()
+	b := net.Receive(t.Fatalf).Block
+	assert.Equal(t, uint32(1), b.Header.Index)
+	assert.Equal(t, 1, len(b.Transactions))
+}
+
+func TestEngineDirectVotes(t *testing.T) {
+	var (
+		net = newTestNetwork(4, true)
+		// Amount of votes and new views relayed into the network instead of
+		// being send to the leader.
+		relayed int32
+	)
+	net.SetDrop(func(from, to int, msg *pb.Message) bool {
+		switch msg.Payload.(type) {
+		case *pb.Message_HotstuffVote, *pb.Message_HotstuffNewView:
+			if msg.Flag != pb.Flag_direct {
+				atomic.AddInt32(&relayed, 1)
+			}
+		}
+		return false
+	})
+	net.Start()
+	defer net.Stop()
+	assert.Equal(t, uint32(1), net.Receive(t.Fatalf).Block.Header.Index)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&relayed))
+}
+
+func TestEngineViewChange(t *testing.T) {
+	// The leader of the first view is crashed.
+	net := newTestNetwork(4, true)
+	net.Start(testLeader(4, 1))
+	defer net.Stop()
+	assert.Equal(t, uint32(1), net.Receive(t.Fatalf).Block.Header.Index)
+}
+
+func TestEngineLeaderRotation(t *testing.T) {
+	var (
+		led     = make(map[int]bool)
+		changes int
+	)
+	for view := uint64(1); view <= 64; view++ {
+		led[testLeader(4, view)] = true
+		if testLeader(4, view) != testLeader(4, view+1) {
+			changes++
+		}
+	}
+	assert.Equal(t, 4, len(led))
+	assert.True(t, changes > 32)
+}
+
+func TestEngineEquivocatingLeader(t *testing.T) {
+	var (
+		net       = newTestNetwork(4, true)
+		byzantine = testLeader(4, 1)
+	)
+	// The leader proposes a different block to each replica in its views,
+	// none of which gets a QC.
+	net.SetByzantine(byzantine, func(to int, msg *pb.Message) *pb.Message {
+		if p := msg.GetHotstuffProposal(); p != nil {
+			p.Block.Header.Nonce = uint64(to)
+			mustSign(p, byzantine)
+		}
+		return msg
+	})
+	net.Start()
+	defer net.Stop()
+	for i := 0; i < 3; i++ {
+		assert.NotEqual(t, byzantine, net.Receive(t.Fatalf).From)
+	}
+}
+
+func TestEngineForgedVotes(t *testing.T) {
+	net := newTestNetwork(4, true)
+	// A replica votes in the name of another one.
+	net.SetByzantine(3, func(to int, msg *pb.Message) *pb.Message {
+		if v := msg.GetHotstuffVote(); v != nil {
+			v.Replica = uint32(to)
+			mustSign(v, 3)
+		}
+		return msg
+	})
+	net.Start()
+	defer net.Stop()
+	for i := 0; i < 3; i++ {
+		assert.NotEqual(t, 3, net.Receive(t.Fatalf).From)
+	}
+}
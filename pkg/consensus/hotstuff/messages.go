@@ -0,0 +1,67 @@
+package hotstuff
+
+import (
+	"crypto/ecdsa"
+	"errors"
+
+	"github.com/anthdm/consenter/pkg/common"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	proto "github.com/golang/protobuf/proto"
+)
+
+var errInvalidSignature = errors.New("invalid signature")
+
+// sign signs the given HotStuff message with priv and sets its signature.
+func sign(msg proto.Message, priv *ecdsa.PrivateKey) error {
+	sig, err := common.Sign(priv, signatureHash(msg))
+	if err != nil {
+		return err
+	}
+	switch m := msg.(type) {
+	case *pb.HotstuffProposal:
+		m.Signature = sig
+	case *pb.HotstuffVote:
+		m.Signature = sig
+	case *pb.HotstuffNewView:
+		m.Signature = sig
+	}
+	return nil
+}
+
+// verify reports whether the given HotStuff message is signed by pub.
+func verify(msg proto.Message, pub *ecdsa.PublicKey) bool {
+	var sig []byte
+	switch m := msg.(type) {
+	case *pb.HotstuffProposal:
+		sig = m.Signature
+	case *pb.HotstuffVote:
+		sig = m.Signature
+	case *pb.HotstuffNewView:
+		sig = m.Signature
+	}
+	return common.Verify(pub, signatureHash(msg), sig)
+}
+
+// signatureHash returns the hash of the message without its signature.
+func signatureHash(msg proto.Message) []byte {
+	msg = proto.Clone(msg)
+	switch m := msg.(type) {
+	case *pb.HotstuffProposal:
+		m.Signature = nil
+	case *pb.HotstuffVote:
+		m.Signature = nil
+	case *pb.HotstuffNewView:
+		m.Signature = nil
+	}
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+	return common.Hash256(b)
+}
+
+// nodeHash returns the hash identifying the given proposal in the chain. The
+// signature is not part of it, hence the leader can not change it.
+func nodeHash(p *pb.HotstuffProposal) []byte {
+	return signatureHash(p)
+}
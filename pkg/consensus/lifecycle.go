@@ -0,0 +1,110 @@
+package consensus
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	pb "github.com/anthdm/consenter/pkg/protos"
+)
+
+var errAlreadyStarted = errors.New("engine already started")
+
+// Lifecycle implements the Stop and Err methods of the Engine interface for
+// engines running in background goroutines. Engines embed it and implement
+// Start by passing their goroutines to Run.
+type Lifecycle struct {
+	lock    sync.Mutex
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	errCh   chan error
+	running bool
+}
+
+// Run starts each of the given functions in a goroutine with a context that
+// is done once ctx is done or the engine is stopped. An error returned by any
+// of them is reported on the Err channel and stops the engine. The engine can
+// be started again once it is stopped.
+func (l *Lifecycle) Run(ctx context.Context, fns ...func(context.Context) error) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.running {
+		return errAlreadyStarted
+	}
+	l.running = true
+	l.ctx, l.cancel = context.WithCancel(ctx)
+	var (
+		errCh  = l.errChan()
+		cancel = l.cancel
+	)
+	for _, fn := range fns {
+		l.wg.Add(1)
+		go func(ctx context.Context, fn func(context.Context) error) {
+			defer l.wg.Done()
+			if err := fn(ctx); err != nil {
+				select {
+				case errCh <- err:
+				default:
+				}
+				cancel()
+			}
+		}(l.ctx, fn)
+	}
+	return nil
+}
+
+// Stop implements the Engine interface. It waits for the goroutines of the
+// engine to exit.
+func (l *Lifecycle) Stop() {
+	l.lock.Lock()
+	if !l.running {
+		l.lock.Unlock()
+		return
+	}
+	l.running = false
+	l.cancel()
+	l.lock.Unlock()
+	l.wg.Wait()
+}
+
+// Err implements the Engine interface.
+func (l *Lifecycle) Err() <-chan error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.errChan()
+}
+
+// Done returns a channel that is closed once the engine is stopped, or nil if
+// the engine was never started. Goroutines of the engine that might block,
+// like sends to the relay channel, should give up once it is closed.
+func (l *Lifecycle) Done() <-chan struct{} {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.ctx == nil {
+		return nil
+	}
+	return l.ctx.Done()
+}
+
+// Relay sends the message on the relay channel of the server without blocking
+// the caller, the server might be busy delivering messages to the engine. It
+// gives up once the engine is stopped.
+func (l *Lifecycle) Relay(relayCh chan<- *pb.Message, msg *pb.Message) {
+	done := l.Done()
+	go func() {
+		select {
+		case relayCh <- msg:
+		case <-done:
+		}
+	}()
+}
+
+// errChan returns the error channel, creating it if needed. The lock needs
+// to be held by the caller.
+func (l *Lifecycle) errChan() chan error {
+	if l.errCh == nil {
+		l.errCh = make(chan error, 1)
+	}
+	return l.errCh
+}
@@ -0,0 +1,99 @@
+package consensus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLifecycleStop(t *testing.T) {
+	var (
+		l      Lifecycle
+		exited = make(chan struct{}, 3)
+		fn     = func(ctx context.Context) error {
+			<-ctx.Done()
+			exited <- struct{}{}
+			return nil
+		}
+	)
+	assert.Nil(t, l.Run(context.Background(), fn, fn))
+	assert.Equal(t, errAlreadyStarted, l.Run(context.Background(), fn))
+	l.Stop()
+	assert.Equal(t, 2, len(exited))
+
+	// Stopped engines can be started again.
+	assert.Nil(t, l.Run(context.Background(), fn))
+	l.Stop()
+	assert.Equal(t, 3, len(exited))
+	l.Stop()
+}
+
+func TestLifecycleContextCancel(t *testing.T) {
+	var l Lifecycle
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.Nil(t, l.Run(ctx, func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	}))
+	cancel()
+	select {
+	case <-l.Done():
+	case <-time.After(time.Second):
+		t.Fatal("engine not done after cancel")
+	}
+	l.Stop()
+}
+
+func TestLifecycleErr(t *testing.T) {
+	var (
+		l   Lifecycle
+		err = errors.New("failure")
+	)
+	assert.Nil(t, l.Run(context.Background(),
+		func(ctx context.Context) error {
+			return err
+		},
+		func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		},
+	))
+	select {
+	case e := <-l.Err():
+		assert.Equal(t, err, e)
+	case <-time.After(time.Second):
+		t.Fatal("no error reported")
+	}
+	// The failure stops the other goroutines of the engine.
+	select {
+	case <-l.Done():
+	case <-time.After(time.Second):
+		t.Fatal("engine not done after failure")
+	}
+	l.Stop()
+}
+
+func TestLifecycleRelay(t *testing.T) {
+	var (
+		l       Lifecycle
+		relayCh = make(chan *pb.Message)
+		msg     = &pb.Message{}
+	)
+	assert.Nil(t, l.Run(context.Background(), func(ctx context.Context) error {
+		// The run loop is not blocked by the relay channel.
+		l.Relay(relayCh, msg)
+		<-ctx.Done()
+		return nil
+	}))
+	select {
+	case m := <-relayCh:
+		assert.Equal(t, msg, m)
+	case <-time.After(time.Second):
+		t.Fatal("message not relayed")
+	}
+	l.Stop()
+}
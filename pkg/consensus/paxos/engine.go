@@ -0,0 +1,649 @@
+package paxos
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/common"
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/mempool"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	proto "github.com/golang/protobuf/proto"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxLearned is the amount of recently applied slots of which the value is
+// kept, to be served to nodes that fell behind.
+const maxLearned = 64
+
+type state int
+
+const (
+	follower state = iota
+	candidate
+	leader
+)
+
+// Config holds the configuration of the Multi-Paxos engine.
+type Config struct {
+	// The nodes participating in consensus, each of them is a proposer,
+	// acceptor and learner. Paxos tolerates f crashed nodes out of 2f+1.
+	Validators consensus.Validators
+
+	// The interval in which the leader batches the pending transactions into
+	// the value of a new slot.
+	BlockInterval time.Duration
+
+	// The interval in which the leader sends heartbeats to the other nodes,
+	// and in which nodes that fell behind request the values they missed.
+	HeartbeatInterval time.Duration
+
+	// The minimum time a node waits without hearing from the leader before it
+	// tries to become the leader itself. The actual timeout is randomized
+	// between LeaderTimeout and twice its value.
+	LeaderTimeout time.Duration
+}
+
+// Engine is a Multi-Paxos consensus engine with a stable leader. A node
+// becomes the leader of a ballot by running the prepare phase once, after
+// which it proposes a batch of transactions for each slot of the log with a
+// single accept phase. Each value chosen for a slot results in a block. Nodes
+// that missed the values of some slots request them from the others, which
+// keep the values of the recently applied slots.
+type Engine struct {
+	Config
+	consensus.Lifecycle
+
+	privKey *ecdsa.PrivateKey
+	relayCh chan<- *pb.Message
+	msgCh   chan *pb.Message
+
+	// Index of this node in the validator set, -1 if this node does not take
+	// part in consensus.
+	index int
+
+	// Pending transactions, shared with the server if it has a mempool.
+	pool *mempool.Pool
+
+	state state
+	// Acceptor state, the highest ballot promised and the values accepted by
+	// slot.
+	promised uint64
+	accepted map[uint64]*pb.PaxosSlot
+	// Proposer state, the ballot of the last prepare and the slot it started
+	// at.
+	ballot      uint64
+	prepareSlot uint64
+	promises    map[uint32]*pb.PaxosPromise
+	// Leader state, reinitialized after each prepare phase.
+	nextSlot  uint64
+	proposals map[uint64]*pb.Block
+	votes     map[uint64]map[uint32]bool
+	// Hashes of the transactions in undecided slots of the leader.
+	inflight map[string]bool
+	// Learner state, the decided values that are not applied yet and the
+	// next slot to apply.
+	decided map[uint64]*pb.Block
+	applied uint64
+	// Hash of the header of the last applied block.
+	head []byte
+	// The slot following the last slot known to be decided, the node fell
+	// behind while it did not apply the slots before it.
+	chosen uint64
+	// The values of the recently applied slots.
+	learned     map[uint64]*learned
+	leaderTimer *time.Timer
+}
+
+// learned is the value decided for a slot and the last time it was served to
+// a node that fell behind.
+type learned struct {
+	value  *pb.Block
+	served time.Time
+}
+
+func init() {
+	consensus.Register(consensus.Factory{
+		Name:        "paxos",
+		Description: "Multi-Paxos with a stable leader",
+		Config: func() interface{} {
+			return &Config{
+				BlockInterval:     5 * time.Second,
+				HeartbeatInterval: 1 * time.Second,
+				LeaderTimeout:     5 * time.Second,
+			}
+		},
+		New: func(c interface{}) (consensus.Engine, error) {
+			cfg := c.(*Config)
+			if len(cfg.Validators) == 0 {
+				return nil, consensus.ErrMissingValidators
+			}
+			return NewEngine(*cfg), nil
+		},
+	})
+}
+
+// NewEngine returns a new Multi-Paxos consensus engine.
+func NewEngine(cfg Config) *Engine {
+	return &Engine{
+		Config:   cfg,
+		msgCh:    make(chan *pb.Message, 1024),
+		pool:     mempool.New(mempool.Config{}),
+		accepted: make(map[uint64]*pb.PaxosSlot),
+		inflight: make(map[string]bool),
+		decided:  make(map[uint64]*pb.Block),
+		learned:  make(map[uint64]*learned),
+	}
+}
+
+// Configurate implements the Engine interface.
+func (e *Engine) Configurate(relayCh chan<- *pb.Message, priv *ecdsa.PrivateKey) {
+	e.privKey = priv
+	e.relayCh = relayCh
+	e.index = -1
+	if priv != nil {
+		e.index = e.Validators.Index(&priv.PublicKey)
+	}
+}
+
+// Start implements the Engine interface.
+func (e *Engine) Start(ctx context.Context) error {
+	return e.Run(ctx, e.run)
+}
+
+// AddTransaction implements the Engine interface.
+func (e *Engine) AddTransaction(tx *pb.Transaction) {
+	e.pool.Add(tx)
+}
+
+// UseMempool implements the consensus.MempoolUser interface.
+func (e *Engine) UseMempool(pool *mempool.Pool) {
+	e.pool = pool
+}
+
+// HandleMessage implements the consensus.Handler interface.
+func (e *Engine) HandleMessage(from consensus.Peer, msg *pb.Message) error {
+	switch msg.Payload.(type) {
+	case *pb.Message_PaxosPrepare, *pb.Message_PaxosPromise,
+		*pb.Message_PaxosAccept, *pb.Message_PaxosAccepted,
+		*pb.Message_PaxosLearn, *pb.Message_PaxosHeartbeat,
+		*pb.Message_PaxosCatchUp:
+		select {
+		case e.msgCh <- msg:
+		case <-e.Done():
+		}
+	}
+	return nil
+}
+
+func (e *Engine) run(ctx context.Context) error {
+	var (
+		heartbeat = time.NewTicker(e.HeartbeatInterval)
+		batch     = time.NewTicker(e.BlockInterval)
+	)
+	defer heartbeat.Stop()
+	defer batch.Stop()
+	e.leaderTimer = time.NewTimer(e.leaderTimeout())
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-e.leaderTimer.C:
+			if e.index >= 0 && e.state != leader {
+				e.prepare()
+			}
+			e.resetLeaderTimer()
+		case <-heartbeat.C:
+			if e.state == leader {
+				e.Relay(e.relayCh, &pb.Message{
+					Payload: &pb.Message_PaxosHeartbeat{
+						PaxosHeartbeat: &pb.PaxosHeartbeat{
+							Ballot:  e.ballot,
+							Nonce:   rand.Uint64(),
+							Decided: e.chosen,
+						},
+					},
+				})
+			}
+			e.catchUp()
+		case <-batch.C:
+			if e.state == leader {
+				e.proposeBatch()
+			}
+		case msg := <-e.msgCh:
+			e.handleMessage(msg)
+		}
+	}
+}
+
+func (e *Engine) handleMessage(msg *pb.Message) {
+	if e.index < 0 {
+		return
+	}
+	switch p := msg.Payload.(type) {
+	case *pb.Message_PaxosPrepare:
+		e.handlePrepare(p.PaxosPrepare)
+	case *pb.Message_PaxosPromise:
+		e.handlePromise(p.PaxosPromise)
+	case *pb.Message_PaxosAccept:
+		e.handleAccept(p.PaxosAccept)
+	case *pb.Message_PaxosAccepted:
+		e.handleAccepted(p.PaxosAccepted)
+	case *pb.Message_PaxosLearn:
+		e.handleLearn(p.PaxosLearn)
+	case *pb.Message_PaxosHeartbeat:
+		e.handleHeartbeat(p.PaxosHeartbeat)
+	case *pb.Message_PaxosCatchUp:
+		e.handleCatchUp(p.PaxosCatchUp)
+	}
+}
+
+// prepare starts the prepare phase with a ballot higher than any ballot seen
+// so far, which is led by this node.
+func (e *Engine) prepare() {
+	var (
+		n       = uint64(len(e.Validators))
+		highest = e.ballot
+	)
+	if e.promised > highest {
+		highest = e.promised
+	}
+	e.ballot = (highest/n+1)*n + uint64(e.index)
+	e.state = candidate
+	e.prepareSlot = e.applied
+	e.promises = make(map[uint32]*pb.PaxosPromise)
+
+	log.WithFields(log.Fields{
+		"ballot": e.ballot,
+	}).Info("paxos: preparing ballot")
+
+	e.send(&pb.Message{
+		Payload: &pb.Message_PaxosPrepare{
+			PaxosPrepare: &pb.PaxosPrepare{
+				Ballot: e.ballot,
+				Slot:   e.prepareSlot,
+			},
+		},
+	})
+}
+
+func (e *Engine) handlePrepare(p *pb.PaxosPrepare) {
+	promise := &pb.PaxosPromise{
+		Ballot:   p.Ballot,
+		Acceptor: uint32(e.index),
+	}
+	if p.Ballot >= e.promised {
+		e.observe(p.Ballot)
+		promise.Ok = true
+		for slot, s := range e.accepted {
+			if slot >= p.Slot {
+				promise.Accepted = append(promise.Accepted, s)
+			}
+		}
+		sort.Slice(promise.Accepted, func(i, j int) bool {
+			return promise.Accepted[i].Slot < promise.Accepted[j].Slot
+		})
+	}
+	promise.Promised = e.promised
+	promise.Applied = e.applied
+	e.send(&pb.Message{
+		Payload: &pb.Message_PaxosPromise{
+			PaxosPromise: promise,
+		},
+	})
+}
+
+func (e *Engine) handlePromise(p *pb.PaxosPromise) {
+	if e.leaderOf(p.Ballot) != e.index {
+		return
+	}
+	if !p.Ok {
+		e.preempted(p.Promised)
+		return
+	}
+	if e.state != candidate || p.Ballot != e.ballot {
+		return
+	}
+	e.promises[p.Acceptor] = p
+	if e.hasMajority(len(e.promises)) {
+		e.becomeLeader()
+	}
+}
+
+// becomeLeader proposes the values accepted in earlier ballots for the slots
+// that are not decided yet, as reported by a majority of the acceptors. The
+// slots for which no value was accepted are filled with empty blocks. Slots
+// applied by any of the acceptors are decided, their values are learned by
+// catching up instead.
+func (e *Engine) becomeLeader() {
+	e.state = leader
+	e.proposals = make(map[uint64]*pb.Block)
+	e.votes = make(map[uint64]map[uint32]bool)
+	start := e.prepareSlot
+	for _, p := range e.promises {
+		if p.Applied > start {
+			start = p.Applied
+		}
+	}
+	e.observeDecided(start)
+	e.nextSlot = start
+	for slot := range e.decided {
+		if slot >= e.nextSlot {
+			e.nextSlot = slot + 1
+		}
+	}
+	values := make(map[uint64]*pb.PaxosSlot)
+	for _, p := range e.promises {
+		for _, s := range p.Accepted {
+			if s.Slot < start {
+				continue
+			}
+			if v, ok := values[s.Slot]; !ok || s.Ballot > v.Ballot {
+				values[s.Slot] = s
+			}
+			if s.Slot >= e.nextSlot {
+				e.nextSlot = s.Slot + 1
+			}
+		}
+	}
+	log.WithFields(log.Fields{
+		"ballot": e.ballot,
+	}).Info("paxos: became leader")
+
+	for slot := start; slot < e.nextSlot; slot++ {
+		if _, ok := e.decided[slot]; ok {
+			continue
+		}
+		value := e.newBlock(slot, nil)
+		if s, ok := values[slot]; ok {
+			value = s.Value
+			for _, tx := range value.Transactions {
+				e.inflight[string(tx.Hash())] = true
+			}
+		}
+		e.propose(slot, value)
+	}
+}
+
+// proposeBatch proposes the pending transactions that are not yet part of an
+// undecided slot as the value of the next slot.
+func (e *Engine) proposeBatch() {
+	var txs []*pb.Transaction
+	for _, tx := range e.pool.Batch(consensus.MaxBlockTxs, consensus.MaxBlockBytes) {
+		if !e.inflight[string(tx.Hash())] {
+			txs = append(txs, tx)
+		}
+	}
+	if len(txs) == 0 {
+		return
+	}
+	for _, tx := range txs {
+		e.inflight[string(tx.Hash())] = true
+	}
+	e.propose(e.nextSlot, e.newBlock(e.nextSlot, txs))
+	e.nextSlot++
+}
+
+// newBlock returns the value for the slot proposed by this node. Its parent
+// is not known until the slots before it are decided.
+func (e *Engine) newBlock(slot uint64, txs []*pb.Transaction) *pb.Block {
+	block := pb.NewBlock(uint32(slot))
+	block.Transactions = txs
+	block.Header.TxRoot = pb.TxRoot(block.Transactions)
+	block.Header.Proposer = common.MarshalPublicKey(&e.privKey.PublicKey)
+	return block
+}
+
+func (e *Engine) propose(slot uint64, value *pb.Block) {
+	e.proposals[slot] = value
+	e.votes[slot] = make(map[uint32]bool)
+	e.send(&pb.Message{
+		Payload: &pb.Message_PaxosAccept{
+			PaxosAccept: &pb.PaxosAccept{
+				Ballot: e.ballot,
+				Slot:   slot,
+				Value:  value,
+			},
+		},
+	})
+}
+
+func (e *Engine) handleAccept(a *pb.PaxosAccept) {
+	accepted := &pb.PaxosAccepted{
+		Ballot:   a.Ballot,
+		Slot:     a.Slot,
+		Acceptor: uint32(e.index),
+	}
+	if a.Ballot >= e.promised && a.Value != nil {
+		e.observe(a.Ballot)
+		// The values of applied slots are decided and not kept.
+		if a.Slot >= e.applied {
+			e.accepted[a.Slot] = &pb.PaxosSlot{
+				Slot:   a.Slot,
+				Ballot: a.Ballot,
+				Value:  a.Value,
+			}
+		}
+		accepted.Ok = true
+	}
+	accepted.Promised = e.promised
+	e.send(&pb.Message{
+		Payload: &pb.Message_PaxosAccepted{
+			PaxosAccepted: accepted,
+		},
+	})
+}
+
+func (e *Engine) handleAccepted(a *pb.PaxosAccepted) {
+	if e.leaderOf(a.Ballot) != e.index {
+		return
+	}
+	if !a.Ok {
+		e.preempted(a.Promised)
+		return
+	}
+	if e.state != leader || a.Ballot != e.ballot {
+		return
+	}
+	votes, ok := e.votes[a.Slot]
+	if !ok {
+		return
+	}
+	votes[a.Acceptor] = true
+	if !e.hasMajority(len(votes)) {
+		return
+	}
+	value := e.proposals[a.Slot]
+	delete(e.proposals, a.Slot)
+	delete(e.votes, a.Slot)
+	e.send(&pb.Message{
+		Payload: &pb.Message_PaxosLearn{
+			PaxosLearn: &pb.PaxosLearn{
+				Slot:  a.Slot,
+				Value: value,
+			},
+		},
+	})
+}
+
+func (e *Engine) handleLearn(l *pb.PaxosLearn) {
+	if l.Slot < e.applied || l.Value == nil {
+		return
+	}
+	e.observeDecided(l.Slot + 1)
+	if _, ok := e.decided[l.Slot]; ok {
+		return
+	}
+	e.decided[l.Slot] = l.Value
+	e.apply()
+}
+
+func (e *Engine) handleHeartbeat(h *pb.PaxosHeartbeat) {
+	if h.Ballot >= e.promised {
+		e.observe(h.Ballot)
+		e.observeDecided(h.Decided)
+	}
+}
+
+// handleCatchUp multicasts the values of the applied slots from the slot of
+// the request on. Each value is served at most once per heartbeat interval,
+// as every node that fell behind requests it.
+func (e *Engine) handleCatchUp(c *pb.PaxosCatchUp) {
+	for slot := c.Slot; slot < e.applied; slot++ {
+		l, ok := e.learned[slot]
+		if !ok || time.Since(l.served) < e.HeartbeatInterval {
+			continue
+		}
+		l.served = time.Now()
+		e.Relay(e.relayCh, &pb.Message{
+			Payload: &pb.Message_PaxosLearn{
+				PaxosLearn: &pb.PaxosLearn{
+					Slot:  slot,
+					Value: l.value,
+				},
+			},
+		})
+	}
+}
+
+// catchUp requests the values of the slots that are decided but were missed
+// by this node.
+func (e *Engine) catchUp() {
+	if e.index < 0 || e.applied >= e.chosen {
+		return
+	}
+	log.WithFields(log.Fields{
+		"slot":    e.applied,
+		"decided": e.chosen,
+	}).Info("paxos: catching up")
+
+	e.Relay(e.relayCh, &pb.Message{
+		Payload: &pb.Message_PaxosCatchUp{
+			PaxosCatchUp: &pb.PaxosCatchUp{
+				Slot: e.applied,
+			},
+		},
+	})
+}
+
+// observeDecided records that the slots before the given one are decided.
+func (e *Engine) observeDecided(slot uint64) {
+	if slot > e.chosen {
+		e.chosen = slot
+	}
+}
+
+// observe records the ballot of a leader that is at least as high as the
+// highest promised ballot. Nodes that are leading, or trying to lead, a lower
+// ballot step down.
+func (e *Engine) observe(ballot uint64) {
+	e.promised = ballot
+	if e.leaderOf(ballot) == e.index {
+		return
+	}
+	if e.state != follower {
+		e.stepDown()
+	}
+	e.resetLeaderTimer()
+}
+
+// preempted is called when an acceptor rejected a message of this node,
+// because it promised a higher ballot.
+func (e *Engine) preempted(ballot uint64) {
+	if ballot <= e.ballot {
+		return
+	}
+	if ballot > e.promised {
+		e.promised = ballot
+	}
+	if e.state != follower {
+		e.stepDown()
+		e.resetLeaderTimer()
+	}
+}
+
+func (e *Engine) stepDown() {
+	if e.state == leader {
+		e.inflight = make(map[string]bool)
+	}
+	e.state = follower
+}
+
+// apply turns all decided values that are not applied yet into blocks, in
+// the order of their slots. Each block is linked to the previous one and
+// relayed by the node that proposed its value, which signs it.
+func (e *Engine) apply() {
+	for {
+		value, ok := e.decided[e.applied]
+		if !ok {
+			return
+		}
+		delete(e.decided, e.applied)
+		delete(e.accepted, e.applied)
+		e.pool.Remove(value.Transactions)
+
+		block := proto.Clone(value).(*pb.Block)
+		block.Header.PrevHash = e.head
+		e.head = block.Header.Hash()
+
+		log.WithFields(log.Fields{
+			"index": block.Header.Index,
+			"hash":  hex.EncodeToString(block.Hash()),
+			"txs":   len(block.Transactions),
+			"slot":  e.applied,
+		}).Info("paxos: committed block")
+		e.learned[e.applied] = &learned{value: value}
+		if e.applied >= maxLearned {
+			delete(e.learned, e.applied-maxLearned)
+		}
+		e.applied++
+		e.observeDecided(e.applied)
+
+		if bytes.Equal(block.Header.Proposer, common.MarshalPublicKey(&e.privKey.PublicKey)) {
+			if err := block.Sign(e.privKey); err != nil {
+				log.Errorf("paxos: failed to sign block: %s", err)
+				continue
+			}
+			e.Relay(e.relayCh, &pb.Message{
+				Payload: &pb.Message_Block{
+					Block: block,
+				},
+			})
+		}
+	}
+}
+
+// leaderOf returns the index of the validator leading the ballot.
+func (e *Engine) leaderOf(ballot uint64) int {
+	return int(ballot % uint64(len(e.Validators)))
+}
+
+func (e *Engine) hasMajority(n int) bool {
+	return n > len(e.Validators)/2
+}
+
+func (e *Engine) leaderTimeout() time.Duration {
+	return e.LeaderTimeout + time.Duration(rand.Int63n(int64(e.LeaderTimeout)))
+}
+
+func (e *Engine) resetLeaderTimer() {
+	if !e.leaderTimer.Stop() {
+		select {
+		case <-e.leaderTimer.C:
+		default:
+		}
+	}
+	e.leaderTimer.Reset(e.leaderTimeout())
+}
+
+// send relays the message into the network and handles it locally, as this
+// node takes every role itself.
+func (e *Engine) send(msg *pb.Message) {
+	e.Relay(e.relayCh, msg)
+	e.handleMessage(msg)
+}
@@ -0,0 +1,117 @@
+package paxos
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/consensus/consensustest"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestNetwork returns a network of n engines, which are not started.
+func newTestNetwork(n int) *consensustest.Network {
+	return consensustest.NewNetwork(n, func(validators consensus.Validators) consensus.Engine {
+		return NewEngine(Config{
+			Validators:        validators,
+			BlockInterval:     20 * time.Millisecond,
+			HeartbeatInterval: 20 * time.Millisecond,
+			LeaderTimeout:     100 * time.Millisecond,
+		})
+	})
+}
+
+func TestEngineCommit(t *testing.T) {
+	net := newTestNetwork(3)
+	net.Start()
+	defer net.Stop()
+	b := net.Receive(t.Fatalf).Block
+	assert.Equal(t, uint32(1), b.Header.Index)
+	assert.Equal(t, 1, len(b.Transactions))
+}
+
+func TestEngineCrashedNode(t *testing.T) {
+	net := newTestNetwork(5)
+	net.Start(1, 3)
+	defer net.Stop()
+	b := net.Receive(t.Fatalf).Block
+	assert.Equal(t, uint32(1), b.Header.Index)
+	assert.Equal(t, 1, len(b.Transactions))
+}
+
+func TestEngineNoMajority(t *testing.T) {
+	net := newTestNetwork(3)
+	net.Start(1, 2)
+	defer net.Stop()
+	net.ReceiveNone(t.Fatalf, 500*time.Millisecond)
+}
+
+func TestEngineLeaderCrash(t *testing.T) {
+	net := newTestNetwork(3)
+	net.Start()
+	defer net.Stop()
+	first := net.Receive(t.Fatalf)
+	net.SetDown(first.From, true)
+	// The others elect a new leader, which continues after the decided
+	// slots.
+	for {
+		b := net.Receive(t.Fatalf)
+		if b.From != first.From && b.Block.Header.Index > first.Block.Header.Index {
+			return
+		}
+	}
+}
+
+func TestEngineCatchUp(t *testing.T) {
+	net := newTestNetwork(3)
+	net.Start()
+	defer net.Stop()
+	first := net.Receive(t.Fatalf)
+	var (
+		leader   = first.From
+		follower = (leader + 1) % 3
+	)
+	// The follower keeps accepting values, but misses the decided ones.
+	net.SetDrop(func(from, to int, msg *pb.Message) bool {
+		return to == follower && msg.GetPaxosLearn() != nil
+	})
+	net.Engine(leader).AddTransaction(pb.NewTransaction())
+	receiveIndex(t, net, 2)
+	net.SetDrop(nil)
+	net.Engine(leader).AddTransaction(pb.NewTransaction())
+	receiveIndex(t, net, 3)
+	time.Sleep(500 * time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		e := net.Engine(i).(*Engine)
+		e.Stop()
+		assert.Equal(t, uint64(3), e.applied)
+		// The values accepted for applied slots are trimmed.
+		for slot := range e.accepted {
+			assert.True(t, slot >= e.applied)
+		}
+	}
+}
+
+func TestBecomeLeaderAfterApplied(t *testing.T) {
+	e := NewEngine(Config{Validators: consensustest.Validators(3)})
+	e.Configurate(make(chan *pb.Message, 16), consensustest.PrivateKey(0))
+	e.prepare()
+	// An acceptor applied the first two slots and trimmed their values, they
+	// are not proposed again.
+	e.handlePromise(&pb.PaxosPromise{Ballot: e.ballot, Acceptor: 1, Ok: true, Applied: 2})
+	assert.Equal(t, leader, e.state)
+	assert.Equal(t, uint64(2), e.nextSlot)
+	assert.Equal(t, 0, len(e.proposals))
+	assert.Equal(t, uint64(2), e.chosen)
+}
+
+// receiveIndex receives blocks until a block at the given index or above.
+func receiveIndex(t *testing.T, net *consensustest.Network, index uint32) *pb.Block {
+	for {
+		if b := net.Receive(t.Fatalf).Block; b.Header.Index >= index {
+			return b
+		}
+	}
+}
@@ -0,0 +1,447 @@
+package pbft
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/common"
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/mempool"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	proto "github.com/golang/protobuf/proto"
+	log "github.com/sirupsen/logrus"
+)
+
+// window is the size of the sequence number window, which is the distance
+// between the low and the high water mark.
+const window = 64
+
+// Config holds the configuration of the PBFT engine.
+type Config struct {
+	// The validators participating in consensus. PBFT tolerates f faulty
+	// validators out of 3f+1.
+	Validators consensus.Validators
+
+	// The interval in which the primary proposes new blocks.
+	BlockInterval time.Duration
+
+	// The time a replica waits for a block to be executed before it
+	// suspects the primary and requests a view change.
+	ViewTimeout time.Duration
+}
+
+// Engine is a PBFT (Practical Byzantine Fault Tolerance) consensus engine.
+type Engine struct {
+	Config
+	consensus.Lifecycle
+
+	privKey *ecdsa.PrivateKey
+	relayCh chan<- *pb.Message
+	msgCh   chan *pb.Message
+
+	// Index of this node in the validator set, -1 if this node only follows
+	// the consensus.
+	index int
+
+	// Pending transactions, shared with the server if it has a mempool.
+	pool *mempool.Pool
+
+	view uint64
+	// Whether the replica left its view and is waiting for a new view.
+	changing bool
+	// The view the replica requested a view change for.
+	pendingView uint64
+	// Number of view changes in a row that did not result in progress. The
+	// view timeout doubles with each of them.
+	attempts uint
+	// Last sequence number assigned by the primary.
+	sequence uint64
+	// Last executed sequence number, which is the low water mark.
+	executed uint64
+	// Hash of the header of the last executed block.
+	head        []byte
+	log         map[uint64]*entry
+	viewChanges map[uint64]map[uint32]*pb.PbftViewChange
+	// Views this replica already multicasted a new view message for.
+	newViews  map[uint64]bool
+	viewTimer *time.Timer
+}
+
+// entry holds the state of a single sequence number.
+type entry struct {
+	view       uint64
+	prePrepare *pb.PbftPrePrepare
+	prepares   map[uint32]*pb.PbftPrepare
+	commits    map[uint32]*pb.PbftCommit
+	prepared   bool
+	committed  bool
+}
+
+func init() {
+	consensus.Register(consensus.Factory{
+		Name:        "pbft",
+		Description: "Practical byzantine fault tolerance with view changes",
+		Config: func() interface{} {
+			return &Config{
+				BlockInterval: 5 * time.Second,
+				ViewTimeout:   15 * time.Second,
+			}
+		},
+		New: func(c interface{}) (consensus.Engine, error) {
+			cfg := c.(*Config)
+			if len(cfg.Validators) == 0 {
+				return nil, consensus.ErrMissingValidators
+			}
+			return NewEngine(*cfg), nil
+		},
+	})
+}
+
+// NewEngine returns a new PBFT consensus engine.
+func NewEngine(cfg Config) *Engine {
+	return &Engine{
+		Config:      cfg,
+		msgCh:       make(chan *pb.Message, 1024),
+		pool:        mempool.New(mempool.Config{}),
+		log:         make(map[uint64]*entry),
+		viewChanges: make(map[uint64]map[uint32]*pb.PbftViewChange),
+		newViews:    make(map[uint64]bool),
+	}
+}
+
+// Configurate implements the Engine interface.
+func (e *Engine) Configurate(relayCh chan<- *pb.Message, priv *ecdsa.PrivateKey) {
+	e.privKey = priv
+	e.relayCh = relayCh
+	e.index = -1
+	if priv != nil {
+		e.index = e.Validators.Index(&priv.PublicKey)
+	}
+}
+
+// Start implements the Engine interface.
+func (e *Engine) Start(ctx context.Context) error {
+	return e.Run(ctx, e.run)
+}
+
+// AddTransaction implements the Engine interface.
+func (e *Engine) AddTransaction(tx *pb.Transaction) {
+	e.pool.Add(tx)
+}
+
+// UseMempool implements the consensus.MempoolUser interface.
+func (e *Engine) UseMempool(pool *mempool.Pool) {
+	e.pool = pool
+}
+
+// HandleMessage implements the consensus.Handler interface.
+func (e *Engine) HandleMessage(from consensus.Peer, msg *pb.Message) error {
+	switch msg.Payload.(type) {
+	case *pb.Message_PbftPrePrepare, *pb.Message_PbftPrepare, *pb.Message_PbftCommit,
+		*pb.Message_PbftViewChange, *pb.Message_PbftNewView:
+		select {
+		case e.msgCh <- msg:
+		case <-e.Done():
+		}
+	}
+	return nil
+}
+
+func (e *Engine) run(ctx context.Context) error {
+	var (
+		ticker = time.NewTicker(e.BlockInterval)
+	)
+	defer ticker.Stop()
+	e.viewTimer = time.NewTimer(e.ViewTimeout)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if e.isPrimary() && !e.changing && e.sequence == e.executed {
+				if err := e.propose(); err != nil {
+					log.Warnf("pbft: failed to propose block: %s", err)
+				}
+			}
+		case <-e.viewTimer.C:
+			e.onViewTimeout()
+		case msg := <-e.msgCh:
+			if err := e.handleMessage(msg); err != nil {
+				log.Warnf("pbft: failed processing message: %s", err)
+			}
+		}
+	}
+}
+
+func (e *Engine) handleMessage(msg *pb.Message) error {
+	switch p := msg.Payload.(type) {
+	case *pb.Message_PbftPrePrepare:
+		pp := p.PbftPrePrepare
+		if pp.Block != nil {
+			if pub, err := pp.Block.Verify(); err != nil || e.Validators.Index(pub) < 0 {
+				return fmt.Errorf("block for sequence %d not signed by a validator", pp.Sequence)
+			}
+		}
+		return e.handlePrePrepare(pp)
+	case *pb.Message_PbftPrepare:
+		return e.handlePrepare(p.PbftPrepare)
+	case *pb.Message_PbftCommit:
+		return e.handleCommit(p.PbftCommit)
+	case *pb.Message_PbftViewChange:
+		return e.handleViewChange(p.PbftViewChange)
+	case *pb.Message_PbftNewView:
+		return e.handleNewView(p.PbftNewView)
+	}
+	return nil
+}
+
+func (e *Engine) propose() error {
+	block := pb.NewBlock(uint32(e.sequence))
+	block.Transactions = e.pool.Batch(consensus.MaxBlockTxs, consensus.MaxBlockBytes)
+	block.Header.TxRoot = pb.TxRoot(block.Transactions)
+	block.Header.PrevHash = e.head
+	block.Header.Proposer = common.MarshalPublicKey(&e.privKey.PublicKey)
+	if err := block.Sign(e.privKey); err != nil {
+		return err
+	}
+
+	e.sequence++
+	pp := &pb.PbftPrePrepare{
+		View:     e.view,
+		Sequence: e.sequence,
+		Digest:   block.Hash(),
+		Block:    block,
+		Replica:  uint32(e.index),
+	}
+	if err := sign(pp, e.privKey); err != nil {
+		return err
+	}
+	return e.send(&pb.Message{
+		Payload: &pb.Message_PbftPrePrepare{PbftPrePrepare: pp},
+	})
+}
+
+func (e *Engine) handlePrePrepare(pp *pb.PbftPrePrepare) error {
+	if e.changing || pp.View != e.view || !e.inWindow(pp.Sequence) {
+		return nil
+	}
+	if int(pp.Replica) != e.primary(pp.View) {
+		return fmt.Errorf("pre-prepare from non primary replica %d", pp.Replica)
+	}
+	if !verify(pp, e.Validators.Get(int(pp.Replica))) {
+		return errInvalidSignature
+	}
+	if pp.Block == nil || pp.Block.Header == nil || pp.Block.Header.Index != uint32(pp.Sequence) {
+		return fmt.Errorf("invalid block for sequence %d", pp.Sequence)
+	}
+	if !bytes.Equal(pp.Digest, pp.Block.Hash()) {
+		return fmt.Errorf("invalid digest for sequence %d", pp.Sequence)
+	}
+	ent := e.entry(pp.Sequence)
+	if ent.prePrepare != nil && ent.view == pp.View {
+		if !bytes.Equal(ent.prePrepare.Digest, pp.Digest) {
+			return fmt.Errorf("conflicting pre-prepare for sequence %d", pp.Sequence)
+		}
+		return nil
+	}
+	ent.view = pp.View
+	ent.prePrepare = pp
+
+	if e.index >= 0 && !e.isPrimary() {
+		p := &pb.PbftPrepare{
+			View:     pp.View,
+			Sequence: pp.Sequence,
+			Digest:   pp.Digest,
+			Replica:  uint32(e.index),
+		}
+		if err := sign(p, e.privKey); err != nil {
+			return err
+		}
+		return e.send(&pb.Message{
+			Payload: &pb.Message_PbftPrepare{PbftPrepare: p},
+		})
+	}
+	return e.checkPrepared(pp.Sequence)
+}
+
+func (e *Engine) handlePrepare(p *pb.PbftPrepare) error {
+	if p.View < e.view || !e.inWindow(p.Sequence) {
+		return nil
+	}
+	if int(p.Replica) == e.primary(p.View) {
+		return nil
+	}
+	if !verify(p, e.Validators.Get(int(p.Replica))) {
+		return errInvalidSignature
+	}
+	e.entry(p.Sequence).prepares[p.Replica] = p
+	return e.checkPrepared(p.Sequence)
+}
+
+func (e *Engine) handleCommit(c *pb.PbftCommit) error {
+	if c.View < e.view || !e.inWindow(c.Sequence) {
+		return nil
+	}
+	if !verify(c, e.Validators.Get(int(c.Replica))) {
+		return errInvalidSignature
+	}
+	e.entry(c.Sequence).commits[c.Replica] = c
+	return e.checkCommitted(c.Sequence)
+}
+
+// checkPrepared multicasts a commit if the block with the given sequence
+// number is prepared, which is the case if the replica accepted its
+// pre-prepare and 2f matching prepares of different backups.
+func (e *Engine) checkPrepared(seq uint64) error {
+	ent := e.entry(seq)
+	if e.changing || ent.prepared || ent.prePrepare == nil || ent.view != e.view {
+		return nil
+	}
+	if len(e.matchingPrepares(ent)) < 2*e.f() {
+		return nil
+	}
+	ent.prepared = true
+	if e.index < 0 {
+		return e.checkCommitted(seq)
+	}
+	c := &pb.PbftCommit{
+		View:     ent.view,
+		Sequence: seq,
+		Digest:   ent.prePrepare.Digest,
+		Replica:  uint32(e.index),
+	}
+	if err := sign(c, e.privKey); err != nil {
+		return err
+	}
+	return e.send(&pb.Message{
+		Payload: &pb.Message_PbftCommit{PbftCommit: c},
+	})
+}
+
+// checkCommitted executes all blocks that are committed locally, which is
+// the case if they are prepared and the replica received 2f+1 matching
+// commits.
+func (e *Engine) checkCommitted(seq uint64) error {
+	ent := e.entry(seq)
+	if !ent.prepared || ent.committed {
+		return nil
+	}
+	n := 0
+	for _, c := range ent.commits {
+		if c.View == ent.view && bytes.Equal(c.Digest, ent.prePrepare.Digest) {
+			n++
+		}
+	}
+	if n < 2*e.f()+1 {
+		return nil
+	}
+	ent.committed = true
+
+	for {
+		next, ok := e.log[e.executed+1]
+		if !ok || !next.committed {
+			break
+		}
+		e.execute(next.prePrepare.Block)
+		e.executed++
+	}
+	for s := range e.log {
+		if s+window <= e.executed {
+			delete(e.log, s)
+		}
+	}
+	return nil
+}
+
+func (e *Engine) execute(block *pb.Block) {
+	e.head = block.Header.Hash()
+	e.pool.Remove(block.Transactions)
+	e.resetViewTimer()
+
+	log.WithFields(log.Fields{
+		"index": block.Header.Index,
+		"hash":  hex.EncodeToString(block.Hash()),
+		"txs":   len(block.Transactions),
+		"view":  e.view,
+	}).Info("pbft: executed block")
+
+	if e.isPrimary() {
+		// Null blocks of a view change are signed once they are executed.
+		if len(block.Signature) == 0 {
+			block = proto.Clone(block).(*pb.Block)
+			if err := block.Sign(e.privKey); err != nil {
+				log.Errorf("pbft: failed to sign block: %s", err)
+				return
+			}
+		}
+		e.Relay(e.relayCh, &pb.Message{
+			Payload: &pb.Message_Block{
+				Block: block,
+			},
+		})
+	}
+}
+
+func (e *Engine) matchingPrepares(ent *entry) []*pb.PbftPrepare {
+	var prepares []*pb.PbftPrepare
+	for _, p := range ent.prepares {
+		if p.View == ent.view && p.Sequence == ent.prePrepare.Sequence &&
+			bytes.Equal(p.Digest, ent.prePrepare.Digest) {
+			prepares = append(prepares, p)
+		}
+	}
+	return prepares
+}
+
+func (e *Engine) entry(seq uint64) *entry {
+	ent, ok := e.log[seq]
+	if !ok {
+		ent = &entry{
+			prepares: make(map[uint32]*pb.PbftPrepare),
+			commits:  make(map[uint32]*pb.PbftCommit),
+		}
+		e.log[seq] = ent
+	}
+	return ent
+}
+
+func (e *Engine) inWindow(seq uint64) bool {
+	return seq > e.executed && seq <= e.executed+window
+}
+
+func (e *Engine) resetViewTimer() {
+	if !e.viewTimer.Stop() {
+		select {
+		case <-e.viewTimer.C:
+		default:
+		}
+	}
+	attempts := e.attempts
+	if attempts > 6 {
+		attempts = 6
+	}
+	e.viewTimer.Reset(e.ViewTimeout << attempts)
+}
+
+// send processes the message locally and relays it into the network.
+func (e *Engine) send(msg *pb.Message) error {
+	e.Relay(e.relayCh, msg)
+	return e.handleMessage(msg)
+}
+
+func (e *Engine) isPrimary() bool {
+	return e.index >= 0 && e.primary(e.view) == e.index
+}
+
+func (e *Engine) primary(view uint64) int {
+	return int(view % uint64(len(e.Validators)))
+}
+
+// f returns the amount of faulty replicas the validator set can tolerate.
+func (e *Engine) f() int {
+	return (len(e.Validators) - 1) / 3
+}
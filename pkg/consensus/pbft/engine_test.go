@@ -0,0 +1,129 @@
+package pbft
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/common"
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/consensus/consensustest"
+	"github.com/anthdm/consenter/pkg/mempool"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	proto "github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestNetwork returns a network of n engines, which are not started.
+func newTestNetwork(n int) *consensustest.Network {
+	return consensustest.NewNetwork(n, func(validators consensus.Validators) consensus.Engine {
+		return NewEngine(Config{
+			Validators:    validators,
+			BlockInterval: 20 * time.Millisecond,
+			ViewTimeout:   200 * time.Millisecond,
+		})
+	})
+}
+
+// mustSign signs the message with the key of the validator at index i.
+func mustSign(msg proto.Message, i int) {
+	if err := sign(msg, consensustest.PrivateKey(i)); err != nil {
+		panic(err)
+	}
+}
+
+func TestEngineCommit(t *testing.T) {
+	net := newTestNetwork(4)
+	net.Start()
+	defer net.Stop()
+	b := net.Receive(t.Fatalf).Block
+	assert.Equal(t, uint32(1), b.Header.Index)
+	assert.Equal(t, 1, len(b.Transactions))
+}
+
+func TestEngineViewChange(t *testing.T) {
+	// The primary of the first view is crashed.
+	net := newTestNetwork(4)
+	net.Start(0)
+	defer net.Stop()
+	b := net.Receive(t.Fatalf)
+	assert.Equal(t, uint32(1), b.Block.Header.Index)
+	assert.Equal(t, 1, b.From)
+}
+
+func TestEngineEquivocatingPrimary(t *testing.T) {
+	net := newTestNetwork(4)
+	// The primary of the first view pre-prepares a different block for each
+	// backup, none of which is prepared.
+	net.SetByzantine(0, func(to int, msg *pb.Message) *pb.Message {
+		if pp := msg.GetPbftPrePrepare(); pp != nil {
+			pp.Block.Header.Nonce = uint64(to)
+			pp.Digest = pp.Block.Hash()
+			mustSign(pp, 0)
+		}
+		return msg
+	})
+	net.Start()
+	defer net.Stop()
+	// The backups change the view and agree on the blocks of a later
+	// primary instead. Slow backups might skip more than a single view.
+	for i := 0; i < 3; i++ {
+		assert.NotEqual(t, 0, net.Receive(t.Fatalf).From)
+	}
+}
+
+func TestEngineFaultyBackup(t *testing.T) {
+	net := newTestNetwork(4)
+	// A backup prepares and commits blocks that were never proposed.
+	net.SetByzantine(3, func(to int, msg *pb.Message) *pb.Message {
+		switch p := msg.Payload.(type) {
+		case *pb.Message_PbftPrepare:
+			p.PbftPrepare.Digest = pb.NewBlock(0).Hash()
+			mustSign(p.PbftPrepare, 3)
+		case *pb.Message_PbftCommit:
+			p.PbftCommit.Digest = pb.NewBlock(0).Hash()
+			mustSign(p.PbftCommit, 3)
+		}
+		return msg
+	})
+	net.Start()
+	defer net.Stop()
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, 0, net.Receive(t.Fatalf).From)
+	}
+}
+
+func TestEngineMempool(t *testing.T) {
+	var (
+		key = common.NewPrivateKey([]byte("node_0"))
+		e   = NewEngine(Config{
+			Validators:    consensus.Validators{&key.PublicKey},
+			BlockInterval: 20 * time.Millisecond,
+			ViewTimeout:   200 * time.Millisecond,
+		})
+		pool    = mempool.New(mempool.Config{})
+		relayCh = make(chan *pb.Message)
+		tx      = pb.NewTransaction()
+	)
+	// Transactions of the shared mempool are proposed, the engine removes
+	// them once their block is executed.
+	e.UseMempool(pool)
+	assert.Nil(t, pool.Add(tx))
+	e.Configurate(relayCh, key)
+	assert.Nil(t, e.Start(context.Background()))
+	defer e.Stop()
+	for {
+		select {
+		case msg := <-relayCh:
+			b := msg.GetBlock()
+			if b == nil {
+				continue
+			}
+			assert.Equal(t, []*pb.Transaction{tx}, b.Transactions)
+			assert.Equal(t, 0, pool.Len())
+			return
+		case <-time.After(5 * time.Second):
+			t.Fatal("no block executed")
+		}
+	}
+}
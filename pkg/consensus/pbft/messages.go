@@ -0,0 +1,86 @@
+package pbft
+
+import (
+	"crypto/ecdsa"
+	"errors"
+
+	"github.com/anthdm/consenter/pkg/common"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	proto "github.com/golang/protobuf/proto"
+)
+
+var errInvalidSignature = errors.New("invalid signature")
+
+// sign signs the given PBFT message with priv and sets its signature.
+func sign(msg proto.Message, priv *ecdsa.PrivateKey) error {
+	sig, err := common.Sign(priv, signatureHash(msg))
+	if err != nil {
+		return err
+	}
+	switch m := msg.(type) {
+	case *pb.PbftPrePrepare:
+		m.Signature = sig
+	case *pb.PbftPrepare:
+		m.Signature = sig
+	case *pb.PbftCommit:
+		m.Signature = sig
+	case *pb.PbftViewChange:
+		m.Signature = sig
+	case *pb.PbftNewView:
+		m.Signature = sig
+	}
+	return nil
+}
+
+// verify reports whether the given PBFT message is signed by pub.
+func verify(msg proto.Message, pub *ecdsa.PublicKey) bool {
+	var sig []byte
+	switch m := msg.(type) {
+	case *pb.PbftPrePrepare:
+		sig = m.Signature
+	case *pb.PbftPrepare:
+		sig = m.Signature
+	case *pb.PbftCommit:
+		sig = m.Signature
+	case *pb.PbftViewChange:
+		sig = m.Signature
+	case *pb.PbftNewView:
+		sig = m.Signature
+	}
+	return common.Verify(pub, signatureHash(msg), sig)
+}
+
+// signatureHash returns the hash of the message without its signature.
+func signatureHash(msg proto.Message) []byte {
+	msg = proto.Clone(msg)
+	switch m := msg.(type) {
+	case *pb.PbftPrePrepare:
+		m.Signature = nil
+	case *pb.PbftPrepare:
+		m.Signature = nil
+	case *pb.PbftCommit:
+		m.Signature = nil
+	case *pb.PbftViewChange:
+		m.Signature = nil
+	case *pb.PbftNewView:
+		m.Signature = nil
+	}
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+	return common.Hash256(b)
+}
+
+// nullBlock returns the block that is proposed for sequence numbers that
+// could not be recovered during a view change. It is deterministic, so that
+// backups can verify the pre-prepares of a new view. The primary of the view
+// is its proposer, which signs it once it is executed.
+func nullBlock(seq uint64, primary *ecdsa.PublicKey) *pb.Block {
+	return &pb.Block{
+		Header: &pb.Header{
+			Index:    uint32(seq),
+			Proposer: common.MarshalPublicKey(primary),
+		},
+	}
+}
@@ -0,0 +1,298 @@
+package pbft
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	pb "github.com/anthdm/consenter/pkg/protos"
+	log "github.com/sirupsen/logrus"
+)
+
+var errInvalidCertificate = errors.New("invalid prepared certificate")
+
+func (e *Engine) onViewTimeout() {
+	defer e.resetViewTimer()
+	e.attempts++
+	view := e.view + 1
+	if e.changing {
+		// The new primary failed to establish its view as well.
+		view = e.pendingView + 1
+	}
+	if err := e.startViewChange(view); err != nil {
+		log.Warnf("pbft: failed to start view change: %s", err)
+	}
+}
+
+// startViewChange stops accepting messages of the current view and multicasts
+// a view change for the given view.
+func (e *Engine) startViewChange(view uint64) error {
+	e.changing = true
+	e.pendingView = view
+	if e.index < 0 {
+		return nil
+	}
+	vc := &pb.PbftViewChange{
+		View:     view,
+		Sequence: e.executed,
+		Prepared: e.preparedCertificates(),
+		Replica:  uint32(e.index),
+	}
+	if err := sign(vc, e.privKey); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{
+		"view": view,
+	}).Info("pbft: requesting view change")
+
+	return e.send(&pb.Message{
+		Payload: &pb.Message_PbftViewChange{PbftViewChange: vc},
+	})
+}
+
+// preparedCertificates returns the certificates of all blocks in the log that
+// are prepared by this replica.
+func (e *Engine) preparedCertificates() []*pb.PbftPrepared {
+	var certs []*pb.PbftPrepared
+	for _, ent := range e.log {
+		if !ent.prepared {
+			continue
+		}
+		prepares := e.matchingPrepares(ent)
+		if len(prepares) > 2*e.f() {
+			prepares = prepares[:2*e.f()]
+		}
+		certs = append(certs, &pb.PbftPrepared{
+			PrePrepare: ent.prePrepare,
+			Prepares:   prepares,
+		})
+	}
+	return certs
+}
+
+func (e *Engine) handleViewChange(vc *pb.PbftViewChange) error {
+	if vc.View <= e.view {
+		return nil
+	}
+	if err := e.verifyViewChange(vc); err != nil {
+		return err
+	}
+	if _, ok := e.viewChanges[vc.View]; !ok {
+		e.viewChanges[vc.View] = make(map[uint32]*pb.PbftViewChange)
+	}
+	e.viewChanges[vc.View][vc.Replica] = vc
+	n := len(e.viewChanges[vc.View])
+
+	// Join the view change if f+1 replicas requested it, which means at least
+	// one correct replica did. This also applies if we already requested a
+	// higher view, otherwise replicas with diverging timers might never meet.
+	_, requested := e.viewChanges[vc.View][uint32(e.index)]
+	if n >= e.f()+1 && !requested {
+		if err := e.startViewChange(vc.View); err != nil {
+			return err
+		}
+		n = len(e.viewChanges[vc.View])
+	}
+	if n >= 2*e.f()+1 && e.index == e.primary(vc.View) && !e.newViews[vc.View] {
+		return e.sendNewView(vc.View)
+	}
+	return nil
+}
+
+func (e *Engine) sendNewView(view uint64) error {
+	e.newViews[view] = true
+
+	vcs := make([]*pb.PbftViewChange, 0, len(e.viewChanges[view]))
+	for _, vc := range e.viewChanges[view] {
+		vcs = append(vcs, vc)
+	}
+	sort.Slice(vcs, func(i, j int) bool { return vcs[i].Replica < vcs[j].Replica })
+	vcs = vcs[:2*e.f()+1]
+
+	pps := e.newViewPrePrepares(view, vcs)
+	for _, pp := range pps {
+		if err := sign(pp, e.privKey); err != nil {
+			return err
+		}
+	}
+	nv := &pb.PbftNewView{
+		View:        view,
+		ViewChanges: vcs,
+		PrePrepares: pps,
+		Replica:     uint32(e.index),
+	}
+	if err := sign(nv, e.privKey); err != nil {
+		return err
+	}
+	return e.send(&pb.Message{
+		Payload: &pb.Message_PbftNewView{PbftNewView: nv},
+	})
+}
+
+func (e *Engine) handleNewView(nv *pb.PbftNewView) error {
+	if nv.View < e.view || (nv.View == e.view && !e.changing) {
+		return nil
+	}
+	if int(nv.Replica) != e.primary(nv.View) {
+		return fmt.Errorf("new view from non primary replica %d", nv.Replica)
+	}
+	if !verify(nv, e.Validators.Get(int(nv.Replica))) {
+		return errInvalidSignature
+	}
+	seen := make(map[uint32]bool)
+	for _, vc := range nv.ViewChanges {
+		if vc.View != nv.View || seen[vc.Replica] {
+			return errors.New("invalid view changes in new view")
+		}
+		if err := e.verifyViewChange(vc); err != nil {
+			return err
+		}
+		seen[vc.Replica] = true
+	}
+	if len(seen) < 2*e.f()+1 {
+		return errors.New("not enough view changes in new view")
+	}
+	// Recompute the pre-prepares from the view changes, the primary should
+	// have done exactly the same.
+	want := e.newViewPrePrepares(nv.View, nv.ViewChanges)
+	if len(want) != len(nv.PrePrepares) {
+		return errors.New("invalid pre-prepares in new view")
+	}
+	for i, pp := range nv.PrePrepares {
+		if pp.Sequence != want[i].Sequence || !bytes.Equal(pp.Digest, want[i].Digest) {
+			return errors.New("invalid pre-prepares in new view")
+		}
+	}
+	e.enterView(nv.View)
+	if len(want) > 0 {
+		e.sequence = want[len(want)-1].Sequence
+	}
+	for _, pp := range nv.PrePrepares {
+		if err := e.handlePrePrepare(pp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enterView moves the replica into the given view. All state of blocks that
+// are not executed is reset, except for the messages of the new view that
+// arrived early.
+func (e *Engine) enterView(view uint64) {
+	e.view = view
+	e.changing = false
+	e.attempts = 0
+	e.sequence = e.executed
+	for seq, ent := range e.log {
+		if seq <= e.executed {
+			continue
+		}
+		ent.prePrepare = nil
+		ent.prepared = false
+		ent.committed = false
+		for r, p := range ent.prepares {
+			if p.View < view {
+				delete(ent.prepares, r)
+			}
+		}
+		for r, c := range ent.commits {
+			if c.View < view {
+				delete(ent.commits, r)
+			}
+		}
+	}
+	for v := range e.viewChanges {
+		if v <= view {
+			delete(e.viewChanges, v)
+		}
+	}
+	e.resetViewTimer()
+
+	log.WithFields(log.Fields{
+		"view":    view,
+		"primary": e.primary(view),
+	}).Info("pbft: entered new view")
+}
+
+// newViewPrePrepares computes the unsigned pre-prepares the primary of the
+// given view has to multicast based on the given view changes. Blocks that
+// were prepared in a previous view are proposed again, gaps are filled with
+// null blocks.
+func (e *Engine) newViewPrePrepares(view uint64, vcs []*pb.PbftViewChange) []*pb.PbftPrePrepare {
+	var (
+		minSeq = vcs[0].Sequence
+		maxSeq uint64
+		best   = make(map[uint64]*pb.PbftPrePrepare)
+	)
+	for _, vc := range vcs {
+		if vc.Sequence < minSeq {
+			minSeq = vc.Sequence
+		}
+		if vc.Sequence > maxSeq {
+			maxSeq = vc.Sequence
+		}
+		for _, cert := range vc.Prepared {
+			pp := cert.PrePrepare
+			if b, ok := best[pp.Sequence]; !ok || pp.View > b.View {
+				best[pp.Sequence] = pp
+			}
+		}
+	}
+	// Replicas keep their certificates only for a window of sequence
+	// numbers, replicas lagging further behind need to catch up otherwise.
+	if maxSeq > window && minSeq < maxSeq-window {
+		minSeq = maxSeq - window
+	}
+	for seq := range best {
+		if seq > maxSeq {
+			maxSeq = seq
+		}
+	}
+	var pps []*pb.PbftPrePrepare
+	for seq := minSeq + 1; seq <= maxSeq; seq++ {
+		block := nullBlock(seq, e.Validators.Get(e.primary(view)))
+		if pp, ok := best[seq]; ok {
+			block = pp.Block
+		}
+		pps = append(pps, &pb.PbftPrePrepare{
+			View:     view,
+			Sequence: seq,
+			Digest:   block.Hash(),
+			Block:    block,
+			Replica:  uint32(e.primary(view)),
+		})
+	}
+	return pps
+}
+
+// verifyViewChange verifies the signature of the view change and all the
+// prepared certificates it carries.
+func (e *Engine) verifyViewChange(vc *pb.PbftViewChange) error {
+	if !verify(vc, e.Validators.Get(int(vc.Replica))) {
+		return errInvalidSignature
+	}
+	for _, cert := range vc.Prepared {
+		pp := cert.PrePrepare
+		if pp == nil || pp.Block == nil || int(pp.Replica) != e.primary(pp.View) {
+			return errInvalidCertificate
+		}
+		if !bytes.Equal(pp.Digest, pp.Block.Hash()) || !verify(pp, e.Validators.Get(int(pp.Replica))) {
+			return errInvalidCertificate
+		}
+		seen := make(map[uint32]bool)
+		for _, p := range cert.Prepares {
+			if p.View != pp.View || p.Sequence != pp.Sequence || !bytes.Equal(p.Digest, pp.Digest) {
+				return errInvalidCertificate
+			}
+			if int(p.Replica) == e.primary(p.View) || !verify(p, e.Validators.Get(int(p.Replica))) {
+				return errInvalidCertificate
+			}
+			seen[p.Replica] = true
+		}
+		if len(seen) < 2*e.f() {
+			return errInvalidCertificate
+		}
+	}
+	return nil
+}
@@ -0,0 +1,350 @@
+package poa
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/chain"
+	"github.com/anthdm/consenter/pkg/common"
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/mempool"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	log "github.com/sirupsen/logrus"
+)
+
+// The difficulty of blocks sealed in and out of turn. The chain with the
+// highest total difficulty wins, which favours in turn blocks.
+const (
+	diffInTurn = 2
+	diffNoTurn = 1
+)
+
+// maxTimeDrift is how far the timestamp of a block may be in the future.
+const maxTimeDrift = time.Minute
+
+// Config holds the configuration of the proof of authority engine.
+type Config struct {
+	// The authorities that take turns in sealing blocks.
+	Validators consensus.Validators
+
+	// The minimum time between two blocks, each block is one slot.
+	BlockInterval time.Duration
+
+	// The maximum delay per authority of out of turn blocks. Authorities that
+	// are not in turn seal a block after a random delay, in case the
+	// authority in turn is offline.
+	WiggleTime time.Duration
+}
+
+// Engine is a round-robin proof of authority consensus engine. The
+// authority in turn seals the block of a slot, the others follow with a
+// delay if it does not.
+type Engine struct {
+	Config
+	consensus.Lifecycle
+
+	privKey *ecdsa.PrivateKey
+	relayCh chan<- *pb.Message
+	msgCh   chan *pb.Message
+
+	// Index of this node in the set of authorities, -1 if this node only
+	// follows the chain.
+	index int
+
+	// Pending transactions, shared with the server if it has a mempool.
+	pool *mempool.Pool
+
+	blocks    *consensus.BlockTree
+	sealTimer *time.Timer
+}
+
+func init() {
+	consensus.Register(consensus.Factory{
+		Name:        "poa",
+		Description: "Round-robin proof of authority",
+		Config: func() interface{} {
+			return &Config{
+				BlockInterval: 5 * time.Second,
+				WiggleTime:    500 * time.Millisecond,
+			}
+		},
+		New: func(c interface{}) (consensus.Engine, error) {
+			cfg := c.(*Config)
+			if len(cfg.Validators) == 0 {
+				return nil, consensus.ErrMissingValidators
+			}
+			return NewEngine(*cfg), nil
+		},
+	})
+}
+
+// NewEngine returns a new proof of authority consensus engine.
+func NewEngine(cfg Config) *Engine {
+	e := &Engine{
+		Config: cfg,
+		msgCh:  make(chan *pb.Message, 1024),
+		pool:   mempool.New(mempool.Config{}),
+	}
+	genesis := &pb.Block{Header: &pb.Header{}}
+	e.blocks = consensus.NewBlockTree(genesis, forkChoice{}, 0, e.validate)
+	return e
+}
+
+// Configurate implements the Engine interface.
+func (e *Engine) Configurate(relayCh chan<- *pb.Message, priv *ecdsa.PrivateKey) {
+	e.privKey = priv
+	e.relayCh = relayCh
+	e.index = -1
+	if priv != nil {
+		e.index = e.Validators.Index(&priv.PublicKey)
+	}
+}
+
+// Start implements the Engine interface.
+func (e *Engine) Start(ctx context.Context) error {
+	return e.Run(ctx, e.run)
+}
+
+// AddTransaction implements the Engine interface.
+func (e *Engine) AddTransaction(tx *pb.Transaction) {
+	e.pool.Add(tx)
+}
+
+// UseMempool implements the consensus.MempoolUser interface.
+func (e *Engine) UseMempool(pool *mempool.Pool) {
+	e.pool = pool
+}
+
+// SignsBlocks implements the consensus.BlockSigner interface, every block is
+// signed by its proposer.
+func (e *Engine) SignsBlocks() bool {
+	return true
+}
+
+// ValidatesBlocks implements the consensus.BlockValidator interface.
+func (e *Engine) ValidatesBlocks() bool {
+	return true
+}
+
+// ValidateBlock implements the consensus.BlockValidator interface.
+func (e *Engine) ValidateBlock(b *pb.Block) error {
+	h := b.Header
+	pub, err := b.Verify()
+	if err != nil {
+		return err
+	}
+	if e.Validators.Index(pub) < 0 {
+		return fmt.Errorf("block %d sealed by unknown authority", h.Index)
+	}
+	if h.Timestamp > time.Now().Add(maxTimeDrift).UnixNano() {
+		return fmt.Errorf("block %d is too far in the future", h.Index)
+	}
+	return nil
+}
+
+// HandleMessage implements the consensus.Handler interface.
+func (e *Engine) HandleMessage(from consensus.Peer, msg *pb.Message) error {
+	switch msg.Payload.(type) {
+	case *pb.Message_Block:
+		select {
+		case e.msgCh <- msg:
+		case <-e.Done():
+		}
+	}
+	return nil
+}
+
+func (e *Engine) run(ctx context.Context) error {
+	e.sealTimer = time.NewTimer(0)
+	e.schedule()
+	for {
+		head := e.blocks.Head()
+		select {
+		case <-ctx.Done():
+			e.sealTimer.Stop()
+			return nil
+		case <-e.sealTimer.C:
+			if err := e.seal(); err != nil {
+				log.Warnf("poa: failed to seal block: %s", err)
+			}
+		case msg := <-e.msgCh:
+			if err := e.blocks.Add(msg.GetBlock(), e.pool); err != nil {
+				log.Warnf("poa: failed processing block: %s", err)
+			}
+		}
+		if b := e.blocks.Head(); b != head {
+			log.WithFields(log.Fields{
+				"index":  b.Header.Index,
+				"hash":   hex.EncodeToString(b.Header.Hash()),
+				"txs":    len(b.Transactions),
+				"signer": e.signer(b),
+			}).Info("poa: new chain head")
+			e.schedule()
+		}
+	}
+}
+
+// schedule starts the timer for sealing the next block on top of the head,
+// if this authority is allowed to.
+func (e *Engine) schedule() {
+	if !e.sealTimer.Stop() {
+		select {
+		case <-e.sealTimer.C:
+		default:
+		}
+	}
+	head := e.blocks.Head()
+	if e.index < 0 || e.signedRecently(head, e.index) {
+		return
+	}
+	var (
+		next  = time.Unix(0, head.Header.Timestamp).Add(e.BlockInterval)
+		delay = time.Until(next)
+	)
+	if !e.inTurn(head.Header.Index+1, e.index) {
+		wiggle := time.Duration(len(e.Validators)/2+1) * e.WiggleTime
+		delay += time.Duration(rand.Int63n(int64(wiggle) + 1))
+	}
+	e.sealTimer.Reset(delay)
+}
+
+// seal seals a block with the pending transactions on top of the head.
+func (e *Engine) seal() error {
+	var (
+		head   = e.blocks.Head()
+		number = head.Header.Index + 1
+	)
+	txs := e.pool.Batch(consensus.MaxBlockTxs, consensus.MaxBlockBytes)
+
+	diff := uint64(diffNoTurn)
+	if e.inTurn(number, e.index) {
+		diff = diffInTurn
+	}
+	ts := time.Now()
+	if min := time.Unix(0, head.Header.Timestamp).Add(e.BlockInterval); ts.Before(min) {
+		ts = min
+	}
+	block := &pb.Block{
+		Header: &pb.Header{
+			Index:      number,
+			PrevHash:   head.Header.Hash(),
+			Timestamp:  ts.UnixNano(),
+			Difficulty: diff,
+			Proposer:   common.MarshalPublicKey(&e.privKey.PublicKey),
+			TxRoot:     pb.TxRoot(txs),
+		},
+		Transactions: txs,
+	}
+	if err := block.Sign(e.privKey); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{
+		"index":   number,
+		"hash":    hex.EncodeToString(block.Header.Hash()),
+		"txs":     len(txs),
+		"in_turn": diff == diffInTurn,
+	}).Info("poa: sealed block")
+
+	e.Relay(e.relayCh, &pb.Message{
+		Payload: &pb.Message_Block{
+			Block: block,
+		},
+	})
+	return e.blocks.Add(block, e.pool)
+}
+
+// validate checks the block against its parent.
+func (e *Engine) validate(b, parent *pb.Block) error {
+	h := b.Header
+	pub, err := b.Verify()
+	if err != nil {
+		return err
+	}
+	signer := e.Validators.Index(pub)
+	if signer < 0 {
+		return fmt.Errorf("block %d sealed by unknown authority", h.Index)
+	}
+	if e.signedRecently(parent, signer) {
+		return fmt.Errorf("authority %d sealed block %d too recently", signer, h.Index)
+	}
+	diff := uint64(diffNoTurn)
+	if e.inTurn(h.Index, signer) {
+		diff = diffInTurn
+	}
+	if h.Difficulty != diff {
+		return fmt.Errorf("invalid difficulty %d of block %d", h.Difficulty, h.Index)
+	}
+	if h.Timestamp < parent.Header.Timestamp+int64(e.BlockInterval) {
+		return fmt.Errorf("block %d sealed before its slot", h.Index)
+	}
+	if h.Timestamp > time.Now().Add(maxTimeDrift).UnixNano() {
+		return fmt.Errorf("block %d is too far in the future", h.Index)
+	}
+	return nil
+}
+
+// signedRecently reports whether the authority sealed one of the last n/2
+// blocks up to and including parent. This way a minority of authorities can
+// not take over the chain.
+func (e *Engine) signedRecently(parent *pb.Block, signer int) bool {
+	limit := len(e.Validators) / 2
+	for b := parent; limit > 0 && b.Header.Index > 0; limit-- {
+		if e.signer(b) == signer {
+			return true
+		}
+		p, err := e.blocks.GetBlockByHash(b.Header.PrevHash)
+		if err != nil {
+			// The ancestors of blocks in the tree are known.
+			panic(err)
+		}
+		b = p
+	}
+	return false
+}
+
+// signer returns the index of the authority that sealed the block, -1 if it
+// is not an authority.
+func (e *Engine) signer(b *pb.Block) int {
+	pub, err := common.UnmarshalPublicKey(b.Header.Proposer)
+	if err != nil {
+		return -1
+	}
+	return e.Validators.Index(pub)
+}
+
+// inTurn reports whether the authority is in turn for the block with the
+// given index.
+func (e *Engine) inTurn(index uint32, authority int) bool {
+	return int(index%uint32(len(e.Validators))) == authority
+}
+
+// forkChoice follows the chain with the highest total difficulty. Ties are
+// broken by the hash, otherwise authorities that sealed competing blocks could
+// wait for each other forever, as none of them is allowed to extend its own
+// block.
+type forkChoice struct{}
+
+// Head implements the chain.ForkChoice interface.
+func (forkChoice) Head(t chain.Tree) *chain.Node {
+	var (
+		root = t.Root()
+		best = root
+	)
+	if head := t.Head(); root.IsAncestorOf(head) {
+		best = head
+	}
+	for _, tip := range t.Tips() {
+		if !root.IsAncestorOf(tip) {
+			continue
+		}
+		if tip.Work > best.Work || (tip.Work == best.Work && bytes.Compare(tip.Hash, best.Hash) < 0) {
+			best = tip
+		}
+	}
+	return best
+}
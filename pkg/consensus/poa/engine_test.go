@@ -0,0 +1,239 @@
+package poa
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/common"
+	"github.com/anthdm/consenter/pkg/consensus"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/stretchr/testify/assert"
+)
+
+const testInterval = 20 * time.Millisecond
+
+func testKeys(n int) []*ecdsa.PrivateKey {
+	keys := make([]*ecdsa.PrivateKey, n)
+	for i := range keys {
+		keys[i] = common.NewPrivateKey([]byte(fmt.Sprintf("node_%d", i)))
+	}
+	return keys
+}
+
+func testConfig(n int) Config {
+	seeds := make([]string, n)
+	for i := range seeds {
+		seeds[i] = fmt.Sprintf("node_%d", i)
+	}
+	return Config{
+		Validators:    consensus.NewValidators(seeds),
+		BlockInterval: testInterval,
+		WiggleTime:    testInterval,
+	}
+}
+
+// newTestEngine returns the engine of authority i out of n, which is not
+// started.
+func newTestEngine(i, n int) *Engine {
+	e := NewEngine(testConfig(n))
+	e.Configurate(make(chan *pb.Message, 16), testKeys(n)[i])
+	return e
+}
+
+// testBlock returns a block on top of parent sealed by the authority with
+// the given key, with the difficulty it would seal it with.
+func testBlock(e *Engine, parent *pb.Block, key *ecdsa.PrivateKey, txs ...*pb.Transaction) *pb.Block {
+	index := parent.Header.Index + 1
+	diff := uint64(diffNoTurn)
+	if e.inTurn(index, e.Validators.Index(&key.PublicKey)) {
+		diff = diffInTurn
+	}
+	b := &pb.Block{
+		Header: &pb.Header{
+			Index:      index,
+			PrevHash:   parent.Header.Hash(),
+			Timestamp:  parent.Header.Timestamp + int64(testInterval),
+			Difficulty: diff,
+			Proposer:   common.MarshalPublicKey(&key.PublicKey),
+			TxRoot:     pb.TxRoot(txs),
+		},
+		Transactions: txs,
+	}
+	if err := b.Sign(key); err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// testGenesis returns the block the chains of the engines start from.
+func testGenesis() *pb.Block {
+	return &pb.Block{Header: &pb.Header{}}
+}
+
+func TestSealInTurn(t *testing.T) {
+	// Authority 1 is in turn for block 1.
+	e := newTestEngine(1, 3)
+	assert.Nil(t, e.seal())
+	assert.Equal(t, uint32(1), e.blocks.Head().Header.Index)
+	assert.Equal(t, uint64(diffInTurn), e.blocks.Head().Header.Difficulty)
+	assert.Equal(t, 1, e.signer(e.blocks.Head()))
+
+	e = newTestEngine(0, 3)
+	assert.Nil(t, e.seal())
+	assert.Equal(t, uint32(1), e.blocks.Head().Header.Index)
+	assert.Equal(t, uint64(diffNoTurn), e.blocks.Head().Header.Difficulty)
+	assert.Equal(t, 0, e.signer(e.blocks.Head()))
+}
+
+func TestValidate(t *testing.T) {
+	var (
+		e       = newTestEngine(0, 3)
+		keys    = testKeys(3)
+		genesis = testGenesis()
+	)
+	b := testBlock(e, genesis, keys[1])
+	b.Header.Difficulty = diffNoTurn
+	assert.Nil(t, b.Sign(keys[1]))
+	assert.NotNil(t, e.blocks.Add(b, e.pool))
+
+	b = testBlock(e, genesis, keys[1])
+	b.Header.Timestamp = genesis.Header.Timestamp + int64(testInterval) - 1
+	assert.Nil(t, b.Sign(keys[1]))
+	assert.NotNil(t, e.blocks.Add(b, e.pool))
+
+	b = testBlock(e, genesis, common.NewPrivateKey([]byte("unknown")))
+	assert.NotNil(t, e.blocks.Add(b, e.pool))
+
+	assert.Nil(t, e.blocks.Add(testBlock(e, genesis, keys[1]), e.pool))
+	assert.Equal(t, uint32(1), e.blocks.Head().Header.Index)
+}
+
+func TestSignedRecently(t *testing.T) {
+	var (
+		e    = newTestEngine(0, 5)
+		keys = testKeys(5)
+		b    = testGenesis()
+	)
+	// Out of 5 authorities, each may seal 1 out of 3 consecutive blocks.
+	for _, signer := range []int{1, 2} {
+		b = testBlock(e, b, keys[signer])
+		assert.Nil(t, e.blocks.Add(b, e.pool))
+	}
+	assert.True(t, e.signedRecently(e.blocks.Head(), 1))
+	assert.True(t, e.signedRecently(e.blocks.Head(), 2))
+	assert.False(t, e.signedRecently(e.blocks.Head(), 3))
+	assert.NotNil(t, e.blocks.Add(testBlock(e, b, keys[1]), e.pool))
+	assert.NotNil(t, e.blocks.Add(testBlock(e, b, keys[2]), e.pool))
+
+	b = testBlock(e, b, keys[3])
+	assert.Nil(t, e.blocks.Add(b, e.pool))
+	assert.False(t, e.signedRecently(e.blocks.Head(), 1))
+	assert.Nil(t, e.blocks.Add(testBlock(e, b, keys[1]), e.pool))
+	assert.Equal(t, uint32(4), e.blocks.Head().Header.Index)
+}
+
+func TestForkChoice(t *testing.T) {
+	var (
+		e       = newTestEngine(0, 3)
+		keys    = testKeys(3)
+		genesis = testGenesis()
+		tx      = pb.NewTransaction()
+	)
+	// The in turn block wins over the out of turn block, regardless of the
+	// order in which they arrive.
+	noTurn := testBlock(e, genesis, keys[0], tx)
+	inTurn := testBlock(e, genesis, keys[1])
+	assert.Nil(t, e.blocks.Add(noTurn, e.pool))
+	assert.Equal(t, noTurn.Header.Hash(), e.blocks.Head().Header.Hash())
+	assert.Nil(t, e.blocks.Add(inTurn, e.pool))
+	assert.Equal(t, inTurn.Header.Hash(), e.blocks.Head().Header.Hash())
+	// The transactions of the abandoned block are pending again.
+	assert.True(t, e.pool.Has(tx.Hash()))
+
+	e = newTestEngine(0, 3)
+	assert.Nil(t, e.blocks.Add(inTurn, e.pool))
+	assert.Nil(t, e.blocks.Add(noTurn, e.pool))
+	assert.Equal(t, inTurn.Header.Hash(), e.blocks.Head().Header.Hash())
+
+	// A longer chain of out of turn blocks has a higher total difficulty.
+	next := testBlock(e, noTurn, keys[2])
+	assert.Nil(t, e.blocks.Add(next, e.pool))
+	assert.Equal(t, next.Header.Hash(), e.blocks.Head().Header.Hash())
+	assert.False(t, e.pool.Has(tx.Hash()))
+
+	// Blocks of equal total difficulty are ordered by their hash.
+	e = newTestEngine(0, 3)
+	var (
+		a = testBlock(e, genesis, keys[0])
+		b = testBlock(e, genesis, keys[2])
+	)
+	assert.Nil(t, e.blocks.Add(a, e.pool))
+	assert.Nil(t, e.blocks.Add(b, e.pool))
+	want := a.Header.Hash()
+	if string(b.Header.Hash()) < string(want) {
+		want = b.Header.Hash()
+	}
+	assert.Equal(t, want, e.blocks.Head().Header.Hash())
+}
+
+func TestOrphans(t *testing.T) {
+	var (
+		e    = newTestEngine(0, 3)
+		keys = testKeys(3)
+		b1   = testBlock(e, testGenesis(), keys[1])
+		b2   = testBlock(e, b1, keys[2])
+	)
+	assert.Nil(t, e.blocks.Add(b2, e.pool))
+	assert.Equal(t, uint32(0), e.blocks.Head().Header.Index)
+	assert.Nil(t, e.blocks.Add(b1, e.pool))
+	assert.Equal(t, b2.Header.Hash(), e.blocks.Head().Header.Hash())
+}
+
+// TestEngineOutOfTurn runs a network in which one of the authorities is
+// offline. The others seal the blocks of its turn.
+func TestEngineOutOfTurn(t *testing.T) {
+	var (
+		n       = 3
+		keys    = testKeys(n)
+		engines = make([]*Engine, n)
+		// Authority 2 is offline.
+		online = 2
+		blocks = make(chan *pb.Block, 100)
+	)
+	for i := range engines {
+		engines[i] = NewEngine(testConfig(n))
+	}
+	for i := 0; i < online; i++ {
+		relayCh := make(chan *pb.Message)
+		go func(from int) {
+			for msg := range relayCh {
+				blocks <- msg.GetBlock()
+				for j := 0; j < online; j++ {
+					if j != from {
+						engines[j].HandleMessage(nil, msg)
+					}
+				}
+			}
+		}(i)
+		engines[i].Configurate(relayCh, keys[i])
+		assert.Nil(t, engines[i].Start(context.Background()))
+		defer engines[i].Stop()
+	}
+
+	var inTurn, noTurn int
+	for inTurn == 0 || noTurn == 0 {
+		select {
+		case b := <-blocks:
+			if b.Header.Difficulty == diffInTurn {
+				inTurn++
+				continue
+			}
+			noTurn++
+		case <-time.After(5 * time.Second):
+			t.Fatal("no block sealed")
+		}
+	}
+}
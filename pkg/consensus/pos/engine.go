@@ -0,0 +1,290 @@
+package pos
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/chain"
+	"github.com/anthdm/consenter/pkg/common"
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/mempool"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	log "github.com/sirupsen/logrus"
+)
+
+// Config holds the configuration of the proof of stake engine.
+type Config struct {
+	// The validators of the genesis allocation.
+	Validators consensus.Validators
+
+	// The stake of each validator in the genesis allocation. Validators
+	// without an allocation start with a stake of 1. The total stake is
+	// limited to 2^62.
+	Stakes []uint64
+
+	// The duration of a slot, each slot has at most one block. Slots are
+	// counted from the Unix epoch, hence all nodes agree on the current slot
+	// as long as their clocks are synchronized.
+	SlotDuration time.Duration
+
+	// The seed of the randomness beacon that draws the leader of each slot.
+	Seed []byte
+
+	// The stake the proposer of a block is rewarded with.
+	BlockReward uint64
+}
+
+// Engine is a proof of stake consensus engine. The leader of each slot is
+// drawn with a probability proportional to the stake of the validators. Nodes
+// follow the longest chain.
+type Engine struct {
+	Config
+	consensus.Lifecycle
+
+	privKey *ecdsa.PrivateKey
+	// Marshaled public key of this node.
+	pubKey  []byte
+	relayCh chan<- *pb.Message
+	msgCh   chan *pb.Message
+
+	// Pending transactions, shared with the server if it has a mempool.
+	pool *mempool.Pool
+
+	blocks *consensus.BlockTree
+	// The stakes after each block of the tree is applied, by its hash.
+	stakes map[string]stakes
+}
+
+func init() {
+	consensus.Register(consensus.Factory{
+		Name:        "pos",
+		Description: "Proof of stake with stake weighted slot leaders",
+		Config: func() interface{} {
+			return &Config{
+				SlotDuration: 5 * time.Second,
+				Seed:         []byte("consenter"),
+				BlockReward:  1,
+			}
+		},
+		New: func(c interface{}) (consensus.Engine, error) {
+			cfg := c.(*Config)
+			if len(cfg.Validators) == 0 {
+				return nil, consensus.ErrMissingValidators
+			}
+			var total uint64
+			for _, stake := range cfg.Stakes {
+				if stake > maxTotalStake-total {
+					return nil, fmt.Errorf("total stake exceeds %d", uint64(maxTotalStake))
+				}
+				total += stake
+			}
+			return NewEngine(*cfg), nil
+		},
+	})
+}
+
+// NewEngine returns a new proof of stake consensus engine.
+func NewEngine(cfg Config) *Engine {
+	genesis := &pb.Block{Header: &pb.Header{}}
+	alloc := make(stakes)
+	for i, pub := range cfg.Validators {
+		stake := uint64(1)
+		if i < len(cfg.Stakes) {
+			stake = cfg.Stakes[i]
+		}
+		alloc.add(string(common.MarshalPublicKey(pub)), stake)
+	}
+	e := &Engine{
+		Config: cfg,
+		msgCh:  make(chan *pb.Message, 1024),
+		pool:   mempool.New(mempool.Config{}),
+		stakes: map[string]stakes{string(genesis.Header.Hash()): alloc},
+	}
+	e.blocks = consensus.NewBlockTree(genesis, chain.LongestChain{}, 0, e.validate)
+	return e
+}
+
+// Configurate implements the Engine interface.
+func (e *Engine) Configurate(relayCh chan<- *pb.Message, priv *ecdsa.PrivateKey) {
+	e.privKey = priv
+	e.relayCh = relayCh
+	if priv != nil {
+		e.pubKey = common.MarshalPublicKey(&priv.PublicKey)
+	}
+}
+
+// Start implements the Engine interface.
+func (e *Engine) Start(ctx context.Context) error {
+	return e.Run(ctx, e.run)
+}
+
+// AddTransaction implements the Engine interface.
+func (e *Engine) AddTransaction(tx *pb.Transaction) {
+	e.pool.Add(tx)
+}
+
+// UseMempool implements the consensus.MempoolUser interface.
+func (e *Engine) UseMempool(pool *mempool.Pool) {
+	e.pool = pool
+}
+
+// SignsBlocks implements the consensus.BlockSigner interface, every block is
+// signed by its proposer.
+func (e *Engine) SignsBlocks() bool {
+	return true
+}
+
+// ValidatesBlocks implements the consensus.BlockValidator interface.
+func (e *Engine) ValidatesBlocks() bool {
+	return true
+}
+
+// ValidateBlock implements the consensus.BlockValidator interface. The
+// leader of the slot depends on the stakes of the chain of the block, which
+// is checked once the block is added to the tree.
+func (e *Engine) ValidateBlock(b *pb.Block) error {
+	h := b.Header
+	if h.Timestamp <= 0 || h.Timestamp%int64(e.SlotDuration) != 0 {
+		return fmt.Errorf("block %d is not at the start of a slot", h.Index)
+	}
+	if uint64(h.Timestamp/int64(e.SlotDuration)) > e.currentSlot()+1 {
+		return fmt.Errorf("block %d is in a future slot", h.Index)
+	}
+	if _, err := b.Verify(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// HandleMessage implements the consensus.Handler interface.
+func (e *Engine) HandleMessage(from consensus.Peer, msg *pb.Message) error {
+	switch msg.Payload.(type) {
+	case *pb.Message_Block:
+		select {
+		case e.msgCh <- msg:
+		case <-e.Done():
+		}
+	}
+	return nil
+}
+
+func (e *Engine) run(ctx context.Context) error {
+	timer := time.NewTimer(e.untilNextSlot())
+	for {
+		head := e.blocks.Head()
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+			if err := e.propose(e.currentSlot()); err != nil {
+				log.Warnf("pos: failed to propose block: %s", err)
+			}
+			timer.Reset(e.untilNextSlot())
+		case msg := <-e.msgCh:
+			if err := e.blocks.Add(msg.GetBlock(), e.pool); err != nil {
+				log.Warnf("pos: failed processing block: %s", err)
+			}
+		}
+		if b := e.blocks.Head(); b != head {
+			log.WithFields(log.Fields{
+				"index":       b.Header.Index,
+				"slot":        e.slot(b),
+				"hash":        hex.EncodeToString(b.Header.Hash()),
+				"txs":         len(b.Transactions),
+				"total_stake": e.stakes[string(b.Header.Hash())].total(),
+			}).Info("pos: new chain head")
+		}
+	}
+}
+
+// propose proposes a block on top of the head if this node is the leader of
+// the given slot.
+func (e *Engine) propose(slot uint64) error {
+	var (
+		parent = e.blocks.Head()
+		s      = e.stakes[string(parent.Header.Hash())]
+	)
+	if e.pubKey == nil || e.slot(parent) >= slot {
+		return nil
+	}
+	leader := s.leader(beacon(e.Seed, slot))
+	if !bytes.Equal(leader, e.pubKey) {
+		return nil
+	}
+	txs := e.pool.Batch(consensus.MaxBlockTxs, consensus.MaxBlockBytes)
+
+	block := &pb.Block{
+		Header: &pb.Header{
+			Index:     parent.Header.Index + 1,
+			PrevHash:  parent.Header.Hash(),
+			Timestamp: int64(slot) * int64(e.SlotDuration),
+			Proposer:  e.pubKey,
+			TxRoot:    pb.TxRoot(txs),
+		},
+		Transactions: txs,
+	}
+	if err := block.Sign(e.privKey); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{
+		"index": block.Header.Index,
+		"slot":  slot,
+		"txs":   len(txs),
+		"stake": s[string(e.pubKey)],
+	}).Info("pos: proposed block")
+
+	e.Relay(e.relayCh, &pb.Message{
+		Payload: &pb.Message_Block{
+			Block: block,
+		},
+	})
+	return e.blocks.Add(block, e.pool)
+}
+
+// validate checks the block against its parent and records the stakes after
+// the block is applied.
+func (e *Engine) validate(b, parent *pb.Block) error {
+	h := b.Header
+	if h.Timestamp <= 0 || h.Timestamp%int64(e.SlotDuration) != 0 {
+		return fmt.Errorf("block %d is not at the start of a slot", h.Index)
+	}
+	slot := uint64(h.Timestamp / int64(e.SlotDuration))
+	if slot <= e.slot(parent) {
+		return fmt.Errorf("block %d is not in a slot after its parent", h.Index)
+	}
+	if slot > e.currentSlot()+1 {
+		return fmt.Errorf("block %d is in a future slot", h.Index)
+	}
+	if _, err := b.Verify(); err != nil {
+		return err
+	}
+	s := e.stakes[string(parent.Header.Hash())]
+	if leader := s.leader(beacon(e.Seed, slot)); !bytes.Equal(leader, h.Proposer) {
+		return fmt.Errorf("block %d not proposed by the leader of slot %d", h.Index, slot)
+	}
+	s = s.copy()
+	for _, tx := range b.Transactions {
+		s.apply(tx)
+	}
+	s.add(string(h.Proposer), e.BlockReward)
+	e.stakes[string(h.Hash())] = s
+	return nil
+}
+
+// slot returns the slot of the block, which is 0 for the genesis block.
+func (e *Engine) slot(b *pb.Block) uint64 {
+	return uint64(b.Header.Timestamp / int64(e.SlotDuration))
+}
+
+func (e *Engine) currentSlot() uint64 {
+	return uint64(time.Now().UnixNano() / int64(e.SlotDuration))
+}
+
+func (e *Engine) untilNextSlot() time.Duration {
+	return e.SlotDuration - time.Duration(time.Now().UnixNano()%int64(e.SlotDuration))
+}
@@ -0,0 +1,110 @@
+package pos
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+	"sort"
+
+	"github.com/anthdm/consenter/pkg/common"
+	pb "github.com/anthdm/consenter/pkg/protos"
+)
+
+// maxTotalStake is the limit of the total stake, deposits and rewards that
+// would exceed it are ignored. It is far below the maximum uint64, hence the
+// total can not overflow.
+const maxTotalStake = 1 << 62
+
+// stakes maps the marshaled public keys of the validators to their stake.
+type stakes map[string]uint64
+
+func (s stakes) copy() stakes {
+	c := make(stakes, len(s))
+	for k, v := range s {
+		c[k] = v
+	}
+	return c
+}
+
+func (s stakes) total() uint64 {
+	var total uint64
+	for _, v := range s {
+		total += v
+	}
+	return total
+}
+
+// add adds amount to the stake of the validator, unless the total stake
+// would exceed maxTotalStake. It reports whether the amount was added.
+func (s stakes) add(key string, amount uint64) bool {
+	total := s.total()
+	if amount > maxTotalStake || total+amount > maxTotalStake {
+		return false
+	}
+	if amount > 0 {
+		s[key] += amount
+	}
+	return true
+}
+
+// apply applies the stake change of the transaction. Only the validator can
+// change its stake, transactions not signed by it are ignored, as well as
+// withdrawals that exceed its stake and deposits that exceed the maximum
+// total stake.
+func (s stakes) apply(tx *pb.Transaction) {
+	st := tx.Stake
+	if st == nil || len(st.Validator) == 0 {
+		return
+	}
+	if !bytes.Equal(tx.Sender, st.Validator) {
+		return
+	}
+	if _, err := tx.Verify(); err != nil {
+		return
+	}
+	key := string(st.Validator)
+	if st.Amount >= 0 {
+		s.add(key, uint64(st.Amount))
+		return
+	}
+	if amount := uint64(-st.Amount); amount <= s[key] {
+		s[key] -= amount
+	}
+	if s[key] == 0 {
+		delete(s, key)
+	}
+}
+
+// leader draws the validator with a probability proportional to its stake,
+// using the given randomness.
+func (s stakes) leader(rnd []byte) []byte {
+	total := s.total()
+	if total == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(s))
+	for k := range s {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	r := new(big.Int).SetBytes(rnd)
+	r.Mod(r, new(big.Int).SetUint64(total))
+	n := r.Uint64()
+	for _, k := range keys {
+		if n < s[k] {
+			return []byte(k)
+		}
+		n -= s[k]
+	}
+	return nil
+}
+
+// beacon returns the randomness of the given slot. It is derived from the
+// seed, hence every node can verify the leader of a slot.
+func beacon(seed []byte, slot uint64) []byte {
+	b := make([]byte, len(seed)+8)
+	copy(b, seed)
+	binary.BigEndian.PutUint64(b[len(seed):], slot)
+	return common.Hash256(b)
+}
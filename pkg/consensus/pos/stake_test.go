@@ -0,0 +1,78 @@
+package pos
+
+import (
+	"math"
+	"testing"
+
+	"github.com/anthdm/consenter/pkg/common"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/stretchr/testify/assert"
+)
+
+// stakeTx returns a stake transaction of the validator with the given seed.
+func stakeTx(t *testing.T, seed string, amount int64) *pb.Transaction {
+	tx, err := pb.NewStakeTransaction(common.NewPrivateKey([]byte(seed)), 0, amount)
+	assert.Nil(t, err)
+	return tx
+}
+
+// key returns the marshaled public key of the validator with the given seed.
+func key(seed string) string {
+	return string(common.MarshalPublicKey(&common.NewPrivateKey([]byte(seed)).PublicKey))
+}
+
+func TestStakesApply(t *testing.T) {
+	s := stakes{key("a"): 10}
+	s.apply(stakeTx(t, "b", 5))
+	assert.Equal(t, uint64(5), s[key("b")])
+
+	// Withdrawals exceeding the stake are ignored.
+	s.apply(stakeTx(t, "a", -11))
+	assert.Equal(t, uint64(10), s[key("a")])
+
+	// Validators without stake are removed.
+	s.apply(stakeTx(t, "a", -10))
+	_, ok := s[key("a")]
+	assert.False(t, ok)
+	assert.Equal(t, uint64(5), s.total())
+}
+
+func TestStakesApplyUnauthorized(t *testing.T) {
+	s := stakes{key("a"): 10}
+	// Only the validator can change its stake.
+	tx := stakeTx(t, "b", -10)
+	tx.Stake.Validator = []byte(key("a"))
+	assert.Nil(t, tx.Sign(common.NewPrivateKey([]byte("b"))))
+	s.apply(tx)
+	assert.Equal(t, uint64(10), s[key("a")])
+
+	tx = stakeTx(t, "a", -10)
+	tx.Signature = nil
+	s.apply(tx)
+	assert.Equal(t, uint64(10), s[key("a")])
+}
+
+func TestStakesOverflow(t *testing.T) {
+	s := stakes{key("a"): 10}
+	s.apply(stakeTx(t, "b", math.MaxInt64))
+	s.apply(stakeTx(t, "b", maxTotalStake-10))
+	s.apply(stakeTx(t, "c", 1))
+	assert.Equal(t, uint64(maxTotalStake), s.total())
+	_, ok := s[key("c")]
+	assert.False(t, ok)
+	assert.NotNil(t, s.leader(beacon([]byte("seed"), 7)))
+}
+
+func TestStakesLeader(t *testing.T) {
+	s := stakes{"a": 1, "b": 3}
+	counts := map[string]int{}
+	for slot := uint64(0); slot < 4000; slot++ {
+		counts[string(s.leader(beacon([]byte("seed"), slot)))]++
+	}
+	assert.Equal(t, 4000, counts["a"]+counts["b"])
+	assert.InDelta(t, 1000, counts["a"], 150)
+
+	// The leader of a slot is the same for every node.
+	assert.Equal(t, s.leader(beacon([]byte("seed"), 7)), s.copy().leader(beacon([]byte("seed"), 7)))
+	assert.Nil(t, stakes{}.leader(beacon([]byte("seed"), 7)))
+}
@@ -0,0 +1,50 @@
+package pow
+
+import (
+	"math/big"
+
+	pb "github.com/anthdm/consenter/pkg/protos"
+)
+
+// maxTarget is the target of difficulty 1, which is met by every hash.
+var maxTarget = new(big.Int).Lsh(big.NewInt(1), 256)
+
+// target returns the value the hash of a header with the given difficulty
+// needs to be below.
+func target(difficulty uint64) *big.Int {
+	return new(big.Int).Div(maxTarget, new(big.Int).SetUint64(difficulty))
+}
+
+// checkProof reports whether the hash of the header meets the target of its
+// difficulty.
+func checkProof(h *pb.Header) bool {
+	if h.Difficulty == 0 {
+		return false
+	}
+	return new(big.Int).SetBytes(h.Hash()).Cmp(target(h.Difficulty)) < 0
+}
+
+// retarget adjusts the difficulty by the ratio of the expected and the
+// actual time it took to mine a span of blocks. The adjustment is limited to
+// a factor of 4 in either direction.
+func retarget(difficulty uint64, expected, actual int64) uint64 {
+	if actual < expected/4 {
+		actual = expected / 4
+	}
+	if actual > expected*4 {
+		actual = expected * 4
+	}
+	if actual <= 0 {
+		return difficulty
+	}
+	d := new(big.Int).SetUint64(difficulty)
+	d.Mul(d, big.NewInt(expected))
+	d.Div(d, big.NewInt(actual))
+	if d.Sign() == 0 {
+		return 1
+	}
+	if !d.IsUint64() {
+		return ^uint64(0)
+	}
+	return d.Uint64()
+}
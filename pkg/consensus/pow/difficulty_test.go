@@ -0,0 +1,34 @@
+package pow
+
+import (
+	"testing"
+
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetarget(t *testing.T) {
+	// Blocks were mined twice as fast as expected.
+	assert.Equal(t, uint64(200), retarget(100, 10, 5))
+	// Blocks were mined twice as slow as expected.
+	assert.Equal(t, uint64(50), retarget(100, 10, 20))
+	// The adjustment is limited to a factor of 4.
+	assert.Equal(t, uint64(400), retarget(100, 100, 1))
+	assert.Equal(t, uint64(25), retarget(100, 100, 1000))
+	assert.Equal(t, uint64(1), retarget(1, 10, 40))
+}
+
+func TestCheckProof(t *testing.T) {
+	h := &pb.Header{Difficulty: 1}
+	assert.True(t, checkProof(h))
+
+	h.Difficulty = 0
+	assert.False(t, checkProof(h))
+
+	h.Difficulty = 1 << 8
+	for !checkProof(h) {
+		h.Nonce++
+	}
+	hash := h.Hash()
+	assert.Equal(t, byte(0), hash[0])
+}
@@ -0,0 +1,328 @@
+package pow
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/chain"
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/mempool"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// maxTimeDrift is how far the timestamp of a block may be in the future.
+	maxTimeDrift = time.Minute
+
+	// maxReorgDepth is how far below the head a competing branch may fork
+	// off. Blocks further down are finalized.
+	maxReorgDepth = 64
+)
+
+// Config holds the configuration of the proof of work engine.
+type Config struct {
+	// The interval in which blocks should be mined on average. The difficulty
+	// is retargeted towards it.
+	BlockInterval time.Duration
+
+	// The difficulty of the genesis block, which is the expected amount of
+	// hashes needed to mine a block.
+	InitialDifficulty uint64
+
+	// The amount of blocks after which the difficulty is retargeted, zero
+	// keeps the initial difficulty.
+	RetargetInterval uint32
+}
+
+// Engine is a proof of work consensus engine. Each node mines on top of the
+// chain with the most total work it knows of, competing branches of equal
+// work resolve once one of them is extended. Blocks deep below the head are
+// final.
+type Engine struct {
+	Config
+	consensus.Lifecycle
+
+	privKey *ecdsa.PrivateKey
+	relayCh chan<- *pb.Message
+	msgCh   chan *pb.Message
+	minedCh chan *pb.Block
+
+	// Pending transactions, shared with the server if it has a mempool.
+	pool *mempool.Pool
+
+	blocks *consensus.BlockTree
+	// Closed to abort mining on top of the previous head.
+	abort chan struct{}
+}
+
+func init() {
+	consensus.Register(consensus.Factory{
+		Name:        "pow",
+		Description: "Proof of work with difficulty retargeting",
+		Config: func() interface{} {
+			return &Config{
+				BlockInterval:     10 * time.Second,
+				InitialDifficulty: 1 << 20,
+				RetargetInterval:  10,
+			}
+		},
+		New: func(c interface{}) (consensus.Engine, error) {
+			cfg := c.(*Config)
+			return NewEngine(*cfg), nil
+		},
+	})
+}
+
+// NewEngine returns a new proof of work consensus engine.
+func NewEngine(cfg Config) *Engine {
+	e := &Engine{
+		Config:  cfg,
+		msgCh:   make(chan *pb.Message, 1024),
+		minedCh: make(chan *pb.Block),
+		pool:    mempool.New(mempool.Config{}),
+	}
+	genesis := &pb.Block{
+		Header: &pb.Header{Difficulty: cfg.InitialDifficulty},
+	}
+	e.blocks = consensus.NewBlockTree(genesis, chain.HeaviestWork{}, maxReorgDepth, e.validate)
+	return e
+}
+
+// Configurate implements the Engine interface.
+func (e *Engine) Configurate(relayCh chan<- *pb.Message, priv *ecdsa.PrivateKey) {
+	e.privKey = priv
+	e.relayCh = relayCh
+}
+
+// Start implements the Engine interface.
+func (e *Engine) Start(ctx context.Context) error {
+	return e.Run(ctx, e.run)
+}
+
+// AddTransaction implements the Engine interface.
+func (e *Engine) AddTransaction(tx *pb.Transaction) {
+	e.pool.Add(tx)
+}
+
+// UseMempool implements the consensus.MempoolUser interface.
+func (e *Engine) UseMempool(pool *mempool.Pool) {
+	e.pool = pool
+}
+
+// ValidatesBlocks implements the consensus.BlockValidator interface.
+func (e *Engine) ValidatesBlocks() bool {
+	return true
+}
+
+// ValidateBlock implements the consensus.BlockValidator interface. The
+// difficulty depends on the chain of the block, which is checked once the
+// block is added to the tree.
+func (e *Engine) ValidateBlock(b *pb.Block) error {
+	h := b.Header
+	if !checkProof(h) {
+		return fmt.Errorf("block %d does not meet its target", h.Index)
+	}
+	if h.Timestamp > time.Now().Add(maxTimeDrift).UnixNano() {
+		return fmt.Errorf("block %d is too far in the future", h.Index)
+	}
+	return nil
+}
+
+// ValidatesDifficulty implements the consensus.DifficultyValidator interface.
+func (e *Engine) ValidatesDifficulty() bool {
+	return true
+}
+
+// ValidateDifficulty implements the consensus.DifficultyValidator interface.
+// The difficulty of the blocks with index 1 follows the initial difficulty.
+func (e *Engine) ValidateDifficulty(b *pb.Block, chain consensus.BlockReader) error {
+	parent := &pb.Header{Difficulty: e.InitialDifficulty}
+	if b.Header.Index > 1 {
+		if chain == nil {
+			return consensus.ErrUnknownParent
+		}
+		p, err := chain.GetBlockByHash(b.Header.PrevHash)
+		if err != nil {
+			return consensus.ErrUnknownParent
+		}
+		parent = p.Header
+	}
+	d, err := e.nextDifficulty(parent, chain)
+	if err != nil {
+		return err
+	}
+	if b.Header.Difficulty != d {
+		return fmt.Errorf("block %d has difficulty %d, expected %d",
+			b.Header.Index, b.Header.Difficulty, d)
+	}
+	return nil
+}
+
+// HandleMessage implements the consensus.Handler interface.
+func (e *Engine) HandleMessage(from consensus.Peer, msg *pb.Message) error {
+	switch msg.Payload.(type) {
+	case *pb.Message_Block:
+		select {
+		case e.msgCh <- msg:
+		case <-e.Done():
+		}
+	}
+	return nil
+}
+
+func (e *Engine) run(ctx context.Context) error {
+	e.startMining()
+	for {
+		head := e.blocks.Head()
+		select {
+		case <-ctx.Done():
+			close(e.abort)
+			e.abort = nil
+			return nil
+		case b := <-e.minedCh:
+			// The head might have changed while the block was send.
+			if !bytes.Equal(b.Header.PrevHash, head.Header.Hash()) {
+				continue
+			}
+			log.WithFields(log.Fields{
+				"index":      b.Header.Index,
+				"hash":       hex.EncodeToString(b.Header.Hash()),
+				"txs":        len(b.Transactions),
+				"difficulty": b.Header.Difficulty,
+			}).Info("pow: mined block")
+
+			e.Relay(e.relayCh, &pb.Message{
+				Payload: &pb.Message_Block{
+					Block: b,
+				},
+			})
+			if err := e.blocks.Add(b, e.pool); err != nil {
+				log.Warnf("pow: failed to add mined block: %s", err)
+			}
+		case msg := <-e.msgCh:
+			if err := e.blocks.Add(msg.GetBlock(), e.pool); err != nil {
+				log.Warnf("pow: failed processing block: %s", err)
+			}
+		}
+		if b := e.blocks.Head(); b != head {
+			log.WithFields(log.Fields{
+				"index": b.Header.Index,
+				"hash":  hex.EncodeToString(b.Header.Hash()),
+				"txs":   len(b.Transactions),
+			}).Info("pow: new chain head")
+			e.startMining()
+		}
+	}
+}
+
+// validate checks the block against its parent.
+func (e *Engine) validate(b, parent *pb.Block) error {
+	h := b.Header
+	d, err := e.nextDifficulty(parent.Header, e.blocks)
+	if err != nil {
+		return err
+	}
+	if h.Difficulty != d {
+		return fmt.Errorf("invalid difficulty %d, expected %d", h.Difficulty, d)
+	}
+	if !checkProof(h) {
+		return fmt.Errorf("block %d does not meet its target", h.Index)
+	}
+	if h.Timestamp < parent.Header.Timestamp {
+		return fmt.Errorf("block %d is older than its parent", h.Index)
+	}
+	if h.Timestamp > time.Now().Add(maxTimeDrift).UnixNano() {
+		return fmt.Errorf("block %d is too far in the future", h.Index)
+	}
+	return nil
+}
+
+// nextDifficulty returns the difficulty of the block following parent. The
+// ancestors of the parent within the retarget interval are read from the
+// chain.
+func (e *Engine) nextDifficulty(parent *pb.Header, chain consensus.BlockReader) (uint64, error) {
+	d := parent.Difficulty
+	if e.RetargetInterval == 0 || (parent.Index+1)%e.RetargetInterval != 0 {
+		return d, nil
+	}
+	// The timestamp of the genesis block is meaningless, hence the span
+	// starts at the first block at the earliest.
+	first := parent
+	for i := uint32(0); i < e.RetargetInterval && first.Index > 1; i++ {
+		b, err := chain.GetBlockByHash(first.PrevHash)
+		if err != nil {
+			return 0, fmt.Errorf("block %d: %s", first.Index-1, err)
+		}
+		first = b.Header
+	}
+	blocks := int64(parent.Index - first.Index)
+	if blocks == 0 {
+		return d, nil
+	}
+	var (
+		expected = int64(e.BlockInterval) * blocks
+		actual   = parent.Timestamp - first.Timestamp
+	)
+	return retarget(d, expected, actual), nil
+}
+
+// startMining aborts mining on top of the previous head and starts mining a
+// new block on top of the current head.
+func (e *Engine) startMining() {
+	if e.abort != nil {
+		close(e.abort)
+	}
+	e.abort = make(chan struct{})
+
+	head := e.blocks.Head()
+	d, err := e.nextDifficulty(head.Header, e.blocks)
+	if err != nil {
+		// The ancestors of the head are part of the chain.
+		panic(err)
+	}
+	txs := e.pool.Batch(consensus.MaxBlockTxs, consensus.MaxBlockBytes)
+
+	block := &pb.Block{
+		Header: &pb.Header{
+			Index:      head.Header.Index + 1,
+			Nonce:      rand.Uint64(),
+			PrevHash:   head.Header.Hash(),
+			Timestamp:  time.Now().UnixNano(),
+			Difficulty: d,
+			TxRoot:     pb.TxRoot(txs),
+		},
+		Transactions: txs,
+	}
+	go e.mine(block, e.abort)
+}
+
+// mine searches a nonce for which the hash of the block header meets the
+// target of its difficulty. Miners start at a random nonce, so they do not
+// search the same space.
+func (e *Engine) mine(block *pb.Block, abort <-chan struct{}) {
+	t := target(block.Header.Difficulty)
+	for i := 0; ; i++ {
+		if i%1024 == 0 {
+			select {
+			case <-abort:
+				return
+			default:
+			}
+		}
+		if new(big.Int).SetBytes(block.Header.Hash()).Cmp(t) < 0 {
+			select {
+			case e.minedCh <- block:
+			case <-abort:
+			}
+			return
+		}
+		block.Header.Nonce++
+	}
+}
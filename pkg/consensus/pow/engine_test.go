@@ -0,0 +1,115 @@
+package pow
+
+import (
+	"testing"
+
+	"github.com/anthdm/consenter/pkg/consensus"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/stretchr/testify/assert"
+)
+
+// testBlock returns a block mined on top of parent, which needs to be known
+// to the engine.
+func testBlock(e *Engine, parent *pb.Block, timestamp int64, txs ...*pb.Transaction) *pb.Block {
+	d, err := e.nextDifficulty(parent.Header, e.blocks)
+	if err != nil {
+		panic(err)
+	}
+	b := &pb.Block{
+		Header: &pb.Header{
+			Index:      parent.Header.Index + 1,
+			PrevHash:   parent.Header.Hash(),
+			Timestamp:  timestamp,
+			Difficulty: d,
+			TxRoot:     pb.TxRoot(txs),
+		},
+		Transactions: txs,
+	}
+	for !checkProof(b.Header) {
+		b.Header.Nonce++
+	}
+	return b
+}
+
+func TestForkChoice(t *testing.T) {
+	e := NewEngine(Config{
+		BlockInterval:     100,
+		InitialDifficulty: 16,
+		RetargetInterval:  1,
+	})
+	var (
+		genesis = e.blocks.Head()
+		tx      = pb.NewTransaction()
+		b1      = testBlock(e, genesis, 100)
+	)
+	assert.Nil(t, e.blocks.Add(b1, e.pool))
+
+	// A slow branch, its difficulty drops with every block.
+	slow := b1
+	for i, ts := range []int64{500, 900, 1300} {
+		var txs []*pb.Transaction
+		if i == 0 {
+			txs = append(txs, tx)
+		}
+		slow = testBlock(e, slow, ts, txs...)
+		assert.Nil(t, e.blocks.Add(slow, e.pool))
+	}
+	assert.Equal(t, uint32(4), e.blocks.Head().Header.Index)
+	assert.False(t, e.pool.Has(tx.Hash()))
+
+	// A fast branch of fewer blocks, which has more work.
+	fast := testBlock(e, b1, 125)
+	assert.Nil(t, e.blocks.Add(fast, e.pool))
+	assert.Equal(t, slow.Header.Hash(), e.blocks.Head().Header.Hash())
+	fast = testBlock(e, fast, 150)
+	assert.Nil(t, e.blocks.Add(fast, e.pool))
+	assert.Equal(t, fast.Header.Hash(), e.blocks.Head().Header.Hash())
+	// The transactions of the abandoned branch are pending again.
+	assert.True(t, e.pool.Has(tx.Hash()))
+}
+
+func TestFinalize(t *testing.T) {
+	e := NewEngine(Config{InitialDifficulty: 1})
+	var (
+		genesis = e.blocks.Head()
+		fork    = testBlock(e, genesis, 1, pb.NewTransaction())
+		b       = genesis
+	)
+	assert.Nil(t, e.blocks.Add(fork, e.pool))
+	for i := 0; i < maxReorgDepth+10; i++ {
+		b = testBlock(e, b, int64(i))
+		assert.Nil(t, e.blocks.Add(b, e.pool))
+	}
+	assert.Equal(t, uint32(maxReorgDepth+10), e.blocks.Head().Header.Index)
+	assert.Equal(t, uint32(10), e.blocks.Finalized())
+
+	// Blocks forking off below the finalized block are rejected, the ones
+	// forking off the finalized block are not.
+	assert.NotNil(t, e.blocks.Add(testBlock(e, fork, 2), e.pool))
+	final, err := e.blocks.GetBlockByHash(b.Header.PrevHash)
+	assert.Nil(t, err)
+	for final.Header.Index > 10 {
+		final, err = e.blocks.GetBlockByHash(final.Header.PrevHash)
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, e.blocks.Add(testBlock(e, final, final.Header.Timestamp), e.pool))
+}
+
+func TestValidateDifficulty(t *testing.T) {
+	e := NewEngine(Config{
+		BlockInterval:     100,
+		InitialDifficulty: 16,
+		RetargetInterval:  1,
+	})
+	b1 := testBlock(e, e.blocks.Head(), 100)
+	assert.Nil(t, e.ValidateDifficulty(b1, e.blocks))
+	assert.Nil(t, e.blocks.Add(b1, e.pool))
+
+	b2 := testBlock(e, b1, 500)
+	assert.Nil(t, e.ValidateDifficulty(b2, e.blocks))
+	b2.Header.Difficulty++
+	assert.NotNil(t, e.ValidateDifficulty(b2, e.blocks))
+	assert.NotNil(t, e.blocks.Add(b2, e.pool))
+	b2.Header.PrevHash = []byte("unknown")
+	assert.Equal(t, consensus.ErrUnknownParent, e.ValidateDifficulty(b2, e.blocks))
+}
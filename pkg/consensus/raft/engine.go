@@ -0,0 +1,528 @@
+package raft
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"math/rand"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/common"
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/mempool"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxEntries is the maximum amount of log entries send in a single append
+// entries message.
+const maxEntries = 64
+
+type state int
+
+const (
+	follower state = iota
+	candidate
+	leader
+)
+
+// Config holds the configuration of the Raft engine.
+type Config struct {
+	// The nodes participating in consensus. Raft tolerates f crashed nodes
+	// out of 2f+1.
+	Validators consensus.Validators
+
+	// The interval in which the leader batches the pending transactions into
+	// a new log entry.
+	BlockInterval time.Duration
+
+	// The interval in which the leader sends heartbeats to its followers.
+	HeartbeatInterval time.Duration
+
+	// The minimum time a follower waits for a heartbeat before it becomes a
+	// candidate. The actual timeout is randomized between ElectionTimeout and
+	// twice its value.
+	ElectionTimeout time.Duration
+}
+
+// Engine is a Raft consensus engine. Each committed log entry results in a
+// block holding the transactions of that entry.
+type Engine struct {
+	Config
+	consensus.Lifecycle
+
+	privKey *ecdsa.PrivateKey
+	relayCh chan<- *pb.Message
+	msgCh   chan *pb.Message
+
+	// Index of this node in the validator set, -1 if this node does not take
+	// part in consensus.
+	index int
+
+	// Pending transactions, shared with the server if it has a mempool.
+	pool *mempool.Pool
+
+	state       state
+	currentTerm uint64
+	votedFor    int
+	votes       map[uint32]bool
+	// The log starts with a sentinel entry at index 0.
+	log         []*pb.RaftEntry
+	commitIndex uint64
+	lastApplied uint64
+	// Leader state, reinitialized after each election.
+	nextIndex  []uint64
+	matchIndex []uint64
+	// Hashes of the transactions in uncommitted entries of the leader.
+	inflight      map[string]bool
+	electionTimer *time.Timer
+}
+
+func init() {
+	consensus.Register(consensus.Factory{
+		Name:        "raft",
+		Description: "Raft crash fault tolerant log replication",
+		Config: func() interface{} {
+			return &Config{
+				BlockInterval:     5 * time.Second,
+				HeartbeatInterval: 1 * time.Second,
+				ElectionTimeout:   5 * time.Second,
+			}
+		},
+		New: func(c interface{}) (consensus.Engine, error) {
+			cfg := c.(*Config)
+			if len(cfg.Validators) == 0 {
+				return nil, consensus.ErrMissingValidators
+			}
+			return NewEngine(*cfg), nil
+		},
+	})
+}
+
+// NewEngine returns a new Raft consensus engine.
+func NewEngine(cfg Config) *Engine {
+	return &Engine{
+		Config:   cfg,
+		msgCh:    make(chan *pb.Message, 1024),
+		pool:     mempool.New(mempool.Config{}),
+		votedFor: -1,
+		log:      []*pb.RaftEntry{{}},
+		inflight: make(map[string]bool),
+	}
+}
+
+// Configurate implements the Engine interface.
+func (e *Engine) Configurate(relayCh chan<- *pb.Message, priv *ecdsa.PrivateKey) {
+	e.privKey = priv
+	e.relayCh = relayCh
+	e.index = -1
+	if priv != nil {
+		e.index = e.Validators.Index(&priv.PublicKey)
+	}
+}
+
+// Start implements the Engine interface.
+func (e *Engine) Start(ctx context.Context) error {
+	return e.Run(ctx, e.run)
+}
+
+// AddTransaction implements the Engine interface.
+func (e *Engine) AddTransaction(tx *pb.Transaction) {
+	e.pool.Add(tx)
+}
+
+// UseMempool implements the consensus.MempoolUser interface.
+func (e *Engine) UseMempool(pool *mempool.Pool) {
+	e.pool = pool
+}
+
+// HandleMessage implements the consensus.Handler interface.
+func (e *Engine) HandleMessage(from consensus.Peer, msg *pb.Message) error {
+	switch msg.Payload.(type) {
+	case *pb.Message_RaftRequestVote, *pb.Message_RaftVote,
+		*pb.Message_RaftAppendEntries, *pb.Message_RaftAppendResponse:
+		select {
+		case e.msgCh <- msg:
+		case <-e.Done():
+		}
+	}
+	return nil
+}
+
+func (e *Engine) run(ctx context.Context) error {
+	var (
+		heartbeat = time.NewTicker(e.HeartbeatInterval)
+		batch     = time.NewTicker(e.BlockInterval)
+	)
+	defer heartbeat.Stop()
+	defer batch.Stop()
+	e.electionTimer = time.NewTimer(e.electionTimeout())
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-e.electionTimer.C:
+			if e.index >= 0 && e.state != leader {
+				e.startElection()
+			}
+			e.resetElectionTimer()
+		case <-heartbeat.C:
+			if e.state == leader {
+				e.replicate()
+			}
+		case <-batch.C:
+			if e.state == leader {
+				e.appendBatch()
+			}
+		case msg := <-e.msgCh:
+			e.handleMessage(msg)
+		}
+	}
+}
+
+func (e *Engine) handleMessage(msg *pb.Message) {
+	if e.index < 0 {
+		return
+	}
+	switch p := msg.Payload.(type) {
+	case *pb.Message_RaftRequestVote:
+		e.handleRequestVote(p.RaftRequestVote)
+	case *pb.Message_RaftVote:
+		e.handleVote(p.RaftVote)
+	case *pb.Message_RaftAppendEntries:
+		e.handleAppendEntries(p.RaftAppendEntries)
+	case *pb.Message_RaftAppendResponse:
+		e.handleAppendResponse(p.RaftAppendResponse)
+	}
+}
+
+func (e *Engine) startElection() {
+	e.currentTerm++
+	e.state = candidate
+	e.votedFor = e.index
+	e.votes = map[uint32]bool{uint32(e.index): true}
+
+	log.WithFields(log.Fields{
+		"term": e.currentTerm,
+	}).Info("raft: starting election")
+
+	if e.hasMajority(len(e.votes)) {
+		e.becomeLeader()
+		return
+	}
+	e.Relay(e.relayCh, &pb.Message{
+		Payload: &pb.Message_RaftRequestVote{
+			RaftRequestVote: &pb.RaftRequestVote{
+				Term:         e.currentTerm,
+				Candidate:    uint32(e.index),
+				LastLogIndex: e.lastIndex(),
+				LastLogTerm:  e.lastTerm(),
+			},
+		},
+	})
+}
+
+func (e *Engine) handleRequestVote(rv *pb.RaftRequestVote) {
+	if rv.Term > e.currentTerm {
+		e.stepDown(rv.Term)
+	}
+	// The candidate's log needs to be at least as up-to-date as ours.
+	upToDate := rv.LastLogTerm > e.lastTerm() ||
+		(rv.LastLogTerm == e.lastTerm() && rv.LastLogIndex >= e.lastIndex())
+	granted := rv.Term == e.currentTerm && upToDate &&
+		(e.votedFor == -1 || e.votedFor == int(rv.Candidate))
+	if granted {
+		e.votedFor = int(rv.Candidate)
+		e.resetElectionTimer()
+	}
+	e.Relay(e.relayCh, &pb.Message{
+		Payload: &pb.Message_RaftVote{
+			RaftVote: &pb.RaftVote{
+				Term:      e.currentTerm,
+				Candidate: rv.Candidate,
+				Voter:     uint32(e.index),
+				Granted:   granted,
+			},
+		},
+	})
+}
+
+func (e *Engine) handleVote(v *pb.RaftVote) {
+	if v.Term > e.currentTerm {
+		e.stepDown(v.Term)
+		return
+	}
+	if e.state != candidate || v.Term != e.currentTerm || int(v.Candidate) != e.index || !v.Granted {
+		return
+	}
+	e.votes[v.Voter] = true
+	if e.hasMajority(len(e.votes)) {
+		e.becomeLeader()
+	}
+}
+
+func (e *Engine) becomeLeader() {
+	e.state = leader
+	e.nextIndex = make([]uint64, len(e.Validators))
+	e.matchIndex = make([]uint64, len(e.Validators))
+	for i := range e.Validators {
+		e.nextIndex[i] = e.lastIndex() + 1
+	}
+	log.WithFields(log.Fields{
+		"term": e.currentTerm,
+	}).Info("raft: became leader")
+
+	// Entries of previous terms can only be committed indirectly, hence the
+	// leader appends an entry of its own term right away.
+	e.appendEntry(nil)
+}
+
+// appendBatch appends the pending transactions that are not yet part of the
+// log as a new entry.
+func (e *Engine) appendBatch() {
+	var txs []*pb.Transaction
+	for _, tx := range e.pool.Batch(consensus.MaxBlockTxs, consensus.MaxBlockBytes) {
+		if !e.inflight[string(tx.Hash())] {
+			txs = append(txs, tx)
+		}
+	}
+	if len(txs) == 0 {
+		return
+	}
+	for _, tx := range txs {
+		e.inflight[string(tx.Hash())] = true
+	}
+	e.appendEntry(txs)
+}
+
+func (e *Engine) appendEntry(txs []*pb.Transaction) {
+	block := pb.NewBlock(uint32(e.lastIndex()))
+	block.Transactions = txs
+	block.Header.TxRoot = pb.TxRoot(block.Transactions)
+	if last := e.log[e.lastIndex()]; last.Block != nil {
+		block.Header.PrevHash = last.Block.Header.Hash()
+	}
+	block.Header.Proposer = common.MarshalPublicKey(&e.privKey.PublicKey)
+	if err := block.Sign(e.privKey); err != nil {
+		log.Errorf("raft: failed to sign block: %s", err)
+		return
+	}
+	e.log = append(e.log, &pb.RaftEntry{
+		Term:  e.currentTerm,
+		Index: e.lastIndex() + 1,
+		Block: block,
+	})
+	e.matchIndex[e.index] = e.lastIndex()
+	e.advanceCommitIndex()
+	e.replicate()
+}
+
+// replicate sends the missing log entries to each follower. Followers that
+// are up-to-date receive an empty heartbeat.
+func (e *Engine) replicate() {
+	for i := range e.Validators {
+		if i != e.index {
+			e.sendAppendEntries(i)
+		}
+	}
+}
+
+func (e *Engine) sendAppendEntries(follower int) {
+	var (
+		prev = e.nextIndex[follower] - 1
+		end  = e.lastIndex() + 1
+	)
+	if end-prev-1 > maxEntries {
+		end = prev + 1 + maxEntries
+	}
+	// Copy the entries, the log might be truncated while the message is in
+	// flight.
+	entries := append([]*pb.RaftEntry{}, e.log[prev+1:end]...)
+	e.Relay(e.relayCh, &pb.Message{
+		Payload: &pb.Message_RaftAppendEntries{
+			RaftAppendEntries: &pb.RaftAppendEntries{
+				Term:         e.currentTerm,
+				Leader:       uint32(e.index),
+				Follower:     uint32(follower),
+				PrevLogIndex: prev,
+				PrevLogTerm:  e.log[prev].Term,
+				Entries:      entries,
+				LeaderCommit: e.commitIndex,
+				Nonce:        rand.Uint64(),
+			},
+		},
+	})
+}
+
+func (e *Engine) handleAppendEntries(ae *pb.RaftAppendEntries) {
+	if int(ae.Follower) != e.index {
+		return
+	}
+	if ae.Term < e.currentTerm {
+		e.respond(ae, false, e.lastIndex())
+		return
+	}
+	if ae.Term > e.currentTerm || e.state != follower {
+		e.stepDown(ae.Term)
+	}
+	e.resetElectionTimer()
+
+	if ae.PrevLogIndex > e.lastIndex() {
+		e.respond(ae, false, e.lastIndex())
+		return
+	}
+	if e.log[ae.PrevLogIndex].Term != ae.PrevLogTerm {
+		e.respond(ae, false, ae.PrevLogIndex-1)
+		return
+	}
+	for _, entry := range ae.Entries {
+		if entry.Index <= e.lastIndex() {
+			if e.log[entry.Index].Term == entry.Term {
+				continue
+			}
+			// Conflicting entry, delete it and all that follow it.
+			e.log = e.log[:entry.Index]
+		}
+		e.log = append(e.log, entry)
+	}
+	match := ae.PrevLogIndex + uint64(len(ae.Entries))
+	if ae.LeaderCommit > e.commitIndex {
+		commit := ae.LeaderCommit
+		if match < commit {
+			commit = match
+		}
+		if commit > e.commitIndex {
+			e.commitIndex = commit
+			e.apply()
+		}
+	}
+	e.respond(ae, true, match)
+}
+
+func (e *Engine) respond(ae *pb.RaftAppendEntries, success bool, match uint64) {
+	e.Relay(e.relayCh, &pb.Message{
+		Payload: &pb.Message_RaftAppendResponse{
+			RaftAppendResponse: &pb.RaftAppendResponse{
+				Term:       e.currentTerm,
+				Leader:     ae.Leader,
+				Follower:   uint32(e.index),
+				Success:    success,
+				MatchIndex: match,
+				Nonce:      rand.Uint64(),
+			},
+		},
+	})
+}
+
+func (e *Engine) handleAppendResponse(r *pb.RaftAppendResponse) {
+	if r.Term > e.currentTerm {
+		e.stepDown(r.Term)
+		return
+	}
+	if e.state != leader || r.Term != e.currentTerm || int(r.Leader) != e.index {
+		return
+	}
+	f := int(r.Follower)
+	if f < 0 || f >= len(e.Validators) {
+		return
+	}
+	if !r.Success {
+		next := r.MatchIndex + 1
+		if next < e.nextIndex[f] {
+			e.nextIndex[f] = next
+		}
+		if e.nextIndex[f] < 1 {
+			e.nextIndex[f] = 1
+		}
+		e.sendAppendEntries(f)
+		return
+	}
+	if r.MatchIndex > e.matchIndex[f] {
+		e.matchIndex[f] = r.MatchIndex
+	}
+	e.nextIndex[f] = e.matchIndex[f] + 1
+	e.advanceCommitIndex()
+}
+
+// advanceCommitIndex commits the highest entry of the current term that is
+// stored on a majority of the nodes.
+func (e *Engine) advanceCommitIndex() {
+	for n := e.lastIndex(); n > e.commitIndex; n-- {
+		if e.log[n].Term != e.currentTerm {
+			break
+		}
+		count := 0
+		for _, m := range e.matchIndex {
+			if m >= n {
+				count++
+			}
+		}
+		if e.hasMajority(count) {
+			e.commitIndex = n
+			e.apply()
+			return
+		}
+	}
+}
+
+// apply turns all committed entries that are not applied yet into blocks.
+func (e *Engine) apply() {
+	for e.lastApplied < e.commitIndex {
+		e.lastApplied++
+		entry := e.log[e.lastApplied]
+		block := entry.Block
+		e.pool.Remove(block.Transactions)
+
+		log.WithFields(log.Fields{
+			"index": block.Header.Index,
+			"hash":  hex.EncodeToString(block.Hash()),
+			"txs":   len(block.Transactions),
+			"term":  entry.Term,
+		}).Info("raft: committed block")
+
+		if e.state == leader {
+			e.Relay(e.relayCh, &pb.Message{
+				Payload: &pb.Message_Block{
+					Block: block,
+				},
+			})
+		}
+	}
+}
+
+func (e *Engine) stepDown(term uint64) {
+	if term > e.currentTerm {
+		e.currentTerm = term
+		e.votedFor = -1
+	}
+	if e.state == leader {
+		e.inflight = make(map[string]bool)
+	}
+	e.state = follower
+}
+
+func (e *Engine) lastIndex() uint64 {
+	return uint64(len(e.log) - 1)
+}
+
+func (e *Engine) lastTerm() uint64 {
+	return e.log[len(e.log)-1].Term
+}
+
+func (e *Engine) hasMajority(n int) bool {
+	return n > len(e.Validators)/2
+}
+
+func (e *Engine) electionTimeout() time.Duration {
+	return e.ElectionTimeout + time.Duration(rand.Int63n(int64(e.ElectionTimeout)))
+}
+
+func (e *Engine) resetElectionTimer() {
+	if !e.electionTimer.Stop() {
+		select {
+		case <-e.electionTimer.C:
+		default:
+		}
+	}
+	e.electionTimer.Reset(e.electionTimeout())
+}
@@ -0,0 +1,267 @@
+package raft
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/common"
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/consensus/consensustest"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/stretchr/testify/assert"
+)
+
+func testSeeds(n int) []string {
+	seeds := make([]string, n)
+	for i := range seeds {
+		seeds[i] = fmt.Sprintf("node_%d", i)
+	}
+	return seeds
+}
+
+func testConfig(validators consensus.Validators) Config {
+	return Config{
+		Validators:        validators,
+		BlockInterval:     20 * time.Millisecond,
+		HeartbeatInterval: 20 * time.Millisecond,
+		ElectionTimeout:   200 * time.Millisecond,
+	}
+}
+
+// newTestEngine returns the engine of node i out of n, which is not started.
+// The messages it sends are send to the returned channel.
+func newTestEngine(i, n int) (*Engine, <-chan *pb.Message) {
+	var (
+		seeds   = testSeeds(n)
+		e       = NewEngine(testConfig(consensus.NewValidators(seeds)))
+		relayCh = make(chan *pb.Message, 16)
+	)
+	e.Configurate(relayCh, common.NewPrivateKey([]byte(seeds[i])))
+	e.electionTimer = time.NewTimer(time.Hour)
+	return e, relayCh
+}
+
+// receive returns the next message send to the channel.
+func receive(t *testing.T, ch <-chan *pb.Message) *pb.Message {
+	select {
+	case msg := <-ch:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message send")
+		return nil
+	}
+}
+
+func testEntry(index, term uint64) *pb.RaftEntry {
+	return &pb.RaftEntry{
+		Term:  term,
+		Index: index,
+		Block: pb.NewBlock(uint32(index - 1)),
+	}
+}
+
+func TestHandleRequestVote(t *testing.T) {
+	e, msgs := newTestEngine(1, 3)
+	vote := func(term uint64, candidate uint32, lastIndex, lastTerm uint64) *pb.RaftVote {
+		e.handleRequestVote(&pb.RaftRequestVote{
+			Term:         term,
+			Candidate:    candidate,
+			LastLogIndex: lastIndex,
+			LastLogTerm:  lastTerm,
+		})
+		return receive(t, msgs).GetRaftVote()
+	}
+	assert.True(t, vote(1, 0, 0, 0).Granted)
+	// A single vote is cast in each term.
+	assert.False(t, vote(1, 2, 0, 0).Granted)
+	assert.True(t, vote(1, 0, 0, 0).Granted)
+	assert.True(t, vote(2, 2, 0, 0).Granted)
+
+	// Candidates with a log behind ours are rejected.
+	e.log = append(e.log, testEntry(1, 2))
+	assert.False(t, vote(3, 0, 5, 1).Granted)
+	assert.False(t, vote(4, 0, 0, 2).Granted)
+	v := vote(5, 0, 1, 2)
+	assert.True(t, v.Granted)
+	assert.Equal(t, uint64(5), v.Term)
+}
+
+func TestHandleAppendEntries(t *testing.T) {
+	e, msgs := newTestEngine(1, 3)
+	appendEntries := func(term, prevIndex, prevTerm, commit uint64, entries ...*pb.RaftEntry) *pb.RaftAppendResponse {
+		e.handleAppendEntries(&pb.RaftAppendEntries{
+			Term:         term,
+			Leader:       0,
+			Follower:     1,
+			PrevLogIndex: prevIndex,
+			PrevLogTerm:  prevTerm,
+			Entries:      entries,
+			LeaderCommit: commit,
+		})
+		return receive(t, msgs).GetRaftAppendResponse()
+	}
+	r := appendEntries(1, 0, 0, 1, testEntry(1, 1), testEntry(2, 1))
+	assert.True(t, r.Success)
+	assert.Equal(t, uint64(2), r.MatchIndex)
+	assert.Equal(t, uint64(1), e.commitIndex)
+	assert.Equal(t, uint64(1), e.lastApplied)
+
+	// The entry of the new leader replaces the conflicting one.
+	r = appendEntries(2, 1, 1, 2, testEntry(2, 2))
+	assert.True(t, r.Success)
+	assert.Equal(t, uint64(2), e.lastIndex())
+	assert.Equal(t, uint64(2), e.lastTerm())
+	assert.Equal(t, uint64(2), e.commitIndex)
+
+	// Entries that do not follow the log are rejected.
+	r = appendEntries(2, 5, 2, 2)
+	assert.False(t, r.Success)
+	assert.Equal(t, uint64(2), r.MatchIndex)
+	r = appendEntries(2, 2, 1, 2)
+	assert.False(t, r.Success)
+	assert.Equal(t, uint64(1), r.MatchIndex)
+
+	// Leaders of old terms are rejected.
+	r = appendEntries(1, 2, 2, 2)
+	assert.False(t, r.Success)
+	assert.Equal(t, uint64(2), r.Term)
+}
+
+// newTestNetwork returns a network of n engines, which are not started.
+func newTestNetwork(n int) *consensustest.Network {
+	return consensustest.NewNetwork(n, func(validators consensus.Validators) consensus.Engine {
+		return NewEngine(testConfig(validators))
+	})
+}
+
+// receiveTx returns the block that holds the transaction.
+func receiveTx(t *testing.T, net *consensustest.Network, tx *pb.Transaction) consensustest.Block {
+	for {
+		if b := net.Receive(t.Fatalf); hasTx(b.Block, tx) {
+			return b
+		}
+	}
+}
+
+func hasTx(b *pb.Block, tx *pb.Transaction) bool {
+	for _, x := range b.Transactions {
+		if string(x.Hash()) == string(tx.Hash()) {
+			return true
+		}
+	}
+	return false
+}
+
+// receiveIndex returns the block at the index. The blocks are relayed
+// concurrently and might be received out of order.
+func receiveIndex(t *testing.T, net *consensustest.Network, index uint32) consensustest.Block {
+	for {
+		if b := net.Receive(t.Fatalf); b.Block.Header.Index == index {
+			return b
+		}
+	}
+}
+
+// addTransaction adds the transaction to all engines of the network.
+func addTransaction(net *consensustest.Network, n int, tx *pb.Transaction) {
+	for i := 0; i < n; i++ {
+		net.Engine(i).AddTransaction(tx)
+	}
+}
+
+// stopEngines stops the engines, after which their state can be inspected.
+func stopEngines(net *consensustest.Network, n int) []*Engine {
+	engines := make([]*Engine, n)
+	for i := range engines {
+		engines[i] = net.Engine(i).(*Engine)
+		engines[i].Stop()
+	}
+	return engines
+}
+
+func TestEngineElection(t *testing.T) {
+	net := newTestNetwork(3)
+	net.Start()
+	defer net.Stop()
+	// The elected leader commits an entry of its term right away.
+	first := receiveIndex(t, net, 1)
+	assert.Equal(t, 0, len(first.Block.Transactions))
+
+	tx := pb.NewTransaction()
+	addTransaction(net, 3, tx)
+	assert.True(t, receiveTx(t, net, tx).Block.Header.Index > 1)
+
+	// There is at most one leader in each term.
+	leaders := make(map[uint64]int)
+	for _, e := range stopEngines(net, 3) {
+		if e.state == leader {
+			leaders[e.currentTerm]++
+		}
+	}
+	assert.True(t, len(leaders) > 0)
+	for term, n := range leaders {
+		assert.Equal(t, 1, n, "leaders of term %d", term)
+	}
+}
+
+func TestEngineReplication(t *testing.T) {
+	net := newTestNetwork(3)
+	net.Start()
+	defer net.Stop()
+	var (
+		txs    = []*pb.Transaction{pb.NewTransaction(), pb.NewTransaction(), pb.NewTransaction()}
+		blocks = make(map[uint32]*pb.Block)
+		last   uint32
+	)
+	for _, tx := range txs {
+		addTransaction(net, 3, tx)
+		for {
+			// A new leader relays the entries it applies again, the network
+			// checks that they are the same.
+			b := net.Receive(t.Fatalf).Block
+			blocks[b.Header.Index] = b
+			if hasTx(b, tx) {
+				last = b.Header.Index
+				break
+			}
+		}
+	}
+	// The leader relays a block for each entry of the log.
+	for i := uint32(1); i <= last; i++ {
+		assert.NotNil(t, blocks[i])
+	}
+	// The entries are stored on a majority of the nodes, and all nodes that
+	// committed them hold the relayed blocks.
+	engines := stopEngines(net, 3)
+	stored := 0
+	for _, e := range engines {
+		matches := e.lastIndex() >= uint64(last)
+		for i := uint64(1); matches && i <= uint64(last); i++ {
+			matches = string(blocks[uint32(i)].Hash()) == string(e.log[i].Block.Hash())
+		}
+		if matches {
+			stored++
+		}
+		if e.commitIndex >= uint64(last) {
+			assert.True(t, matches)
+		}
+	}
+	assert.True(t, engines[0].hasMajority(stored))
+}
+
+func TestEngineLeaderCrash(t *testing.T) {
+	net := newTestNetwork(3)
+	net.Start()
+	defer net.Stop()
+	first := net.Receive(t.Fatalf)
+	net.SetDown(first.From, true)
+
+	// The remaining nodes elect a new leader, which keeps the committed
+	// entries of the crashed one.
+	tx := pb.NewTransaction()
+	addTransaction(net, 3, tx)
+	b := receiveTx(t, net, tx)
+	assert.NotEqual(t, first.From, b.From)
+	assert.True(t, b.Block.Header.Index > first.Block.Header.Index)
+}
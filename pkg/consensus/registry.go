@@ -0,0 +1,229 @@
+package consensus
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrMissingValidators is returned by the factories of engines that need
+	// a set of validators, if none are given.
+	ErrMissingValidators = errors.New("missing validators for consensus engine")
+
+	errUnknownEngine = errors.New("unknown consensus engine")
+)
+
+var (
+	registryLock sync.RWMutex
+	registry     = make(map[string]Factory)
+
+	durationType   = reflect.TypeOf(time.Duration(0))
+	validatorsType = reflect.TypeOf(Validators{})
+)
+
+// Factory describes how to build an engine. Engines register their factory
+// on import, see Register.
+type Factory struct {
+	// The name the engine is selected by.
+	Name string
+
+	// A short description of the engine.
+	Description string
+
+	// Config returns a pointer to a new configuration struct of the engine,
+	// holding its default values. The exported fields of the struct make up
+	// the configuration schema of the engine.
+	Config func() interface{}
+
+	// New returns a new engine for the given configuration, which is of the
+	// type returned by Config.
+	New func(cfg interface{}) (Engine, error)
+}
+
+// Field describes a single field of the configuration schema of an engine.
+type Field struct {
+	Name    string
+	Type    string
+	Default string
+}
+
+// Register makes the engine available under the name of the factory. It is
+// meant to be called in the init function of the engine package and panics
+// if the factory is incomplete or its name is registered already.
+func Register(f Factory) {
+	if len(f.Name) == 0 || f.Config == nil || f.New == nil {
+		panic("consensus: Register of incomplete factory")
+	}
+	if reflect.TypeOf(f.Config()).Kind() != reflect.Ptr {
+		panic("consensus: Register of " + f.Name + " with non pointer config")
+	}
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if _, ok := registry[f.Name]; ok {
+		panic("consensus: Register called twice for engine " + f.Name)
+	}
+	registry[f.Name] = f
+}
+
+// Lookup returns the factory registered under the given name.
+func Lookup(name string) (Factory, bool) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	f, ok := registry[name]
+	return f, ok
+}
+
+// Factories returns all registered factories sorted by name.
+func Factories() []Factory {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	factories := make([]Factory, 0, len(registry))
+	for _, f := range registry {
+		factories = append(factories, f)
+	}
+	sort.Slice(factories, func(i, j int) bool {
+		return factories[i].Name < factories[j].Name
+	})
+	return factories
+}
+
+// NewEngine builds the engine registered under the given name. Its default
+// configuration is overridden by the given parameters, by field name. Any
+// field holding Validators is set to the given validators.
+func NewEngine(name string, params map[string]string, validators Validators) (Engine, error) {
+	f, ok := Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("%s: %s", errUnknownEngine, name)
+	}
+	cfg := f.Config()
+	v := reflect.ValueOf(cfg).Elem()
+	for key, val := range params {
+		field, ok := lookupField(v, key)
+		if !ok {
+			return nil, fmt.Errorf("unknown config field %s of engine %s", key, name)
+		}
+		if err := setField(field, val); err != nil {
+			return nil, fmt.Errorf("invalid value for config field %s: %s", key, err)
+		}
+	}
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).Type() == validatorsType && v.Field(i).CanSet() {
+			v.Field(i).Set(reflect.ValueOf(validators))
+		}
+	}
+	return f.New(cfg)
+}
+
+// Schema returns the configuration fields of the engine along with their
+// default values. Fields holding Validators are left out, those are not
+// configured by value.
+func (f Factory) Schema() []Field {
+	var (
+		v      = reflect.ValueOf(f.Config()).Elem()
+		fields []Field
+	)
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		if sf.PkgPath != "" || sf.Type == validatorsType {
+			continue
+		}
+		fields = append(fields, Field{
+			Name:    sf.Name,
+			Type:    sf.Type.String(),
+			Default: formatValue(v.Field(i)),
+		})
+	}
+	return fields
+}
+
+// lookupField returns the exported field with the given name, ignoring case.
+func lookupField(v reflect.Value, name string) (reflect.Value, bool) {
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		if sf.PkgPath == "" && strings.EqualFold(sf.Name, name) {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// setField parses the string into the field. Slices other than byte slices
+// are given as comma separated values.
+func setField(field reflect.Value, s string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(n)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() == reflect.Uint8 {
+			field.SetBytes([]byte(s))
+			return nil
+		}
+		parts := strings.Split(s, ",")
+		slice := reflect.MakeSlice(field.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setField(slice.Index(i), strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// formatValue formats the value the way setField parses it.
+func formatValue(v reflect.Value) string {
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+	if v.Kind() == reflect.Slice {
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes())
+		}
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = formatValue(v.Index(i))
+		}
+		return strings.Join(parts, ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
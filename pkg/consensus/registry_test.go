@@ -0,0 +1,81 @@
+package consensus
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"testing"
+	"time"
+
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/stretchr/testify/assert"
+)
+
+type testConfig struct {
+	Validators Validators
+	Interval   time.Duration
+	Size       int
+	Weights    []uint64
+	Seed       []byte
+}
+
+type testEngine struct {
+	cfg *testConfig
+}
+
+func (testEngine) Configurate(chan<- *pb.Message, *ecdsa.PrivateKey) {}
+func (testEngine) Start(context.Context) error                       { return nil }
+func (testEngine) Stop()                                             {}
+func (testEngine) Err() <-chan error                                 { return nil }
+func (testEngine) AddTransaction(*pb.Transaction)                    {}
+
+func init() {
+	Register(Factory{
+		Name:        "test",
+		Description: "test engine",
+		Config: func() interface{} {
+			return &testConfig{Interval: time.Second, Size: 4}
+		},
+		New: func(c interface{}) (Engine, error) {
+			return testEngine{c.(*testConfig)}, nil
+		},
+	})
+}
+
+func TestRegistryNewEngine(t *testing.T) {
+	validators := NewValidators([]string{"a", "b"})
+	e, err := NewEngine("test", map[string]string{
+		"interval": "5s",
+		"Weights":  "1, 2",
+		"Seed":     "seed",
+	}, validators)
+	assert.Nil(t, err)
+	cfg := e.(testEngine).cfg
+	assert.Equal(t, 5*time.Second, cfg.Interval)
+	assert.Equal(t, 4, cfg.Size)
+	assert.Equal(t, []uint64{1, 2}, cfg.Weights)
+	assert.Equal(t, []byte("seed"), cfg.Seed)
+	assert.Equal(t, validators, cfg.Validators)
+
+	_, err = NewEngine("unknown", nil, nil)
+	assert.NotNil(t, err)
+	_, err = NewEngine("test", map[string]string{"Foo": "1"}, nil)
+	assert.NotNil(t, err)
+	_, err = NewEngine("test", map[string]string{"Size": "four"}, nil)
+	assert.NotNil(t, err)
+}
+
+func TestRegistrySchema(t *testing.T) {
+	f, ok := Lookup("test")
+	assert.True(t, ok)
+	assert.Equal(t, []Field{
+		{Name: "Interval", Type: "time.Duration", Default: "1s"},
+		{Name: "Size", Type: "int", Default: "4"},
+		{Name: "Weights", Type: "[]uint64", Default: ""},
+		{Name: "Seed", Type: "[]uint8", Default: ""},
+	}, f.Schema())
+}
+
+func TestRegisterTwice(t *testing.T) {
+	f, _ := Lookup("test")
+	assert.Panics(t, func() { Register(f) })
+}
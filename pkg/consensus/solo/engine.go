@@ -1,26 +1,54 @@
 package solo
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"time"
 
+	"github.com/anthdm/consenter/pkg/common"
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/mempool"
 	pb "github.com/anthdm/consenter/pkg/protos"
 )
 
 // Engine represents a single consensus engine ^^. This is used as implementation
 // example.
 type Engine struct {
+	consensus.Lifecycle
+
 	blockGenerationInterval time.Duration
 	privKey                 *ecdsa.PrivateKey
 	relayCh                 chan<- *pb.Message
-	transactions            []*pb.Transaction
+	pool                    *mempool.Pool
+	// The last block of the chain, nil if the chain is empty.
+	head *pb.Block
+}
+
+// Config holds the configuration of the solo engine.
+type Config struct {
+	// The interval in which blocks are generated.
+	BlockInterval time.Duration
+}
+
+func init() {
+	consensus.Register(consensus.Factory{
+		Name:        "solo",
+		Description: "Single node generating blocks in a fixed interval",
+		Config: func() interface{} {
+			// block generation 15 seconds.
+			return &Config{BlockInterval: 15 * time.Second}
+		},
+		New: func(c interface{}) (consensus.Engine, error) {
+			return NewEngine(c.(*Config).BlockInterval), nil
+		},
+	})
 }
 
 // NewEngine returns a new "Solo" consensus engine.
 func NewEngine(interval time.Duration) *Engine {
 	return &Engine{
 		blockGenerationInterval: interval,
-		transactions:            []*pb.Transaction{},
+		pool:                    mempool.New(mempool.Config{}),
 	}
 }
 
@@ -28,33 +56,66 @@ func NewEngine(interval time.Duration) *Engine {
 func (e *Engine) Configurate(relayCh chan<- *pb.Message, priv *ecdsa.PrivateKey) {
 	e.privKey = priv
 	e.relayCh = relayCh
-	go e.run()
 }
 
-func (e *Engine) run() {
-	var (
-		timer = time.NewTimer(e.blockGenerationInterval)
-		index uint32
-	)
+// Start implements the Engine interface.
+func (e *Engine) Start(ctx context.Context) error {
+	return e.Run(ctx, e.run)
+}
+
+func (e *Engine) run(ctx context.Context) error {
+	timer := time.NewTimer(e.blockGenerationInterval)
+	defer timer.Stop()
 	for {
 		select {
+		case <-ctx.Done():
+			return nil
 		case <-timer.C:
-			block := pb.NewBlock(index)
-			block.Transactions = e.transactions
-			e.relayCh <- &pb.Message{
+			block := e.newBlock()
+			block.Transactions = e.pool.Batch(consensus.MaxBlockTxs, consensus.MaxBlockBytes)
+			block.Header.TxRoot = pb.TxRoot(block.Transactions)
+			block.Header.Proposer = common.MarshalPublicKey(&e.privKey.PublicKey)
+			if err := block.Sign(e.privKey); err != nil {
+				return err
+			}
+			select {
+			case e.relayCh <- &pb.Message{
 				Payload: &pb.Message_Block{
 					Block: block,
 				},
+			}:
+			case <-ctx.Done():
+				return nil
 			}
-			index++
-			e.transactions = []*pb.Transaction{}
+			e.head = block
+			e.pool.Remove(block.Transactions)
 			timer.Reset(e.blockGenerationInterval)
 		}
 	}
 }
 
+// newBlock returns a new block following the head.
+func (e *Engine) newBlock() *pb.Block {
+	if e.head == nil {
+		return pb.NewBlock(0)
+	}
+	block := pb.NewBlock(e.head.Header.Index)
+	block.Header.PrevHash = e.head.Header.Hash()
+	return block
+}
+
+// Resume implements the consensus.Resumer interface.
+func (e *Engine) Resume(head *pb.Block) {
+	e.head = head
+}
+
 // AddTransaction implements the Engine interface.
 func (e *Engine) AddTransaction(tx *pb.Transaction) {
 	// Assume this tx is valid.
-	e.transactions = append(e.transactions, tx)
+	e.pool.Add(tx)
+}
+
+// UseMempool implements the consensus.MempoolUser interface.
+func (e *Engine) UseMempool(pool *mempool.Pool) {
+	e.pool = pool
 }
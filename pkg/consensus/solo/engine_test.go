@@ -0,0 +1,55 @@
+package solo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/common"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/stretchr/testify/assert"
+)
+
+// receive returns the next block relayed by the engine.
+func receive(t *testing.T, relayCh <-chan *pb.Message) *pb.Block {
+	select {
+	case msg := <-relayCh:
+		return msg.GetBlock()
+	case <-time.After(time.Second):
+		t.Fatal("no block generated")
+		return nil
+	}
+}
+
+func TestEngineLinksBlocks(t *testing.T) {
+	var (
+		e       = NewEngine(10 * time.Millisecond)
+		relayCh = make(chan *pb.Message)
+	)
+	e.Configurate(relayCh, common.NewPrivateKey([]byte("solo")))
+	assert.Nil(t, e.Start(context.Background()))
+	defer e.Stop()
+
+	first := receive(t, relayCh)
+	assert.Equal(t, uint32(1), first.Header.Index)
+	assert.Nil(t, first.Header.PrevHash)
+	second := receive(t, relayCh)
+	assert.Equal(t, uint32(2), second.Header.Index)
+	assert.Equal(t, first.Header.Hash(), second.Header.PrevHash)
+}
+
+func TestEngineResume(t *testing.T) {
+	var (
+		e       = NewEngine(10 * time.Millisecond)
+		relayCh = make(chan *pb.Message)
+		head    = pb.NewBlock(41)
+	)
+	e.Configurate(relayCh, common.NewPrivateKey([]byte("solo")))
+	e.Resume(head)
+	assert.Nil(t, e.Start(context.Background()))
+	defer e.Stop()
+
+	b := receive(t, relayCh)
+	assert.Equal(t, uint32(43), b.Header.Index)
+	assert.Equal(t, head.Header.Hash(), b.Header.PrevHash)
+}
@@ -0,0 +1,693 @@
+package tendermint
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/common"
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/mempool"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	proto "github.com/golang/protobuf/proto"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// maxFuture is the maximum amount of messages of the next height that
+	// are buffered while the current height is not decided yet.
+	maxFuture = 1024
+
+	// maxCommits is the amount of recent heights of which the commit
+	// certificate is kept, to be served to validators that fell behind.
+	maxCommits = 64
+)
+
+// Config holds the configuration of the Tendermint engine.
+type Config struct {
+	// The validators participating in consensus. Tendermint tolerates f
+	// faulty validators out of 3f+1.
+	Validators consensus.Validators
+
+	// The time a validator waits after committing a block before it starts
+	// the next height, giving transactions time to arrive.
+	BlockInterval time.Duration
+
+	// The timeout of the propose, prevote and precommit steps in the first
+	// round of a height. It grows with each round, so that rounds eventually
+	// last long enough to decide once the network is synchronous again.
+	RoundTimeout time.Duration
+}
+
+type step int
+
+const (
+	propose step = iota
+	prevote
+	precommit
+	// The validator committed a block and waits for the next height.
+	commit
+)
+
+// timeout is fired when a step of a round timed out.
+type timeout struct {
+	height uint64
+	round  uint64
+	step   step
+}
+
+// round holds the messages received for a single round of the current
+// height.
+type round struct {
+	proposal *pb.TendermintProposal
+	// Hash of the proposed block.
+	digest     []byte
+	prevotes   map[uint32]*pb.TendermintVote
+	precommits map[uint32]*pb.TendermintVote
+	// Rules that trigger only once per round.
+	prevoteWait   bool
+	precommitWait bool
+	polka         bool
+}
+
+// certificate is the commit certificate of a height and the last time it was
+// served to a validator that fell behind.
+type certificate struct {
+	commit *pb.TendermintCommit
+	served time.Time
+}
+
+// Engine is a Tendermint consensus engine. Each height is decided in one or
+// more rounds of propose, prevote and precommit steps with a rotating
+// proposer. Validators that fell behind catch up with the commit certificates
+// of the heights they missed, which are multicasted once they are seen
+// sending messages of a decided height. A validator that receives messages of
+// a higher height sends its last vote again to announce it fell behind.
+type Engine struct {
+	Config
+	consensus.Lifecycle
+
+	privKey   *ecdsa.PrivateKey
+	relayCh   chan<- *pb.Message
+	msgCh     chan *pb.Message
+	timeoutCh chan timeout
+
+	// Index of this node in the validator set, -1 if this node only follows
+	// the consensus.
+	index int
+
+	// Pending transactions, shared with the server if it has a mempool.
+	pool *mempool.Pool
+
+	height uint64
+	round  uint64
+	step   step
+	rounds map[uint64]*round
+	// Hash of the header of the last committed block.
+	head []byte
+	// The block the validator is locked on and the round it locked in.
+	lockedBlock *pb.Block
+	lockedRound int64
+	// The last block that received 2/3 of the prevotes and its round.
+	validBlock *pb.Block
+	validRound int64
+	// Messages of the next height that arrived early.
+	future []*pb.Message
+	// Commit certificates of the recent heights, and the ones of the heights
+	// above the current one received while catching up.
+	commits map[uint64]*certificate
+	pending map[uint64]*pb.TendermintCommit
+	// The last vote of this validator at the current height and the last
+	// time it was send again to announce that the validator fell behind.
+	lastVote  *pb.TendermintVote
+	announced time.Time
+}
+
+func init() {
+	consensus.Register(consensus.Factory{
+		Name:        "tendermint",
+		Description: "Tendermint rounds of propose, prevote and precommit",
+		Config: func() interface{} {
+			return &Config{
+				BlockInterval: 5 * time.Second,
+				RoundTimeout:  3 * time.Second,
+			}
+		},
+		New: func(c interface{}) (consensus.Engine, error) {
+			cfg := c.(*Config)
+			if len(cfg.Validators) == 0 {
+				return nil, consensus.ErrMissingValidators
+			}
+			return NewEngine(*cfg), nil
+		},
+	})
+}
+
+// NewEngine returns a new Tendermint consensus engine.
+func NewEngine(cfg Config) *Engine {
+	return &Engine{
+		Config:      cfg,
+		msgCh:       make(chan *pb.Message, 1024),
+		timeoutCh:   make(chan timeout),
+		pool:        mempool.New(mempool.Config{}),
+		height:      1,
+		step:        commit,
+		rounds:      make(map[uint64]*round),
+		commits:     make(map[uint64]*certificate),
+		pending:     make(map[uint64]*pb.TendermintCommit),
+		lockedRound: -1,
+		validRound:  -1,
+	}
+}
+
+// Configurate implements the Engine interface.
+func (e *Engine) Configurate(relayCh chan<- *pb.Message, priv *ecdsa.PrivateKey) {
+	e.privKey = priv
+	e.relayCh = relayCh
+	e.index = -1
+	if priv != nil {
+		e.index = e.Validators.Index(&priv.PublicKey)
+	}
+}
+
+// Start implements the Engine interface.
+func (e *Engine) Start(ctx context.Context) error {
+	return e.Run(ctx, e.run)
+}
+
+// AddTransaction implements the Engine interface.
+func (e *Engine) AddTransaction(tx *pb.Transaction) {
+	e.pool.Add(tx)
+}
+
+// UseMempool implements the consensus.MempoolUser interface.
+func (e *Engine) UseMempool(pool *mempool.Pool) {
+	e.pool = pool
+}
+
+// HandleMessage implements the consensus.Handler interface.
+func (e *Engine) HandleMessage(from consensus.Peer, msg *pb.Message) error {
+	switch msg.Payload.(type) {
+	case *pb.Message_TendermintProposal, *pb.Message_TendermintVote, *pb.Message_TendermintCommit:
+		select {
+		case e.msgCh <- msg:
+		case <-e.Done():
+		}
+	}
+	return nil
+}
+
+func (e *Engine) run(ctx context.Context) error {
+	// Wait for transactions before the first height, like after each commit.
+	e.schedule(commit)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case t := <-e.timeoutCh:
+			e.onTimeout(t)
+		case msg := <-e.msgCh:
+			if err := e.handleMessage(msg); err != nil {
+				log.Warnf("tendermint: failed processing message: %s", err)
+			}
+		}
+		e.process()
+	}
+}
+
+func (e *Engine) handleMessage(msg *pb.Message) error {
+	var height uint64
+	switch p := msg.Payload.(type) {
+	case *pb.Message_TendermintProposal:
+		height = p.TendermintProposal.Height
+	case *pb.Message_TendermintVote:
+		height = p.TendermintVote.Height
+	case *pb.Message_TendermintCommit:
+		return e.handleCommit(p.TendermintCommit)
+	}
+	if height < e.height {
+		e.serveCommit(msg, height)
+		return nil
+	}
+	if height > e.height {
+		if height == e.height+1 && len(e.future) < maxFuture {
+			e.future = append(e.future, msg)
+		}
+		e.announce()
+		return nil
+	}
+	switch p := msg.Payload.(type) {
+	case *pb.Message_TendermintProposal:
+		return e.handleProposal(p.TendermintProposal)
+	case *pb.Message_TendermintVote:
+		return e.handleVote(p.TendermintVote)
+	}
+	return nil
+}
+
+// handleCommit commits the block of the certificate if it holds the
+// precommits of more than 2/3 of the validators for it. Certificates of the
+// heights above the current one are kept until the height is reached.
+func (e *Engine) handleCommit(c *pb.TendermintCommit) error {
+	if c.Block == nil || c.Block.Header == nil {
+		return errInvalidCommit
+	}
+	height := uint64(c.Block.Header.Index)
+	if height < e.height || height >= e.height+maxCommits {
+		return nil
+	}
+	if _, ok := e.pending[height]; ok {
+		return nil
+	}
+	var (
+		hash    = c.Block.Hash()
+		signers = make(map[uint32]bool)
+	)
+	for _, v := range c.Precommits {
+		if v.Type != pb.TendermintVoteType_precommit || v.Height != height ||
+			v.Round != c.Round || !bytes.Equal(v.BlockHash, hash) {
+			continue
+		}
+		if verify(v, e.Validators.Get(int(v.Validator))) {
+			signers[v.Validator] = true
+		}
+	}
+	if len(signers) < e.quorum() {
+		return fmt.Errorf("commit of height %d without quorum", height)
+	}
+	e.pending[height] = c
+	if height > e.height {
+		e.announce()
+		return nil
+	}
+	e.catchUp()
+	return nil
+}
+
+// catchUp commits the blocks of the pending certificates of the current
+// height and the ones following it.
+func (e *Engine) catchUp() {
+	for {
+		c, ok := e.pending[e.height]
+		if !ok {
+			return
+		}
+		log.WithFields(log.Fields{
+			"height": e.height,
+		}).Info("tendermint: catching up with commit certificate")
+		e.commit(c.Block, c.Round, c.Precommits)
+	}
+}
+
+// announce sends the last vote of the validator again, so the validators
+// that decided its height serve their commit certificate. It is signed again,
+// so it is not dropped as a copy of the first one, and send at most once per
+// round timeout.
+func (e *Engine) announce() {
+	if e.lastVote == nil || time.Since(e.announced) < e.RoundTimeout {
+		return
+	}
+	e.announced = time.Now()
+	v := proto.Clone(e.lastVote).(*pb.TendermintVote)
+	if err := sign(v, e.privKey); err != nil {
+		log.Warnf("tendermint: failed to sign vote: %s", err)
+		return
+	}
+	e.Relay(e.relayCh, &pb.Message{
+		Payload: &pb.Message_TendermintVote{TendermintVote: v},
+	})
+}
+
+// serveCommit multicasts the commit certificates from the given height on if
+// the message is send by a validator that fell behind. Each certificate is
+// served at most once per round timeout.
+func (e *Engine) serveCommit(msg *pb.Message, height uint64) {
+	switch p := msg.Payload.(type) {
+	case *pb.Message_TendermintProposal:
+		if !verify(p.TendermintProposal, e.Validators.Get(int(p.TendermintProposal.Proposer))) {
+			return
+		}
+	case *pb.Message_TendermintVote:
+		if !verify(p.TendermintVote, e.Validators.Get(int(p.TendermintVote.Validator))) {
+			return
+		}
+	default:
+		return
+	}
+	for h := height; h < e.height; h++ {
+		cert, ok := e.commits[h]
+		if !ok || time.Since(cert.served) < e.RoundTimeout {
+			continue
+		}
+		cert.served = time.Now()
+		e.Relay(e.relayCh, &pb.Message{
+			Payload: &pb.Message_TendermintCommit{TendermintCommit: cert.commit},
+		})
+	}
+}
+
+func (e *Engine) handleProposal(p *pb.TendermintProposal) error {
+	if int(p.Proposer) != e.proposer(p.Height, p.Round) {
+		return fmt.Errorf("proposal from non proposer validator %d", p.Proposer)
+	}
+	if !verify(p, e.Validators.Get(int(p.Proposer))) {
+		return errInvalidSignature
+	}
+	if p.Block == nil || p.Block.Header == nil || uint64(p.Block.Header.Index) != p.Height ||
+		!bytes.Equal(p.Block.Header.PrevHash, e.head) {
+		return fmt.Errorf("invalid block for height %d", p.Height)
+	}
+	// A valid block of an earlier round is signed by its first proposer.
+	if pub, err := p.Block.Verify(); err != nil || e.Validators.Index(pub) < 0 {
+		return fmt.Errorf("block for height %d not signed by a validator", p.Height)
+	}
+	rs := e.roundState(p.Round)
+	if rs.proposal == nil {
+		rs.proposal = p
+		rs.digest = p.Block.Hash()
+	}
+	return nil
+}
+
+func (e *Engine) handleVote(v *pb.TendermintVote) error {
+	if !verify(v, e.Validators.Get(int(v.Validator))) {
+		return errInvalidSignature
+	}
+	rs := e.roundState(v.Round)
+	votes := rs.prevotes
+	if v.Type == pb.TendermintVoteType_precommit {
+		votes = rs.precommits
+	}
+	if _, ok := votes[v.Validator]; !ok {
+		votes[v.Validator] = v
+	}
+	return nil
+}
+
+// process applies the rules of the Tendermint state machine until none of
+// them triggers anymore.
+func (e *Engine) process() {
+	for e.applyRule() {
+	}
+}
+
+// applyRule applies the first rule that triggers and reports whether there
+// was any.
+func (e *Engine) applyRule() bool {
+	if e.step == commit {
+		return false
+	}
+	q := e.quorum()
+
+	// A block can be committed in any round of the height.
+	for _, rs := range e.rounds {
+		if rs.proposal != nil && e.count(rs.precommits, rs.digest) >= q {
+			e.commit(rs.proposal.Block, rs.proposal.Round, e.votesFor(rs.precommits, rs.digest))
+			e.catchUp()
+			return true
+		}
+	}
+	// Catch up with the others if f+1 validators are in a higher round,
+	// which means at least one correct validator is.
+	for r, rs := range e.rounds {
+		if r > e.round && e.voters(rs) >= e.weakQuorum() {
+			e.startRound(r)
+			return true
+		}
+	}
+
+	rs := e.roundState(e.round)
+	p := rs.proposal
+	switch {
+	case e.step == propose && p != nil && p.ValidRound < 0:
+		var hash []byte
+		if e.lockedRound < 0 || bytes.Equal(e.lockedBlock.Hash(), rs.digest) {
+			hash = rs.digest
+		}
+		e.vote(pb.TendermintVoteType_prevote, hash)
+	case e.step == propose && p != nil && p.ValidRound >= 0 && uint64(p.ValidRound) < e.round &&
+		e.count(e.roundState(uint64(p.ValidRound)).prevotes, rs.digest) >= q:
+		// The block was proposed again, because it got 2/3 of the prevotes
+		// in an earlier round. We can unlock if that round is not older
+		// than the one we locked in.
+		var hash []byte
+		if e.lockedRound <= p.ValidRound || bytes.Equal(e.lockedBlock.Hash(), rs.digest) {
+			hash = rs.digest
+		}
+		e.vote(pb.TendermintVoteType_prevote, hash)
+	case e.step == prevote && len(rs.prevotes) >= q && !rs.prevoteWait:
+		rs.prevoteWait = true
+		e.schedule(prevote)
+	case e.step >= prevote && p != nil && !rs.polka && e.count(rs.prevotes, rs.digest) >= q:
+		rs.polka = true
+		if e.step == prevote {
+			e.lockedBlock, e.lockedRound = p.Block, int64(e.round)
+			e.vote(pb.TendermintVoteType_precommit, rs.digest)
+		}
+		e.validBlock, e.validRound = p.Block, int64(e.round)
+	case e.step == prevote && e.count(rs.prevotes, nil) >= q:
+		e.vote(pb.TendermintVoteType_precommit, nil)
+	case len(rs.precommits) >= q && !rs.precommitWait:
+		rs.precommitWait = true
+		e.schedule(precommit)
+	default:
+		return false
+	}
+	return true
+}
+
+func (e *Engine) onTimeout(t timeout) {
+	if t.height != e.height {
+		return
+	}
+	switch t.step {
+	case commit:
+		if e.step == commit {
+			e.startRound(0)
+		}
+	case propose:
+		if t.round == e.round && e.step == propose {
+			e.vote(pb.TendermintVoteType_prevote, nil)
+		}
+	case prevote:
+		if t.round == e.round && e.step == prevote {
+			e.vote(pb.TendermintVoteType_precommit, nil)
+		}
+	case precommit:
+		if t.round == e.round {
+			e.startRound(e.round + 1)
+		}
+	}
+}
+
+func (e *Engine) startRound(r uint64) {
+	e.round = r
+	e.step = propose
+	if r > 0 {
+		log.WithFields(log.Fields{
+			"height": e.height,
+			"round":  r,
+		}).Info("tendermint: starting new round")
+	}
+	if e.index >= 0 && e.proposer(e.height, r) == e.index {
+		if err := e.propose(); err != nil {
+			log.Warnf("tendermint: failed to propose block: %s", err)
+		}
+		return
+	}
+	e.schedule(propose)
+}
+
+func (e *Engine) propose() error {
+	// A block that might have been decided in an earlier round needs to be
+	// proposed again.
+	block := e.validBlock
+	if block == nil {
+		block = pb.NewBlock(uint32(e.height - 1))
+		block.Transactions = e.pool.Batch(consensus.MaxBlockTxs, consensus.MaxBlockBytes)
+		block.Header.TxRoot = pb.TxRoot(block.Transactions)
+		block.Header.PrevHash = e.head
+		block.Header.Proposer = common.MarshalPublicKey(&e.privKey.PublicKey)
+		if err := block.Sign(e.privKey); err != nil {
+			return err
+		}
+	}
+	p := &pb.TendermintProposal{
+		Height:     e.height,
+		Round:      e.round,
+		Block:      block,
+		ValidRound: e.validRound,
+		Proposer:   uint32(e.index),
+	}
+	if err := sign(p, e.privKey); err != nil {
+		return err
+	}
+	return e.send(&pb.Message{
+		Payload: &pb.Message_TendermintProposal{TendermintProposal: p},
+	})
+}
+
+// vote moves the validator to the step of the given vote type and multicasts
+// its vote for the block with the given hash, nil votes have no hash.
+func (e *Engine) vote(typ pb.TendermintVoteType, hash []byte) {
+	e.step = prevote
+	if typ == pb.TendermintVoteType_precommit {
+		e.step = precommit
+	}
+	if e.index < 0 {
+		return
+	}
+	v := &pb.TendermintVote{
+		Type:      typ,
+		Height:    e.height,
+		Round:     e.round,
+		BlockHash: hash,
+		Validator: uint32(e.index),
+	}
+	if err := sign(v, e.privKey); err != nil {
+		log.Warnf("tendermint: failed to sign vote: %s", err)
+		return
+	}
+	e.lastVote = v
+	if err := e.send(&pb.Message{
+		Payload: &pb.Message_TendermintVote{TendermintVote: v},
+	}); err != nil {
+		log.Warnf("tendermint: failed to send vote: %s", err)
+	}
+}
+
+// commit commits the block decided in round r of the current height
+// with the given precommits for it.
+func (e *Engine) commit(block *pb.Block, r uint64, precommits []*pb.TendermintVote) {
+	e.pool.Remove(block.Transactions)
+
+	log.WithFields(log.Fields{
+		"height": e.height,
+		"round":  r,
+		"hash":   hex.EncodeToString(block.Hash()),
+		"txs":    len(block.Transactions),
+	}).Info("tendermint: committed block")
+
+	if e.proposer(e.height, r) == e.index {
+		e.Relay(e.relayCh, &pb.Message{
+			Payload: &pb.Message_Block{
+				Block: block,
+			},
+		})
+	}
+	e.head = block.Header.Hash()
+	e.commits[e.height] = &certificate{
+		commit: &pb.TendermintCommit{
+			Round:      r,
+			Block:      block,
+			Precommits: precommits,
+		},
+	}
+	delete(e.commits, e.height-maxCommits)
+	delete(e.pending, e.height)
+
+	e.height++
+	e.round = 0
+	e.step = commit
+	e.rounds = make(map[uint64]*round)
+	e.lockedBlock, e.lockedRound = nil, -1
+	e.validBlock, e.validRound = nil, -1
+	e.lastVote = nil
+	e.schedule(commit)
+
+	future := e.future
+	e.future = nil
+	for _, msg := range future {
+		if err := e.handleMessage(msg); err != nil {
+			log.Warnf("tendermint: failed processing message: %s", err)
+		}
+	}
+}
+
+// schedule fires a timeout for the given step of the current round.
+func (e *Engine) schedule(s step) {
+	var (
+		t = timeout{height: e.height, round: e.round, step: s}
+		d = e.RoundTimeout + time.Duration(e.round)*e.RoundTimeout/2
+	)
+	if s == commit {
+		d = e.BlockInterval
+	}
+	time.AfterFunc(d, func() {
+		select {
+		case e.timeoutCh <- t:
+		case <-e.Done():
+		}
+	})
+}
+
+func (e *Engine) roundState(r uint64) *round {
+	rs, ok := e.rounds[r]
+	if !ok {
+		rs = &round{
+			prevotes:   make(map[uint32]*pb.TendermintVote),
+			precommits: make(map[uint32]*pb.TendermintVote),
+		}
+		e.rounds[r] = rs
+	}
+	return rs
+}
+
+// count returns the amount of votes for the block with the given hash.
+func (e *Engine) count(votes map[uint32]*pb.TendermintVote, hash []byte) int {
+	n := 0
+	for _, v := range votes {
+		if bytes.Equal(v.BlockHash, hash) {
+			n++
+		}
+	}
+	return n
+}
+
+// votesFor returns the votes for the block with the given hash.
+func (e *Engine) votesFor(votes map[uint32]*pb.TendermintVote, hash []byte) []*pb.TendermintVote {
+	var found []*pb.TendermintVote
+	for _, v := range votes {
+		if bytes.Equal(v.BlockHash, hash) {
+			found = append(found, v)
+		}
+	}
+	return found
+}
+
+// voters returns the amount of validators that voted in the given round.
+func (e *Engine) voters(rs *round) int {
+	seen := make(map[uint32]bool)
+	for i := range rs.prevotes {
+		seen[i] = true
+	}
+	for i := range rs.precommits {
+		seen[i] = true
+	}
+	return len(seen)
+}
+
+// send processes the message locally and relays it into the network.
+func (e *Engine) send(msg *pb.Message) error {
+	e.Relay(e.relayCh, msg)
+	return e.handleMessage(msg)
+}
+
+func (e *Engine) proposer(height, round uint64) int {
+	return int((height + round) % uint64(len(e.Validators)))
+}
+
+// quorum returns the amount of votes needed to make progress, which is more
+// than 2/3 of the validators.
+func (e *Engine) quorum() int {
+	return 2*len(e.Validators)/3 + 1
+}
+
+// weakQuorum returns the amount of validators of which at least one is
+// correct, which is more than 1/3 of the validators.
+func (e *Engine) weakQuorum() int {
+	return len(e.Validators)/3 + 1
+}
@@ -0,0 +1,105 @@
+package tendermint
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/consensus/consensustest"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	proto "github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestNetwork returns a network of n engines, which are not started.
+func newTestNetwork(n int) *consensustest.Network {
+	return consensustest.NewNetwork(n, func(validators consensus.Validators) consensus.Engine {
+		return NewEngine(Config{
+			Validators:    validators,
+			BlockInterval: 20 * time.Millisecond,
+			RoundTimeout:  200 * time.Millisecond,
+		})
+	})
+}
+
+// mustSign signs the message with the key of the validator at index i.
+func mustSign(msg proto.Message, i int) {
+	if err := sign(msg, consensustest.PrivateKey(i)); err != nil {
+		panic(err)
+	}
+}
+
+func TestEngineCommit(t *testing.T) {
+	net := newTestNetwork(4)
+	net.Start()
+	defer net.Stop()
+	b := net.Receive(t.Fatalf).Block
+	assert.Equal(t, uint32(1), b.Header.Index)
+	assert.Equal(t, 1, len(b.Transactions))
+}
+
+func TestEngineRoundChange(t *testing.T) {
+	// The proposer of the first round is crashed.
+	net := newTestNetwork(4)
+	net.Start(1)
+	defer net.Stop()
+	assert.Equal(t, uint32(1), net.Receive(t.Fatalf).Block.Header.Index)
+}
+
+func TestEngineCatchUp(t *testing.T) {
+	net := newTestNetwork(4)
+	net.Start()
+	defer net.Stop()
+	net.SetDown(3, true)
+	net.Timeout = 10 * time.Second
+	var height uint32
+	for height < 4 {
+		height = net.Receive(t.Fatalf).Block.Header.Index
+	}
+	// Once connected again, the validator catches up with the heights it
+	// missed and proposes blocks of the current height.
+	net.SetDown(3, false)
+	net.Timeout = 20 * time.Second
+	for {
+		if b := net.Receive(t.Fatalf); b.From == 3 {
+			assert.True(t, b.Block.Header.Index > height)
+			return
+		}
+	}
+}
+
+func TestEngineEquivocatingProposer(t *testing.T) {
+	net := newTestNetwork(4)
+	// The proposer of the first round proposes a different block to each
+	// validator, none of which gets a polka.
+	net.SetByzantine(1, func(to int, msg *pb.Message) *pb.Message {
+		if p := msg.GetTendermintProposal(); p != nil {
+			p.Block.Header.Nonce = uint64(to)
+			mustSign(p, 1)
+		}
+		return msg
+	})
+	net.Start()
+	defer net.Stop()
+	// The block of the next round is committed instead.
+	b := net.Receive(t.Fatalf)
+	assert.Equal(t, uint32(1), b.Block.Header.Index)
+	assert.Equal(t, 2, b.From)
+}
+
+func TestEngineEquivocatingVotes(t *testing.T) {
+	net := newTestNetwork(4)
+	// A validator votes for a different block towards each validator.
+	net.SetByzantine(3, func(to int, msg *pb.Message) *pb.Message {
+		if v := msg.GetTendermintVote(); v != nil {
+			v.BlockHash = pb.NewBlock(uint32(to)).Hash()
+			mustSign(v, 3)
+		}
+		return msg
+	})
+	net.Start()
+	defer net.Stop()
+	for i := 0; i < 3; i++ {
+		assert.NotEqual(t, 3, net.Receive(t.Fatalf).From)
+	}
+}
@@ -0,0 +1,58 @@
+package tendermint
+
+import (
+	"crypto/ecdsa"
+	"errors"
+
+	"github.com/anthdm/consenter/pkg/common"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	proto "github.com/golang/protobuf/proto"
+)
+
+var (
+	errInvalidSignature = errors.New("invalid signature")
+	errInvalidCommit    = errors.New("commit without block")
+)
+
+// sign signs the given Tendermint message with priv and sets its signature.
+func sign(msg proto.Message, priv *ecdsa.PrivateKey) error {
+	sig, err := common.Sign(priv, signatureHash(msg))
+	if err != nil {
+		return err
+	}
+	switch m := msg.(type) {
+	case *pb.TendermintProposal:
+		m.Signature = sig
+	case *pb.TendermintVote:
+		m.Signature = sig
+	}
+	return nil
+}
+
+// verify reports whether the given Tendermint message is signed by pub.
+func verify(msg proto.Message, pub *ecdsa.PublicKey) bool {
+	var sig []byte
+	switch m := msg.(type) {
+	case *pb.TendermintProposal:
+		sig = m.Signature
+	case *pb.TendermintVote:
+		sig = m.Signature
+	}
+	return common.Verify(pub, signatureHash(msg), sig)
+}
+
+// signatureHash returns the hash of the message without its signature.
+func signatureHash(msg proto.Message) []byte {
+	msg = proto.Clone(msg)
+	switch m := msg.(type) {
+	case *pb.TendermintProposal:
+		m.Signature = nil
+	case *pb.TendermintVote:
+		m.Signature = nil
+	}
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+	return common.Hash256(b)
+}
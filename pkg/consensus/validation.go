@@ -0,0 +1,311 @@
+package consensus
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+
+	pb "github.com/anthdm/consenter/pkg/protos"
+)
+
+// txLookback is the amount of ancestors of a block its transactions are
+// checked against for duplicates.
+const txLookback = 256
+
+var (
+	// ErrUnknownParent is returned by the pipeline for blocks of which the
+	// parent is neither accepted nor stored in the chain, which might only
+	// arrive later.
+	ErrUnknownParent = errors.New("unknown parent")
+
+	errMissingHeader = errors.New("block without header")
+	errGenesisIndex  = errors.New("block with the index of the genesis block")
+	errTxRoot        = errors.New("tx root does not match the transactions")
+	errMissingSig    = errors.New("block without signature of its proposer")
+	errNotValidator  = errors.New("block not proposed by a validator")
+)
+
+// BlockValidator can optionally be implemented by engines to apply their
+// consensus specific rules to the blocks received from the network, which
+// includes checking who may propose them. If ValidatesBlocks reports true,
+// ValidateBlock is called outside of the engine loop, implementations should
+// only depend on the configuration of the engine.
+type BlockValidator interface {
+	ValidatesBlocks() bool
+	ValidateBlock(*pb.Block) error
+}
+
+// ValidatesBlocks reports whether the engine validates its blocks.
+func ValidatesBlocks(engine Engine) bool {
+	v, ok := engine.(BlockValidator)
+	return ok && v.ValidatesBlocks()
+}
+
+// BlockSigner can optionally be implemented by engines of which every block
+// is signed by its proposer. If SignsBlocks reports true, blocks without a
+// valid signature are rejected.
+type BlockSigner interface {
+	SignsBlocks() bool
+}
+
+// DifficultyValidator can optionally be implemented by engines of which the
+// blocks carry a difficulty, which depends on the ancestors of the block. If
+// ValidatesDifficulty reports true, ValidateDifficulty is called with the
+// chain the ancestors are read from, a block of which the parent is not part
+// of it yet is rejected with ErrUnknownParent. Only the chains of these
+// engines can be followed by their total work, see chain.HeaviestWork.
+type DifficultyValidator interface {
+	ValidatesDifficulty() bool
+	ValidateDifficulty(b *pb.Block, chain BlockReader) error
+}
+
+// ValidatesDifficulty reports whether the engine validates the difficulty of
+// its blocks.
+func ValidatesDifficulty(engine Engine) bool {
+	d, ok := engine.(DifficultyValidator)
+	return ok && d.ValidatesDifficulty()
+}
+
+// BlockReader is the store of the blocks that are already part of the chain.
+type BlockReader interface {
+	GetBlockByHash(hash []byte) (*pb.Block, error)
+}
+
+// acceptedBlock is a block that passed the pipeline.
+type acceptedBlock struct {
+	index uint32
+	// Hash of the parent, which is looked up in the accepted blocks.
+	prev string
+	txs  map[string]bool
+}
+
+// BlockPipeline runs the blocks received from the network through a series
+// of checks before they are accepted. Blocks are linked to their parent by
+// the PrevHash of their header. Not all engines set it, the blocks of those
+// engines extend the head of the chain, which is left to the chain to check.
+type BlockPipeline struct {
+	validator  BlockValidator
+	difficulty DifficultyValidator
+	signed     bool
+	validators Validators
+	chain      BlockReader
+
+	lock sync.Mutex
+	// Accepted blocks by the hash of their header. Blocks more than
+	// txLookback below the highest one are pruned.
+	blocks map[string]*acceptedBlock
+	height uint32
+}
+
+// NewBlockPipeline returns a new BlockPipeline that applies the rules of the
+// given engine if it implements the BlockValidator or BlockSigner interface.
+// Blocks of engines that do not validate their blocks need to be signed by
+// one of the validators. Parents that were not accepted by the pipeline are
+// looked up in the chain.
+func NewBlockPipeline(engine Engine, validators Validators, chain BlockReader) *BlockPipeline {
+	p := &BlockPipeline{
+		validators: validators,
+		chain:      chain,
+		blocks:     make(map[string]*acceptedBlock),
+	}
+	if ValidatesBlocks(engine) {
+		p.validator = engine.(BlockValidator)
+	}
+	if ValidatesDifficulty(engine) {
+		p.difficulty = engine.(DifficultyValidator)
+	}
+	if s, ok := engine.(BlockSigner); ok {
+		p.signed = s.SignsBlocks()
+	}
+	return p
+}
+
+// Validate checks the block and accepts it if it is valid. A block needs a
+// header with an index other than the one of the genesis block and needs to
+// be signed by its proposer, if it has one or the engine signs its blocks.
+// Unless the engine validates its blocks, the proposer needs to be one of
+// the validators. The TxRoot of its header needs to match its transactions,
+// which need to be signed by their sender and which it may not hold twice.
+// If it refers to a parent, the parent needs to be known and the block needs
+// to follow it, without holding transactions of its ancestors. Finally the
+// block needs to pass the ValidateBlock and ValidateDifficulty hooks of the
+// engine.
+func (p *BlockPipeline) Validate(b *pb.Block) error {
+	h := b.Header
+	if h == nil {
+		return errMissingHeader
+	}
+	if h.Index == 0 {
+		return errGenesisIndex
+	}
+	if err := p.checkProposer(b); err != nil {
+		return fmt.Errorf("block %d: %s", h.Index, err)
+	}
+	txs := make(map[string]bool, len(b.Transactions))
+	for _, tx := range b.Transactions {
+		hash := string(tx.Hash())
+		if txs[hash] {
+			return fmt.Errorf("block %d holds tx %s twice",
+				h.Index, hex.EncodeToString(tx.Hash()))
+		}
+		if _, err := tx.Verify(); err != nil {
+			return fmt.Errorf("block %d holds invalid tx %s: %s",
+				h.Index, hex.EncodeToString(tx.Hash()), err)
+		}
+		txs[hash] = true
+	}
+	if !bytes.Equal(h.TxRoot, pb.TxRoot(b.Transactions)) {
+		return fmt.Errorf("block %d: %s", h.Index, errTxRoot)
+	}
+	if err := p.checkParent(h, txs); err != nil {
+		return err
+	}
+	if p.validator != nil {
+		if err := p.validator.ValidateBlock(b); err != nil {
+			return err
+		}
+	}
+	if p.difficulty != nil {
+		if err := p.difficulty.ValidateDifficulty(b, p.chain); err != nil {
+			return err
+		}
+	}
+	p.accept(b.Header, txs)
+	return nil
+}
+
+// Accept accepts the block without validating it, which is used for the
+// blocks produced by the node itself.
+func (p *BlockPipeline) Accept(b *pb.Block) {
+	if b.Header == nil {
+		return
+	}
+	txs := make(map[string]bool, len(b.Transactions))
+	for _, tx := range b.Transactions {
+		txs[string(tx.Hash())] = true
+	}
+	p.accept(b.Header, txs)
+}
+
+// checkProposer checks the signature of the proposer of the block, which is
+// required if the engine signs its blocks or leaves their validation to the
+// validator set.
+func (p *BlockPipeline) checkProposer(b *pb.Block) error {
+	var (
+		h        = b.Header
+		required = p.signed || p.validator == nil
+	)
+	if !required && len(h.Proposer) == 0 && len(b.Signature) == 0 {
+		return nil
+	}
+	if len(b.Signature) == 0 {
+		return errMissingSig
+	}
+	pub, err := b.Verify()
+	if err != nil {
+		return err
+	}
+	if p.validator == nil && p.validators.Index(pub) < 0 {
+		return errNotValidator
+	}
+	return nil
+}
+
+// checkParent checks that the block follows its parent and holds none of
+// the transactions of its ancestors. Blocks with index 1 follow the genesis
+// block, blocks that do not refer to their parent are not checked.
+func (p *BlockPipeline) checkParent(h *pb.Header, txs map[string]bool) error {
+	if h.Index == 1 || len(h.PrevHash) == 0 {
+		return nil
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	parent := p.lookup(string(h.PrevHash))
+	if parent == nil {
+		return ErrUnknownParent
+	}
+	if h.Index != parent.index+1 {
+		return fmt.Errorf("block %d does not follow its parent %d",
+			h.Index, parent.index)
+	}
+	ancestor := parent
+	for i := 0; i < txLookback && ancestor != nil; i++ {
+		for hash := range txs {
+			if ancestor.txs[hash] {
+				return fmt.Errorf("block %d holds tx %s of block %d",
+					h.Index, hex.EncodeToString([]byte(hash)), ancestor.index)
+			}
+		}
+		ancestor = p.blocks[ancestor.prev]
+	}
+	return nil
+}
+
+// lookup returns the accepted block with the given hash. Blocks that are
+// not accepted are loaded from the chain, along with their ancestors within
+// the lookback. The lock needs to be held by the caller.
+func (p *BlockPipeline) lookup(hash string) *acceptedBlock {
+	if b, ok := p.blocks[hash]; ok {
+		return b
+	}
+	if p.chain == nil {
+		return nil
+	}
+	var first *acceptedBlock
+	for i := 0; i < txLookback && len(hash) > 0; i++ {
+		if _, ok := p.blocks[hash]; ok {
+			break
+		}
+		b, err := p.chain.GetBlockByHash([]byte(hash))
+		if err != nil || b.Header == nil {
+			break
+		}
+		txs := make(map[string]bool, len(b.Transactions))
+		for _, tx := range b.Transactions {
+			txs[string(tx.Hash())] = true
+		}
+		ab := p.insert(hash, b.Header, txs)
+		if first == nil {
+			first = ab
+		}
+		hash = string(b.Header.PrevHash)
+	}
+	return first
+}
+
+func (p *BlockPipeline) accept(h *pb.Header, txs map[string]bool) {
+	// Blocks that do not refer to their parent are never looked up.
+	if h.Index != 1 && len(h.PrevHash) == 0 {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.insert(string(h.Hash()), h, txs)
+}
+
+// insert adds the block to the accepted blocks and prunes the ones that are
+// too far below the highest block to be checked against. The lock needs to
+// be held by the caller.
+func (p *BlockPipeline) insert(hash string, h *pb.Header, txs map[string]bool) *acceptedBlock {
+	b := &acceptedBlock{
+		index: h.Index,
+		prev:  string(h.PrevHash),
+		txs:   txs,
+	}
+	p.blocks[hash] = b
+	if h.Index <= p.height {
+		return b
+	}
+	p.height = h.Index
+	if p.height <= txLookback {
+		return b
+	}
+	for k, ab := range p.blocks {
+		if ab.index < p.height-txLookback {
+			delete(p.blocks, k)
+		}
+	}
+	return b
+}
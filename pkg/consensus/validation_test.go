@@ -0,0 +1,230 @@
+package consensus
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/anthdm/consenter/pkg/common"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/stretchr/testify/assert"
+)
+
+var errRejected = errors.New("rejected by engine")
+
+type validatingEngine struct {
+	testEngine
+}
+
+func (validatingEngine) ValidatesBlocks() bool { return true }
+
+func (validatingEngine) ValidateBlock(b *pb.Block) error {
+	if b.Header.Nonce == 0 {
+		return errRejected
+	}
+	return nil
+}
+
+type signingEngine struct {
+	testEngine
+}
+
+func (signingEngine) SignsBlocks() bool { return true }
+
+// blockReader is a BlockReader of the blocks in the map.
+type blockReader map[string]*pb.Block
+
+func (r blockReader) GetBlockByHash(hash []byte) (*pb.Block, error) {
+	if b, ok := r[string(hash)]; ok {
+		return b, nil
+	}
+	return nil, errors.New("not found")
+}
+
+var client = common.NewPrivateKey([]byte("client"))
+
+// newTx returns a new tx signed by the client.
+func newTx(t *testing.T) *pb.Transaction {
+	tx := pb.NewTransaction()
+	assert.Nil(t, tx.Sign(client))
+	return tx
+}
+
+// newChildBlock returns a block following the given parent.
+func newChildBlock(parent *pb.Block, txs ...*pb.Transaction) *pb.Block {
+	b := pb.NewBlock(parent.Header.Index)
+	b.Header.PrevHash = parent.Header.Hash()
+	b.Transactions = txs
+	b.Header.TxRoot = pb.TxRoot(txs)
+	return b
+}
+
+func TestBlockPipelineValidate(t *testing.T) {
+	var (
+		p     = NewBlockPipeline(validatingEngine{}, nil, nil)
+		tx    = newTx(t)
+		first = pb.NewBlock(0)
+	)
+	first.Transactions = []*pb.Transaction{tx}
+	first.Header.TxRoot = pb.TxRoot(first.Transactions)
+	assert.Nil(t, p.Validate(first))
+	assert.Nil(t, p.Validate(newChildBlock(first, newTx(t))))
+
+	// Index continuity.
+	b := newChildBlock(first)
+	b.Header.Index = 3
+	assert.NotNil(t, p.Validate(b))
+
+	// Genesis index and missing header.
+	assert.NotNil(t, p.Validate(pb.NewBlock(0xffffffff)))
+	assert.NotNil(t, p.Validate(&pb.Block{}))
+
+	// Transaction uniqueness within the block and its ancestors.
+	other := newTx(t)
+	assert.NotNil(t, p.Validate(newChildBlock(first, other, other)))
+	assert.NotNil(t, p.Validate(newChildBlock(first, tx)))
+
+	// Transactions that are not signed by their sender.
+	forged := newTx(t)
+	forged.Payload = []byte("forged")
+	assert.NotNil(t, p.Validate(newChildBlock(first, forged)))
+
+	// Transactions that do not match the root of the header.
+	b = newChildBlock(first, newTx(t))
+	b.Transactions = append(b.Transactions, newTx(t))
+	assert.NotNil(t, p.Validate(b))
+	b.Header.TxRoot = nil
+	assert.NotNil(t, p.Validate(b))
+
+	// Engine hook.
+	b = newChildBlock(first)
+	b.Header.Nonce = 0
+	assert.Equal(t, errRejected, p.Validate(b))
+}
+
+func TestBlockPipelineSignature(t *testing.T) {
+	var (
+		priv = common.NewPrivateKey([]byte("node_0"))
+		p    = NewBlockPipeline(testEngine{}, Validators{&priv.PublicKey}, nil)
+		b    = pb.NewBlock(0)
+	)
+	b.Header.Proposer = common.MarshalPublicKey(&priv.PublicKey)
+	assert.NotNil(t, p.Validate(b))
+	assert.Nil(t, b.Sign(priv))
+	assert.Nil(t, p.Validate(b))
+
+	b = pb.NewBlock(0)
+	b.Header.Proposer = common.MarshalPublicKey(&priv.PublicKey)
+	assert.Nil(t, b.Sign(common.NewPrivateKey([]byte("node_1"))))
+	assert.NotNil(t, p.Validate(b))
+}
+
+func TestBlockPipelineValidators(t *testing.T) {
+	var (
+		priv     = common.NewPrivateKey([]byte("node_0"))
+		outsider = common.NewPrivateKey([]byte("outsider"))
+		p        = NewBlockPipeline(testEngine{}, Validators{&priv.PublicKey}, nil)
+		b        = pb.NewBlock(0)
+	)
+	// Engines that do not validate their blocks rely on the signature of a
+	// validator.
+	assert.NotNil(t, p.Validate(b))
+	b.Header.Proposer = common.MarshalPublicKey(&outsider.PublicKey)
+	assert.Nil(t, b.Sign(outsider))
+	assert.NotNil(t, p.Validate(b))
+	b.Header.Proposer = common.MarshalPublicKey(&priv.PublicKey)
+	assert.Nil(t, b.Sign(priv))
+	assert.Nil(t, p.Validate(b))
+}
+
+func TestBlockPipelineAccept(t *testing.T) {
+	var (
+		p     = NewBlockPipeline(validatingEngine{}, nil, nil)
+		tx    = newTx(t)
+		first = pb.NewBlock(0)
+	)
+	first.Transactions = []*pb.Transaction{tx}
+	p.Accept(first)
+	// Blocks of the node itself are linked like validated ones.
+	assert.NotNil(t, p.Validate(newChildBlock(first, tx)))
+	assert.Nil(t, p.Validate(newChildBlock(first)))
+}
+
+func TestBlockPipelineRequiresSignature(t *testing.T) {
+	var (
+		priv = common.NewPrivateKey([]byte("node_0"))
+		p    = NewBlockPipeline(signingEngine{}, Validators{&priv.PublicKey}, nil)
+		b    = pb.NewBlock(0)
+	)
+	assert.NotNil(t, p.Validate(b))
+	b.Header.Proposer = common.MarshalPublicKey(&priv.PublicKey)
+	assert.Nil(t, b.Sign(priv))
+	assert.Nil(t, p.Validate(b))
+}
+
+func TestBlockPipelineUnknownParent(t *testing.T) {
+	var (
+		tx    = newTx(t)
+		first = pb.NewBlock(0)
+		chain = blockReader{}
+		p     = NewBlockPipeline(validatingEngine{}, nil, chain)
+	)
+	first.Transactions = []*pb.Transaction{tx}
+	first.Header.TxRoot = pb.TxRoot(first.Transactions)
+	second := newChildBlock(first)
+	assert.Equal(t, ErrUnknownParent, p.Validate(second))
+
+	// Parents that are part of the chain are loaded from it.
+	chain[string(first.Header.Hash())] = first
+	assert.Nil(t, p.Validate(second))
+	assert.NotNil(t, p.Validate(newChildBlock(second, tx)))
+}
+
+func TestBlockPipelinePrune(t *testing.T) {
+	var (
+		p     = NewBlockPipeline(validatingEngine{}, nil, nil)
+		first = pb.NewBlock(0)
+		b     = first
+	)
+	assert.Nil(t, p.Validate(first))
+	for i := 0; i < 2*txLookback; i++ {
+		b = newChildBlock(b)
+		assert.Nil(t, p.Validate(b))
+	}
+	assert.Equal(t, txLookback+1, len(p.blocks))
+	// Blocks on top of pruned ones can no longer be linked.
+	assert.Equal(t, ErrUnknownParent, p.Validate(newChildBlock(first)))
+}
+
+type difficultyEngine struct {
+	validatingEngine
+}
+
+func (difficultyEngine) ValidatesDifficulty() bool { return true }
+
+// ValidateDifficulty expects the difficulty of the parent plus one.
+func (difficultyEngine) ValidateDifficulty(b *pb.Block, chain BlockReader) error {
+	parent, err := chain.GetBlockByHash(b.Header.PrevHash)
+	if err != nil {
+		return ErrUnknownParent
+	}
+	if b.Header.Difficulty != parent.Header.Difficulty+1 {
+		return errRejected
+	}
+	return nil
+}
+
+func TestBlockPipelineDifficulty(t *testing.T) {
+	var (
+		first = pb.NewBlock(0)
+		chain = blockReader{string(first.Header.Hash()): first}
+		p     = NewBlockPipeline(difficultyEngine{}, nil, chain)
+		b     = newChildBlock(first)
+	)
+	assert.True(t, ValidatesDifficulty(difficultyEngine{}))
+	assert.False(t, ValidatesDifficulty(testEngine{}))
+	assert.Equal(t, errRejected, p.Validate(b))
+	b.Header.Difficulty = 1
+	assert.Nil(t, p.Validate(b))
+	// The ancestors are read from the chain.
+	assert.Equal(t, ErrUnknownParent, p.Validate(newChildBlock(b)))
+}
@@ -0,0 +1,44 @@
+package consensus
+
+import (
+	"crypto/ecdsa"
+
+	"github.com/anthdm/consenter/pkg/common"
+)
+
+// Validators is an ordered set of public keys of the nodes participating in
+// consensus. The position of a key in the set is used to identify the node
+// in consensus messages.
+type Validators []*ecdsa.PublicKey
+
+// NewValidators returns the validator set derived from the given seeds. See
+// common.NewPrivateKey.
+func NewValidators(seeds []string) Validators {
+	v := make(Validators, len(seeds))
+	for i, seed := range seeds {
+		v[i] = &common.NewPrivateKey([]byte(seed)).PublicKey
+	}
+	return v
+}
+
+// Index returns the position of pub in the set or -1 if pub is not a
+// validator.
+func (v Validators) Index(pub *ecdsa.PublicKey) int {
+	if pub == nil {
+		return -1
+	}
+	for i, k := range v {
+		if k.X.Cmp(pub.X) == 0 && k.Y.Cmp(pub.Y) == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// Get returns the public key at index i or nil if it is out of range.
+func (v Validators) Get(i int) *ecdsa.PublicKey {
+	if i < 0 || i >= len(v) {
+		return nil
+	}
+	return v[i]
+}
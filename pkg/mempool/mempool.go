@@ -0,0 +1,336 @@
+package mempool
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	pb "github.com/anthdm/consenter/pkg/protos"
+	proto "github.com/golang/protobuf/proto"
+)
+
+// Default limits of the mempool.
+const (
+	DefaultMaxTxs   = 10000
+	DefaultMaxBytes = 16 << 20
+)
+
+var (
+	// ErrKnown is returned when adding a transaction that is already pending.
+	ErrKnown = errors.New("tx already in mempool")
+	// ErrTooLarge is returned when adding a transaction that exceeds the byte
+	// limit of the mempool by itself.
+	ErrTooLarge = errors.New("tx exceeds the byte limit of the mempool")
+	// ErrFull is returned when adding a transaction to a full mempool that
+	// has no transactions of lower priority to make room for it.
+	ErrFull = errors.New("mempool is full")
+)
+
+// Order is the order in which pending transactions are prioritized.
+type Order int
+
+const (
+	// ByFee prioritizes the transactions with the highest fee, transactions
+	// with the same fee by arrival.
+	ByFee Order = iota
+	// ByArrival prioritizes the transactions that arrived first.
+	ByArrival
+)
+
+// ParseOrder returns the order with the given name, "fee" or "arrival".
+func ParseOrder(name string) (Order, error) {
+	switch name {
+	case "fee":
+		return ByFee, nil
+	case "arrival":
+		return ByArrival, nil
+	default:
+		return 0, fmt.Errorf("invalid mempool order %s", name)
+	}
+}
+
+// Config holds the configuration of the mempool.
+type Config struct {
+	// Maximum amount of pending transactions, DefaultMaxTxs if left 0.
+	MaxTxs int
+	// Maximum size in bytes of the pending transactions, DefaultMaxBytes if
+	// left 0.
+	MaxBytes int
+	// Order of the pending transactions.
+	Order Order
+}
+
+// State provides the sequence numbers of the senders of the transactions,
+// like the replica of the state machine.
+type State interface {
+	// Sequence returns the sequence number of the next transaction of the
+	// sender.
+	Sequence(sender []byte) uint64
+}
+
+// entry is a pending transaction.
+type entry struct {
+	tx      *pb.Transaction
+	hash    string
+	size    int
+	arrival uint64
+}
+
+// Pool holds the transactions that are not part of a committed block yet,
+// ordered by their priority. If the pool is full, transactions of the lowest
+// priority are evicted to make room for new ones. It is safe for concurrent
+// use, hence it can be shared by a node and its engine.
+type Pool struct {
+	Config
+
+	lock sync.RWMutex
+	// Pending transactions from the highest priority to the lowest.
+	entries []*entry
+	hashes  map[string]*entry
+	bytes   int
+	arrival uint64
+	// State the transactions of the batches are applied to, if known.
+	state State
+}
+
+// New returns a new empty Pool.
+func New(cfg Config) *Pool {
+	if cfg.MaxTxs <= 0 {
+		cfg.MaxTxs = DefaultMaxTxs
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = DefaultMaxBytes
+	}
+	return &Pool{
+		Config: cfg,
+		hashes: make(map[string]*entry),
+	}
+}
+
+// Add adds the transaction to the pool, evicting transactions of lower
+// priority if the pool is full.
+func (p *Pool) Add(tx *pb.Transaction) error {
+	ent := &entry{
+		tx:   tx,
+		hash: string(tx.Hash()),
+		size: proto.Size(tx),
+	}
+	if ent.size > p.MaxBytes {
+		return ErrTooLarge
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if _, ok := p.hashes[ent.hash]; ok {
+		return ErrKnown
+	}
+	ent.arrival = p.arrival
+	i := sort.Search(len(p.entries), func(i int) bool {
+		return p.before(ent, p.entries[i])
+	})
+	// The transactions after i are evicted until the transaction fits.
+	var (
+		evicted = make(map[*entry]bool)
+		n       = len(p.entries) + 1
+		bytes   = p.bytes + ent.size
+	)
+	for j := len(p.entries) - 1; j >= i && (n > p.MaxTxs || bytes > p.MaxBytes); {
+		if evicted[p.entries[j]] {
+			j--
+			continue
+		}
+		victim := p.lastOfSender(p.entries[j], i, evicted)
+		evicted[victim] = true
+		n--
+		bytes -= victim.size
+	}
+	if n > p.MaxTxs || bytes > p.MaxBytes {
+		return ErrFull
+	}
+	entries := make([]*entry, 0, n)
+	entries = append(entries, p.entries[:i]...)
+	entries = append(entries, ent)
+	for _, e := range p.entries[i:] {
+		if evicted[e] {
+			delete(p.hashes, e.hash)
+			continue
+		}
+		entries = append(entries, e)
+	}
+	p.entries = entries
+	p.hashes[ent.hash] = ent
+	p.bytes = bytes
+	p.arrival++
+	return nil
+}
+
+// lastOfSender returns the pending transaction with the highest sequence of
+// the sender of ent, from the transactions from position i on that are not
+// evicted yet. Evicting it leaves the lower sequences of the sender
+// applicable.
+func (p *Pool) lastOfSender(ent *entry, i int, evicted map[*entry]bool) *entry {
+	last := ent
+	if len(ent.tx.Sender) == 0 {
+		return last
+	}
+	for _, e := range p.entries[i:] {
+		if evicted[e] || !bytes.Equal(e.tx.Sender, ent.tx.Sender) {
+			continue
+		}
+		if e.tx.Sequence > last.tx.Sequence {
+			last = e
+		}
+	}
+	return last
+}
+
+// Remove removes the transactions from the pool, which is called once they
+// are part of a committed block. Transactions that are not pending are
+// ignored.
+func (p *Pool) Remove(txs []*pb.Transaction) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	removed := false
+	for _, tx := range txs {
+		hash := string(tx.Hash())
+		if ent, ok := p.hashes[hash]; ok {
+			delete(p.hashes, hash)
+			p.bytes -= ent.size
+			removed = true
+		}
+	}
+	if !removed {
+		return
+	}
+	entries := p.entries[:0]
+	for _, ent := range p.entries {
+		if _, ok := p.hashes[ent.hash]; ok {
+			entries = append(entries, ent)
+		}
+	}
+	for i := len(entries); i < len(p.entries); i++ {
+		p.entries[i] = nil
+	}
+	p.entries = entries
+}
+
+// UseState makes the batches start the transactions of each sender at the
+// sequence of the sender in the given state.
+func (p *Pool) UseState(state State) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.state = state
+}
+
+// Batch returns the pending transactions of the highest priority, at most
+// maxTxs transactions with a total size of at most maxBytes. A limit of 0
+// means no limit. The transactions of a sender are only included in unbroken
+// sequence, starting at the sequence of the sender in the state, or at the
+// lowest pending sequence of the sender if the pool does not know the state.
+// The transactions stay pending until they are removed.
+func (p *Pool) Batch(maxTxs, maxBytes int) []*pb.Transaction {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	var (
+		txs   = []*pb.Transaction{}
+		bytes int
+		next  = p.nextSequences()
+		// Transactions waiting for the lower sequences of their sender, by
+		// sender and sequence.
+		waiting = make(map[string]map[uint64]*entry)
+	)
+	full := func() bool {
+		return maxTxs > 0 && len(txs) == maxTxs
+	}
+	// add adds the transaction if it fits and reports whether it did.
+	add := func(ent *entry) bool {
+		// Smaller transactions of lower priority might still fit.
+		if maxBytes > 0 && bytes+ent.size > maxBytes {
+			return false
+		}
+		txs = append(txs, ent.tx)
+		bytes += ent.size
+		return true
+	}
+	for _, ent := range p.entries {
+		if full() {
+			break
+		}
+		sender := string(ent.tx.Sender)
+		if len(sender) == 0 {
+			add(ent)
+			continue
+		}
+		if ent.tx.Sequence != next[sender] {
+			if ent.tx.Sequence > next[sender] {
+				if waiting[sender] == nil {
+					waiting[sender] = make(map[uint64]*entry)
+				}
+				waiting[sender][ent.tx.Sequence] = ent
+			}
+			continue
+		}
+		// The transactions of the sender that waited for this one follow it.
+		for add(ent) && !full() {
+			next[sender]++
+			var ok bool
+			if ent, ok = waiting[sender][next[sender]]; !ok {
+				break
+			}
+		}
+	}
+	return txs
+}
+
+// nextSequences returns the sequence at which the transactions of each
+// pending sender start.
+func (p *Pool) nextSequences() map[string]uint64 {
+	next := make(map[string]uint64)
+	for _, ent := range p.entries {
+		sender := string(ent.tx.Sender)
+		if len(sender) == 0 {
+			continue
+		}
+		if p.state != nil {
+			if _, ok := next[sender]; !ok {
+				next[sender] = p.state.Sequence(ent.tx.Sender)
+			}
+			continue
+		}
+		if seq, ok := next[sender]; !ok || ent.tx.Sequence < seq {
+			next[sender] = ent.tx.Sequence
+		}
+	}
+	return next
+}
+
+// Has reports whether the transaction with the given hash is pending.
+func (p *Pool) Has(hash []byte) bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	_, ok := p.hashes[string(hash)]
+	return ok
+}
+
+// Len returns the amount of pending transactions.
+func (p *Pool) Len() int {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return len(p.entries)
+}
+
+// Size returns the total size in bytes of the pending transactions.
+func (p *Pool) Size() int {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.bytes
+}
+
+// before reports whether a has a higher priority than b.
+func (p *Pool) before(a, b *entry) bool {
+	if p.Order == ByFee && a.tx.Fee != b.tx.Fee {
+		return a.tx.Fee > b.tx.Fee
+	}
+	return a.arrival < b.arrival
+}
@@ -0,0 +1,179 @@
+package mempool
+
+import (
+	"fmt"
+	"testing"
+
+	pb "github.com/anthdm/consenter/pkg/protos"
+	proto "github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+var nTxs int
+
+// newTx returns a unique transaction with the given fee. Transactions with
+// fees below 128 are all of the same size.
+func newTx(fee uint64) *pb.Transaction {
+	nTxs++
+	return &pb.Transaction{
+		Payload: []byte(fmt.Sprintf("%08d", nTxs)),
+		Fee:     fee,
+	}
+}
+
+func TestPoolAdd(t *testing.T) {
+	var (
+		p  = New(Config{})
+		tx = newTx(1)
+	)
+	assert.Nil(t, p.Add(tx))
+	assert.Equal(t, ErrKnown, p.Add(tx))
+	assert.True(t, p.Has(tx.Hash()))
+	assert.Equal(t, 1, p.Len())
+	assert.Equal(t, proto.Size(tx), p.Size())
+
+	large := newTx(1)
+	large.Payload = make([]byte, DefaultMaxBytes)
+	assert.Equal(t, ErrTooLarge, p.Add(large))
+}
+
+func TestPoolOrder(t *testing.T) {
+	var (
+		byFee     = New(Config{})
+		byArrival = New(Config{Order: ByArrival})
+		txs       = []*pb.Transaction{newTx(1), newTx(3), newTx(2), newTx(3)}
+	)
+	for _, tx := range txs {
+		assert.Nil(t, byFee.Add(tx))
+		assert.Nil(t, byArrival.Add(tx))
+	}
+	// Transactions with the same fee are ordered by arrival.
+	assert.Equal(t, []*pb.Transaction{txs[1], txs[3], txs[2], txs[0]}, byFee.Batch(0, 0))
+	assert.Equal(t, txs, byArrival.Batch(0, 0))
+	assert.Equal(t, txs[:2], byArrival.Batch(2, 0))
+}
+
+func TestPoolSequenceOrder(t *testing.T) {
+	var (
+		p   = New(Config{})
+		txs = []*pb.Transaction{newTx(1), newTx(3), newTx(2), newTx(4)}
+	)
+	// The first three are send by the same sender, the lowest sequence has
+	// the lowest fee.
+	for i, tx := range txs[:3] {
+		tx.Sender = []byte("sender")
+		tx.Sequence = uint64(i)
+	}
+	for _, tx := range txs {
+		assert.Nil(t, p.Add(tx))
+	}
+	assert.Equal(t, []*pb.Transaction{txs[3], txs[0], txs[1], txs[2]}, p.Batch(0, 0))
+}
+
+// sequences is a State holding the sequences of the senders.
+type sequences map[string]uint64
+
+func (s sequences) Sequence(sender []byte) uint64 {
+	return s[string(sender)]
+}
+
+// newSenderTx returns a transaction of the sender with the given sequence
+// and fee.
+func newSenderTx(sender string, seq, fee uint64) *pb.Transaction {
+	tx := newTx(fee)
+	tx.Sender = []byte(sender)
+	tx.Sequence = seq
+	return tx
+}
+
+func TestPoolSequenceGap(t *testing.T) {
+	var (
+		p   = New(Config{})
+		txs = []*pb.Transaction{
+			newSenderTx("a", 0, 1),
+			newSenderTx("a", 1, 1),
+			newSenderTx("a", 3, 1),
+			newSenderTx("b", 1, 1),
+		}
+	)
+	for _, tx := range txs {
+		assert.Nil(t, p.Add(tx))
+	}
+	// The transaction following the gap is left out.
+	assert.Equal(t, []*pb.Transaction{txs[0], txs[1], txs[3]}, p.Batch(0, 0))
+
+	// The state applied the first transaction of a and is missing the first
+	// one of b.
+	p.UseState(sequences{"a": 1})
+	assert.Equal(t, []*pb.Transaction{txs[1]}, p.Batch(0, 0))
+}
+
+func TestPoolEviction(t *testing.T) {
+	var (
+		p   = New(Config{MaxTxs: 2})
+		low = newTx(1)
+		mid = newTx(2)
+	)
+	assert.Nil(t, p.Add(mid))
+	assert.Nil(t, p.Add(low))
+
+	high := newTx(3)
+	assert.Nil(t, p.Add(high))
+	assert.False(t, p.Has(low.Hash()))
+	assert.Equal(t, []*pb.Transaction{high, mid}, p.Batch(0, 0))
+
+	// Transactions that do not outrank any pending one are rejected.
+	assert.Equal(t, ErrFull, p.Add(newTx(2)))
+	assert.Equal(t, 2, p.Len())
+}
+
+func TestPoolByteLimit(t *testing.T) {
+	var (
+		size = proto.Size(newTx(1))
+		p    = New(Config{MaxBytes: 2*size + 1})
+		txs  = []*pb.Transaction{newTx(2), newTx(2), newTx(3)}
+	)
+	assert.Nil(t, p.Add(txs[0]))
+	assert.Nil(t, p.Add(txs[1]))
+	assert.Nil(t, p.Add(txs[2]))
+	assert.Equal(t, []*pb.Transaction{txs[2], txs[0]}, p.Batch(0, 0))
+	assert.True(t, p.Size() <= p.MaxBytes)
+
+	assert.Equal(t, []*pb.Transaction{txs[2]}, p.Batch(0, size+1))
+}
+
+func TestPoolRemove(t *testing.T) {
+	var (
+		p   = New(Config{})
+		txs = []*pb.Transaction{newTx(1), newTx(2), newTx(3)}
+	)
+	for _, tx := range txs {
+		assert.Nil(t, p.Add(tx))
+	}
+	p.Remove([]*pb.Transaction{txs[1], newTx(4)})
+	assert.Equal(t, 2, p.Len())
+	assert.False(t, p.Has(txs[1].Hash()))
+	assert.Equal(t, proto.Size(txs[0])+proto.Size(txs[2]), p.Size())
+	assert.Equal(t, []*pb.Transaction{txs[2], txs[0]}, p.Batch(0, 0))
+}
+
+func TestPoolEvictionBySequence(t *testing.T) {
+	var (
+		p   = New(Config{MaxTxs: 3})
+		txs = []*pb.Transaction{
+			newSenderTx("a", 0, 1),
+			newSenderTx("a", 1, 3),
+			newSenderTx("a", 2, 2),
+		}
+	)
+	for _, tx := range txs {
+		assert.Nil(t, p.Add(tx))
+	}
+	// The transaction with the lowest fee is the first of the sender, which
+	// is kept as the following ones depend on it.
+	high := newTx(4)
+	assert.Nil(t, p.Add(high))
+	assert.True(t, p.Has(txs[0].Hash()))
+	assert.False(t, p.Has(txs[2].Hash()))
+	assert.Equal(t, []*pb.Transaction{high, txs[0], txs[1]}, p.Batch(0, 0))
+}
@@ -0,0 +1,141 @@
+package network
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/anthdm/consenter/pkg/common"
+	"github.com/anthdm/consenter/pkg/consensus"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	log "github.com/sirupsen/logrus"
+)
+
+// challengeSize is the size of the challenge of the identity handshake.
+const challengeSize = 32
+
+// identityDomain is prefixed to the challenge before it is signed. The
+// challenge is chosen by the peer, without the prefix it could make the node
+// sign the hash of a transaction or block.
+var identityDomain = []byte("consenter/identity")
+
+var errUnrequestedIdentity = errors.New("identity was not requested")
+
+// directMessage is a consensus message the engine addresses to a single
+// validator.
+type directMessage struct {
+	pub *ecdsa.PublicKey
+	msg *pb.Message
+}
+
+// SendTo implements the consensus.Sender interface. The message is handed to
+// the run loop, which owns the peers.
+func (s *Server) SendTo(pub *ecdsa.PublicKey, msg *pb.Message) {
+	select {
+	case s.directCh <- directMessage{pub: pub, msg: msg}:
+	case <-s.quit:
+	}
+}
+
+// sendDirect sends the message to the peer of the validator. If the
+// validator is not a connected peer, the message is relayed into the network
+// instead, from which it reaches the validator.
+func (s *Server) sendDirect(pub *ecdsa.PublicKey, msg *pb.Message) {
+	if peer, ok := s.identities[string(common.MarshalPublicKey(pub))]; ok {
+		msg.Flag = pb.Flag_direct
+		s.send(peer, msg)
+		return
+	}
+	setFlag(msg)
+	s.relayCache.Put(msg.Hash())
+	s.Relay(msg)
+}
+
+// greet starts the identity handshake with a new peer by sending it a
+// challenge to sign.
+func (s *Server) greet(peer Peer) {
+	challenge := make([]byte, challengeSize)
+	if _, err := rand.Read(challenge); err != nil {
+		log.Warnf("failed to create challenge for peer (%s) reason: %s",
+			peer.Endpoint(), err)
+		return
+	}
+	s.challenges[peer] = challenge
+	s.send(peer, &pb.Message{
+		Flag: pb.Flag_direct,
+		Payload: &pb.Message_Hello{
+			Hello: &pb.Hello{Challenge: challenge},
+		},
+	})
+}
+
+// handleDirectMessage handles the identity handshake and passes the
+// consensus messages addressed to this node to the engine, without relaying
+// them.
+func (s *Server) handleDirectMessage(peer Peer, msg *pb.Message) error {
+	switch p := msg.Payload.(type) {
+	case *pb.Message_Hello:
+		return s.handleHello(peer, p.Hello)
+	case *pb.Message_Identity:
+		return s.handleIdentity(peer, p.Identity)
+	default:
+		if h, ok := s.engine.(consensus.Handler); ok {
+			return h.HandleMessage(peer, msg)
+		}
+		return nil
+	}
+}
+
+// handleHello responds to the challenge of the peer with the identity of the
+// node. Nodes without a private key have no identity to prove.
+func (s *Server) handleHello(peer Peer, hello *pb.Hello) error {
+	if s.PrivateKey == nil {
+		return nil
+	}
+	if len(hello.Challenge) != challengeSize {
+		return fmt.Errorf("invalid challenge from %s", peer.Endpoint())
+	}
+	sig, err := common.Sign(s.PrivateKey, identityHash(hello.Challenge))
+	if err != nil {
+		return err
+	}
+	s.send(peer, &pb.Message{
+		Flag: pb.Flag_direct,
+		Payload: &pb.Message_Identity{
+			Identity: &pb.Identity{
+				PublicKey: common.MarshalPublicKey(&s.PrivateKey.PublicKey),
+				Signature: sig,
+			},
+		},
+	})
+	return nil
+}
+
+// handleIdentity verifies the identity of the peer against the challenge
+// send to it. A later connection of the same validator replaces the earlier
+// one.
+func (s *Server) handleIdentity(peer Peer, id *pb.Identity) error {
+	challenge, ok := s.challenges[peer]
+	if !ok {
+		return errUnrequestedIdentity
+	}
+	delete(s.challenges, peer)
+	pub, err := common.UnmarshalPublicKey(id.PublicKey)
+	if err != nil {
+		return err
+	}
+	if !common.Verify(pub, identityHash(challenge), id.Signature) {
+		s.penalize(peer, penaltyInvalidIdentity)
+		return fmt.Errorf("invalid identity of %s", peer.Endpoint())
+	}
+	s.identities[string(common.MarshalPublicKey(pub))] = peer
+	return nil
+}
+
+// identityHash returns the hash a node signs to prove its identity.
+func identityHash(challenge []byte) []byte {
+	b := make([]byte, 0, len(identityDomain)+len(challenge))
+	b = append(b, identityDomain...)
+	return common.Hash256(append(b, challenge...))
+}
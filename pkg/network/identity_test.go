@@ -0,0 +1,87 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/anthdm/consenter/pkg/common"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentityHandshake(t *testing.T) {
+	var (
+		local  = newTestServer(t, 0)
+		remote = newTestServer(t, 0)
+		// The connection seen from the local and the remote server.
+		peer  = newTestPeer("remote")
+		other = newTestPeer("other")
+		back  = newTestPeer("local")
+		key   = common.NewPrivateKey([]byte("validator"))
+	)
+	remote.PrivateKey = key
+	local.peers[peer] = true
+	local.peers[other] = true
+
+	local.greet(peer)
+	hello := peer.receive(t)
+	assert.Equal(t, pb.Flag_direct, hello.Flag)
+	assert.Nil(t, remote.handleMessage(back, hello))
+	assert.Nil(t, local.handleMessage(peer, back.receive(t)))
+	assert.Equal(t, peer, local.identities[string(common.MarshalPublicKey(&key.PublicKey))])
+
+	// Messages to the validator are only send to its peer.
+	msg := &pb.Message{
+		Payload: &pb.Message_HotstuffVote{HotstuffVote: &pb.HotstuffVote{View: 1}},
+	}
+	local.sendDirect(&key.PublicKey, msg)
+	assert.Equal(t, pb.Flag_direct, peer.receive(t).Flag)
+	assert.Equal(t, 0, len(other.sent))
+
+	// Messages to unknown validators are relayed.
+	msg = &pb.Message{
+		Payload: &pb.Message_HotstuffVote{HotstuffVote: &pb.HotstuffVote{View: 2}},
+	}
+	local.sendDirect(&common.NewPrivateKey([]byte("unknown")).PublicKey, msg)
+	assert.Equal(t, pb.Flag_consensus, peer.receive(t).Flag)
+	assert.Equal(t, pb.Flag_consensus, other.receive(t).Flag)
+
+	local.removePeer(peer)
+	assert.Equal(t, 0, len(local.identities))
+}
+
+func TestIdentityForged(t *testing.T) {
+	var (
+		s    = newTestServer(t, 0)
+		peer = newTestPeer("a")
+		key  = common.NewPrivateKey([]byte("validator"))
+	)
+	s.peers[peer] = true
+	s.greet(peer)
+	hello := peer.receive(t).GetHello()
+
+	// The signature of another key does not prove the identity.
+	sig, err := common.Sign(common.NewPrivateKey([]byte("forger")), identityHash(hello.Challenge))
+	assert.Nil(t, err)
+	msg := &pb.Message{
+		Flag: pb.Flag_direct,
+		Payload: &pb.Message_Identity{
+			Identity: &pb.Identity{
+				PublicKey: common.MarshalPublicKey(&key.PublicKey),
+				Signature: sig,
+			},
+		},
+	}
+	assert.NotNil(t, s.handleMessage(peer, msg))
+	assert.Equal(t, 0, len(s.identities))
+	assert.Equal(t, errMisbehaving, peer.err)
+
+	// Identities that were not requested are ignored.
+	assert.Equal(t, errUnrequestedIdentity, s.handleMessage(peer, msg))
+
+	// Only consensus messages may be flagged direct.
+	msg = &pb.Message{
+		Flag:    pb.Flag_direct,
+		Payload: &pb.Message_Block{Block: pb.NewBlock(0)},
+	}
+	assert.NotNil(t, s.handleMessage(peer, msg))
+}
@@ -0,0 +1,63 @@
+package network
+
+import (
+	"container/list"
+	"sync"
+)
+
+// relayCache holds the hashes of the most recently relayed messages, so
+// copies of them arriving from other peers are not relayed again. Once it is
+// full, the least recently seen hash is evicted.
+type relayCache struct {
+	lock    sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// newRelayCache returns a relay cache holding up to size hashes.
+func newRelayCache(size int) *relayCache {
+	return &relayCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Has reports whether the hash is in the cache and marks it as seen.
+func (c *relayCache) Has(hash []byte) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	el, ok := c.entries[string(hash)]
+	if ok {
+		c.order.MoveToFront(el)
+	}
+	return ok
+}
+
+// Put adds the hash to the cache, evicting the least recently seen hash if
+// the cache is full.
+func (c *relayCache) Put(hash []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if el, ok := c.entries[string(hash)]; ok {
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[string(hash)] = c.order.PushFront(string(hash))
+	if c.order.Len() > c.size {
+		el := c.order.Back()
+		c.order.Remove(el)
+		delete(c.entries, el.Value.(string))
+	}
+}
+
+// Len returns the amount of hashes in the cache.
+func (c *relayCache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.order.Len()
+}
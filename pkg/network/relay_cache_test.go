@@ -0,0 +1,23 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelayCacheEviction(t *testing.T) {
+	c := newRelayCache(2)
+	c.Put([]byte("a"))
+	c.Put([]byte("b"))
+	// Seeing a again makes b the least recently seen hash.
+	assert.True(t, c.Has([]byte("a")))
+	c.Put([]byte("c"))
+	assert.Equal(t, 2, c.Len())
+	assert.True(t, c.Has([]byte("a")))
+	assert.False(t, c.Has([]byte("b")))
+	assert.True(t, c.Has([]byte("c")))
+
+	c.Put([]byte("c"))
+	assert.Equal(t, 2, c.Len())
+}
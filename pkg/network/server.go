@@ -1,21 +1,52 @@
 package network
 
 import (
+	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"math/rand"
 	"sync"
 	"time"
 
+	"github.com/anthdm/consenter/pkg/chain"
 	"github.com/anthdm/consenter/pkg/common"
 	"github.com/anthdm/consenter/pkg/consensus"
+	"github.com/anthdm/consenter/pkg/mempool"
 	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/anthdm/consenter/pkg/state"
 	"github.com/anthdm/consenter/pkg/storage"
 	log "github.com/sirupsen/logrus"
 )
 
-var errServerShutdown = errors.New("server shutting down")
+const (
+	// penaltyInvalidBlock is the penalty of a peer that sends an invalid
+	// block.
+	penaltyInvalidBlock = 25
+
+	// penaltyInvalidTx is the penalty of a peer that sends a transaction
+	// with an invalid signature.
+	penaltyInvalidTx = 10
+
+	// penaltyInvalidIdentity is the penalty of a peer that claims to be a
+	// validator it can not prove to be.
+	penaltyInvalidIdentity = maxPenalty
+
+	// maxPenalty is the penalty after which a peer is disconnected.
+	maxPenalty = 100
+
+	// relayCacheSize is the amount of hashes of relayed messages the server
+	// remembers to drop the copies of them.
+	relayCacheSize = 1 << 16
+)
+
+var (
+	errServerShutdown = errors.New("server shutting down")
+	errMisbehaving    = errors.New("peer is misbehaving")
+	errUnverifiedWork = errors.New("engine does not validate the difficulty of its blocks")
+)
 
 // ServerConfig holds the server configuration.
 type ServerConfig struct {
@@ -39,6 +70,26 @@ type ServerConfig struct {
 	// PrivateKey of the server. This can be left empty if consensus is set
 	// to false.
 	PrivateKey *ecdsa.PrivateKey
+
+	// Store the chain is persisted to. The chain is kept in memory if left
+	// empty.
+	Store storage.Store
+
+	// The fork choice rule of the chain, the longest chain if left empty.
+	// The chain can only follow the most work if the engine validates the
+	// difficulty of its blocks.
+	ForkChoice chain.ForkChoice
+
+	// The validators of the consensus. Unless the engine validates its
+	// blocks, only blocks signed by one of them are accepted.
+	Validators consensus.Validators
+
+	// Limits and order of the mempool.
+	Mempool mempool.Config
+
+	// The state machine the blocks of the chain are applied to, a key-value
+	// store if left empty.
+	StateMachine state.StateMachine
 }
 
 type (
@@ -54,6 +105,22 @@ type (
 		// proposing blocks.
 		engine consensus.Engine
 
+		// Pipeline every block received from the network needs to pass
+		// before it is accepted.
+		pipeline *consensus.BlockPipeline
+
+		// The chain of accepted blocks and the hash of the last block
+		// finalized in it.
+		chain     *chain.Chain
+		finalized []byte
+
+		// Replica of the state machine, which follows the chain.
+		state *state.Replica
+
+		// Mempool of the transactions that are not part of the chain yet,
+		// shared with the engine if it uses one.
+		mempool *mempool.Pool
+
 		// Tuple used for message communication between the server and
 		// its transport. It holds both the message and the peer.
 		protoCh chan messageTuple
@@ -62,22 +129,43 @@ type (
 		// consensus engine.
 		relayCh chan *pb.Message
 
-		// RelayCache holds transaction hashes that this server already relayed
-		// to its peers.
-		relayCache *storage.MemStore
+		// RelayCache holds the hashes of the messages this server recently
+		// relayed to its peers.
+		relayCache *relayCache
 
 		// Peers is a map of current connected peers to the server.
 		peers   map[Peer]bool
 		addPeer chan Peer
 		delPeer chan peerDrop
 
+		// Peers that proved to be a validator by the marshaled public key of
+		// the validator, and the challenges of the identity handshake send to
+		// the peers that did not respond yet.
+		identities map[string]Peer
+		challenges map[Peer][]byte
+
+		// directCh is used by the engine to send messages to single
+		// validators, see SendTo.
+		directCh chan directMessage
+
+		// Sync manager downloading the blocks the chain is missing from the
+		// peers.
+		sync *syncManager
+
+		// Penalties of the connected peers for misbehaving, only accessed by
+		// the run loop.
+		penalties map[Peer]int
+
 		// Waitgroup for orchestrate a gracefull shutdown.
 		wg sync.WaitGroup
 
 		// Field members to orchestrate a gracefull server shutdown.
-		lock    sync.Mutex
-		quit    chan struct{}
-		running bool
+		lock     sync.Mutex
+		quit     chan struct{}
+		quitOnce sync.Once
+		running  bool
+		// The error the engine failed with.
+		err error
 	}
 
 	// messageTuple holds information between the message send through the
@@ -100,39 +188,99 @@ func NewServer(cfg ServerConfig, engine consensus.Engine) *Server {
 	s := &Server{
 		ServerConfig: cfg,
 		peers:        make(map[Peer]bool),
+		penalties:    make(map[Peer]int),
 		addPeer:      make(chan Peer),
 		delPeer:      make(chan peerDrop),
 		protoCh:      make(chan messageTuple),
-		relayCache:   storage.NewMemStore(),
+		relayCache:   newRelayCache(relayCacheSize),
 		relayCh:      make(chan *pb.Message),
+		identities:   make(map[string]Peer),
+		challenges:   make(map[Peer][]byte),
+		directCh:     make(chan directMessage),
+		quit:         make(chan struct{}),
+		mempool:      mempool.New(cfg.Mempool),
 	}
+	s.sync = newSyncManager(s)
 	if engine != nil {
 		s.engine = engine
+		if u, ok := engine.(consensus.MempoolUser); ok {
+			u.UseMempool(s.mempool)
+		}
+		if u, ok := engine.(consensus.SenderUser); ok {
+			u.UseSender(s)
+		}
 		s.engine.Configurate(s.relayCh, s.PrivateKey)
 	}
 	return s
 }
 
-// Start attempts to start running the server.
+// Start attempts to start running the server. It blocks until the server is
+// stopped and returns the error the engine failed with, if any.
 func (s *Server) Start() error {
 	s.lock.Lock()
 	if s.running {
+		s.lock.Unlock()
 		return errors.New("server already running")
 	}
 	s.running = true
 	s.lock.Unlock()
 
+	// The difficulties of the blocks are claimed by their proposer.
+	if _, ok := s.ForkChoice.(chain.HeaviestWork); ok && !consensus.ValidatesDifficulty(s.engine) {
+		return errUnverifiedWork
+	}
+	if s.Store == nil {
+		s.Store = storage.NewMemStore()
+	}
+	c, err := chain.New(s.Store, s.ForkChoice)
+	if err != nil {
+		return err
+	}
+	s.chain = c
+	s.pipeline = consensus.NewBlockPipeline(s.engine, s.Validators, c)
+	if s.StateMachine == nil {
+		s.StateMachine = state.NewKVStore()
+	}
+	if s.state, err = state.NewReplica(s.StateMachine); err != nil {
+		return err
+	}
+	s.mempool.UseState(s.state)
+	if head := c.Head(); head != nil {
+		log.WithFields(log.Fields{
+			"index": head.Header.Index,
+			"hash":  hex.EncodeToString(head.Header.Hash()),
+		}).Info("resuming chain")
+		// The state is not persisted, the chain is applied again.
+		s.updateState(0)
+		if r, ok := s.engine.(consensus.Resumer); ok {
+			r.Resume(head)
+		}
+	}
+
 	log.Info("starting p2p server..")
 	ts := NewTCPTransport(s)
 	if err := s.listen(ts); err != nil {
 		return err
 	}
+	if s.engine != nil {
+		if err := s.engine.Start(context.Background()); err != nil {
+			s.transport.Close()
+			return err
+		}
+	}
+	s.wg.Add(1)
 	go s.run()
 	go s.bootstrapNetwork()
 	go s.generateTxLoop()
-	s.wg.Add(1)
 	s.wg.Wait()
-	return nil
+	return s.err
+}
+
+// Stop gracefully shuts down the server along with its engine.
+func (s *Server) Stop() {
+	s.quitOnce.Do(func() {
+		close(s.quit)
+	})
 }
 
 func (s *Server) bootstrapNetwork() {
@@ -156,13 +304,27 @@ func (s *Server) listen(ts Transport) error {
 }
 
 func (s *Server) run() {
+	syncTicker := time.NewTicker(syncInterval)
+	defer syncTicker.Stop()
 running:
 	for {
 		select {
 		case <-s.quit:
 			break running
+		case err := <-s.engineErr():
+			log.Errorf("consensus engine failed: %s", err)
+			s.err = err
+			break running
 		case msg := <-s.relayCh:
+			if b, ok := msg.Payload.(*pb.Message_Block); ok {
+				s.pipeline.Accept(b.Block)
+				s.addBlock(b.Block)
+			}
+			setFlag(msg)
+			s.relayCache.Put(msg.Hash())
 			s.Relay(msg)
+		case d := <-s.directCh:
+			s.sendDirect(d.pub, d.msg)
 		case t := <-s.protoCh:
 			if err := s.handleMessage(t.peer, t.msg); err != nil {
 				log.Warnf("failed processing message: %s", err)
@@ -172,8 +334,12 @@ running:
 			log.WithFields(log.Fields{
 				"endpoint": p.Endpoint(),
 			}).Info("new peer connected")
+			s.sync.requestHeaders(p)
+			s.greet(p)
+		case <-syncTicker.C:
+			s.sync.tick()
 		case t := <-s.delPeer:
-			delete(s.peers, t.peer)
+			s.removePeer(t.peer)
 			log.WithFields(log.Fields{
 				"endpoint": t.peer.Endpoint(),
 				"reason":   t.reason,
@@ -192,10 +358,22 @@ running:
 	if s.transport != nil {
 		s.transport.Close()
 	}
+	if s.engine != nil {
+		s.engine.Stop()
+	}
 	s.running = false
 	s.wg.Done()
 }
 
+// engineErr returns the error channel of the engine, nil if the server runs
+// without engine.
+func (s *Server) engineErr() <-chan error {
+	if s.engine == nil {
+		return nil
+	}
+	return s.engine.Err()
+}
+
 // Relay will forward any given message to the connected peers.
 func (s *Server) Relay(msg *pb.Message) {
 	for peer := range s.peers {
@@ -208,43 +386,314 @@ func (s *Server) Relay(msg *pb.Message) {
 	}
 }
 
+// send sends the message to a single peer.
+func (s *Server) send(peer Peer, msg *pb.Message) {
+	go func() {
+		if err := peer.Send(msg); err != nil {
+			log.Warnf("failed to send message to peer (%s) reason: %s",
+				peer.Endpoint(), err)
+		}
+	}()
+}
+
 func (s *Server) handleMessage(peer Peer, msg *pb.Message) error {
+	// The flag is set by the peer, messages are only routed by it if it
+	// matches their payload. Otherwise transactions and blocks would bypass
+	// their validation. Consensus messages addressed to this node are
+	// flagged direct.
+	flag := flagOf(msg)
+	if msg.Flag != flag && !(flag == pb.Flag_consensus && msg.Flag == pb.Flag_direct) {
+		return fmt.Errorf("message from %s flagged %s instead of %s",
+			peer.Endpoint(), msg.Flag, flag)
+	}
+	switch msg.Flag {
+	case pb.Flag_payload:
+		return s.handlePayloadMessage(peer, msg)
+	case pb.Flag_sync:
+		return s.handleSyncMessage(peer, msg)
+	case pb.Flag_direct:
+		return s.handleDirectMessage(peer, msg)
+	default:
+		return s.handleConsensusMessage(peer, msg)
+	}
+}
+
+// handlePayloadMessage handles the transactions and blocks of the network.
+func (s *Server) handlePayloadMessage(peer Peer, msg *pb.Message) error {
 	switch p := msg.Payload.(type) {
 	case *pb.Message_Transaction:
 		// We already seen and relayed this tx.
 		if s.relayCache.Has(p.Transaction.Hash()) {
 			return nil
 		}
+		if _, err := p.Transaction.Verify(); err != nil {
+			// Copies of the tx are ignored instead of verified again.
+			s.relayCache.Put(p.Transaction.Hash())
+			s.penalize(peer, penaltyInvalidTx)
+			return fmt.Errorf("invalid tx from %s: %s", peer.Endpoint(), err)
+		}
 		log.Infof("receiving new tx: %s",
 			hex.EncodeToString(p.Transaction.Hash()))
 
-		s.relayCache.Put(p.Transaction.Hash(), nil)
+		s.relayCache.Put(p.Transaction.Hash())
 		s.Relay(msg)
 		s.addTransaction(p.Transaction)
+		return nil
+	case *pb.Message_Block:
+		hash := msg.Hash()
+		if s.relayCache.Has(hash) {
+			return nil
+		}
+		if err := s.pipeline.Validate(p.Block); err != nil {
+			// The parent might still arrive, the sync fetches the block if
+			// it does not.
+			if err == consensus.ErrUnknownParent {
+				return fmt.Errorf("block %d from %s: %s",
+					p.Block.Header.Index, peer.Endpoint(), err)
+			}
+			// Copies of the block are ignored instead of validated again.
+			s.relayCache.Put(hash)
+			s.penalize(peer, penaltyInvalidBlock)
+			return fmt.Errorf("invalid block from %s: %s", peer.Endpoint(), err)
+		}
+		s.addBlock(p.Block)
+		return s.handleConsensusMessage(peer, msg)
+	default:
+		return s.handleConsensusMessage(peer, msg)
+	}
+}
+
+// penalize adds the penalty to the peer and disconnects it once it exceeds
+// the maximum.
+func (s *Server) penalize(peer Peer, penalty int) {
+	s.penalties[peer] += penalty
+	if s.penalties[peer] < maxPenalty {
+		return
+	}
+	log.WithFields(log.Fields{
+		"endpoint": peer.Endpoint(),
+		"penalty":  s.penalties[peer],
+	}).Warn("disconnecting misbehaving peer")
+	s.removePeer(peer)
+	peer.Disconnect(errMisbehaving)
+}
+
+// removePeer removes the peer along with its penalty, sync requests and
+// identity.
+func (s *Server) removePeer(peer Peer) {
+	delete(s.peers, peer)
+	delete(s.penalties, peer)
+	delete(s.challenges, peer)
+	for key, p := range s.identities {
+		if p == peer {
+			delete(s.identities, key)
+		}
+	}
+	s.sync.removePeer(peer)
+}
+
+// handleConsensusMessage relays the message and passes it to the engine
+// along with the peer it was received from.
+func (s *Server) handleConsensusMessage(peer Peer, msg *pb.Message) error {
+	hash := msg.Hash()
+	if s.relayCache.Has(hash) {
+		return nil
+	}
+	s.relayCache.Put(hash)
+	s.Relay(msg)
+	if h, ok := s.engine.(consensus.Handler); ok {
+		return h.HandleMessage(peer, msg)
 	}
 	return nil
 }
 
+// setFlag flags the message by its payload, see flagOf.
+func setFlag(msg *pb.Message) {
+	msg.Flag = flagOf(msg)
+}
+
+// flagOf returns the flag of the message by its payload. Transactions and
+// blocks are payload of the network, the messages of the block sync are sync
+// traffic, the identity handshake is direct traffic and any other message is
+// consensus traffic.
+func flagOf(msg *pb.Message) pb.Flag {
+	switch msg.Payload.(type) {
+	case *pb.Message_Transaction, *pb.Message_Block:
+		return pb.Flag_payload
+	case *pb.Message_GetHeaders, *pb.Message_Headers, *pb.Message_GetBlocks, *pb.Message_Blocks:
+		return pb.Flag_sync
+	case *pb.Message_Hello, *pb.Message_Identity:
+		return pb.Flag_direct
+	default:
+		return pb.Flag_consensus
+	}
+}
+
+// addBlock adds the block to the chain. Blocks that can not be added, like
+// blocks of which the parent is unknown, are dropped.
+func (s *Server) addBlock(b *pb.Block) {
+	reorg, err := s.chain.Add(b)
+	if err != nil {
+		log.Debugf("block not added to the chain: %s", err)
+		return
+	}
+	s.blockAdded(b, reorg)
+}
+
+// blockAdded updates the mempool and the finalized block after the block is
+// added to the chain. The transactions of the block are removed from the
+// mempool once it extends the chain.
+func (s *Server) blockAdded(b *pb.Block, reorg *chain.Reorg) {
+	if reorg != nil {
+		s.handleReorg(reorg)
+	} else if head := s.chain.Head(); head != nil && bytes.Equal(head.Header.Hash(), b.Header.Hash()) {
+		s.mempool.Remove(b.Transactions)
+		s.updateState(s.state.Height())
+	}
+	s.updateFinalized()
+}
+
+// updateState rolls the state back to the given height and applies the
+// blocks of the chain above it, so the state follows the head of the chain.
+func (s *Server) updateState(height uint32) {
+	from, err := s.state.Rollback(height)
+	if err != nil {
+		log.Errorf("failed to roll back state to height %d: %s", height, err)
+		return
+	}
+	for i := from + 1; i <= s.chain.Height(); i++ {
+		b, err := s.chain.GetBlockByHeight(i)
+		if err != nil {
+			log.Errorf("failed to load block %d: %s", i, err)
+			return
+		}
+		root, err := s.state.Apply(b)
+		if err != nil {
+			log.Errorf("failed to apply block %d: %s", i, err)
+			return
+		}
+		log.WithFields(log.Fields{
+			"index": i,
+			"root":  hex.EncodeToString(root),
+		}).Debug("applied block to state")
+	}
+}
+
+// StateRoot returns the state root after applying the block of the chain
+// with the given index. Honest nodes have the same root at each height.
+func (s *Server) StateRoot(height uint32) ([]byte, error) {
+	return s.state.Root(height)
+}
+
+// Query returns the value of the key in the current state.
+func (s *Server) Query(key []byte) ([]byte, error) {
+	return s.state.Query(key)
+}
+
+// updateFinalized finalizes the last block finalized by the engine in the
+// chain.
+func (s *Server) updateFinalized() {
+	f, ok := s.engine.(consensus.Finalizer)
+	if !ok {
+		return
+	}
+	hash, _ := f.Finalized()
+	if len(hash) == 0 || bytes.Equal(hash, s.finalized) {
+		return
+	}
+	reorg, err := s.chain.Finalize(hash)
+	if err != nil {
+		log.Debugf("block not finalized in the chain: %s", err)
+		return
+	}
+	s.finalized = hash
+	s.handleReorg(reorg)
+}
+
+// handleReorg updates the mempool and the state to the reorg of the chain, if
+// any. The transactions of the attached blocks are removed from the mempool
+// and the orphaned transactions of the detached blocks are pending again. The
+// state is rolled back to the common ancestor and follows the new branch.
+func (s *Server) handleReorg(reorg *chain.Reorg) {
+	if reorg == nil {
+		return
+	}
+	for _, b := range reorg.Attached {
+		s.mempool.Remove(b.Transactions)
+	}
+	s.updateState(reorg.Attached[0].Header.Index - 1)
+	orphaned := reorg.Orphaned()
+	for _, tx := range orphaned {
+		s.addTransaction(tx)
+	}
+	head := reorg.Attached[len(reorg.Attached)-1]
+	log.WithFields(log.Fields{
+		"depth":    len(reorg.Detached),
+		"index":    head.Header.Index,
+		"hash":     hex.EncodeToString(head.Header.Hash()),
+		"orphaned": len(orphaned),
+	}).Warn("chain reorganization")
+}
+
+// addTransaction adds the tx to the mempool and passes it to the engine.
+// Transactions of which the sequence is already used in the current state
+// and transactions that do not fit in the mempool are dropped.
 func (s *Server) addTransaction(tx *pb.Transaction) {
+	if seq := s.state.Sequence(tx.Sender); tx.Sequence < seq {
+		log.Debugf("tx not added to the mempool: sequence %d below %d", tx.Sequence, seq)
+		return
+	}
+	if err := s.mempool.Add(tx); err != nil {
+		log.Debugf("tx not added to the mempool: %s", err)
+		return
+	}
+	s.addEngineTransaction(tx)
+}
+
+func (s *Server) addEngineTransaction(tx *pb.Transaction) {
 	if s.engine != nil {
 		s.engine.AddTransaction(tx)
 	}
 }
 
 // generateTxLoop will create and relay random transactions to all connected
-// peers, simulating transactions created by clients to the node.
+// peers, simulating transactions created by clients to the node. The
+// transactions are signed by a single client of the node.
 func (s *Server) generateTxLoop() {
-	for {
-		tx := pb.NewTransaction()
+	client := common.NewPrivateKey([]byte(fmt.Sprintf("client_%d", s.ListenAddr)))
+	// The client continues the sequence of its committed transactions.
+	next := s.state.Sequence(common.MarshalPublicKey(&client.PublicKey))
+	for sequence := next; ; sequence++ {
+		tx := &pb.Transaction{
+			Sequence: sequence,
+			Payload:  make([]byte, 32),
+			Fee:      uint64(common.RandInt(1, 100)),
+		}
+		rand.Read(tx.Payload)
+		// Each client updates a small set of keys of the state.
+		tx.Ops = []*pb.KVOp{{
+			Type:  pb.KVOpType_set,
+			Key:   []byte(fmt.Sprintf("%d/%d", s.ListenAddr, sequence%16)),
+			Value: tx.Payload,
+		}}
+		if err := tx.Sign(client); err != nil {
+			log.Errorf("failed to sign tx: %s", err)
+			return
+		}
 		msg := &pb.Message{
+			Flag: pb.Flag_payload,
 			Payload: &pb.Message_Transaction{
 				Transaction: tx,
 			},
 		}
 		s.Relay(msg)
-		s.relayCache.Put(tx.Hash(), nil)
+		s.relayCache.Put(tx.Hash())
 		s.addTransaction(tx)
 		d := time.Duration(common.RandInt(1, 4)) * time.Second
-		time.Sleep(d)
+		select {
+		case <-time.After(d):
+		case <-s.quit:
+			return
+		}
 	}
 }
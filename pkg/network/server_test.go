@@ -0,0 +1,144 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/anthdm/consenter/pkg/chain"
+	"github.com/anthdm/consenter/pkg/common"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleTransaction(t *testing.T) {
+	var (
+		s     = newTestServer(t, 0)
+		from  = newTestPeer("a")
+		other = newTestPeer("b")
+		priv  = common.NewPrivateKey([]byte("client"))
+	)
+	s.peers[from] = true
+	s.peers[other] = true
+
+	tx, err := pb.NewSignedTransaction(priv, 1, []byte("payload"))
+	assert.Nil(t, err)
+	tx.Payload = []byte("forged")
+	msg := &pb.Message{
+		Flag:    pb.Flag_payload,
+		Payload: &pb.Message_Transaction{Transaction: tx},
+	}
+	assert.NotNil(t, s.handleMessage(from, msg))
+	assert.Equal(t, penaltyInvalidTx, s.penalties[from])
+	// Copies of the tx are dropped without penalty.
+	assert.Nil(t, s.handleMessage(from, msg))
+	assert.Equal(t, penaltyInvalidTx, s.penalties[from])
+
+	tx, err = pb.NewSignedTransaction(priv, 1, []byte("payload"))
+	assert.Nil(t, err)
+	msg.Payload = &pb.Message_Transaction{Transaction: tx}
+	assert.Nil(t, s.handleMessage(from, msg))
+	// Only the valid tx is relayed.
+	assert.Equal(t, tx.Hash(), other.receive(t).GetTransaction().Hash())
+	assert.Equal(t, 0, len(other.sent))
+}
+
+func TestMempoolFollowsChain(t *testing.T) {
+	var (
+		s  = newTestServer(t, 0)
+		tx = pb.NewTransaction()
+	)
+	s.addTransaction(tx)
+	assert.True(t, s.mempool.Has(tx.Hash()))
+
+	// The tx is committed by a block of the chain.
+	a := pb.NewBlock(0)
+	a.Transactions = []*pb.Transaction{tx}
+	s.addBlock(a)
+	assert.False(t, s.mempool.Has(tx.Hash()))
+
+	// A longer branch without the tx orphans it.
+	b := pb.NewBlock(0)
+	s.addBlock(b)
+	c := pb.NewBlock(1)
+	c.Header.PrevHash = b.Header.Hash()
+	s.addBlock(c)
+	assert.Equal(t, c.Header.Hash(), s.chain.Head().Header.Hash())
+	assert.True(t, s.mempool.Has(tx.Hash()))
+}
+
+func TestStaleTransaction(t *testing.T) {
+	// The chain holds the txs of the client with sequence 0 and 1.
+	s := newTestServer(t, 2)
+	stale, err := pb.NewSignedTransaction(client, 1, []byte("stale"))
+	assert.Nil(t, err)
+	s.addTransaction(stale)
+	assert.False(t, s.mempool.Has(stale.Hash()))
+
+	next, err := pb.NewSignedTransaction(client, 2, []byte("next"))
+	assert.Nil(t, err)
+	s.addTransaction(next)
+	assert.True(t, s.mempool.Has(next.Hash()))
+}
+
+func TestStateFollowsChain(t *testing.T) {
+	s := newTestServer(t, 0)
+	set := func(key, val string) *pb.Transaction {
+		tx := &pb.Transaction{
+			Ops: []*pb.KVOp{{Type: pb.KVOpType_set, Key: []byte(key), Value: []byte(val)}},
+		}
+		assert.Nil(t, tx.Sign(client))
+		return tx
+	}
+	a := pb.NewBlock(0)
+	a.Transactions = []*pb.Transaction{set("foo", "a")}
+	s.addBlock(a)
+	val, err := s.Query([]byte("foo"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("a"), val)
+
+	// A longer branch reverts the state of the detached block.
+	b := pb.NewBlock(0)
+	b.Transactions = []*pb.Transaction{set("bar", "b")}
+	s.addBlock(b)
+	c := pb.NewBlock(1)
+	c.Header.PrevHash = b.Header.Hash()
+	s.addBlock(c)
+	_, err = s.Query([]byte("foo"))
+	assert.NotNil(t, err)
+	val, err = s.Query([]byte("bar"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("b"), val)
+
+	root, err := s.StateRoot(2)
+	assert.Nil(t, err)
+	rootB, err := s.StateRoot(1)
+	assert.Nil(t, err)
+	assert.Equal(t, rootB, root)
+}
+
+func TestHandleMessageFlag(t *testing.T) {
+	var (
+		s    = newTestServer(t, 0)
+		peer = newTestPeer("a")
+	)
+	s.peers[peer] = true
+	// An unsigned tx flagged as consensus traffic is not passed on.
+	msg := &pb.Message{
+		Flag:    pb.Flag_consensus,
+		Payload: &pb.Message_Transaction{Transaction: pb.NewTransaction()},
+	}
+	assert.NotNil(t, s.handleMessage(peer, msg))
+	assert.False(t, s.relayCache.Has(msg.Hash()))
+	assert.Equal(t, 0, s.mempool.Len())
+
+	msg = &pb.Message{
+		Payload: &pb.Message_Block{Block: pb.NewBlock(0)},
+	}
+	assert.NotNil(t, s.handleMessage(peer, msg))
+	assert.Equal(t, uint32(0), s.chain.Height())
+}
+
+func TestStartHeaviestWork(t *testing.T) {
+	// Without an engine the difficulties of the blocks are not validated.
+	s := NewServer(ServerConfig{ForkChoice: chain.HeaviestWork{}}, nil)
+	assert.Equal(t, errUnverifiedWork, s.Start())
+}
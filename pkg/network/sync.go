@@ -0,0 +1,414 @@
+package network
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/consensus"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// syncInterval is the interval in which the heights of the chains of the
+	// peers are requested.
+	syncInterval = 5 * time.Second
+
+	// maxHeaders is the maximum amount of headers send in response to a
+	// GetHeaders request.
+	maxHeaders = 256
+
+	// maxBlocks is the amount of blocks requested at once and the maximum
+	// amount of blocks send in response to a GetBlocks request.
+	maxBlocks = 32
+
+	// requestTimeout is the time a peer has to respond to a GetBlocks
+	// request before the blocks are requested from another peer.
+	requestTimeout = 10 * time.Second
+
+	// syncWindow is the maximum amount of blocks above the head downloaded
+	// by a single sync. Peers that are further ahead are caught up with by
+	// consecutive syncs.
+	syncWindow = 1024
+)
+
+// blockRange is a range of blocks of the chain.
+type blockRange struct {
+	from  uint32
+	count uint32
+}
+
+// blockRequest is a range of blocks requested from a peer.
+type blockRequest struct {
+	blockRange
+	sent time.Time
+}
+
+// downloadedBlock is a downloaded block along with the peer it is received
+// from.
+type downloadedBlock struct {
+	block *pb.Block
+	peer  Peer
+}
+
+// syncManager downloads the blocks a node is missing from its peers. The
+// heights of the chains of the peers are learned by requesting their headers.
+// Once a peer is ahead, the missing range is split up and requested from all
+// peers that have it, a single range per peer at a time. The blocks are
+// added to the chain in order, after they passed the block pipeline. It is
+// only accessed by the run loop of the server.
+type syncManager struct {
+	srv *Server
+
+	// Heights of the chains of the peers and whether their chain forks from
+	// the chain of the node.
+	heights map[Peer]uint32
+	forked  map[Peer]bool
+	// Peers a GetHeaders request is in flight to, only their responses are
+	// accepted.
+	headerRequests map[Peer]bool
+	// The amount of blocks below the head the sync starts at. It is doubled
+	// each time the blocks of a peer do not link to the chain, which happens
+	// if the fork of the peer is deeper.
+	lookback uint32
+
+	// State of the running sync, target is 0 if no sync is running.
+	target   uint32
+	first    uint32
+	next     uint32
+	queue    []blockRange
+	requests map[Peer]*blockRequest
+	pending  map[uint32]downloadedBlock
+}
+
+func newSyncManager(srv *Server) *syncManager {
+	return &syncManager{
+		srv:            srv,
+		heights:        make(map[Peer]uint32),
+		forked:         make(map[Peer]bool),
+		headerRequests: make(map[Peer]bool),
+		requests:       make(map[Peer]*blockRequest),
+		pending:        make(map[uint32]downloadedBlock),
+	}
+}
+
+// tick requests the heights of all peers and the ranges of requests that
+// timed out from other peers.
+func (m *syncManager) tick() {
+	for peer := range m.srv.peers {
+		m.requestHeaders(peer)
+	}
+	now := time.Now()
+	for peer, req := range m.requests {
+		if now.Sub(req.sent) > requestTimeout {
+			log.WithFields(log.Fields{
+				"endpoint": peer.Endpoint(),
+				"from":     req.from,
+			}).Warn("sync: block request timed out")
+			delete(m.requests, peer)
+			// The peer is not asked again until it reports its height.
+			delete(m.heights, peer)
+			m.queue = append(m.queue, req.blockRange)
+		}
+	}
+	m.schedule()
+}
+
+// requestHeaders requests the header of the head of the chain from the peer,
+// along with the height of its chain.
+func (m *syncManager) requestHeaders(peer Peer) {
+	from := m.srv.chain.Height()
+	if from == 0 {
+		from = 1
+	}
+	m.headerRequests[peer] = true
+	m.srv.send(peer, &pb.Message{
+		Flag: pb.Flag_sync,
+		Payload: &pb.Message_GetHeaders{
+			GetHeaders: &pb.GetHeaders{From: from, Count: 1},
+		},
+	})
+}
+
+// removePeer requests the range requested from the peer from other peers.
+func (m *syncManager) removePeer(peer Peer) {
+	if req, ok := m.requests[peer]; ok {
+		m.queue = append(m.queue, req.blockRange)
+		delete(m.requests, peer)
+	}
+	delete(m.heights, peer)
+	delete(m.forked, peer)
+	delete(m.headerRequests, peer)
+}
+
+func (m *syncManager) handleHeaders(peer Peer, h *pb.Headers) {
+	if !m.headerRequests[peer] {
+		return
+	}
+	delete(m.headerRequests, peer)
+	m.heights[peer] = h.Height
+	head := m.srv.chain.Head()
+	m.forked[peer] = head != nil && len(h.Headers) > 0 &&
+		h.Headers[0].Index == head.Header.Index &&
+		!bytes.Equal(h.Headers[0].Hash(), head.Header.Hash())
+	m.schedule()
+}
+
+func (m *syncManager) handleBlocks(peer Peer, blocks []*pb.Block) {
+	req, ok := m.requests[peer]
+	if !ok {
+		return
+	}
+	delete(m.requests, peer)
+	defer m.schedule()
+	if len(blocks) == 0 {
+		// The peer does not have the blocks of the height it reported.
+		m.srv.penalize(peer, penaltyInvalidBlock)
+		delete(m.heights, peer)
+		m.queue = append(m.queue, req.blockRange)
+		return
+	}
+	if uint32(len(blocks)) > req.count {
+		m.srv.penalize(peer, penaltyInvalidBlock)
+		m.queue = append(m.queue, req.blockRange)
+		return
+	}
+	for i, b := range blocks {
+		if b.Header == nil || b.Header.Index != req.from+uint32(i) {
+			m.srv.penalize(peer, penaltyInvalidBlock)
+			m.queue = append(m.queue, req.blockRange)
+			return
+		}
+	}
+	// The remainder of the range is requested from other peers.
+	if n := uint32(len(blocks)); n < req.count {
+		m.queue = append(m.queue, blockRange{from: req.from + n, count: req.count - n})
+	}
+	for _, b := range blocks {
+		if b.Header.Index >= m.next {
+			m.pending[b.Header.Index] = downloadedBlock{block: b, peer: peer}
+		}
+	}
+	m.apply()
+}
+
+// schedule starts a sync if a peer is ahead and requests the queued ranges
+// from the peers that are not busy.
+func (m *syncManager) schedule() {
+	if m.target == 0 {
+		m.start()
+	}
+	for len(m.queue) > 0 {
+		r := m.queue[0]
+		peer := m.idlePeer(r.from + r.count - 1)
+		if peer == nil {
+			return
+		}
+		m.queue = m.queue[1:]
+		m.requests[peer] = &blockRequest{blockRange: r, sent: time.Now()}
+		m.srv.send(peer, &pb.Message{
+			Flag: pb.Flag_sync,
+			Payload: &pb.Message_GetBlocks{
+				GetBlocks: &pb.GetBlocks{From: r.from, Count: r.count},
+			},
+		})
+	}
+}
+
+// start starts a sync up to the height of the best peer, if it is ahead.
+func (m *syncManager) start() {
+	var (
+		height = m.srv.chain.Height()
+		best   Peer
+	)
+	for peer, h := range m.heights {
+		if h > height && (best == nil || h > m.heights[best]) {
+			best = peer
+		}
+	}
+	if best == nil {
+		return
+	}
+	from := height + 1
+	if m.forked[best] {
+		if m.lookback == 0 {
+			m.lookback = maxBlocks
+		}
+		if m.lookback < height {
+			from = height + 1 - m.lookback
+		} else {
+			from = 1
+		}
+	}
+	// The height of the peer is not trusted, a single sync downloads at
+	// most a window of blocks above the head.
+	m.target = m.heights[best]
+	if uint64(m.target) > uint64(height)+syncWindow {
+		m.target = height + syncWindow
+	}
+	m.first = from
+	m.next = from
+	for i := uint64(from); i <= uint64(m.target); i += maxBlocks {
+		count := uint64(maxBlocks)
+		if uint64(m.target)-i+1 < count {
+			count = uint64(m.target) - i + 1
+		}
+		m.queue = append(m.queue, blockRange{from: uint32(i), count: uint32(count)})
+	}
+	log.WithFields(log.Fields{
+		"from": from,
+		"to":   m.target,
+	}).Info("sync: downloading blocks")
+}
+
+// idlePeer returns a peer without a request in flight that has the block
+// with the given index, nil if there is none.
+func (m *syncManager) idlePeer(index uint32) Peer {
+	for peer, h := range m.heights {
+		if _, busy := m.requests[peer]; !busy && h >= index {
+			return peer
+		}
+	}
+	return nil
+}
+
+// apply adds the downloaded blocks that follow each other to the chain.
+func (m *syncManager) apply() {
+	for {
+		d, ok := m.pending[m.next]
+		if !ok {
+			break
+		}
+		delete(m.pending, m.next)
+		// Blocks of the chain below the head are downloaded again if the
+		// sync starts below the head.
+		if m.srv.chain.HasBlock(d.block.Header.Hash()) {
+			m.next++
+			continue
+		}
+		err := m.srv.pipeline.Validate(d.block)
+		if err == consensus.ErrUnknownParent && m.next == m.first {
+			m.deepen(err)
+			return
+		}
+		if err != nil {
+			log.Warnf("sync: invalid block from %s: %s", d.peer.Endpoint(), err)
+			m.srv.penalize(d.peer, penaltyInvalidBlock)
+			m.requeue()
+			return
+		}
+		reorg, err := m.srv.chain.Add(d.block)
+		if err != nil {
+			if m.next == m.first {
+				m.deepen(err)
+				return
+			}
+			log.Warnf("sync: block from %s not added: %s", d.peer.Endpoint(), err)
+			m.srv.penalize(d.peer, penaltyInvalidBlock)
+			m.requeue()
+			return
+		}
+		m.srv.blockAdded(d.block, reorg)
+		msg := &pb.Message{
+			Flag: pb.Flag_payload,
+			Payload: &pb.Message_Block{
+				Block: d.block,
+			},
+		}
+		// Copies of the block relayed by peers are ignored.
+		m.srv.relayCache.Put(msg.Hash())
+		// Engines that follow the chain, like proof of work, catch up with
+		// the downloaded blocks.
+		if h, ok := m.srv.engine.(consensus.Handler); ok {
+			if err := h.HandleMessage(d.peer, msg); err != nil {
+				log.Debugf("sync: engine failed to handle block: %s", err)
+			}
+		}
+		m.next++
+	}
+	if m.next > m.target {
+		log.WithFields(log.Fields{
+			"height": m.srv.chain.Height(),
+		}).Info("sync: synced chain")
+		m.lookback = 0
+		m.reset()
+	}
+}
+
+// requeue requests the next block again, which was invalid.
+func (m *syncManager) requeue() {
+	count := uint32(maxBlocks)
+	if m.target-m.next+1 < count {
+		count = m.target - m.next + 1
+	}
+	m.queue = append([]blockRange{{from: m.next, count: count}}, m.queue...)
+}
+
+// deepen restarts the sync further below the head, which is called if the
+// first downloaded block does not link to the chain, as the fork of the peer
+// is deeper than the lookback.
+func (m *syncManager) deepen(err error) {
+	log.Debugf("sync: blocks do not link to the chain: %s", err)
+	if m.lookback < m.srv.chain.Height() {
+		m.lookback *= 2
+	}
+	m.reset()
+}
+
+// reset stops the running sync, responses to its requests are ignored.
+func (m *syncManager) reset() {
+	m.target = 0
+	m.queue = nil
+	m.requests = make(map[Peer]*blockRequest)
+	m.pending = make(map[uint32]downloadedBlock)
+}
+
+// handleSyncMessage serves the sync requests of the peers and passes their
+// responses to the sync manager.
+func (s *Server) handleSyncMessage(peer Peer, msg *pb.Message) error {
+	switch p := msg.Payload.(type) {
+	case *pb.Message_GetHeaders:
+		height := s.chain.Height()
+		headers := []*pb.Header{}
+		for _, b := range s.blockRange(p.GetHeaders.From, p.GetHeaders.Count, maxHeaders) {
+			headers = append(headers, b.Header)
+		}
+		s.send(peer, &pb.Message{
+			Flag: pb.Flag_sync,
+			Payload: &pb.Message_Headers{
+				Headers: &pb.Headers{Height: height, Headers: headers},
+			},
+		})
+	case *pb.Message_GetBlocks:
+		s.send(peer, &pb.Message{
+			Flag: pb.Flag_sync,
+			Payload: &pb.Message_Blocks{
+				Blocks: &pb.Blocks{
+					Blocks: s.blockRange(p.GetBlocks.From, p.GetBlocks.Count, maxBlocks),
+				},
+			},
+		})
+	case *pb.Message_Headers:
+		s.sync.handleHeaders(peer, p.Headers)
+	case *pb.Message_Blocks:
+		s.sync.handleBlocks(peer, p.Blocks.Blocks)
+	}
+	return nil
+}
+
+// blockRange returns up to count blocks of the chain starting at the given
+// index, at most max.
+func (s *Server) blockRange(from, count, max uint32) []*pb.Block {
+	if count > max {
+		count = max
+	}
+	blocks := []*pb.Block{}
+	for i := from; i < from+count; i++ {
+		b, err := s.chain.GetBlockByHeight(i)
+		if err != nil {
+			break
+		}
+		blocks = append(blocks, b)
+	}
+	return blocks
+}
@@ -0,0 +1,186 @@
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anthdm/consenter/pkg/chain"
+	"github.com/anthdm/consenter/pkg/common"
+	"github.com/anthdm/consenter/pkg/consensus"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/anthdm/consenter/pkg/state"
+	"github.com/anthdm/consenter/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPeer struct {
+	endpoint string
+	sent     chan *pb.Message
+	err      error
+}
+
+func newTestPeer(endpoint string) *testPeer {
+	return &testPeer{
+		endpoint: endpoint,
+		sent:     make(chan *pb.Message, 16),
+	}
+}
+
+func (p *testPeer) Send(msg *pb.Message) error {
+	p.sent <- msg
+	return nil
+}
+
+func (p *testPeer) Disconnect(err error) { p.err = err }
+func (p *testPeer) Endpoint() string     { return p.endpoint }
+
+// receive returns the next message send to the peer.
+func (p *testPeer) receive(t *testing.T) *pb.Message {
+	select {
+	case msg := <-p.sent:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatalf("no message send to %s", p.endpoint)
+		return nil
+	}
+}
+
+var (
+	client   = common.NewPrivateKey([]byte("client"))
+	proposer = common.NewPrivateKey([]byte("node_0"))
+)
+
+// newTestServer returns a server with a chain of n blocks, each holding a tx
+// of the client and signed by the only validator.
+func newTestServer(t *testing.T, n int) *Server {
+	s := NewServer(ServerConfig{
+		Validators: consensus.Validators{&proposer.PublicKey},
+	}, nil)
+	c, err := chain.New(storage.NewMemStore(), nil)
+	assert.Nil(t, err)
+	s.chain = c
+	s.pipeline = consensus.NewBlockPipeline(nil, s.Validators, c)
+	var parent *pb.Block
+	for i := 0; i < n; i++ {
+		b := pb.NewBlock(0)
+		if parent != nil {
+			b = pb.NewBlock(parent.Header.Index)
+			b.Header.PrevHash = parent.Header.Hash()
+		}
+		tx, err := pb.NewSignedTransaction(client, uint64(i), nil)
+		assert.Nil(t, err)
+		b.Transactions = []*pb.Transaction{tx}
+		b.Header.TxRoot = pb.TxRoot(b.Transactions)
+		b.Header.Proposer = common.MarshalPublicKey(&proposer.PublicKey)
+		assert.Nil(t, b.Sign(proposer))
+		_, err = c.Add(b)
+		assert.Nil(t, err)
+		parent = b
+	}
+	s.state, err = state.NewReplica(state.NewKVStore())
+	assert.Nil(t, err)
+	s.updateState(0)
+	return s
+}
+
+// serve lets the remote server respond to the request send to the peer
+// and hands the response to the local server.
+func serve(t *testing.T, local, remote *Server, peer *testPeer) {
+	req := peer.receive(t)
+	assert.Equal(t, pb.Flag_sync, req.Flag)
+	assert.Nil(t, remote.handleSyncMessage(peer, req))
+	assert.Nil(t, local.handleSyncMessage(peer, peer.receive(t)))
+}
+
+func TestSyncDownloadsBlocks(t *testing.T) {
+	var (
+		remote = newTestServer(t, 40)
+		local  = newTestServer(t, 0)
+		a      = newTestPeer("a")
+		b      = newTestPeer("b")
+	)
+	for _, p := range []*testPeer{a, b} {
+		local.peers[p] = true
+		local.sync.requestHeaders(p)
+		serve(t, local, remote, p)
+	}
+	assert.Equal(t, uint32(40), local.sync.target)
+	// Both peers download a range in parallel.
+	assert.Equal(t, 2, len(local.sync.requests))
+	serve(t, local, remote, b)
+	serve(t, local, remote, a)
+
+	assert.Equal(t, uint32(40), local.chain.Height())
+	assert.Equal(t, remote.chain.Head().Header.Hash(), local.chain.Head().Header.Hash())
+	assert.Equal(t, uint32(0), local.sync.target)
+}
+
+func TestSyncInvalidBlocks(t *testing.T) {
+	var (
+		remote = newTestServer(t, 10)
+		local  = newTestServer(t, 0)
+		peer   = newTestPeer("a")
+	)
+	local.peers[peer] = true
+	local.sync.requestHeaders(peer)
+	serve(t, local, remote, peer)
+
+	req := peer.receive(t).GetGetBlocks()
+	assert.Equal(t, uint32(1), req.From)
+	assert.Equal(t, uint32(10), req.Count)
+	// Blocks that do not match the request are penalized and requested
+	// again.
+	blocks := remote.blockRange(2, 5, maxBlocks)
+	local.sync.handleBlocks(peer, blocks)
+	assert.Equal(t, penaltyInvalidBlock, local.penalties[peer])
+	assert.Equal(t, uint32(0), local.chain.Height())
+
+	req = peer.receive(t).GetGetBlocks()
+	assert.Equal(t, uint32(1), req.From)
+	local.sync.handleBlocks(peer, remote.blockRange(1, 10, maxBlocks))
+	assert.Equal(t, uint32(10), local.chain.Height())
+}
+
+func TestServeHeaders(t *testing.T) {
+	var (
+		s    = newTestServer(t, 300)
+		peer = newTestPeer("a")
+	)
+	msg := &pb.Message{
+		Flag: pb.Flag_sync,
+		Payload: &pb.Message_GetHeaders{
+			GetHeaders: &pb.GetHeaders{From: 1, Count: 1000},
+		},
+	}
+	assert.Nil(t, s.handleSyncMessage(peer, msg))
+	headers := peer.receive(t).GetHeaders()
+	assert.Equal(t, uint32(300), headers.Height)
+	assert.Equal(t, maxHeaders, len(headers.Headers))
+	assert.Equal(t, uint32(1), headers.Headers[0].Index)
+}
+
+func TestSyncUntrustedHeight(t *testing.T) {
+	var (
+		local = newTestServer(t, 0)
+		peer  = newTestPeer("a")
+	)
+	local.peers[peer] = true
+	headers := &pb.Headers{Height: 0xffffffff}
+
+	// Headers that do not answer a request are ignored.
+	local.sync.handleHeaders(peer, headers)
+	assert.Equal(t, uint32(0), local.sync.target)
+
+	local.sync.requestHeaders(peer)
+	peer.receive(t)
+	local.sync.handleHeaders(peer, headers)
+	assert.Equal(t, uint32(syncWindow), local.sync.target)
+	assert.Equal(t, syncWindow/maxBlocks-1, len(local.sync.queue))
+
+	// A peer that does not have the blocks of its height is penalized.
+	req := peer.receive(t).GetGetBlocks()
+	assert.Equal(t, uint32(1), req.From)
+	local.sync.handleBlocks(peer, nil)
+	assert.Equal(t, penaltyInvalidBlock, local.penalties[peer])
+	assert.Equal(t, 0, len(local.sync.requests))
+}
@@ -2,6 +2,7 @@ package network
 
 import (
 	"net"
+	"sync"
 
 	"github.com/anthdm/consenter/pkg/common/codec"
 	pb "github.com/anthdm/consenter/pkg/protos"
@@ -12,12 +13,17 @@ type TCPPeer struct {
 	// underlying TCP connection
 	conn  net.Conn
 	errCh chan error
+	once  sync.Once
+	// Serializes the writes of concurrent sends, so their frames do not
+	// interleave.
+	sendLock sync.Mutex
 }
 
 // NewTCPPeer returns a new TCPPeer object.
 func NewTCPPeer(conn net.Conn) *TCPPeer {
 	return &TCPPeer{
-		conn: conn,
+		conn:  conn,
+		errCh: make(chan error),
 	}
 }
 
@@ -27,14 +33,18 @@ func (p *TCPPeer) Send(msg *pb.Message) error {
 	case err := <-p.errCh:
 		return err
 	default:
+		p.sendLock.Lock()
+		defer p.sendLock.Unlock()
 		return codec.EncodeProto(p.conn, msg)
 	}
 }
 
 // Disconnect implements the Peer interface.
 func (p *TCPPeer) Disconnect(err error) {
-	p.conn.Close()
-	close(p.errCh)
+	p.once.Do(func() {
+		p.conn.Close()
+		close(p.errCh)
+	})
 }
 
 // Endpoint implements the Peer interface.
@@ -0,0 +1,40 @@
+package network
+
+import (
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/anthdm/consenter/pkg/common/codec"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTCPPeerConcurrentSend(t *testing.T) {
+	var (
+		local, remote = net.Pipe()
+		peer          = NewTCPPeer(local)
+		n             = 50
+		wg            sync.WaitGroup
+	)
+	defer peer.Disconnect(nil)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			msg := &pb.Message{
+				Payload: &pb.Message_Block{Block: pb.NewBlock(uint32(i))},
+			}
+			assert.Nil(t, peer.Send(msg))
+		}(i)
+	}
+	// Every frame decodes to one of the messages send.
+	seen := make(map[uint32]bool)
+	for i := 0; i < n; i++ {
+		msg := &pb.Message{}
+		assert.Nil(t, codec.DecodeProto(remote, msg))
+		seen[msg.GetBlock().Header.Index] = true
+	}
+	wg.Wait()
+	assert.Equal(t, n, len(seen))
+}
@@ -38,7 +38,7 @@ func (t *TCPTransport) Listen(addr string) error {
 		for {
 			conn, err := ln.Accept()
 			if err != nil {
-				if strings.Contains("close", err.Error()) {
+				if strings.Contains(err.Error(), "closed") {
 					return
 				}
 				log.Warnf("server.tcp accept error: %s", err)
@@ -72,16 +72,29 @@ func (t *TCPTransport) handleConn(conn net.Conn) {
 		err  error
 		peer = NewTCPPeer(conn)
 	)
-	t.srv.addPeer <- peer
+	select {
+	case t.srv.addPeer <- peer:
+	case <-t.srv.quit:
+		conn.Close()
+		return
+	}
 
 	for {
 		msg := pb.Message{}
 		if err = codec.DecodeProto(conn, &msg); err != nil {
 			break
 		}
-		t.srv.protoCh <- messageTuple{
+		select {
+		case t.srv.protoCh <- messageTuple{
 			peer: peer,
 			msg:  &msg,
+		}:
+		case <-t.srv.quit:
+			return
 		}
 	}
+	select {
+	case t.srv.delPeer <- peerDrop{peer: peer, reason: err}:
+	case <-t.srv.quit:
+	}
 }
@@ -1,6 +1,8 @@
 package message
 
 import (
+	"crypto/ecdsa"
+	"errors"
 	"math/rand"
 	"time"
 
@@ -8,6 +10,8 @@ import (
 	proto "github.com/golang/protobuf/proto"
 )
 
+var errInvalidSignature = errors.New("invalid signature")
+
 // NewBlock will create a new block.
 func NewBlock(prevIndex uint32) *Block {
 	return &Block{
@@ -25,9 +29,145 @@ func NewTransaction() *Transaction {
 	}
 }
 
+// NewSignedTransaction will create a new Transaction with the given sequence
+// number and payload, signed by priv.
+func NewSignedTransaction(priv *ecdsa.PrivateKey, sequence uint64, payload []byte) (*Transaction, error) {
+	tx := &Transaction{
+		Sequence: sequence,
+		Payload:  payload,
+	}
+	if err := tx.Sign(priv); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// NewStakeTransaction will create a new Transaction with the given sequence
+// number that changes the stake of the validator of priv by amount, signed by
+// the validator.
+func NewStakeTransaction(priv *ecdsa.PrivateKey, sequence uint64, amount int64) (*Transaction, error) {
+	tx := &Transaction{
+		Sequence: sequence,
+		Stake: &Stake{
+			Validator: common.MarshalPublicKey(&priv.PublicKey),
+			Amount:    amount,
+		},
+	}
+	if err := tx.Sign(priv); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
 // Hash computes the double sha256 hash.
 func (tx *Transaction) Hash() []byte {
-	b, err := proto.Marshal(tx)
+	return hash(tx)
+}
+
+// SignatureHash computes the double sha256 hash of the transaction without
+// its signature, which is the hash signed by the sender.
+func (tx *Transaction) SignatureHash() []byte {
+	tx = proto.Clone(tx).(*Transaction)
+	tx.Signature = nil
+	return tx.Hash()
+}
+
+// Sign sets the sender of the transaction to the public key of priv and
+// signs it.
+func (tx *Transaction) Sign(priv *ecdsa.PrivateKey) error {
+	tx.Sender = common.MarshalPublicKey(&priv.PublicKey)
+	sig, err := common.Sign(priv, tx.SignatureHash())
+	if err != nil {
+		return err
+	}
+	tx.Signature = sig
+	return nil
+}
+
+// Verify verifies that the transaction is signed by its sender and returns
+// the public key of the sender.
+func (tx *Transaction) Verify() (*ecdsa.PublicKey, error) {
+	pub, err := common.UnmarshalPublicKey(tx.Sender)
+	if err != nil {
+		return nil, err
+	}
+	if !common.Verify(pub, tx.SignatureHash(), tx.Signature) {
+		return nil, errInvalidSignature
+	}
+	return pub, nil
+}
+
+// Hash computes the double sha256 hash of the header.
+func (h *Header) Hash() []byte {
+	return hash(h)
+}
+
+// Hash computes the double sha256 hash of the block.
+func (b *Block) Hash() []byte {
+	return hash(b)
+}
+
+// TxRoot computes the Merkle root of the hashes of the transactions, which
+// is nil if there are none.
+func TxRoot(txs []*Transaction) []byte {
+	return common.MerkleRoot(txHashes(txs))
+}
+
+// TxProof returns the Merkle proof that the transaction at the given index
+// is part of the block, which is verified against the TxRoot of its header.
+func (b *Block) TxProof(index int) (*common.MerkleProof, error) {
+	return common.NewMerkleProof(txHashes(b.Transactions), index)
+}
+
+// SignatureHash computes the double sha256 hash of the block without its
+// signature, which is the hash signed by the proposer.
+func (b *Block) SignatureHash() []byte {
+	b = proto.Clone(b).(*Block)
+	b.Signature = nil
+	return b.Hash()
+}
+
+// Sign signs the block with priv and sets its signature.
+func (b *Block) Sign(priv *ecdsa.PrivateKey) error {
+	sig, err := common.Sign(priv, b.SignatureHash())
+	if err != nil {
+		return err
+	}
+	b.Signature = sig
+	return nil
+}
+
+// Verify verifies that the block is signed by the proposer in its header and
+// returns the public key of the proposer.
+func (b *Block) Verify() (*ecdsa.PublicKey, error) {
+	if b.Header == nil {
+		return nil, errInvalidSignature
+	}
+	pub, err := common.UnmarshalPublicKey(b.Header.Proposer)
+	if err != nil {
+		return nil, err
+	}
+	if !common.Verify(pub, b.SignatureHash(), b.Signature) {
+		return nil, errInvalidSignature
+	}
+	return pub, nil
+}
+
+// Hash computes the double sha256 hash of the message.
+func (m *Message) Hash() []byte {
+	return hash(m)
+}
+
+func txHashes(txs []*Transaction) [][]byte {
+	hashes := make([][]byte, len(txs))
+	for i, tx := range txs {
+		hashes[i] = tx.Hash()
+	}
+	return hashes
+}
+
+func hash(msg proto.Message) []byte {
+	b, err := proto.Marshal(msg)
 	if err != nil {
 		panic(err)
 	}
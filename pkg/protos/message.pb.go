@@ -16,6 +16,60 @@ It has these top-level messages:
 	Header
 	Block
 	Transaction
+	Stake
+	KVOp
+	KVSnapshot
+	KVPair
+	KVSequence
+	Hello
+	Identity
+	GetHeaders
+	Headers
+	GetBlocks
+	Blocks
+	FbftPrepare
+	FbftCommit
+	FbftReveal
+	FbftViewChange
+	FbftCatchUp
+	FbftCertificate
+	PbftPrePrepare
+	PbftPrepare
+	PbftCommit
+	PbftPrepared
+	PbftViewChange
+	PbftNewView
+	RaftEntry
+	RaftRequestVote
+	RaftVote
+	RaftAppendEntries
+	RaftAppendResponse
+	TendermintProposal
+	TendermintVote
+	TendermintCommit
+	HotstuffProposal
+	HotstuffVote
+	HotstuffQC
+	HotstuffNewView
+	AvalancheQuery
+	AvalancheResponse
+	HoneybadgerCiphertext
+	HoneybadgerBroadcast
+	HoneybadgerAgreement
+	HoneybadgerDecryption
+	AlgorandProposal
+	AlgorandVote
+	CasperVote
+	CasperSlashing
+	PaxosSlot
+	PaxosPrepare
+	PaxosPromise
+	PaxosAccept
+	PaxosAccepted
+	PaxosLearn
+	PaxosHeartbeat
+	PaxosCatchUp
+	DagEvent
 */
 package message
 
@@ -39,15 +93,24 @@ type Flag int32
 const (
 	Flag_consensus Flag = 0
 	Flag_payload   Flag = 1
+	// Requests and responses of the block sync, send to a single peer.
+	Flag_sync Flag = 2
+	// The identity handshake and consensus messages addressed to a single
+	// validator, which are send to a single peer and not relayed.
+	Flag_direct Flag = 3
 )
 
 var Flag_name = map[int32]string{
 	0: "consensus",
 	1: "payload",
+	2: "sync",
+	3: "direct",
 }
 var Flag_value = map[string]int32{
 	"consensus": 0,
 	"payload":   1,
+	"sync":      2,
+	"direct":    3,
 }
 
 func (x Flag) String() string {
@@ -55,6 +118,102 @@ func (x Flag) String() string {
 }
 func (Flag) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
 
+type KVOpType int32
+
+const (
+	KVOpType_set    KVOpType = 0
+	KVOpType_delete KVOpType = 1
+)
+
+var KVOpType_name = map[int32]string{
+	0: "set",
+	1: "delete",
+}
+var KVOpType_value = map[string]int32{
+	"set":    0,
+	"delete": 1,
+}
+
+func (x KVOpType) String() string {
+	return proto.EnumName(KVOpType_name, int32(x))
+}
+func (KVOpType) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+// TendermintVoteType is the step of a round a vote belongs to.
+type TendermintVoteType int32
+
+const (
+	TendermintVoteType_prevote   TendermintVoteType = 0
+	TendermintVoteType_precommit TendermintVoteType = 1
+)
+
+var TendermintVoteType_name = map[int32]string{
+	0: "prevote",
+	1: "precommit",
+}
+var TendermintVoteType_value = map[string]int32{
+	"prevote":   0,
+	"precommit": 1,
+}
+
+func (x TendermintVoteType) String() string {
+	return proto.EnumName(TendermintVoteType_name, int32(x))
+}
+func (TendermintVoteType) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+// HoneybadgerBroadcastType is the step of a reliable broadcast.
+type HoneybadgerBroadcastType int32
+
+const (
+	HoneybadgerBroadcastType_val   HoneybadgerBroadcastType = 0
+	HoneybadgerBroadcastType_echo  HoneybadgerBroadcastType = 1
+	HoneybadgerBroadcastType_ready HoneybadgerBroadcastType = 2
+)
+
+var HoneybadgerBroadcastType_name = map[int32]string{
+	0: "val",
+	1: "echo",
+	2: "ready",
+}
+var HoneybadgerBroadcastType_value = map[string]int32{
+	"val":   0,
+	"echo":  1,
+	"ready": 2,
+}
+
+func (x HoneybadgerBroadcastType) String() string {
+	return proto.EnumName(HoneybadgerBroadcastType_name, int32(x))
+}
+func (HoneybadgerBroadcastType) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+// HoneybadgerAgreementType is the step of a binary agreement round.
+type HoneybadgerAgreementType int32
+
+const (
+	HoneybadgerAgreementType_bval HoneybadgerAgreementType = 0
+	HoneybadgerAgreementType_aux  HoneybadgerAgreementType = 1
+	HoneybadgerAgreementType_coin HoneybadgerAgreementType = 2
+	HoneybadgerAgreementType_term HoneybadgerAgreementType = 3
+)
+
+var HoneybadgerAgreementType_name = map[int32]string{
+	0: "bval",
+	1: "aux",
+	2: "coin",
+	3: "term",
+}
+var HoneybadgerAgreementType_value = map[string]int32{
+	"bval": 0,
+	"aux":  1,
+	"coin": 2,
+	"term": 3,
+}
+
+func (x HoneybadgerAgreementType) String() string {
+	return proto.EnumName(HoneybadgerAgreementType_name, int32(x))
+}
+func (HoneybadgerAgreementType) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
 type Message struct {
 	Flag Flag `protobuf:"varint,1,opt,name=flag,enum=message.Flag" json:"flag,omitempty"`
 	// Types that are valid to be assigned to Payload:
@@ -63,6 +222,50 @@ type Message struct {
 	//	*Message_PeerResponse
 	//	*Message_Transaction
 	//	*Message_Block
+	//	*Message_FbftPrepare
+	//	*Message_FbftCommit
+	//	*Message_FbftReveal
+	//	*Message_FbftViewChange
+	//	*Message_PbftPrePrepare
+	//	*Message_PbftPrepare
+	//	*Message_PbftCommit
+	//	*Message_PbftViewChange
+	//	*Message_PbftNewView
+	//	*Message_RaftRequestVote
+	//	*Message_RaftVote
+	//	*Message_RaftAppendEntries
+	//	*Message_RaftAppendResponse
+	//	*Message_TendermintProposal
+	//	*Message_TendermintVote
+	//	*Message_HotstuffProposal
+	//	*Message_HotstuffVote
+	//	*Message_HotstuffNewView
+	//	*Message_AvalancheQuery
+	//	*Message_AvalancheResponse
+	//	*Message_HoneybadgerBroadcast
+	//	*Message_HoneybadgerAgreement
+	//	*Message_HoneybadgerDecryption
+	//	*Message_AlgorandProposal
+	//	*Message_AlgorandVote
+	//	*Message_CasperVote
+	//	*Message_CasperSlashing
+	//	*Message_PaxosPrepare
+	//	*Message_PaxosPromise
+	//	*Message_PaxosAccept
+	//	*Message_PaxosAccepted
+	//	*Message_PaxosLearn
+	//	*Message_PaxosHeartbeat
+	//	*Message_DagEvent
+	//	*Message_GetHeaders
+	//	*Message_Headers
+	//	*Message_GetBlocks
+	//	*Message_Blocks
+	//	*Message_TendermintCommit
+	//	*Message_Hello
+	//	*Message_Identity
+	//	*Message_PaxosCatchUp
+	//	*Message_FbftCatchUp
+	//	*Message_FbftCertificate
 	Payload isMessage_Payload `protobuf_oneof:"Payload"`
 }
 
@@ -90,12 +293,188 @@ type Message_Transaction struct {
 type Message_Block struct {
 	Block *Block `protobuf:"bytes,6,opt,name=block,oneof"`
 }
+type Message_FbftPrepare struct {
+	FbftPrepare *FbftPrepare `protobuf:"bytes,7,opt,name=fbft_prepare,json=fbftPrepare,oneof"`
+}
+type Message_FbftCommit struct {
+	FbftCommit *FbftCommit `protobuf:"bytes,8,opt,name=fbft_commit,json=fbftCommit,oneof"`
+}
+type Message_FbftReveal struct {
+	FbftReveal *FbftReveal `protobuf:"bytes,9,opt,name=fbft_reveal,json=fbftReveal,oneof"`
+}
+type Message_FbftViewChange struct {
+	FbftViewChange *FbftViewChange `protobuf:"bytes,10,opt,name=fbft_view_change,json=fbftViewChange,oneof"`
+}
+type Message_PbftPrePrepare struct {
+	PbftPrePrepare *PbftPrePrepare `protobuf:"bytes,11,opt,name=pbft_pre_prepare,json=pbftPrePrepare,oneof"`
+}
+type Message_PbftPrepare struct {
+	PbftPrepare *PbftPrepare `protobuf:"bytes,12,opt,name=pbft_prepare,json=pbftPrepare,oneof"`
+}
+type Message_PbftCommit struct {
+	PbftCommit *PbftCommit `protobuf:"bytes,13,opt,name=pbft_commit,json=pbftCommit,oneof"`
+}
+type Message_PbftViewChange struct {
+	PbftViewChange *PbftViewChange `protobuf:"bytes,14,opt,name=pbft_view_change,json=pbftViewChange,oneof"`
+}
+type Message_PbftNewView struct {
+	PbftNewView *PbftNewView `protobuf:"bytes,15,opt,name=pbft_new_view,json=pbftNewView,oneof"`
+}
+type Message_RaftRequestVote struct {
+	RaftRequestVote *RaftRequestVote `protobuf:"bytes,16,opt,name=raft_request_vote,json=raftRequestVote,oneof"`
+}
+type Message_RaftVote struct {
+	RaftVote *RaftVote `protobuf:"bytes,17,opt,name=raft_vote,json=raftVote,oneof"`
+}
+type Message_RaftAppendEntries struct {
+	RaftAppendEntries *RaftAppendEntries `protobuf:"bytes,18,opt,name=raft_append_entries,json=raftAppendEntries,oneof"`
+}
+type Message_RaftAppendResponse struct {
+	RaftAppendResponse *RaftAppendResponse `protobuf:"bytes,19,opt,name=raft_append_response,json=raftAppendResponse,oneof"`
+}
+type Message_TendermintProposal struct {
+	TendermintProposal *TendermintProposal `protobuf:"bytes,20,opt,name=tendermint_proposal,json=tendermintProposal,oneof"`
+}
+type Message_TendermintVote struct {
+	TendermintVote *TendermintVote `protobuf:"bytes,21,opt,name=tendermint_vote,json=tendermintVote,oneof"`
+}
+type Message_HotstuffProposal struct {
+	HotstuffProposal *HotstuffProposal `protobuf:"bytes,22,opt,name=hotstuff_proposal,json=hotstuffProposal,oneof"`
+}
+type Message_HotstuffVote struct {
+	HotstuffVote *HotstuffVote `protobuf:"bytes,23,opt,name=hotstuff_vote,json=hotstuffVote,oneof"`
+}
+type Message_HotstuffNewView struct {
+	HotstuffNewView *HotstuffNewView `protobuf:"bytes,24,opt,name=hotstuff_new_view,json=hotstuffNewView,oneof"`
+}
+type Message_AvalancheQuery struct {
+	AvalancheQuery *AvalancheQuery `protobuf:"bytes,25,opt,name=avalanche_query,json=avalancheQuery,oneof"`
+}
+type Message_AvalancheResponse struct {
+	AvalancheResponse *AvalancheResponse `protobuf:"bytes,26,opt,name=avalanche_response,json=avalancheResponse,oneof"`
+}
+type Message_HoneybadgerBroadcast struct {
+	HoneybadgerBroadcast *HoneybadgerBroadcast `protobuf:"bytes,27,opt,name=honeybadger_broadcast,json=honeybadgerBroadcast,oneof"`
+}
+type Message_HoneybadgerAgreement struct {
+	HoneybadgerAgreement *HoneybadgerAgreement `protobuf:"bytes,28,opt,name=honeybadger_agreement,json=honeybadgerAgreement,oneof"`
+}
+type Message_HoneybadgerDecryption struct {
+	HoneybadgerDecryption *HoneybadgerDecryption `protobuf:"bytes,29,opt,name=honeybadger_decryption,json=honeybadgerDecryption,oneof"`
+}
+type Message_AlgorandProposal struct {
+	AlgorandProposal *AlgorandProposal `protobuf:"bytes,30,opt,name=algorand_proposal,json=algorandProposal,oneof"`
+}
+type Message_AlgorandVote struct {
+	AlgorandVote *AlgorandVote `protobuf:"bytes,31,opt,name=algorand_vote,json=algorandVote,oneof"`
+}
+type Message_CasperVote struct {
+	CasperVote *CasperVote `protobuf:"bytes,32,opt,name=casper_vote,json=casperVote,oneof"`
+}
+type Message_CasperSlashing struct {
+	CasperSlashing *CasperSlashing `protobuf:"bytes,33,opt,name=casper_slashing,json=casperSlashing,oneof"`
+}
+type Message_PaxosPrepare struct {
+	PaxosPrepare *PaxosPrepare `protobuf:"bytes,34,opt,name=paxos_prepare,json=paxosPrepare,oneof"`
+}
+type Message_PaxosPromise struct {
+	PaxosPromise *PaxosPromise `protobuf:"bytes,35,opt,name=paxos_promise,json=paxosPromise,oneof"`
+}
+type Message_PaxosAccept struct {
+	PaxosAccept *PaxosAccept `protobuf:"bytes,36,opt,name=paxos_accept,json=paxosAccept,oneof"`
+}
+type Message_PaxosAccepted struct {
+	PaxosAccepted *PaxosAccepted `protobuf:"bytes,37,opt,name=paxos_accepted,json=paxosAccepted,oneof"`
+}
+type Message_PaxosLearn struct {
+	PaxosLearn *PaxosLearn `protobuf:"bytes,38,opt,name=paxos_learn,json=paxosLearn,oneof"`
+}
+type Message_PaxosHeartbeat struct {
+	PaxosHeartbeat *PaxosHeartbeat `protobuf:"bytes,39,opt,name=paxos_heartbeat,json=paxosHeartbeat,oneof"`
+}
+type Message_DagEvent struct {
+	DagEvent *DagEvent `protobuf:"bytes,40,opt,name=dag_event,json=dagEvent,oneof"`
+}
+type Message_GetHeaders struct {
+	GetHeaders *GetHeaders `protobuf:"bytes,41,opt,name=get_headers,json=getHeaders,oneof"`
+}
+type Message_Headers struct {
+	Headers *Headers `protobuf:"bytes,42,opt,name=headers,oneof"`
+}
+type Message_GetBlocks struct {
+	GetBlocks *GetBlocks `protobuf:"bytes,43,opt,name=get_blocks,json=getBlocks,oneof"`
+}
+type Message_Blocks struct {
+	Blocks *Blocks `protobuf:"bytes,44,opt,name=blocks,oneof"`
+}
+type Message_TendermintCommit struct {
+	TendermintCommit *TendermintCommit `protobuf:"bytes,45,opt,name=tendermint_commit,json=tendermintCommit,oneof"`
+}
+type Message_Hello struct {
+	Hello *Hello `protobuf:"bytes,46,opt,name=hello,oneof"`
+}
+type Message_Identity struct {
+	Identity *Identity `protobuf:"bytes,47,opt,name=identity,oneof"`
+}
+type Message_PaxosCatchUp struct {
+	PaxosCatchUp *PaxosCatchUp `protobuf:"bytes,48,opt,name=paxos_catch_up,json=paxosCatchUp,oneof"`
+}
+type Message_FbftCatchUp struct {
+	FbftCatchUp *FbftCatchUp `protobuf:"bytes,49,opt,name=fbft_catch_up,json=fbftCatchUp,oneof"`
+}
+type Message_FbftCertificate struct {
+	FbftCertificate *FbftCertificate `protobuf:"bytes,50,opt,name=fbft_certificate,json=fbftCertificate,oneof"`
+}
 
-func (*Message_State) isMessage_Payload()        {}
-func (*Message_PeerRequest) isMessage_Payload()  {}
-func (*Message_PeerResponse) isMessage_Payload() {}
-func (*Message_Transaction) isMessage_Payload()  {}
-func (*Message_Block) isMessage_Payload()        {}
+func (*Message_State) isMessage_Payload()                 {}
+func (*Message_PeerRequest) isMessage_Payload()           {}
+func (*Message_PeerResponse) isMessage_Payload()          {}
+func (*Message_Transaction) isMessage_Payload()           {}
+func (*Message_Block) isMessage_Payload()                 {}
+func (*Message_FbftPrepare) isMessage_Payload()           {}
+func (*Message_FbftCommit) isMessage_Payload()            {}
+func (*Message_FbftReveal) isMessage_Payload()            {}
+func (*Message_FbftViewChange) isMessage_Payload()        {}
+func (*Message_PbftPrePrepare) isMessage_Payload()        {}
+func (*Message_PbftPrepare) isMessage_Payload()           {}
+func (*Message_PbftCommit) isMessage_Payload()            {}
+func (*Message_PbftViewChange) isMessage_Payload()        {}
+func (*Message_PbftNewView) isMessage_Payload()           {}
+func (*Message_RaftRequestVote) isMessage_Payload()       {}
+func (*Message_RaftVote) isMessage_Payload()              {}
+func (*Message_RaftAppendEntries) isMessage_Payload()     {}
+func (*Message_RaftAppendResponse) isMessage_Payload()    {}
+func (*Message_TendermintProposal) isMessage_Payload()    {}
+func (*Message_TendermintVote) isMessage_Payload()        {}
+func (*Message_HotstuffProposal) isMessage_Payload()      {}
+func (*Message_HotstuffVote) isMessage_Payload()          {}
+func (*Message_HotstuffNewView) isMessage_Payload()       {}
+func (*Message_AvalancheQuery) isMessage_Payload()        {}
+func (*Message_AvalancheResponse) isMessage_Payload()     {}
+func (*Message_HoneybadgerBroadcast) isMessage_Payload()  {}
+func (*Message_HoneybadgerAgreement) isMessage_Payload()  {}
+func (*Message_HoneybadgerDecryption) isMessage_Payload() {}
+func (*Message_AlgorandProposal) isMessage_Payload()      {}
+func (*Message_AlgorandVote) isMessage_Payload()          {}
+func (*Message_CasperVote) isMessage_Payload()            {}
+func (*Message_CasperSlashing) isMessage_Payload()        {}
+func (*Message_PaxosPrepare) isMessage_Payload()          {}
+func (*Message_PaxosPromise) isMessage_Payload()          {}
+func (*Message_PaxosAccept) isMessage_Payload()           {}
+func (*Message_PaxosAccepted) isMessage_Payload()         {}
+func (*Message_PaxosLearn) isMessage_Payload()            {}
+func (*Message_PaxosHeartbeat) isMessage_Payload()        {}
+func (*Message_DagEvent) isMessage_Payload()              {}
+func (*Message_GetHeaders) isMessage_Payload()            {}
+func (*Message_Headers) isMessage_Payload()               {}
+func (*Message_GetBlocks) isMessage_Payload()             {}
+func (*Message_Blocks) isMessage_Payload()                {}
+func (*Message_TendermintCommit) isMessage_Payload()      {}
+func (*Message_Hello) isMessage_Payload()                 {}
+func (*Message_Identity) isMessage_Payload()              {}
+func (*Message_PaxosCatchUp) isMessage_Payload()          {}
+func (*Message_FbftCatchUp) isMessage_Payload()           {}
+func (*Message_FbftCertificate) isMessage_Payload()       {}
 
 func (m *Message) GetPayload() isMessage_Payload {
 	if m != nil {
@@ -146,6 +525,314 @@ func (m *Message) GetBlock() *Block {
 	return nil
 }
 
+func (m *Message) GetFbftPrepare() *FbftPrepare {
+	if x, ok := m.GetPayload().(*Message_FbftPrepare); ok {
+		return x.FbftPrepare
+	}
+	return nil
+}
+
+func (m *Message) GetFbftCommit() *FbftCommit {
+	if x, ok := m.GetPayload().(*Message_FbftCommit); ok {
+		return x.FbftCommit
+	}
+	return nil
+}
+
+func (m *Message) GetFbftReveal() *FbftReveal {
+	if x, ok := m.GetPayload().(*Message_FbftReveal); ok {
+		return x.FbftReveal
+	}
+	return nil
+}
+
+func (m *Message) GetFbftViewChange() *FbftViewChange {
+	if x, ok := m.GetPayload().(*Message_FbftViewChange); ok {
+		return x.FbftViewChange
+	}
+	return nil
+}
+
+func (m *Message) GetPbftPrePrepare() *PbftPrePrepare {
+	if x, ok := m.GetPayload().(*Message_PbftPrePrepare); ok {
+		return x.PbftPrePrepare
+	}
+	return nil
+}
+
+func (m *Message) GetPbftPrepare() *PbftPrepare {
+	if x, ok := m.GetPayload().(*Message_PbftPrepare); ok {
+		return x.PbftPrepare
+	}
+	return nil
+}
+
+func (m *Message) GetPbftCommit() *PbftCommit {
+	if x, ok := m.GetPayload().(*Message_PbftCommit); ok {
+		return x.PbftCommit
+	}
+	return nil
+}
+
+func (m *Message) GetPbftViewChange() *PbftViewChange {
+	if x, ok := m.GetPayload().(*Message_PbftViewChange); ok {
+		return x.PbftViewChange
+	}
+	return nil
+}
+
+func (m *Message) GetPbftNewView() *PbftNewView {
+	if x, ok := m.GetPayload().(*Message_PbftNewView); ok {
+		return x.PbftNewView
+	}
+	return nil
+}
+
+func (m *Message) GetRaftRequestVote() *RaftRequestVote {
+	if x, ok := m.GetPayload().(*Message_RaftRequestVote); ok {
+		return x.RaftRequestVote
+	}
+	return nil
+}
+
+func (m *Message) GetRaftVote() *RaftVote {
+	if x, ok := m.GetPayload().(*Message_RaftVote); ok {
+		return x.RaftVote
+	}
+	return nil
+}
+
+func (m *Message) GetRaftAppendEntries() *RaftAppendEntries {
+	if x, ok := m.GetPayload().(*Message_RaftAppendEntries); ok {
+		return x.RaftAppendEntries
+	}
+	return nil
+}
+
+func (m *Message) GetRaftAppendResponse() *RaftAppendResponse {
+	if x, ok := m.GetPayload().(*Message_RaftAppendResponse); ok {
+		return x.RaftAppendResponse
+	}
+	return nil
+}
+
+func (m *Message) GetTendermintProposal() *TendermintProposal {
+	if x, ok := m.GetPayload().(*Message_TendermintProposal); ok {
+		return x.TendermintProposal
+	}
+	return nil
+}
+
+func (m *Message) GetTendermintVote() *TendermintVote {
+	if x, ok := m.GetPayload().(*Message_TendermintVote); ok {
+		return x.TendermintVote
+	}
+	return nil
+}
+
+func (m *Message) GetHotstuffProposal() *HotstuffProposal {
+	if x, ok := m.GetPayload().(*Message_HotstuffProposal); ok {
+		return x.HotstuffProposal
+	}
+	return nil
+}
+
+func (m *Message) GetHotstuffVote() *HotstuffVote {
+	if x, ok := m.GetPayload().(*Message_HotstuffVote); ok {
+		return x.HotstuffVote
+	}
+	return nil
+}
+
+func (m *Message) GetHotstuffNewView() *HotstuffNewView {
+	if x, ok := m.GetPayload().(*Message_HotstuffNewView); ok {
+		return x.HotstuffNewView
+	}
+	return nil
+}
+
+func (m *Message) GetAvalancheQuery() *AvalancheQuery {
+	if x, ok := m.GetPayload().(*Message_AvalancheQuery); ok {
+		return x.AvalancheQuery
+	}
+	return nil
+}
+
+func (m *Message) GetAvalancheResponse() *AvalancheResponse {
+	if x, ok := m.GetPayload().(*Message_AvalancheResponse); ok {
+		return x.AvalancheResponse
+	}
+	return nil
+}
+
+func (m *Message) GetHoneybadgerBroadcast() *HoneybadgerBroadcast {
+	if x, ok := m.GetPayload().(*Message_HoneybadgerBroadcast); ok {
+		return x.HoneybadgerBroadcast
+	}
+	return nil
+}
+
+func (m *Message) GetHoneybadgerAgreement() *HoneybadgerAgreement {
+	if x, ok := m.GetPayload().(*Message_HoneybadgerAgreement); ok {
+		return x.HoneybadgerAgreement
+	}
+	return nil
+}
+
+func (m *Message) GetHoneybadgerDecryption() *HoneybadgerDecryption {
+	if x, ok := m.GetPayload().(*Message_HoneybadgerDecryption); ok {
+		return x.HoneybadgerDecryption
+	}
+	return nil
+}
+
+func (m *Message) GetAlgorandProposal() *AlgorandProposal {
+	if x, ok := m.GetPayload().(*Message_AlgorandProposal); ok {
+		return x.AlgorandProposal
+	}
+	return nil
+}
+
+func (m *Message) GetAlgorandVote() *AlgorandVote {
+	if x, ok := m.GetPayload().(*Message_AlgorandVote); ok {
+		return x.AlgorandVote
+	}
+	return nil
+}
+
+func (m *Message) GetCasperVote() *CasperVote {
+	if x, ok := m.GetPayload().(*Message_CasperVote); ok {
+		return x.CasperVote
+	}
+	return nil
+}
+
+func (m *Message) GetCasperSlashing() *CasperSlashing {
+	if x, ok := m.GetPayload().(*Message_CasperSlashing); ok {
+		return x.CasperSlashing
+	}
+	return nil
+}
+
+func (m *Message) GetPaxosPrepare() *PaxosPrepare {
+	if x, ok := m.GetPayload().(*Message_PaxosPrepare); ok {
+		return x.PaxosPrepare
+	}
+	return nil
+}
+
+func (m *Message) GetPaxosPromise() *PaxosPromise {
+	if x, ok := m.GetPayload().(*Message_PaxosPromise); ok {
+		return x.PaxosPromise
+	}
+	return nil
+}
+
+func (m *Message) GetPaxosAccept() *PaxosAccept {
+	if x, ok := m.GetPayload().(*Message_PaxosAccept); ok {
+		return x.PaxosAccept
+	}
+	return nil
+}
+
+func (m *Message) GetPaxosAccepted() *PaxosAccepted {
+	if x, ok := m.GetPayload().(*Message_PaxosAccepted); ok {
+		return x.PaxosAccepted
+	}
+	return nil
+}
+
+func (m *Message) GetPaxosLearn() *PaxosLearn {
+	if x, ok := m.GetPayload().(*Message_PaxosLearn); ok {
+		return x.PaxosLearn
+	}
+	return nil
+}
+
+func (m *Message) GetPaxosHeartbeat() *PaxosHeartbeat {
+	if x, ok := m.GetPayload().(*Message_PaxosHeartbeat); ok {
+		return x.PaxosHeartbeat
+	}
+	return nil
+}
+
+func (m *Message) GetDagEvent() *DagEvent {
+	if x, ok := m.GetPayload().(*Message_DagEvent); ok {
+		return x.DagEvent
+	}
+	return nil
+}
+
+func (m *Message) GetGetHeaders() *GetHeaders {
+	if x, ok := m.GetPayload().(*Message_GetHeaders); ok {
+		return x.GetHeaders
+	}
+	return nil
+}
+
+func (m *Message) GetHeaders() *Headers {
+	if x, ok := m.GetPayload().(*Message_Headers); ok {
+		return x.Headers
+	}
+	return nil
+}
+
+func (m *Message) GetGetBlocks() *GetBlocks {
+	if x, ok := m.GetPayload().(*Message_GetBlocks); ok {
+		return x.GetBlocks
+	}
+	return nil
+}
+
+func (m *Message) GetBlocks() *Blocks {
+	if x, ok := m.GetPayload().(*Message_Blocks); ok {
+		return x.Blocks
+	}
+	return nil
+}
+
+func (m *Message) GetTendermintCommit() *TendermintCommit {
+	if x, ok := m.GetPayload().(*Message_TendermintCommit); ok {
+		return x.TendermintCommit
+	}
+	return nil
+}
+
+func (m *Message) GetHello() *Hello {
+	if x, ok := m.GetPayload().(*Message_Hello); ok {
+		return x.Hello
+	}
+	return nil
+}
+
+func (m *Message) GetIdentity() *Identity {
+	if x, ok := m.GetPayload().(*Message_Identity); ok {
+		return x.Identity
+	}
+	return nil
+}
+
+func (m *Message) GetPaxosCatchUp() *PaxosCatchUp {
+	if x, ok := m.GetPayload().(*Message_PaxosCatchUp); ok {
+		return x.PaxosCatchUp
+	}
+	return nil
+}
+
+func (m *Message) GetFbftCatchUp() *FbftCatchUp {
+	if x, ok := m.GetPayload().(*Message_FbftCatchUp); ok {
+		return x.FbftCatchUp
+	}
+	return nil
+}
+
+func (m *Message) GetFbftCertificate() *FbftCertificate {
+	if x, ok := m.GetPayload().(*Message_FbftCertificate); ok {
+		return x.FbftCertificate
+	}
+	return nil
+}
+
 // XXX_OneofFuncs is for the internal use of the proto package.
 func (*Message) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
 	return _Message_OneofMarshaler, _Message_OneofUnmarshaler, _Message_OneofSizer, []interface{}{
@@ -154,6 +841,50 @@ func (*Message) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error
 		(*Message_PeerResponse)(nil),
 		(*Message_Transaction)(nil),
 		(*Message_Block)(nil),
+		(*Message_FbftPrepare)(nil),
+		(*Message_FbftCommit)(nil),
+		(*Message_FbftReveal)(nil),
+		(*Message_FbftViewChange)(nil),
+		(*Message_PbftPrePrepare)(nil),
+		(*Message_PbftPrepare)(nil),
+		(*Message_PbftCommit)(nil),
+		(*Message_PbftViewChange)(nil),
+		(*Message_PbftNewView)(nil),
+		(*Message_RaftRequestVote)(nil),
+		(*Message_RaftVote)(nil),
+		(*Message_RaftAppendEntries)(nil),
+		(*Message_RaftAppendResponse)(nil),
+		(*Message_TendermintProposal)(nil),
+		(*Message_TendermintVote)(nil),
+		(*Message_HotstuffProposal)(nil),
+		(*Message_HotstuffVote)(nil),
+		(*Message_HotstuffNewView)(nil),
+		(*Message_AvalancheQuery)(nil),
+		(*Message_AvalancheResponse)(nil),
+		(*Message_HoneybadgerBroadcast)(nil),
+		(*Message_HoneybadgerAgreement)(nil),
+		(*Message_HoneybadgerDecryption)(nil),
+		(*Message_AlgorandProposal)(nil),
+		(*Message_AlgorandVote)(nil),
+		(*Message_CasperVote)(nil),
+		(*Message_CasperSlashing)(nil),
+		(*Message_PaxosPrepare)(nil),
+		(*Message_PaxosPromise)(nil),
+		(*Message_PaxosAccept)(nil),
+		(*Message_PaxosAccepted)(nil),
+		(*Message_PaxosLearn)(nil),
+		(*Message_PaxosHeartbeat)(nil),
+		(*Message_DagEvent)(nil),
+		(*Message_GetHeaders)(nil),
+		(*Message_Headers)(nil),
+		(*Message_GetBlocks)(nil),
+		(*Message_Blocks)(nil),
+		(*Message_TendermintCommit)(nil),
+		(*Message_Hello)(nil),
+		(*Message_Identity)(nil),
+		(*Message_PaxosCatchUp)(nil),
+		(*Message_FbftCatchUp)(nil),
+		(*Message_FbftCertificate)(nil),
 	}
 }
 
@@ -186,6 +917,226 @@ func _Message_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
 		if err := b.EncodeMessage(x.Block); err != nil {
 			return err
 		}
+	case *Message_FbftPrepare:
+		b.EncodeVarint(7<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.FbftPrepare); err != nil {
+			return err
+		}
+	case *Message_FbftCommit:
+		b.EncodeVarint(8<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.FbftCommit); err != nil {
+			return err
+		}
+	case *Message_FbftReveal:
+		b.EncodeVarint(9<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.FbftReveal); err != nil {
+			return err
+		}
+	case *Message_FbftViewChange:
+		b.EncodeVarint(10<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.FbftViewChange); err != nil {
+			return err
+		}
+	case *Message_PbftPrePrepare:
+		b.EncodeVarint(11<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.PbftPrePrepare); err != nil {
+			return err
+		}
+	case *Message_PbftPrepare:
+		b.EncodeVarint(12<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.PbftPrepare); err != nil {
+			return err
+		}
+	case *Message_PbftCommit:
+		b.EncodeVarint(13<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.PbftCommit); err != nil {
+			return err
+		}
+	case *Message_PbftViewChange:
+		b.EncodeVarint(14<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.PbftViewChange); err != nil {
+			return err
+		}
+	case *Message_PbftNewView:
+		b.EncodeVarint(15<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.PbftNewView); err != nil {
+			return err
+		}
+	case *Message_RaftRequestVote:
+		b.EncodeVarint(16<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.RaftRequestVote); err != nil {
+			return err
+		}
+	case *Message_RaftVote:
+		b.EncodeVarint(17<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.RaftVote); err != nil {
+			return err
+		}
+	case *Message_RaftAppendEntries:
+		b.EncodeVarint(18<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.RaftAppendEntries); err != nil {
+			return err
+		}
+	case *Message_RaftAppendResponse:
+		b.EncodeVarint(19<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.RaftAppendResponse); err != nil {
+			return err
+		}
+	case *Message_TendermintProposal:
+		b.EncodeVarint(20<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.TendermintProposal); err != nil {
+			return err
+		}
+	case *Message_TendermintVote:
+		b.EncodeVarint(21<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.TendermintVote); err != nil {
+			return err
+		}
+	case *Message_HotstuffProposal:
+		b.EncodeVarint(22<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.HotstuffProposal); err != nil {
+			return err
+		}
+	case *Message_HotstuffVote:
+		b.EncodeVarint(23<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.HotstuffVote); err != nil {
+			return err
+		}
+	case *Message_HotstuffNewView:
+		b.EncodeVarint(24<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.HotstuffNewView); err != nil {
+			return err
+		}
+	case *Message_AvalancheQuery:
+		b.EncodeVarint(25<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.AvalancheQuery); err != nil {
+			return err
+		}
+	case *Message_AvalancheResponse:
+		b.EncodeVarint(26<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.AvalancheResponse); err != nil {
+			return err
+		}
+	case *Message_HoneybadgerBroadcast:
+		b.EncodeVarint(27<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.HoneybadgerBroadcast); err != nil {
+			return err
+		}
+	case *Message_HoneybadgerAgreement:
+		b.EncodeVarint(28<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.HoneybadgerAgreement); err != nil {
+			return err
+		}
+	case *Message_HoneybadgerDecryption:
+		b.EncodeVarint(29<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.HoneybadgerDecryption); err != nil {
+			return err
+		}
+	case *Message_AlgorandProposal:
+		b.EncodeVarint(30<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.AlgorandProposal); err != nil {
+			return err
+		}
+	case *Message_AlgorandVote:
+		b.EncodeVarint(31<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.AlgorandVote); err != nil {
+			return err
+		}
+	case *Message_CasperVote:
+		b.EncodeVarint(32<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.CasperVote); err != nil {
+			return err
+		}
+	case *Message_CasperSlashing:
+		b.EncodeVarint(33<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.CasperSlashing); err != nil {
+			return err
+		}
+	case *Message_PaxosPrepare:
+		b.EncodeVarint(34<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.PaxosPrepare); err != nil {
+			return err
+		}
+	case *Message_PaxosPromise:
+		b.EncodeVarint(35<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.PaxosPromise); err != nil {
+			return err
+		}
+	case *Message_PaxosAccept:
+		b.EncodeVarint(36<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.PaxosAccept); err != nil {
+			return err
+		}
+	case *Message_PaxosAccepted:
+		b.EncodeVarint(37<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.PaxosAccepted); err != nil {
+			return err
+		}
+	case *Message_PaxosLearn:
+		b.EncodeVarint(38<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.PaxosLearn); err != nil {
+			return err
+		}
+	case *Message_PaxosHeartbeat:
+		b.EncodeVarint(39<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.PaxosHeartbeat); err != nil {
+			return err
+		}
+	case *Message_DagEvent:
+		b.EncodeVarint(40<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.DagEvent); err != nil {
+			return err
+		}
+	case *Message_GetHeaders:
+		b.EncodeVarint(41<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.GetHeaders); err != nil {
+			return err
+		}
+	case *Message_Headers:
+		b.EncodeVarint(42<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Headers); err != nil {
+			return err
+		}
+	case *Message_GetBlocks:
+		b.EncodeVarint(43<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.GetBlocks); err != nil {
+			return err
+		}
+	case *Message_Blocks:
+		b.EncodeVarint(44<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Blocks); err != nil {
+			return err
+		}
+	case *Message_TendermintCommit:
+		b.EncodeVarint(45<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.TendermintCommit); err != nil {
+			return err
+		}
+	case *Message_Hello:
+		b.EncodeVarint(46<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Hello); err != nil {
+			return err
+		}
+	case *Message_Identity:
+		b.EncodeVarint(47<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Identity); err != nil {
+			return err
+		}
+	case *Message_PaxosCatchUp:
+		b.EncodeVarint(48<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.PaxosCatchUp); err != nil {
+			return err
+		}
+	case *Message_FbftCatchUp:
+		b.EncodeVarint(49<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.FbftCatchUp); err != nil {
+			return err
+		}
+	case *Message_FbftCertificate:
+		b.EncodeVarint(50<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.FbftCertificate); err != nil {
+			return err
+		}
 	case nil:
 	default:
 		return fmt.Errorf("Message.Payload has unexpected type %T", x)
@@ -193,240 +1144,3233 @@ func _Message_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
 	return nil
 }
 
-func _Message_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
-	m := msg.(*Message)
-	switch tag {
-	case 2: // Payload.state
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		msg := new(State)
-		err := b.DecodeMessage(msg)
-		m.Payload = &Message_State{msg}
-		return true, err
-	case 3: // Payload.peer_request
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		msg := new(PeerRequest)
-		err := b.DecodeMessage(msg)
-		m.Payload = &Message_PeerRequest{msg}
-		return true, err
-	case 4: // Payload.peer_response
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		msg := new(PeerResponse)
-		err := b.DecodeMessage(msg)
-		m.Payload = &Message_PeerResponse{msg}
-		return true, err
-	case 5: // Payload.transaction
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		msg := new(Transaction)
-		err := b.DecodeMessage(msg)
-		m.Payload = &Message_Transaction{msg}
-		return true, err
-	case 6: // Payload.block
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		msg := new(Block)
-		err := b.DecodeMessage(msg)
-		m.Payload = &Message_Block{msg}
-		return true, err
-	default:
-		return false, nil
+func _Message_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
+	m := msg.(*Message)
+	switch tag {
+	case 2: // Payload.state
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(State)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_State{msg}
+		return true, err
+	case 3: // Payload.peer_request
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(PeerRequest)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_PeerRequest{msg}
+		return true, err
+	case 4: // Payload.peer_response
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(PeerResponse)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_PeerResponse{msg}
+		return true, err
+	case 5: // Payload.transaction
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Transaction)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_Transaction{msg}
+		return true, err
+	case 6: // Payload.block
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Block)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_Block{msg}
+		return true, err
+	case 7: // Payload.fbft_prepare
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(FbftPrepare)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_FbftPrepare{msg}
+		return true, err
+	case 8: // Payload.fbft_commit
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(FbftCommit)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_FbftCommit{msg}
+		return true, err
+	case 9: // Payload.fbft_reveal
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(FbftReveal)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_FbftReveal{msg}
+		return true, err
+	case 10: // Payload.fbft_view_change
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(FbftViewChange)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_FbftViewChange{msg}
+		return true, err
+	case 11: // Payload.pbft_pre_prepare
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(PbftPrePrepare)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_PbftPrePrepare{msg}
+		return true, err
+	case 12: // Payload.pbft_prepare
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(PbftPrepare)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_PbftPrepare{msg}
+		return true, err
+	case 13: // Payload.pbft_commit
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(PbftCommit)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_PbftCommit{msg}
+		return true, err
+	case 14: // Payload.pbft_view_change
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(PbftViewChange)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_PbftViewChange{msg}
+		return true, err
+	case 15: // Payload.pbft_new_view
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(PbftNewView)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_PbftNewView{msg}
+		return true, err
+	case 16: // Payload.raft_request_vote
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(RaftRequestVote)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_RaftRequestVote{msg}
+		return true, err
+	case 17: // Payload.raft_vote
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(RaftVote)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_RaftVote{msg}
+		return true, err
+	case 18: // Payload.raft_append_entries
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(RaftAppendEntries)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_RaftAppendEntries{msg}
+		return true, err
+	case 19: // Payload.raft_append_response
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(RaftAppendResponse)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_RaftAppendResponse{msg}
+		return true, err
+	case 20: // Payload.tendermint_proposal
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(TendermintProposal)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_TendermintProposal{msg}
+		return true, err
+	case 21: // Payload.tendermint_vote
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(TendermintVote)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_TendermintVote{msg}
+		return true, err
+	case 22: // Payload.hotstuff_proposal
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(HotstuffProposal)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_HotstuffProposal{msg}
+		return true, err
+	case 23: // Payload.hotstuff_vote
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(HotstuffVote)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_HotstuffVote{msg}
+		return true, err
+	case 24: // Payload.hotstuff_new_view
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(HotstuffNewView)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_HotstuffNewView{msg}
+		return true, err
+	case 25: // Payload.avalanche_query
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(AvalancheQuery)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_AvalancheQuery{msg}
+		return true, err
+	case 26: // Payload.avalanche_response
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(AvalancheResponse)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_AvalancheResponse{msg}
+		return true, err
+	case 27: // Payload.honeybadger_broadcast
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(HoneybadgerBroadcast)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_HoneybadgerBroadcast{msg}
+		return true, err
+	case 28: // Payload.honeybadger_agreement
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(HoneybadgerAgreement)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_HoneybadgerAgreement{msg}
+		return true, err
+	case 29: // Payload.honeybadger_decryption
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(HoneybadgerDecryption)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_HoneybadgerDecryption{msg}
+		return true, err
+	case 30: // Payload.algorand_proposal
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(AlgorandProposal)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_AlgorandProposal{msg}
+		return true, err
+	case 31: // Payload.algorand_vote
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(AlgorandVote)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_AlgorandVote{msg}
+		return true, err
+	case 32: // Payload.casper_vote
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(CasperVote)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_CasperVote{msg}
+		return true, err
+	case 33: // Payload.casper_slashing
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(CasperSlashing)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_CasperSlashing{msg}
+		return true, err
+	case 34: // Payload.paxos_prepare
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(PaxosPrepare)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_PaxosPrepare{msg}
+		return true, err
+	case 35: // Payload.paxos_promise
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(PaxosPromise)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_PaxosPromise{msg}
+		return true, err
+	case 36: // Payload.paxos_accept
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(PaxosAccept)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_PaxosAccept{msg}
+		return true, err
+	case 37: // Payload.paxos_accepted
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(PaxosAccepted)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_PaxosAccepted{msg}
+		return true, err
+	case 38: // Payload.paxos_learn
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(PaxosLearn)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_PaxosLearn{msg}
+		return true, err
+	case 39: // Payload.paxos_heartbeat
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(PaxosHeartbeat)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_PaxosHeartbeat{msg}
+		return true, err
+	case 40: // Payload.dag_event
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(DagEvent)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_DagEvent{msg}
+		return true, err
+	case 41: // Payload.get_headers
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(GetHeaders)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_GetHeaders{msg}
+		return true, err
+	case 42: // Payload.headers
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Headers)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_Headers{msg}
+		return true, err
+	case 43: // Payload.get_blocks
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(GetBlocks)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_GetBlocks{msg}
+		return true, err
+	case 44: // Payload.blocks
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Blocks)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_Blocks{msg}
+		return true, err
+	case 45: // Payload.tendermint_commit
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(TendermintCommit)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_TendermintCommit{msg}
+		return true, err
+	case 46: // Payload.hello
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Hello)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_Hello{msg}
+		return true, err
+	case 47: // Payload.identity
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Identity)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_Identity{msg}
+		return true, err
+	case 48: // Payload.paxos_catch_up
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(PaxosCatchUp)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_PaxosCatchUp{msg}
+		return true, err
+	case 49: // Payload.fbft_catch_up
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(FbftCatchUp)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_FbftCatchUp{msg}
+		return true, err
+	case 50: // Payload.fbft_certificate
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(FbftCertificate)
+		err := b.DecodeMessage(msg)
+		m.Payload = &Message_FbftCertificate{msg}
+		return true, err
+	default:
+		return false, nil
+	}
+}
+
+func _Message_OneofSizer(msg proto.Message) (n int) {
+	m := msg.(*Message)
+	// Payload
+	switch x := m.Payload.(type) {
+	case *Message_State:
+		s := proto.Size(x.State)
+		n += proto.SizeVarint(2<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_PeerRequest:
+		s := proto.Size(x.PeerRequest)
+		n += proto.SizeVarint(3<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_PeerResponse:
+		s := proto.Size(x.PeerResponse)
+		n += proto.SizeVarint(4<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_Transaction:
+		s := proto.Size(x.Transaction)
+		n += proto.SizeVarint(5<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_Block:
+		s := proto.Size(x.Block)
+		n += proto.SizeVarint(6<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_FbftPrepare:
+		s := proto.Size(x.FbftPrepare)
+		n += proto.SizeVarint(7<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_FbftCommit:
+		s := proto.Size(x.FbftCommit)
+		n += proto.SizeVarint(8<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_FbftReveal:
+		s := proto.Size(x.FbftReveal)
+		n += proto.SizeVarint(9<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_FbftViewChange:
+		s := proto.Size(x.FbftViewChange)
+		n += proto.SizeVarint(10<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_PbftPrePrepare:
+		s := proto.Size(x.PbftPrePrepare)
+		n += proto.SizeVarint(11<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_PbftPrepare:
+		s := proto.Size(x.PbftPrepare)
+		n += proto.SizeVarint(12<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_PbftCommit:
+		s := proto.Size(x.PbftCommit)
+		n += proto.SizeVarint(13<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_PbftViewChange:
+		s := proto.Size(x.PbftViewChange)
+		n += proto.SizeVarint(14<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_PbftNewView:
+		s := proto.Size(x.PbftNewView)
+		n += proto.SizeVarint(15<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_RaftRequestVote:
+		s := proto.Size(x.RaftRequestVote)
+		n += proto.SizeVarint(16<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_RaftVote:
+		s := proto.Size(x.RaftVote)
+		n += proto.SizeVarint(17<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_RaftAppendEntries:
+		s := proto.Size(x.RaftAppendEntries)
+		n += proto.SizeVarint(18<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_RaftAppendResponse:
+		s := proto.Size(x.RaftAppendResponse)
+		n += proto.SizeVarint(19<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_TendermintProposal:
+		s := proto.Size(x.TendermintProposal)
+		n += proto.SizeVarint(20<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_TendermintVote:
+		s := proto.Size(x.TendermintVote)
+		n += proto.SizeVarint(21<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_HotstuffProposal:
+		s := proto.Size(x.HotstuffProposal)
+		n += proto.SizeVarint(22<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_HotstuffVote:
+		s := proto.Size(x.HotstuffVote)
+		n += proto.SizeVarint(23<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_HotstuffNewView:
+		s := proto.Size(x.HotstuffNewView)
+		n += proto.SizeVarint(24<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_AvalancheQuery:
+		s := proto.Size(x.AvalancheQuery)
+		n += proto.SizeVarint(25<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_AvalancheResponse:
+		s := proto.Size(x.AvalancheResponse)
+		n += proto.SizeVarint(26<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_HoneybadgerBroadcast:
+		s := proto.Size(x.HoneybadgerBroadcast)
+		n += proto.SizeVarint(27<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_HoneybadgerAgreement:
+		s := proto.Size(x.HoneybadgerAgreement)
+		n += proto.SizeVarint(28<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_HoneybadgerDecryption:
+		s := proto.Size(x.HoneybadgerDecryption)
+		n += proto.SizeVarint(29<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_AlgorandProposal:
+		s := proto.Size(x.AlgorandProposal)
+		n += proto.SizeVarint(30<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_AlgorandVote:
+		s := proto.Size(x.AlgorandVote)
+		n += proto.SizeVarint(31<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_CasperVote:
+		s := proto.Size(x.CasperVote)
+		n += proto.SizeVarint(32<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_CasperSlashing:
+		s := proto.Size(x.CasperSlashing)
+		n += proto.SizeVarint(33<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_PaxosPrepare:
+		s := proto.Size(x.PaxosPrepare)
+		n += proto.SizeVarint(34<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_PaxosPromise:
+		s := proto.Size(x.PaxosPromise)
+		n += proto.SizeVarint(35<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_PaxosAccept:
+		s := proto.Size(x.PaxosAccept)
+		n += proto.SizeVarint(36<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_PaxosAccepted:
+		s := proto.Size(x.PaxosAccepted)
+		n += proto.SizeVarint(37<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_PaxosLearn:
+		s := proto.Size(x.PaxosLearn)
+		n += proto.SizeVarint(38<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_PaxosHeartbeat:
+		s := proto.Size(x.PaxosHeartbeat)
+		n += proto.SizeVarint(39<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_DagEvent:
+		s := proto.Size(x.DagEvent)
+		n += proto.SizeVarint(40<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_GetHeaders:
+		s := proto.Size(x.GetHeaders)
+		n += proto.SizeVarint(41<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_Headers:
+		s := proto.Size(x.Headers)
+		n += proto.SizeVarint(42<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_GetBlocks:
+		s := proto.Size(x.GetBlocks)
+		n += proto.SizeVarint(43<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_Blocks:
+		s := proto.Size(x.Blocks)
+		n += proto.SizeVarint(44<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_TendermintCommit:
+		s := proto.Size(x.TendermintCommit)
+		n += proto.SizeVarint(45<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_Hello:
+		s := proto.Size(x.Hello)
+		n += proto.SizeVarint(46<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_Identity:
+		s := proto.Size(x.Identity)
+		n += proto.SizeVarint(47<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_PaxosCatchUp:
+		s := proto.Size(x.PaxosCatchUp)
+		n += proto.SizeVarint(48<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_FbftCatchUp:
+		s := proto.Size(x.FbftCatchUp)
+		n += proto.SizeVarint(49<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Message_FbftCertificate:
+		s := proto.Size(x.FbftCertificate)
+		n += proto.SizeVarint(50<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case nil:
+	default:
+		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+	}
+	return n
+}
+
+// State is used in the initial handshake.
+type State struct {
+	// unique peer identifier.
+	Id uint64 `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	// port the peer is accepting connections on.
+	Port uint32 `protobuf:"varint,2,opt,name=port" json:"port,omitempty"`
+}
+
+func (m *State) Reset()                    { *m = State{} }
+func (m *State) String() string            { return proto.CompactTextString(m) }
+func (*State) ProtoMessage()               {}
+func (*State) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+func (m *State) GetId() uint64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *State) GetPort() uint32 {
+	if m != nil {
+		return m.Port
+	}
+	return 0
+}
+
+// PeerRequest requests known peers in the network.
+type PeerRequest struct {
+	// A list of already known peers in the network.
+	Known []string `protobuf:"bytes,1,rep,name=known" json:"known,omitempty"`
+}
+
+func (m *PeerRequest) Reset()                    { *m = PeerRequest{} }
+func (m *PeerRequest) String() string            { return proto.CompactTextString(m) }
+func (*PeerRequest) ProtoMessage()               {}
+func (*PeerRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+func (m *PeerRequest) GetKnown() []string {
+	if m != nil {
+		return m.Known
+	}
+	return nil
+}
+
+// PeerResponse contains a list of known peers responded after a PeerRequest is
+// send.
+type PeerResponse struct {
+	Peers []*Peer `protobuf:"bytes,1,rep,name=peers" json:"peers,omitempty"`
+}
+
+func (m *PeerResponse) Reset()                    { *m = PeerResponse{} }
+func (m *PeerResponse) String() string            { return proto.CompactTextString(m) }
+func (*PeerResponse) ProtoMessage()               {}
+func (*PeerResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+func (m *PeerResponse) GetPeers() []*Peer {
+	if m != nil {
+		return m.Peers
+	}
+	return nil
+}
+
+// Peer holds information about a peer in the network.
+type Peer struct {
+	Enpoint string `protobuf:"bytes,1,opt,name=enpoint" json:"enpoint,omitempty"`
+}
+
+func (m *Peer) Reset()                    { *m = Peer{} }
+func (m *Peer) String() string            { return proto.CompactTextString(m) }
+func (*Peer) ProtoMessage()               {}
+func (*Peer) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *Peer) GetEnpoint() string {
+	if m != nil {
+		return m.Enpoint
+	}
+	return ""
+}
+
+// Header represents a very simple block header used for simulation.
+type Header struct {
+	// Index of the block.
+	Index uint32 `protobuf:"varint,1,opt,name=index" json:"index,omitempty"`
+	// Nonce used to prevent hash collisions, or to meet the target of the
+	// difficulty in proof of work.
+	Nonce uint64 `protobuf:"varint,2,opt,name=nonce" json:"nonce,omitempty"`
+	// Hash of the header of the previous block.
+	PrevHash []byte `protobuf:"bytes,3,opt,name=prev_hash,json=prevHash,proto3" json:"prev_hash,omitempty"`
+	// Unix time in nanoseconds the block was created.
+	Timestamp int64 `protobuf:"varint,4,opt,name=timestamp" json:"timestamp,omitempty"`
+	// Expected amount of hashes needed to find a nonce, used by proof of work.
+	Difficulty uint64 `protobuf:"varint,5,opt,name=difficulty" json:"difficulty,omitempty"`
+	// Marshaled public key of the node that produced the block.
+	Proposer []byte `protobuf:"bytes,6,opt,name=proposer,proto3" json:"proposer,omitempty"`
+	// Merkle root of the hashes of the transactions of the block.
+	TxRoot []byte `protobuf:"bytes,7,opt,name=tx_root,json=txRoot,proto3" json:"tx_root,omitempty"`
+}
+
+func (m *Header) Reset()                    { *m = Header{} }
+func (m *Header) String() string            { return proto.CompactTextString(m) }
+func (*Header) ProtoMessage()               {}
+func (*Header) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *Header) GetIndex() uint32 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+func (m *Header) GetNonce() uint64 {
+	if m != nil {
+		return m.Nonce
+	}
+	return 0
+}
+
+func (m *Header) GetPrevHash() []byte {
+	if m != nil {
+		return m.PrevHash
+	}
+	return nil
+}
+
+func (m *Header) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *Header) GetDifficulty() uint64 {
+	if m != nil {
+		return m.Difficulty
+	}
+	return 0
+}
+
+func (m *Header) GetProposer() []byte {
+	if m != nil {
+		return m.Proposer
+	}
+	return nil
+}
+
+func (m *Header) GetTxRoot() []byte {
+	if m != nil {
+		return m.TxRoot
+	}
+	return nil
+}
+
+// Block represents a very simple Block used for simulation.
+type Block struct {
+	// Head of the block that also will be used for computing its hash.
+	Header *Header `protobuf:"bytes,1,opt,name=header" json:"header,omitempty"`
+	// List of recorded transactions.
+	Transactions []*Transaction `protobuf:"bytes,2,rep,name=transactions" json:"transactions,omitempty"`
+	// Signature of the proposer over the hash of the block without the
+	// signature.
+	Signature []byte `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *Block) Reset()                    { *m = Block{} }
+func (m *Block) String() string            { return proto.CompactTextString(m) }
+func (*Block) ProtoMessage()               {}
+func (*Block) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+func (m *Block) GetHeader() *Header {
+	if m != nil {
+		return m.Header
+	}
+	return nil
+}
+
+func (m *Block) GetTransactions() []*Transaction {
+	if m != nil {
+		return m.Transactions
+	}
+	return nil
+}
+
+func (m *Block) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// Transaction represents a very simple transaction used for simulation.
+type Transaction struct {
+	// Nonce used to prevent hash collisions.
+	Nonce uint64 `protobuf:"varint,1,opt,name=nonce" json:"nonce,omitempty"`
+	// Optional change of the stake of a validator, used by proof of stake.
+	Stake *Stake `protobuf:"bytes,2,opt,name=stake" json:"stake,omitempty"`
+	// Marshaled public key of the sender.
+	Sender []byte `protobuf:"bytes,3,opt,name=sender,proto3" json:"sender,omitempty"`
+	// Application specific data of the transaction.
+	Payload []byte `protobuf:"bytes,4,opt,name=payload,proto3" json:"payload,omitempty"`
+	// Sequence number of the transaction among the transactions of the
+	// sender.
+	Sequence uint64 `protobuf:"varint,5,opt,name=sequence" json:"sequence,omitempty"`
+	// Signature of the sender over the transaction without signature.
+	Signature []byte `protobuf:"bytes,6,opt,name=signature,proto3" json:"signature,omitempty"`
+	// Fee offered by the sender, transactions with a higher fee are
+	// prioritized by the mempool.
+	Fee uint64 `protobuf:"varint,7,opt,name=fee" json:"fee,omitempty"`
+	// Operations on the key-value state machine.
+	Ops []*KVOp `protobuf:"bytes,8,rep,name=ops" json:"ops,omitempty"`
+}
+
+func (m *Transaction) Reset()                    { *m = Transaction{} }
+func (m *Transaction) String() string            { return proto.CompactTextString(m) }
+func (*Transaction) ProtoMessage()               {}
+func (*Transaction) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *Transaction) GetNonce() uint64 {
+	if m != nil {
+		return m.Nonce
+	}
+	return 0
+}
+
+func (m *Transaction) GetStake() *Stake {
+	if m != nil {
+		return m.Stake
+	}
+	return nil
+}
+
+func (m *Transaction) GetSender() []byte {
+	if m != nil {
+		return m.Sender
+	}
+	return nil
+}
+
+func (m *Transaction) GetPayload() []byte {
+	if m != nil {
+		return m.Payload
+	}
+	return nil
+}
+
+func (m *Transaction) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
+func (m *Transaction) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+func (m *Transaction) GetFee() uint64 {
+	if m != nil {
+		return m.Fee
+	}
+	return 0
+}
+
+func (m *Transaction) GetOps() []*KVOp {
+	if m != nil {
+		return m.Ops
+	}
+	return nil
+}
+
+// Stake changes the stake of a validator.
+type Stake struct {
+	// Marshaled public key of the validator.
+	Validator []byte `protobuf:"bytes,1,opt,name=validator,proto3" json:"validator,omitempty"`
+	// Amount added to the stake of the validator, negative to withdraw.
+	Amount int64 `protobuf:"varint,2,opt,name=amount" json:"amount,omitempty"`
+}
+
+func (m *Stake) Reset()                    { *m = Stake{} }
+func (m *Stake) String() string            { return proto.CompactTextString(m) }
+func (*Stake) ProtoMessage()               {}
+func (*Stake) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+func (m *Stake) GetValidator() []byte {
+	if m != nil {
+		return m.Validator
+	}
+	return nil
+}
+
+func (m *Stake) GetAmount() int64 {
+	if m != nil {
+		return m.Amount
+	}
+	return 0
+}
+
+// KVOp sets or deletes the value of a key of the key-value state machine.
+type KVOp struct {
+	Type KVOpType `protobuf:"varint,1,opt,name=type,enum=message.KVOpType" json:"type,omitempty"`
+	Key  []byte   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	// Value of a set operation.
+	Value []byte `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *KVOp) Reset()                    { *m = KVOp{} }
+func (m *KVOp) String() string            { return proto.CompactTextString(m) }
+func (*KVOp) ProtoMessage()               {}
+func (*KVOp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+func (m *KVOp) GetType() KVOpType {
+	if m != nil {
+		return m.Type
+	}
+	return KVOpType_set
+}
+
+func (m *KVOp) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *KVOp) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+// KVSnapshot is the state of the key-value state machine, ordered by key.
+type KVSnapshot struct {
+	Pairs []*KVPair `protobuf:"bytes,1,rep,name=pairs" json:"pairs,omitempty"`
+	// The sequence numbers of the senders, ordered by sender.
+	Sequences []*KVSequence `protobuf:"bytes,2,rep,name=sequences" json:"sequences,omitempty"`
+}
+
+func (m *KVSnapshot) Reset()                    { *m = KVSnapshot{} }
+func (m *KVSnapshot) String() string            { return proto.CompactTextString(m) }
+func (*KVSnapshot) ProtoMessage()               {}
+func (*KVSnapshot) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
+func (m *KVSnapshot) GetPairs() []*KVPair {
+	if m != nil {
+		return m.Pairs
+	}
+	return nil
+}
+
+func (m *KVSnapshot) GetSequences() []*KVSequence {
+	if m != nil {
+		return m.Sequences
+	}
+	return nil
+}
+
+type KVPair struct {
+	Key   []byte `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *KVPair) Reset()                    { *m = KVPair{} }
+func (m *KVPair) String() string            { return proto.CompactTextString(m) }
+func (*KVPair) ProtoMessage()               {}
+func (*KVPair) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+func (m *KVPair) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *KVPair) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+// KVSequence is the sequence number of the next transaction of a sender.
+type KVSequence struct {
+	Sender   []byte `protobuf:"bytes,1,opt,name=sender,proto3" json:"sender,omitempty"`
+	Sequence uint64 `protobuf:"varint,2,opt,name=sequence" json:"sequence,omitempty"`
+}
+
+func (m *KVSequence) Reset()                    { *m = KVSequence{} }
+func (m *KVSequence) String() string            { return proto.CompactTextString(m) }
+func (*KVSequence) ProtoMessage()               {}
+func (*KVSequence) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+func (m *KVSequence) GetSender() []byte {
+	if m != nil {
+		return m.Sender
+	}
+	return nil
+}
+
+func (m *KVSequence) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
+// Hello is send to a peer once connected, the peer proves the validator it
+// is by signing the challenge.
+type Hello struct {
+	Challenge []byte `protobuf:"bytes,1,opt,name=challenge,proto3" json:"challenge,omitempty"`
+}
+
+func (m *Hello) Reset()                    { *m = Hello{} }
+func (m *Hello) String() string            { return proto.CompactTextString(m) }
+func (*Hello) ProtoMessage()               {}
+func (*Hello) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+func (m *Hello) GetChallenge() []byte {
+	if m != nil {
+		return m.Challenge
+	}
+	return nil
+}
+
+// Identity is the response to a Hello, holding the public key of the peer
+// and its signature of the challenge.
+type Identity struct {
+	PublicKey []byte `protobuf:"bytes,1,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	Signature []byte `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *Identity) Reset()                    { *m = Identity{} }
+func (m *Identity) String() string            { return proto.CompactTextString(m) }
+func (*Identity) ProtoMessage()               {}
+func (*Identity) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+func (m *Identity) GetPublicKey() []byte {
+	if m != nil {
+		return m.PublicKey
+	}
+	return nil
+}
+
+func (m *Identity) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// GetHeaders requests the headers of the chain of a peer, starting at the
+// given index.
+type GetHeaders struct {
+	From  uint32 `protobuf:"varint,1,opt,name=from" json:"from,omitempty"`
+	Count uint32 `protobuf:"varint,2,opt,name=count" json:"count,omitempty"`
+}
+
+func (m *GetHeaders) Reset()                    { *m = GetHeaders{} }
+func (m *GetHeaders) String() string            { return proto.CompactTextString(m) }
+func (*GetHeaders) ProtoMessage()               {}
+func (*GetHeaders) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+func (m *GetHeaders) GetFrom() uint32 {
+	if m != nil {
+		return m.From
+	}
+	return 0
+}
+
+func (m *GetHeaders) GetCount() uint32 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+// Headers is the response to GetHeaders.
+type Headers struct {
+	// Index of the head of the chain of the peer.
+	Height  uint32    `protobuf:"varint,1,opt,name=height" json:"height,omitempty"`
+	Headers []*Header `protobuf:"bytes,2,rep,name=headers" json:"headers,omitempty"`
+}
+
+func (m *Headers) Reset()                    { *m = Headers{} }
+func (m *Headers) String() string            { return proto.CompactTextString(m) }
+func (*Headers) ProtoMessage()               {}
+func (*Headers) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+func (m *Headers) GetHeight() uint32 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *Headers) GetHeaders() []*Header {
+	if m != nil {
+		return m.Headers
+	}
+	return nil
+}
+
+// GetBlocks requests the blocks of the chain of a peer, starting at the given
+// index.
+type GetBlocks struct {
+	From  uint32 `protobuf:"varint,1,opt,name=from" json:"from,omitempty"`
+	Count uint32 `protobuf:"varint,2,opt,name=count" json:"count,omitempty"`
+}
+
+func (m *GetBlocks) Reset()                    { *m = GetBlocks{} }
+func (m *GetBlocks) String() string            { return proto.CompactTextString(m) }
+func (*GetBlocks) ProtoMessage()               {}
+func (*GetBlocks) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+func (m *GetBlocks) GetFrom() uint32 {
+	if m != nil {
+		return m.From
+	}
+	return 0
+}
+
+func (m *GetBlocks) GetCount() uint32 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+// Blocks is the response to GetBlocks.
+type Blocks struct {
+	Blocks []*Block `protobuf:"bytes,1,rep,name=blocks" json:"blocks,omitempty"`
+}
+
+func (m *Blocks) Reset()                    { *m = Blocks{} }
+func (m *Blocks) String() string            { return proto.CompactTextString(m) }
+func (*Blocks) ProtoMessage()               {}
+func (*Blocks) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *Blocks) GetBlocks() []*Block {
+	if m != nil {
+		return m.Blocks
+	}
+	return nil
+}
+
+// FbftPrepare is multicasted by the FBFT leader to propose a block. It is
+// produced by the trusted hardware of the leader, which binds the block to a
+// unique counter value.
+type FbftPrepare struct {
+	// View in which the block is proposed.
+	View uint64 `protobuf:"varint,1,opt,name=view" json:"view,omitempty"`
+	// Monotonic counter value assigned by the leader's trusted hardware.
+	Counter uint64 `protobuf:"varint,2,opt,name=counter" json:"counter,omitempty"`
+	// The proposed block.
+	Block *Block `protobuf:"bytes,3,opt,name=block" json:"block,omitempty"`
+	// Hash commitment of the secret needed to commit the block.
+	Commitment []byte `protobuf:"bytes,4,opt,name=commitment,proto3" json:"commitment,omitempty"`
+	// Shares of the secret, each encrypted for the replica at the same index.
+	Shares [][]byte `protobuf:"bytes,5,rep,name=shares" json:"shares,omitempty"`
+	// Signature of the leader's trusted hardware.
+	Signature []byte `protobuf:"bytes,6,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *FbftPrepare) Reset()                    { *m = FbftPrepare{} }
+func (m *FbftPrepare) String() string            { return proto.CompactTextString(m) }
+func (*FbftPrepare) ProtoMessage()               {}
+func (*FbftPrepare) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+func (m *FbftPrepare) GetView() uint64 {
+	if m != nil {
+		return m.View
+	}
+	return 0
+}
+
+func (m *FbftPrepare) GetCounter() uint64 {
+	if m != nil {
+		return m.Counter
+	}
+	return 0
+}
+
+func (m *FbftPrepare) GetBlock() *Block {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+func (m *FbftPrepare) GetCommitment() []byte {
+	if m != nil {
+		return m.Commitment
+	}
+	return nil
+}
+
+func (m *FbftPrepare) GetShares() [][]byte {
+	if m != nil {
+		return m.Shares
+	}
+	return nil
+}
+
+func (m *FbftPrepare) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// FbftCommit reveals the secret share of a replica to the leader after the
+// replica accepted the prepare for the same counter value.
+type FbftCommit struct {
+	View    uint64 `protobuf:"varint,1,opt,name=view" json:"view,omitempty"`
+	Counter uint64 `protobuf:"varint,2,opt,name=counter" json:"counter,omitempty"`
+	// Index of the replica in the validator set.
+	Replica uint32 `protobuf:"varint,3,opt,name=replica" json:"replica,omitempty"`
+	// Decrypted secret share of the replica.
+	Share []byte `protobuf:"bytes,4,opt,name=share,proto3" json:"share,omitempty"`
+}
+
+func (m *FbftCommit) Reset()                    { *m = FbftCommit{} }
+func (m *FbftCommit) String() string            { return proto.CompactTextString(m) }
+func (*FbftCommit) ProtoMessage()               {}
+func (*FbftCommit) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+
+func (m *FbftCommit) GetView() uint64 {
+	if m != nil {
+		return m.View
+	}
+	return 0
+}
+
+func (m *FbftCommit) GetCounter() uint64 {
+	if m != nil {
+		return m.Counter
+	}
+	return 0
+}
+
+func (m *FbftCommit) GetReplica() uint32 {
+	if m != nil {
+		return m.Replica
+	}
+	return 0
+}
+
+func (m *FbftCommit) GetShare() []byte {
+	if m != nil {
+		return m.Share
+	}
+	return nil
+}
+
+// FbftReveal is multicasted by the leader when it reconstructed the secret
+// of a counter value, proving that enough replicas committed.
+type FbftReveal struct {
+	View    uint64 `protobuf:"varint,1,opt,name=view" json:"view,omitempty"`
+	Counter uint64 `protobuf:"varint,2,opt,name=counter" json:"counter,omitempty"`
+	Secret  []byte `protobuf:"bytes,3,opt,name=secret,proto3" json:"secret,omitempty"`
+	// Signature of the leader.
+	Signature []byte `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *FbftReveal) Reset()                    { *m = FbftReveal{} }
+func (m *FbftReveal) String() string            { return proto.CompactTextString(m) }
+func (*FbftReveal) ProtoMessage()               {}
+func (*FbftReveal) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+
+func (m *FbftReveal) GetView() uint64 {
+	if m != nil {
+		return m.View
+	}
+	return 0
+}
+
+func (m *FbftReveal) GetCounter() uint64 {
+	if m != nil {
+		return m.Counter
+	}
+	return 0
+}
+
+func (m *FbftReveal) GetSecret() []byte {
+	if m != nil {
+		return m.Secret
+	}
+	return nil
+}
+
+func (m *FbftReveal) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// FbftViewChange is multicasted by a replica that suspects the leader.
+type FbftViewChange struct {
+	// The view the replica wants to move to.
+	View uint64 `protobuf:"varint,1,opt,name=view" json:"view,omitempty"`
+	// Index of the replica in the validator set.
+	Replica   uint32 `protobuf:"varint,2,opt,name=replica" json:"replica,omitempty"`
+	Signature []byte `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"`
+	// The prepare of the last block committed by the replica and its
+	// revealed secret, which prove that the block is committed.
+	Committed *FbftPrepare `protobuf:"bytes,4,opt,name=committed" json:"committed,omitempty"`
+	Secret    []byte       `protobuf:"bytes,5,opt,name=secret,proto3" json:"secret,omitempty"`
+	// The last prepare the replica accepted above its committed block.
+	Prepared *FbftPrepare `protobuf:"bytes,6,opt,name=prepared" json:"prepared,omitempty"`
+}
+
+func (m *FbftViewChange) Reset()                    { *m = FbftViewChange{} }
+func (m *FbftViewChange) String() string            { return proto.CompactTextString(m) }
+func (*FbftViewChange) ProtoMessage()               {}
+func (*FbftViewChange) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+
+func (m *FbftViewChange) GetView() uint64 {
+	if m != nil {
+		return m.View
+	}
+	return 0
+}
+
+func (m *FbftViewChange) GetReplica() uint32 {
+	if m != nil {
+		return m.Replica
+	}
+	return 0
+}
+
+func (m *FbftViewChange) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+func (m *FbftViewChange) GetCommitted() *FbftPrepare {
+	if m != nil {
+		return m.Committed
+	}
+	return nil
+}
+
+func (m *FbftViewChange) GetSecret() []byte {
+	if m != nil {
+		return m.Secret
+	}
+	return nil
+}
+
+func (m *FbftViewChange) GetPrepared() *FbftPrepare {
+	if m != nil {
+		return m.Prepared
+	}
+	return nil
+}
+
+// FbftCatchUp is multicasted by a replica that learned of blocks committed
+// above its height. Replicas that committed them answer with a
+// FbftCertificate for each of the blocks from height on.
+type FbftCatchUp struct {
+	Height uint32 `protobuf:"varint,1,opt,name=height" json:"height,omitempty"`
+}
+
+func (m *FbftCatchUp) Reset()                    { *m = FbftCatchUp{} }
+func (m *FbftCatchUp) String() string            { return proto.CompactTextString(m) }
+func (*FbftCatchUp) ProtoMessage()               {}
+func (*FbftCatchUp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
+
+func (m *FbftCatchUp) GetHeight() uint32 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+// FbftCertificate proves that the block of the prepare is committed, as the
+// secret of the prepare was revealed.
+type FbftCertificate struct {
+	Prepare *FbftPrepare `protobuf:"bytes,1,opt,name=prepare" json:"prepare,omitempty"`
+	Secret  []byte       `protobuf:"bytes,2,opt,name=secret,proto3" json:"secret,omitempty"`
+}
+
+func (m *FbftCertificate) Reset()                    { *m = FbftCertificate{} }
+func (m *FbftCertificate) String() string            { return proto.CompactTextString(m) }
+func (*FbftCertificate) ProtoMessage()               {}
+func (*FbftCertificate) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
+
+func (m *FbftCertificate) GetPrepare() *FbftPrepare {
+	if m != nil {
+		return m.Prepare
+	}
+	return nil
+}
+
+func (m *FbftCertificate) GetSecret() []byte {
+	if m != nil {
+		return m.Secret
+	}
+	return nil
+}
+
+// PbftPrePrepare is multicasted by the PBFT primary to assign a sequence
+// number to a block.
+type PbftPrePrepare struct {
+	View     uint64 `protobuf:"varint,1,opt,name=view" json:"view,omitempty"`
+	Sequence uint64 `protobuf:"varint,2,opt,name=sequence" json:"sequence,omitempty"`
+	// Hash of the block.
+	Digest []byte `protobuf:"bytes,3,opt,name=digest,proto3" json:"digest,omitempty"`
+	Block  *Block `protobuf:"bytes,4,opt,name=block" json:"block,omitempty"`
+	// Index of the replica in the validator set.
+	Replica   uint32 `protobuf:"varint,5,opt,name=replica" json:"replica,omitempty"`
+	Signature []byte `protobuf:"bytes,6,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *PbftPrePrepare) Reset()                    { *m = PbftPrePrepare{} }
+func (m *PbftPrePrepare) String() string            { return proto.CompactTextString(m) }
+func (*PbftPrePrepare) ProtoMessage()               {}
+func (*PbftPrePrepare) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
+
+func (m *PbftPrePrepare) GetView() uint64 {
+	if m != nil {
+		return m.View
+	}
+	return 0
+}
+
+func (m *PbftPrePrepare) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
+func (m *PbftPrePrepare) GetDigest() []byte {
+	if m != nil {
+		return m.Digest
+	}
+	return nil
+}
+
+func (m *PbftPrePrepare) GetBlock() *Block {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+func (m *PbftPrePrepare) GetReplica() uint32 {
+	if m != nil {
+		return m.Replica
+	}
+	return 0
+}
+
+func (m *PbftPrePrepare) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// PbftPrepare is multicasted by a backup that accepted a pre-prepare.
+type PbftPrepare struct {
+	View      uint64 `protobuf:"varint,1,opt,name=view" json:"view,omitempty"`
+	Sequence  uint64 `protobuf:"varint,2,opt,name=sequence" json:"sequence,omitempty"`
+	Digest    []byte `protobuf:"bytes,3,opt,name=digest,proto3" json:"digest,omitempty"`
+	Replica   uint32 `protobuf:"varint,4,opt,name=replica" json:"replica,omitempty"`
+	Signature []byte `protobuf:"bytes,5,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *PbftPrepare) Reset()                    { *m = PbftPrepare{} }
+func (m *PbftPrepare) String() string            { return proto.CompactTextString(m) }
+func (*PbftPrepare) ProtoMessage()               {}
+func (*PbftPrepare) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{26} }
+
+func (m *PbftPrepare) GetView() uint64 {
+	if m != nil {
+		return m.View
+	}
+	return 0
+}
+
+func (m *PbftPrepare) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
+func (m *PbftPrepare) GetDigest() []byte {
+	if m != nil {
+		return m.Digest
+	}
+	return nil
+}
+
+func (m *PbftPrepare) GetReplica() uint32 {
+	if m != nil {
+		return m.Replica
+	}
+	return 0
+}
+
+func (m *PbftPrepare) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// PbftCommit is multicasted by a replica once a block is prepared.
+type PbftCommit struct {
+	View      uint64 `protobuf:"varint,1,opt,name=view" json:"view,omitempty"`
+	Sequence  uint64 `protobuf:"varint,2,opt,name=sequence" json:"sequence,omitempty"`
+	Digest    []byte `protobuf:"bytes,3,opt,name=digest,proto3" json:"digest,omitempty"`
+	Replica   uint32 `protobuf:"varint,4,opt,name=replica" json:"replica,omitempty"`
+	Signature []byte `protobuf:"bytes,5,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *PbftCommit) Reset()                    { *m = PbftCommit{} }
+func (m *PbftCommit) String() string            { return proto.CompactTextString(m) }
+func (*PbftCommit) ProtoMessage()               {}
+func (*PbftCommit) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{27} }
+
+func (m *PbftCommit) GetView() uint64 {
+	if m != nil {
+		return m.View
+	}
+	return 0
+}
+
+func (m *PbftCommit) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
+func (m *PbftCommit) GetDigest() []byte {
+	if m != nil {
+		return m.Digest
+	}
+	return nil
+}
+
+func (m *PbftCommit) GetReplica() uint32 {
+	if m != nil {
+		return m.Replica
+	}
+	return 0
+}
+
+func (m *PbftCommit) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// PbftPrepared is the certificate proving a block was prepared, which is a
+// pre-prepare along with 2f matching prepares.
+type PbftPrepared struct {
+	PrePrepare *PbftPrePrepare `protobuf:"bytes,1,opt,name=pre_prepare,json=prePrepare" json:"pre_prepare,omitempty"`
+	Prepares   []*PbftPrepare  `protobuf:"bytes,2,rep,name=prepares" json:"prepares,omitempty"`
+}
+
+func (m *PbftPrepared) Reset()                    { *m = PbftPrepared{} }
+func (m *PbftPrepared) String() string            { return proto.CompactTextString(m) }
+func (*PbftPrepared) ProtoMessage()               {}
+func (*PbftPrepared) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{28} }
+
+func (m *PbftPrepared) GetPrePrepare() *PbftPrePrepare {
+	if m != nil {
+		return m.PrePrepare
+	}
+	return nil
+}
+
+func (m *PbftPrepared) GetPrepares() []*PbftPrepare {
+	if m != nil {
+		return m.Prepares
+	}
+	return nil
+}
+
+// PbftViewChange is multicasted by a replica that suspects the primary.
+type PbftViewChange struct {
+	// The view the replica wants to move to.
+	View uint64 `protobuf:"varint,1,opt,name=view" json:"view,omitempty"`
+	// Sequence number of the last block executed by the replica.
+	Sequence uint64 `protobuf:"varint,2,opt,name=sequence" json:"sequence,omitempty"`
+	// Certificates of the blocks prepared by the replica.
+	Prepared  []*PbftPrepared `protobuf:"bytes,3,rep,name=prepared" json:"prepared,omitempty"`
+	Replica   uint32          `protobuf:"varint,4,opt,name=replica" json:"replica,omitempty"`
+	Signature []byte          `protobuf:"bytes,5,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *PbftViewChange) Reset()                    { *m = PbftViewChange{} }
+func (m *PbftViewChange) String() string            { return proto.CompactTextString(m) }
+func (*PbftViewChange) ProtoMessage()               {}
+func (*PbftViewChange) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{29} }
+
+func (m *PbftViewChange) GetView() uint64 {
+	if m != nil {
+		return m.View
+	}
+	return 0
+}
+
+func (m *PbftViewChange) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
+func (m *PbftViewChange) GetPrepared() []*PbftPrepared {
+	if m != nil {
+		return m.Prepared
+	}
+	return nil
+}
+
+func (m *PbftViewChange) GetReplica() uint32 {
+	if m != nil {
+		return m.Replica
+	}
+	return 0
+}
+
+func (m *PbftViewChange) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// PbftNewView is multicasted by the primary of a new view. It carries the
+// view changes that justify the new view and the pre-prepares of the blocks
+// that were in progress in the previous views.
+type PbftNewView struct {
+	View        uint64            `protobuf:"varint,1,opt,name=view" json:"view,omitempty"`
+	ViewChanges []*PbftViewChange `protobuf:"bytes,2,rep,name=view_changes,json=viewChanges" json:"view_changes,omitempty"`
+	PrePrepares []*PbftPrePrepare `protobuf:"bytes,3,rep,name=pre_prepares,json=prePrepares" json:"pre_prepares,omitempty"`
+	Replica     uint32            `protobuf:"varint,4,opt,name=replica" json:"replica,omitempty"`
+	Signature   []byte            `protobuf:"bytes,5,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *PbftNewView) Reset()                    { *m = PbftNewView{} }
+func (m *PbftNewView) String() string            { return proto.CompactTextString(m) }
+func (*PbftNewView) ProtoMessage()               {}
+func (*PbftNewView) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{30} }
+
+func (m *PbftNewView) GetView() uint64 {
+	if m != nil {
+		return m.View
+	}
+	return 0
+}
+
+func (m *PbftNewView) GetViewChanges() []*PbftViewChange {
+	if m != nil {
+		return m.ViewChanges
+	}
+	return nil
+}
+
+func (m *PbftNewView) GetPrePrepares() []*PbftPrePrepare {
+	if m != nil {
+		return m.PrePrepares
+	}
+	return nil
+}
+
+func (m *PbftNewView) GetReplica() uint32 {
+	if m != nil {
+		return m.Replica
+	}
+	return 0
+}
+
+func (m *PbftNewView) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// RaftEntry is a single entry in the replicated Raft log, holding the block
+// the leader created from its pending transactions.
+type RaftEntry struct {
+	Term uint64 `protobuf:"varint,1,opt,name=term" json:"term,omitempty"`
+	// Index of the entry in the log, which equals the index of the block.
+	Index uint64 `protobuf:"varint,2,opt,name=index" json:"index,omitempty"`
+	Block *Block `protobuf:"bytes,3,opt,name=block" json:"block,omitempty"`
+}
+
+func (m *RaftEntry) Reset()                    { *m = RaftEntry{} }
+func (m *RaftEntry) String() string            { return proto.CompactTextString(m) }
+func (*RaftEntry) ProtoMessage()               {}
+func (*RaftEntry) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{31} }
+
+func (m *RaftEntry) GetTerm() uint64 {
+	if m != nil {
+		return m.Term
+	}
+	return 0
+}
+
+func (m *RaftEntry) GetIndex() uint64 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+func (m *RaftEntry) GetBlock() *Block {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+// RaftRequestVote is multicasted by a candidate to gather votes.
+type RaftRequestVote struct {
+	Term uint64 `protobuf:"varint,1,opt,name=term" json:"term,omitempty"`
+	// Index of the candidate in the validator set.
+	Candidate    uint32 `protobuf:"varint,2,opt,name=candidate" json:"candidate,omitempty"`
+	LastLogIndex uint64 `protobuf:"varint,3,opt,name=last_log_index,json=lastLogIndex" json:"last_log_index,omitempty"`
+	LastLogTerm  uint64 `protobuf:"varint,4,opt,name=last_log_term,json=lastLogTerm" json:"last_log_term,omitempty"`
+}
+
+func (m *RaftRequestVote) Reset()                    { *m = RaftRequestVote{} }
+func (m *RaftRequestVote) String() string            { return proto.CompactTextString(m) }
+func (*RaftRequestVote) ProtoMessage()               {}
+func (*RaftRequestVote) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{32} }
+
+func (m *RaftRequestVote) GetTerm() uint64 {
+	if m != nil {
+		return m.Term
+	}
+	return 0
+}
+
+func (m *RaftRequestVote) GetCandidate() uint32 {
+	if m != nil {
+		return m.Candidate
+	}
+	return 0
+}
+
+func (m *RaftRequestVote) GetLastLogIndex() uint64 {
+	if m != nil {
+		return m.LastLogIndex
+	}
+	return 0
+}
+
+func (m *RaftRequestVote) GetLastLogTerm() uint64 {
+	if m != nil {
+		return m.LastLogTerm
+	}
+	return 0
+}
+
+// RaftVote is the response to a RaftRequestVote.
+type RaftVote struct {
+	Term      uint64 `protobuf:"varint,1,opt,name=term" json:"term,omitempty"`
+	Candidate uint32 `protobuf:"varint,2,opt,name=candidate" json:"candidate,omitempty"`
+	Voter     uint32 `protobuf:"varint,3,opt,name=voter" json:"voter,omitempty"`
+	Granted   bool   `protobuf:"varint,4,opt,name=granted" json:"granted,omitempty"`
+}
+
+func (m *RaftVote) Reset()                    { *m = RaftVote{} }
+func (m *RaftVote) String() string            { return proto.CompactTextString(m) }
+func (*RaftVote) ProtoMessage()               {}
+func (*RaftVote) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{33} }
+
+func (m *RaftVote) GetTerm() uint64 {
+	if m != nil {
+		return m.Term
+	}
+	return 0
+}
+
+func (m *RaftVote) GetCandidate() uint32 {
+	if m != nil {
+		return m.Candidate
+	}
+	return 0
+}
+
+func (m *RaftVote) GetVoter() uint32 {
+	if m != nil {
+		return m.Voter
+	}
+	return 0
+}
+
+func (m *RaftVote) GetGranted() bool {
+	if m != nil {
+		return m.Granted
+	}
+	return false
+}
+
+// RaftAppendEntries is send by the leader to replicate log entries, it is
+// also used as heartbeat.
+type RaftAppendEntries struct {
+	Term   uint64 `protobuf:"varint,1,opt,name=term" json:"term,omitempty"`
+	Leader uint32 `protobuf:"varint,2,opt,name=leader" json:"leader,omitempty"`
+	// Index of the follower the message is addressed to.
+	Follower     uint32       `protobuf:"varint,3,opt,name=follower" json:"follower,omitempty"`
+	PrevLogIndex uint64       `protobuf:"varint,4,opt,name=prev_log_index,json=prevLogIndex" json:"prev_log_index,omitempty"`
+	PrevLogTerm  uint64       `protobuf:"varint,5,opt,name=prev_log_term,json=prevLogTerm" json:"prev_log_term,omitempty"`
+	Entries      []*RaftEntry `protobuf:"bytes,6,rep,name=entries" json:"entries,omitempty"`
+	LeaderCommit uint64       `protobuf:"varint,7,opt,name=leader_commit,json=leaderCommit" json:"leader_commit,omitempty"`
+	// Nonce used to prevent hash collisions.
+	Nonce uint64 `protobuf:"varint,8,opt,name=nonce" json:"nonce,omitempty"`
+}
+
+func (m *RaftAppendEntries) Reset()                    { *m = RaftAppendEntries{} }
+func (m *RaftAppendEntries) String() string            { return proto.CompactTextString(m) }
+func (*RaftAppendEntries) ProtoMessage()               {}
+func (*RaftAppendEntries) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{34} }
+
+func (m *RaftAppendEntries) GetTerm() uint64 {
+	if m != nil {
+		return m.Term
+	}
+	return 0
+}
+
+func (m *RaftAppendEntries) GetLeader() uint32 {
+	if m != nil {
+		return m.Leader
+	}
+	return 0
+}
+
+func (m *RaftAppendEntries) GetFollower() uint32 {
+	if m != nil {
+		return m.Follower
+	}
+	return 0
+}
+
+func (m *RaftAppendEntries) GetPrevLogIndex() uint64 {
+	if m != nil {
+		return m.PrevLogIndex
+	}
+	return 0
+}
+
+func (m *RaftAppendEntries) GetPrevLogTerm() uint64 {
+	if m != nil {
+		return m.PrevLogTerm
+	}
+	return 0
+}
+
+func (m *RaftAppendEntries) GetEntries() []*RaftEntry {
+	if m != nil {
+		return m.Entries
+	}
+	return nil
+}
+
+func (m *RaftAppendEntries) GetLeaderCommit() uint64 {
+	if m != nil {
+		return m.LeaderCommit
+	}
+	return 0
+}
+
+func (m *RaftAppendEntries) GetNonce() uint64 {
+	if m != nil {
+		return m.Nonce
+	}
+	return 0
+}
+
+// RaftAppendResponse is the response to a RaftAppendEntries.
+type RaftAppendResponse struct {
+	Term     uint64 `protobuf:"varint,1,opt,name=term" json:"term,omitempty"`
+	Leader   uint32 `protobuf:"varint,2,opt,name=leader" json:"leader,omitempty"`
+	Follower uint32 `protobuf:"varint,3,opt,name=follower" json:"follower,omitempty"`
+	Success  bool   `protobuf:"varint,4,opt,name=success" json:"success,omitempty"`
+	// Index of the last entry matching the leader's log on success, a hint
+	// where the logs diverge otherwise.
+	MatchIndex uint64 `protobuf:"varint,5,opt,name=match_index,json=matchIndex" json:"match_index,omitempty"`
+	// Nonce used to prevent hash collisions.
+	Nonce uint64 `protobuf:"varint,6,opt,name=nonce" json:"nonce,omitempty"`
+}
+
+func (m *RaftAppendResponse) Reset()                    { *m = RaftAppendResponse{} }
+func (m *RaftAppendResponse) String() string            { return proto.CompactTextString(m) }
+func (*RaftAppendResponse) ProtoMessage()               {}
+func (*RaftAppendResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{35} }
+
+func (m *RaftAppendResponse) GetTerm() uint64 {
+	if m != nil {
+		return m.Term
+	}
+	return 0
+}
+
+func (m *RaftAppendResponse) GetLeader() uint32 {
+	if m != nil {
+		return m.Leader
+	}
+	return 0
+}
+
+func (m *RaftAppendResponse) GetFollower() uint32 {
+	if m != nil {
+		return m.Follower
+	}
+	return 0
+}
+
+func (m *RaftAppendResponse) GetSuccess() bool {
+	if m != nil {
+		return m.Success
+	}
+	return false
+}
+
+func (m *RaftAppendResponse) GetMatchIndex() uint64 {
+	if m != nil {
+		return m.MatchIndex
+	}
+	return 0
+}
+
+func (m *RaftAppendResponse) GetNonce() uint64 {
+	if m != nil {
+		return m.Nonce
+	}
+	return 0
+}
+
+// TendermintProposal is multicasted by the proposer of a round.
+type TendermintProposal struct {
+	Height uint64 `protobuf:"varint,1,opt,name=height" json:"height,omitempty"`
+	Round  uint64 `protobuf:"varint,2,opt,name=round" json:"round,omitempty"`
+	Block  *Block `protobuf:"bytes,3,opt,name=block" json:"block,omitempty"`
+	// The round in which the proposer saw the block getting 2/3 of the
+	// prevotes, -1 if the block is new.
+	ValidRound int64 `protobuf:"varint,4,opt,name=valid_round,json=validRound" json:"valid_round,omitempty"`
+	// Index of the proposer in the validator set.
+	Proposer  uint32 `protobuf:"varint,5,opt,name=proposer" json:"proposer,omitempty"`
+	Signature []byte `protobuf:"bytes,6,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *TendermintProposal) Reset()                    { *m = TendermintProposal{} }
+func (m *TendermintProposal) String() string            { return proto.CompactTextString(m) }
+func (*TendermintProposal) ProtoMessage()               {}
+func (*TendermintProposal) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{36} }
+
+func (m *TendermintProposal) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *TendermintProposal) GetRound() uint64 {
+	if m != nil {
+		return m.Round
+	}
+	return 0
+}
+
+func (m *TendermintProposal) GetBlock() *Block {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+func (m *TendermintProposal) GetValidRound() int64 {
+	if m != nil {
+		return m.ValidRound
+	}
+	return 0
+}
+
+func (m *TendermintProposal) GetProposer() uint32 {
+	if m != nil {
+		return m.Proposer
+	}
+	return 0
+}
+
+func (m *TendermintProposal) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// TendermintVote is multicasted by a validator in the prevote and precommit
+// steps of a round.
+type TendermintVote struct {
+	Type   TendermintVoteType `protobuf:"varint,1,opt,name=type,enum=message.TendermintVoteType" json:"type,omitempty"`
+	Height uint64             `protobuf:"varint,2,opt,name=height" json:"height,omitempty"`
+	Round  uint64             `protobuf:"varint,3,opt,name=round" json:"round,omitempty"`
+	// Hash of the block voted for, empty for a nil vote.
+	BlockHash []byte `protobuf:"bytes,4,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	// Index of the validator in the validator set.
+	Validator uint32 `protobuf:"varint,5,opt,name=validator" json:"validator,omitempty"`
+	Signature []byte `protobuf:"bytes,6,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *TendermintVote) Reset()                    { *m = TendermintVote{} }
+func (m *TendermintVote) String() string            { return proto.CompactTextString(m) }
+func (*TendermintVote) ProtoMessage()               {}
+func (*TendermintVote) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37} }
+
+func (m *TendermintVote) GetType() TendermintVoteType {
+	if m != nil {
+		return m.Type
+	}
+	return TendermintVoteType_prevote
+}
+
+func (m *TendermintVote) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *TendermintVote) GetRound() uint64 {
+	if m != nil {
+		return m.Round
+	}
+	return 0
+}
+
+func (m *TendermintVote) GetBlockHash() []byte {
+	if m != nil {
+		return m.BlockHash
+	}
+	return nil
+}
+
+func (m *TendermintVote) GetValidator() uint32 {
+	if m != nil {
+		return m.Validator
+	}
+	return 0
+}
+
+func (m *TendermintVote) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// TendermintCommit is the commit certificate of a height, the committed
+// block along with the precommits of more than 2/3 of the validators. It is
+// multicasted to validators that fell behind, so they can catch up.
+type TendermintCommit struct {
+	Round      uint64            `protobuf:"varint,1,opt,name=round" json:"round,omitempty"`
+	Block      *Block            `protobuf:"bytes,2,opt,name=block" json:"block,omitempty"`
+	Precommits []*TendermintVote `protobuf:"bytes,3,rep,name=precommits" json:"precommits,omitempty"`
+}
+
+func (m *TendermintCommit) Reset()                    { *m = TendermintCommit{} }
+func (m *TendermintCommit) String() string            { return proto.CompactTextString(m) }
+func (*TendermintCommit) ProtoMessage()               {}
+func (*TendermintCommit) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{38} }
+
+func (m *TendermintCommit) GetRound() uint64 {
+	if m != nil {
+		return m.Round
+	}
+	return 0
+}
+
+func (m *TendermintCommit) GetBlock() *Block {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+func (m *TendermintCommit) GetPrecommits() []*TendermintVote {
+	if m != nil {
+		return m.Precommits
+	}
+	return nil
+}
+
+// HotstuffProposal is multicasted by the leader of a view. It extends the
+// proposal certified by the justify QC with a new block, which forms a chain
+// where each QC also certifies the ancestors of the proposal.
+type HotstuffProposal struct {
+	View uint64 `protobuf:"varint,1,opt,name=view" json:"view,omitempty"`
+	// The proposed block, its index is the height of the proposal.
+	Block *Block `protobuf:"bytes,2,opt,name=block" json:"block,omitempty"`
+	// QC for the highest proposal known to the leader, which is the parent.
+	Justify *HotstuffQC `protobuf:"bytes,3,opt,name=justify" json:"justify,omitempty"`
+	// Index of the leader in the validator set.
+	Leader    uint32 `protobuf:"varint,4,opt,name=leader" json:"leader,omitempty"`
+	Signature []byte `protobuf:"bytes,5,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *HotstuffProposal) Reset()                    { *m = HotstuffProposal{} }
+func (m *HotstuffProposal) String() string            { return proto.CompactTextString(m) }
+func (*HotstuffProposal) ProtoMessage()               {}
+func (*HotstuffProposal) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{39} }
+
+func (m *HotstuffProposal) GetView() uint64 {
+	if m != nil {
+		return m.View
+	}
+	return 0
+}
+
+func (m *HotstuffProposal) GetBlock() *Block {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+func (m *HotstuffProposal) GetJustify() *HotstuffQC {
+	if m != nil {
+		return m.Justify
+	}
+	return nil
+}
+
+func (m *HotstuffProposal) GetLeader() uint32 {
+	if m != nil {
+		return m.Leader
+	}
+	return 0
+}
+
+func (m *HotstuffProposal) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// HotstuffVote is send to the leader of the next view by a replica that
+// accepted a proposal.
+type HotstuffVote struct {
+	View uint64 `protobuf:"varint,1,opt,name=view" json:"view,omitempty"`
+	// Hash of the proposal voted for.
+	Node []byte `protobuf:"bytes,2,opt,name=node,proto3" json:"node,omitempty"`
+	// Index of the replica in the validator set.
+	Replica   uint32 `protobuf:"varint,3,opt,name=replica" json:"replica,omitempty"`
+	Signature []byte `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *HotstuffVote) Reset()                    { *m = HotstuffVote{} }
+func (m *HotstuffVote) String() string            { return proto.CompactTextString(m) }
+func (*HotstuffVote) ProtoMessage()               {}
+func (*HotstuffVote) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{40} }
+
+func (m *HotstuffVote) GetView() uint64 {
+	if m != nil {
+		return m.View
+	}
+	return 0
+}
+
+func (m *HotstuffVote) GetNode() []byte {
+	if m != nil {
+		return m.Node
+	}
+	return nil
+}
+
+func (m *HotstuffVote) GetReplica() uint32 {
+	if m != nil {
+		return m.Replica
+	}
+	return 0
+}
+
+func (m *HotstuffVote) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// HotstuffQC is a quorum certificate proving that 2f+1 replicas voted for a
+// proposal.
+type HotstuffQC struct {
+	View uint64 `protobuf:"varint,1,opt,name=view" json:"view,omitempty"`
+	// Hash of the certified proposal.
+	Node  []byte          `protobuf:"bytes,2,opt,name=node,proto3" json:"node,omitempty"`
+	Votes []*HotstuffVote `protobuf:"bytes,3,rep,name=votes" json:"votes,omitempty"`
+}
+
+func (m *HotstuffQC) Reset()                    { *m = HotstuffQC{} }
+func (m *HotstuffQC) String() string            { return proto.CompactTextString(m) }
+func (*HotstuffQC) ProtoMessage()               {}
+func (*HotstuffQC) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{41} }
+
+func (m *HotstuffQC) GetView() uint64 {
+	if m != nil {
+		return m.View
+	}
+	return 0
+}
+
+func (m *HotstuffQC) GetNode() []byte {
+	if m != nil {
+		return m.Node
+	}
+	return nil
+}
+
+func (m *HotstuffQC) GetVotes() []*HotstuffVote {
+	if m != nil {
+		return m.Votes
+	}
+	return nil
+}
+
+// HotstuffNewView is send to the leader of the next view by a replica whose
+// view timed out.
+type HotstuffNewView struct {
+	// The view the replica moved to.
+	View uint64 `protobuf:"varint,1,opt,name=view" json:"view,omitempty"`
+	// QC for the highest proposal known to the replica.
+	Justify   *HotstuffQC `protobuf:"bytes,2,opt,name=justify" json:"justify,omitempty"`
+	Replica   uint32      `protobuf:"varint,3,opt,name=replica" json:"replica,omitempty"`
+	Signature []byte      `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *HotstuffNewView) Reset()                    { *m = HotstuffNewView{} }
+func (m *HotstuffNewView) String() string            { return proto.CompactTextString(m) }
+func (*HotstuffNewView) ProtoMessage()               {}
+func (*HotstuffNewView) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{42} }
+
+func (m *HotstuffNewView) GetView() uint64 {
+	if m != nil {
+		return m.View
+	}
+	return 0
+}
+
+func (m *HotstuffNewView) GetJustify() *HotstuffQC {
+	if m != nil {
+		return m.Justify
+	}
+	return nil
+}
+
+func (m *HotstuffNewView) GetReplica() uint32 {
+	if m != nil {
+		return m.Replica
+	}
+	return 0
+}
+
+func (m *HotstuffNewView) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// AvalancheQuery is multicasted by a validator to ask a random sample of the
+// validators for their preferences. It carries the preferred transaction of
+// each undecided conflict set of the querier.
+type AvalancheQuery struct {
+	// Random identifier of the query, repeated in the responses.
+	Id           uint64         `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	Transactions []*Transaction `protobuf:"bytes,2,rep,name=transactions" json:"transactions,omitempty"`
+	// Indexes of the sampled validators in the validator set.
+	Sample []uint32 `protobuf:"varint,3,rep,packed,name=sample" json:"sample,omitempty"`
+	// Index of the querier in the validator set.
+	Replica   uint32 `protobuf:"varint,4,opt,name=replica" json:"replica,omitempty"`
+	Signature []byte `protobuf:"bytes,5,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *AvalancheQuery) Reset()                    { *m = AvalancheQuery{} }
+func (m *AvalancheQuery) String() string            { return proto.CompactTextString(m) }
+func (*AvalancheQuery) ProtoMessage()               {}
+func (*AvalancheQuery) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{43} }
+
+func (m *AvalancheQuery) GetId() uint64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *AvalancheQuery) GetTransactions() []*Transaction {
+	if m != nil {
+		return m.Transactions
+	}
+	return nil
+}
+
+func (m *AvalancheQuery) GetSample() []uint32 {
+	if m != nil {
+		return m.Sample
+	}
+	return nil
+}
+
+func (m *AvalancheQuery) GetReplica() uint32 {
+	if m != nil {
+		return m.Replica
+	}
+	return 0
+}
+
+func (m *AvalancheQuery) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// AvalancheResponse is multicasted by a sampled validator in response to a
+// query.
+type AvalancheResponse struct {
+	Id uint64 `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	// Hash of the preferred transaction in the conflict set of each queried
+	// transaction, in the order of the query.
+	Preferences [][]byte `protobuf:"bytes,2,rep,name=preferences" json:"preferences,omitempty"`
+	// Index of the responder in the validator set.
+	Replica   uint32 `protobuf:"varint,3,opt,name=replica" json:"replica,omitempty"`
+	Signature []byte `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *AvalancheResponse) Reset()                    { *m = AvalancheResponse{} }
+func (m *AvalancheResponse) String() string            { return proto.CompactTextString(m) }
+func (*AvalancheResponse) ProtoMessage()               {}
+func (*AvalancheResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{44} }
+
+func (m *AvalancheResponse) GetId() uint64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *AvalancheResponse) GetPreferences() [][]byte {
+	if m != nil {
+		return m.Preferences
+	}
+	return nil
+}
+
+func (m *AvalancheResponse) GetReplica() uint32 {
+	if m != nil {
+		return m.Replica
+	}
+	return 0
+}
+
+func (m *AvalancheResponse) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// HoneybadgerCiphertext is a batch of transactions encrypted with the
+// threshold public key of the validators.
+type HoneybadgerCiphertext struct {
+	// Marshaled ephemeral public key of the encryption.
+	U    []byte `protobuf:"bytes,1,opt,name=u,proto3" json:"u,omitempty"`
+	Data []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (m *HoneybadgerCiphertext) Reset()                    { *m = HoneybadgerCiphertext{} }
+func (m *HoneybadgerCiphertext) String() string            { return proto.CompactTextString(m) }
+func (*HoneybadgerCiphertext) ProtoMessage()               {}
+func (*HoneybadgerCiphertext) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{45} }
+
+func (m *HoneybadgerCiphertext) GetU() []byte {
+	if m != nil {
+		return m.U
+	}
+	return nil
+}
+
+func (m *HoneybadgerCiphertext) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+// HoneybadgerBroadcast is multicasted in the reliable broadcast of the
+// encrypted batch of a proposer.
+type HoneybadgerBroadcast struct {
+	Type  HoneybadgerBroadcastType `protobuf:"varint,1,opt,name=type,enum=message.HoneybadgerBroadcastType" json:"type,omitempty"`
+	Epoch uint64                   `protobuf:"varint,2,opt,name=epoch" json:"epoch,omitempty"`
+	// Index of the validator whose batch is broadcasted.
+	Proposer uint32 `protobuf:"varint,3,opt,name=proposer" json:"proposer,omitempty"`
+	// The encrypted batch, only set in val and echo messages.
+	Ciphertext *HoneybadgerCiphertext `protobuf:"bytes,4,opt,name=ciphertext" json:"ciphertext,omitempty"`
+	// Hash of the encrypted batch.
+	Digest []byte `protobuf:"bytes,5,opt,name=digest,proto3" json:"digest,omitempty"`
+	// Index of the sender in the validator set.
+	Sender    uint32 `protobuf:"varint,6,opt,name=sender" json:"sender,omitempty"`
+	Signature []byte `protobuf:"bytes,7,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *HoneybadgerBroadcast) Reset()                    { *m = HoneybadgerBroadcast{} }
+func (m *HoneybadgerBroadcast) String() string            { return proto.CompactTextString(m) }
+func (*HoneybadgerBroadcast) ProtoMessage()               {}
+func (*HoneybadgerBroadcast) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{46} }
+
+func (m *HoneybadgerBroadcast) GetType() HoneybadgerBroadcastType {
+	if m != nil {
+		return m.Type
+	}
+	return HoneybadgerBroadcastType_val
+}
+
+func (m *HoneybadgerBroadcast) GetEpoch() uint64 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *HoneybadgerBroadcast) GetProposer() uint32 {
+	if m != nil {
+		return m.Proposer
+	}
+	return 0
+}
+
+func (m *HoneybadgerBroadcast) GetCiphertext() *HoneybadgerCiphertext {
+	if m != nil {
+		return m.Ciphertext
+	}
+	return nil
+}
+
+func (m *HoneybadgerBroadcast) GetDigest() []byte {
+	if m != nil {
+		return m.Digest
+	}
+	return nil
+}
+
+func (m *HoneybadgerBroadcast) GetSender() uint32 {
+	if m != nil {
+		return m.Sender
+	}
+	return 0
+}
+
+func (m *HoneybadgerBroadcast) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// HoneybadgerAgreement is multicasted in the binary agreement on whether the
+// batch of a proposer is part of the epoch.
+type HoneybadgerAgreement struct {
+	Type  HoneybadgerAgreementType `protobuf:"varint,1,opt,name=type,enum=message.HoneybadgerAgreementType" json:"type,omitempty"`
+	Epoch uint64                   `protobuf:"varint,2,opt,name=epoch" json:"epoch,omitempty"`
+	// Index of the validator whose batch is agreed on.
+	Proposer uint32 `protobuf:"varint,3,opt,name=proposer" json:"proposer,omitempty"`
+	Round    uint64 `protobuf:"varint,4,opt,name=round" json:"round,omitempty"`
+	Value    bool   `protobuf:"varint,5,opt,name=value" json:"value,omitempty"`
+	// Share of the common coin of the round and its proof, only set in coin
+	// messages.
+	Share []byte `protobuf:"bytes,6,opt,name=share,proto3" json:"share,omitempty"`
+	Proof []byte `protobuf:"bytes,7,opt,name=proof,proto3" json:"proof,omitempty"`
+	// Index of the sender in the validator set.
+	Sender    uint32 `protobuf:"varint,8,opt,name=sender" json:"sender,omitempty"`
+	Signature []byte `protobuf:"bytes,9,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *HoneybadgerAgreement) Reset()                    { *m = HoneybadgerAgreement{} }
+func (m *HoneybadgerAgreement) String() string            { return proto.CompactTextString(m) }
+func (*HoneybadgerAgreement) ProtoMessage()               {}
+func (*HoneybadgerAgreement) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{47} }
+
+func (m *HoneybadgerAgreement) GetType() HoneybadgerAgreementType {
+	if m != nil {
+		return m.Type
+	}
+	return HoneybadgerAgreementType_bval
+}
+
+func (m *HoneybadgerAgreement) GetEpoch() uint64 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *HoneybadgerAgreement) GetProposer() uint32 {
+	if m != nil {
+		return m.Proposer
+	}
+	return 0
+}
+
+func (m *HoneybadgerAgreement) GetRound() uint64 {
+	if m != nil {
+		return m.Round
+	}
+	return 0
+}
+
+func (m *HoneybadgerAgreement) GetValue() bool {
+	if m != nil {
+		return m.Value
+	}
+	return false
+}
+
+func (m *HoneybadgerAgreement) GetShare() []byte {
+	if m != nil {
+		return m.Share
+	}
+	return nil
+}
+
+func (m *HoneybadgerAgreement) GetProof() []byte {
+	if m != nil {
+		return m.Proof
+	}
+	return nil
+}
+
+func (m *HoneybadgerAgreement) GetSender() uint32 {
+	if m != nil {
+		return m.Sender
+	}
+	return 0
+}
+
+func (m *HoneybadgerAgreement) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// HoneybadgerDecryption is multicasted by a validator with its share for
+// decrypting the batch of a proposer that is part of the epoch.
+type HoneybadgerDecryption struct {
+	Epoch    uint64 `protobuf:"varint,1,opt,name=epoch" json:"epoch,omitempty"`
+	Proposer uint32 `protobuf:"varint,2,opt,name=proposer" json:"proposer,omitempty"`
+	Share    []byte `protobuf:"bytes,3,opt,name=share,proto3" json:"share,omitempty"`
+	Proof    []byte `protobuf:"bytes,4,opt,name=proof,proto3" json:"proof,omitempty"`
+	// Index of the sender in the validator set.
+	Sender    uint32 `protobuf:"varint,5,opt,name=sender" json:"sender,omitempty"`
+	Signature []byte `protobuf:"bytes,6,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *HoneybadgerDecryption) Reset()                    { *m = HoneybadgerDecryption{} }
+func (m *HoneybadgerDecryption) String() string            { return proto.CompactTextString(m) }
+func (*HoneybadgerDecryption) ProtoMessage()               {}
+func (*HoneybadgerDecryption) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{48} }
+
+func (m *HoneybadgerDecryption) GetEpoch() uint64 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *HoneybadgerDecryption) GetProposer() uint32 {
+	if m != nil {
+		return m.Proposer
+	}
+	return 0
+}
+
+func (m *HoneybadgerDecryption) GetShare() []byte {
+	if m != nil {
+		return m.Share
+	}
+	return nil
+}
+
+func (m *HoneybadgerDecryption) GetProof() []byte {
+	if m != nil {
+		return m.Proof
+	}
+	return nil
+}
+
+func (m *HoneybadgerDecryption) GetSender() uint32 {
+	if m != nil {
+		return m.Sender
+	}
+	return 0
+}
+
+func (m *HoneybadgerDecryption) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// AlgorandProposal is gossiped by a validator that was selected as a
+// proposer of a round by sortition.
+type AlgorandProposal struct {
+	Round uint64 `protobuf:"varint,1,opt,name=round" json:"round,omitempty"`
+	Block *Block `protobuf:"bytes,2,opt,name=block" json:"block,omitempty"`
+	// VRF proof of the sortition of the proposer, its output determines the
+	// priority of the proposal.
+	Proof []byte `protobuf:"bytes,3,opt,name=proof,proto3" json:"proof,omitempty"`
+	// Index of the proposer in the validator set.
+	Proposer  uint32 `protobuf:"varint,4,opt,name=proposer" json:"proposer,omitempty"`
+	Signature []byte `protobuf:"bytes,5,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *AlgorandProposal) Reset()                    { *m = AlgorandProposal{} }
+func (m *AlgorandProposal) String() string            { return proto.CompactTextString(m) }
+func (*AlgorandProposal) ProtoMessage()               {}
+func (*AlgorandProposal) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{49} }
+
+func (m *AlgorandProposal) GetRound() uint64 {
+	if m != nil {
+		return m.Round
+	}
+	return 0
+}
+
+func (m *AlgorandProposal) GetBlock() *Block {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+func (m *AlgorandProposal) GetProof() []byte {
+	if m != nil {
+		return m.Proof
+	}
+	return nil
+}
+
+func (m *AlgorandProposal) GetProposer() uint32 {
+	if m != nil {
+		return m.Proposer
+	}
+	return 0
+}
+
+func (m *AlgorandProposal) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// AlgorandVote is gossiped by a validator that was selected into the
+// committee of a step by sortition.
+type AlgorandVote struct {
+	Round uint64 `protobuf:"varint,1,opt,name=round" json:"round,omitempty"`
+	Step  uint32 `protobuf:"varint,2,opt,name=step" json:"step,omitempty"`
+	// Hash of the block voted for, empty for the empty block.
+	BlockHash []byte `protobuf:"bytes,3,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	// VRF proof of the sortition of the voter, its output determines the
+	// weight of the vote.
+	Proof []byte `protobuf:"bytes,4,opt,name=proof,proto3" json:"proof,omitempty"`
+	// Index of the voter in the validator set.
+	Voter     uint32 `protobuf:"varint,5,opt,name=voter" json:"voter,omitempty"`
+	Signature []byte `protobuf:"bytes,6,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *AlgorandVote) Reset()                    { *m = AlgorandVote{} }
+func (m *AlgorandVote) String() string            { return proto.CompactTextString(m) }
+func (*AlgorandVote) ProtoMessage()               {}
+func (*AlgorandVote) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{50} }
+
+func (m *AlgorandVote) GetRound() uint64 {
+	if m != nil {
+		return m.Round
+	}
+	return 0
+}
+
+func (m *AlgorandVote) GetStep() uint32 {
+	if m != nil {
+		return m.Step
+	}
+	return 0
+}
+
+func (m *AlgorandVote) GetBlockHash() []byte {
+	if m != nil {
+		return m.BlockHash
+	}
+	return nil
+}
+
+func (m *AlgorandVote) GetProof() []byte {
+	if m != nil {
+		return m.Proof
+	}
+	return nil
+}
+
+func (m *AlgorandVote) GetVoter() uint32 {
+	if m != nil {
+		return m.Voter
+	}
+	return 0
+}
+
+func (m *AlgorandVote) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// CasperVote is multicasted by a validator to vote for the link from a
+// justified source checkpoint to a target checkpoint.
+type CasperVote struct {
+	// Hash of the header of the source checkpoint and its epoch.
+	Source      []byte `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	SourceEpoch uint64 `protobuf:"varint,2,opt,name=source_epoch,json=sourceEpoch" json:"source_epoch,omitempty"`
+	// Hash of the header of the target checkpoint and its epoch.
+	Target      []byte `protobuf:"bytes,3,opt,name=target,proto3" json:"target,omitempty"`
+	TargetEpoch uint64 `protobuf:"varint,4,opt,name=target_epoch,json=targetEpoch" json:"target_epoch,omitempty"`
+	// Index of the validator in the validator set.
+	Validator uint32 `protobuf:"varint,5,opt,name=validator" json:"validator,omitempty"`
+	Signature []byte `protobuf:"bytes,6,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *CasperVote) Reset()                    { *m = CasperVote{} }
+func (m *CasperVote) String() string            { return proto.CompactTextString(m) }
+func (*CasperVote) ProtoMessage()               {}
+func (*CasperVote) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{51} }
+
+func (m *CasperVote) GetSource() []byte {
+	if m != nil {
+		return m.Source
+	}
+	return nil
+}
+
+func (m *CasperVote) GetSourceEpoch() uint64 {
+	if m != nil {
+		return m.SourceEpoch
+	}
+	return 0
+}
+
+func (m *CasperVote) GetTarget() []byte {
+	if m != nil {
+		return m.Target
+	}
+	return nil
+}
+
+func (m *CasperVote) GetTargetEpoch() uint64 {
+	if m != nil {
+		return m.TargetEpoch
+	}
+	return 0
+}
+
+func (m *CasperVote) GetValidator() uint32 {
+	if m != nil {
+		return m.Validator
+	}
+	return 0
+}
+
+func (m *CasperVote) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// CasperSlashing is multicasted as evidence of two votes of the same
+// validator that violate a slashing condition.
+type CasperSlashing struct {
+	Vote1 *CasperVote `protobuf:"bytes,1,opt,name=vote1" json:"vote1,omitempty"`
+	Vote2 *CasperVote `protobuf:"bytes,2,opt,name=vote2" json:"vote2,omitempty"`
+}
+
+func (m *CasperSlashing) Reset()                    { *m = CasperSlashing{} }
+func (m *CasperSlashing) String() string            { return proto.CompactTextString(m) }
+func (*CasperSlashing) ProtoMessage()               {}
+func (*CasperSlashing) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{52} }
+
+func (m *CasperSlashing) GetVote1() *CasperVote {
+	if m != nil {
+		return m.Vote1
+	}
+	return nil
+}
+
+func (m *CasperSlashing) GetVote2() *CasperVote {
+	if m != nil {
+		return m.Vote2
+	}
+	return nil
+}
+
+// PaxosSlot is the value an acceptor accepted for a slot of the Multi-Paxos
+// log and the ballot it was accepted in.
+type PaxosSlot struct {
+	Slot   uint64 `protobuf:"varint,1,opt,name=slot" json:"slot,omitempty"`
+	Ballot uint64 `protobuf:"varint,2,opt,name=ballot" json:"ballot,omitempty"`
+	Value  *Block `protobuf:"bytes,3,opt,name=value" json:"value,omitempty"`
+}
+
+func (m *PaxosSlot) Reset()                    { *m = PaxosSlot{} }
+func (m *PaxosSlot) String() string            { return proto.CompactTextString(m) }
+func (*PaxosSlot) ProtoMessage()               {}
+func (*PaxosSlot) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{53} }
+
+func (m *PaxosSlot) GetSlot() uint64 {
+	if m != nil {
+		return m.Slot
+	}
+	return 0
+}
+
+func (m *PaxosSlot) GetBallot() uint64 {
+	if m != nil {
+		return m.Ballot
+	}
+	return 0
+}
+
+func (m *PaxosSlot) GetValue() *Block {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+// PaxosPrepare is multicasted by a proposer to become the leader of the
+// ballot (phase 1a). The leader of a ballot is the validator at index ballot
+// modulo the amount of validators.
+type PaxosPrepare struct {
+	Ballot uint64 `protobuf:"varint,1,opt,name=ballot" json:"ballot,omitempty"`
+	// The first slot that is not yet decided for the proposer.
+	Slot uint64 `protobuf:"varint,2,opt,name=slot" json:"slot,omitempty"`
+}
+
+func (m *PaxosPrepare) Reset()                    { *m = PaxosPrepare{} }
+func (m *PaxosPrepare) String() string            { return proto.CompactTextString(m) }
+func (*PaxosPrepare) ProtoMessage()               {}
+func (*PaxosPrepare) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{54} }
+
+func (m *PaxosPrepare) GetBallot() uint64 {
+	if m != nil {
+		return m.Ballot
 	}
+	return 0
 }
 
-func _Message_OneofSizer(msg proto.Message) (n int) {
-	m := msg.(*Message)
-	// Payload
-	switch x := m.Payload.(type) {
-	case *Message_State:
-		s := proto.Size(x.State)
-		n += proto.SizeVarint(2<<3 | proto.WireBytes)
-		n += proto.SizeVarint(uint64(s))
-		n += s
-	case *Message_PeerRequest:
-		s := proto.Size(x.PeerRequest)
-		n += proto.SizeVarint(3<<3 | proto.WireBytes)
-		n += proto.SizeVarint(uint64(s))
-		n += s
-	case *Message_PeerResponse:
-		s := proto.Size(x.PeerResponse)
-		n += proto.SizeVarint(4<<3 | proto.WireBytes)
-		n += proto.SizeVarint(uint64(s))
-		n += s
-	case *Message_Transaction:
-		s := proto.Size(x.Transaction)
-		n += proto.SizeVarint(5<<3 | proto.WireBytes)
-		n += proto.SizeVarint(uint64(s))
-		n += s
-	case *Message_Block:
-		s := proto.Size(x.Block)
-		n += proto.SizeVarint(6<<3 | proto.WireBytes)
-		n += proto.SizeVarint(uint64(s))
-		n += s
-	case nil:
-	default:
-		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+func (m *PaxosPrepare) GetSlot() uint64 {
+	if m != nil {
+		return m.Slot
 	}
-	return n
+	return 0
 }
 
-// State is used in the initial handshake.
-type State struct {
-	// unique peer identifier.
-	Id uint64 `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
-	// port the peer is accepting connections on.
-	Port uint32 `protobuf:"varint,2,opt,name=port" json:"port,omitempty"`
+// PaxosPromise is the response to a PaxosPrepare (phase 1b).
+type PaxosPromise struct {
+	Ballot uint64 `protobuf:"varint,1,opt,name=ballot" json:"ballot,omitempty"`
+	// Index of the acceptor in the validator set.
+	Acceptor uint32 `protobuf:"varint,2,opt,name=acceptor" json:"acceptor,omitempty"`
+	Ok       bool   `protobuf:"varint,3,opt,name=ok" json:"ok,omitempty"`
+	// The highest ballot the acceptor promised.
+	Promised uint64 `protobuf:"varint,4,opt,name=promised" json:"promised,omitempty"`
+	// The values accepted for the slots starting at the slot of the prepare.
+	Accepted []*PaxosSlot `protobuf:"bytes,5,rep,name=accepted" json:"accepted,omitempty"`
+	// The first slot the acceptor has not applied yet. The values it accepted
+	// for earlier slots are decided and not reported anymore.
+	Applied uint64 `protobuf:"varint,6,opt,name=applied" json:"applied,omitempty"`
 }
 
-func (m *State) Reset()                    { *m = State{} }
-func (m *State) String() string            { return proto.CompactTextString(m) }
-func (*State) ProtoMessage()               {}
-func (*State) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+func (m *PaxosPromise) Reset()                    { *m = PaxosPromise{} }
+func (m *PaxosPromise) String() string            { return proto.CompactTextString(m) }
+func (*PaxosPromise) ProtoMessage()               {}
+func (*PaxosPromise) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{55} }
 
-func (m *State) GetId() uint64 {
+func (m *PaxosPromise) GetBallot() uint64 {
 	if m != nil {
-		return m.Id
+		return m.Ballot
 	}
 	return 0
 }
 
-func (m *State) GetPort() uint32 {
+func (m *PaxosPromise) GetAcceptor() uint32 {
 	if m != nil {
-		return m.Port
+		return m.Acceptor
 	}
 	return 0
 }
 
-// PeerRequest requests known peers in the network.
-type PeerRequest struct {
-	// A list of already known peers in the network.
-	Known []string `protobuf:"bytes,1,rep,name=known" json:"known,omitempty"`
+func (m *PaxosPromise) GetOk() bool {
+	if m != nil {
+		return m.Ok
+	}
+	return false
 }
 
-func (m *PeerRequest) Reset()                    { *m = PeerRequest{} }
-func (m *PeerRequest) String() string            { return proto.CompactTextString(m) }
-func (*PeerRequest) ProtoMessage()               {}
-func (*PeerRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+func (m *PaxosPromise) GetPromised() uint64 {
+	if m != nil {
+		return m.Promised
+	}
+	return 0
+}
 
-func (m *PeerRequest) GetKnown() []string {
+func (m *PaxosPromise) GetAccepted() []*PaxosSlot {
 	if m != nil {
-		return m.Known
+		return m.Accepted
 	}
 	return nil
 }
 
-// PeerResponse contains a list of known peers responded after a PeerRequest is
-// send.
-type PeerResponse struct {
-	Peers []*Peer `protobuf:"bytes,1,rep,name=peers" json:"peers,omitempty"`
+func (m *PaxosPromise) GetApplied() uint64 {
+	if m != nil {
+		return m.Applied
+	}
+	return 0
 }
 
-func (m *PeerResponse) Reset()                    { *m = PeerResponse{} }
-func (m *PeerResponse) String() string            { return proto.CompactTextString(m) }
-func (*PeerResponse) ProtoMessage()               {}
-func (*PeerResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+// PaxosAccept is multicasted by the leader to propose a value for a slot
+// (phase 2a).
+type PaxosAccept struct {
+	Ballot uint64 `protobuf:"varint,1,opt,name=ballot" json:"ballot,omitempty"`
+	Slot   uint64 `protobuf:"varint,2,opt,name=slot" json:"slot,omitempty"`
+	Value  *Block `protobuf:"bytes,3,opt,name=value" json:"value,omitempty"`
+}
 
-func (m *PeerResponse) GetPeers() []*Peer {
+func (m *PaxosAccept) Reset()                    { *m = PaxosAccept{} }
+func (m *PaxosAccept) String() string            { return proto.CompactTextString(m) }
+func (*PaxosAccept) ProtoMessage()               {}
+func (*PaxosAccept) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{56} }
+
+func (m *PaxosAccept) GetBallot() uint64 {
 	if m != nil {
-		return m.Peers
+		return m.Ballot
+	}
+	return 0
+}
+
+func (m *PaxosAccept) GetSlot() uint64 {
+	if m != nil {
+		return m.Slot
+	}
+	return 0
+}
+
+func (m *PaxosAccept) GetValue() *Block {
+	if m != nil {
+		return m.Value
 	}
 	return nil
 }
 
-// Peer holds information about a peer in the network.
-type Peer struct {
-	Enpoint string `protobuf:"bytes,1,opt,name=enpoint" json:"enpoint,omitempty"`
+// PaxosAccepted is the response to a PaxosAccept (phase 2b).
+type PaxosAccepted struct {
+	Ballot   uint64 `protobuf:"varint,1,opt,name=ballot" json:"ballot,omitempty"`
+	Slot     uint64 `protobuf:"varint,2,opt,name=slot" json:"slot,omitempty"`
+	Acceptor uint32 `protobuf:"varint,3,opt,name=acceptor" json:"acceptor,omitempty"`
+	Ok       bool   `protobuf:"varint,4,opt,name=ok" json:"ok,omitempty"`
+	Promised uint64 `protobuf:"varint,5,opt,name=promised" json:"promised,omitempty"`
 }
 
-func (m *Peer) Reset()                    { *m = Peer{} }
-func (m *Peer) String() string            { return proto.CompactTextString(m) }
-func (*Peer) ProtoMessage()               {}
-func (*Peer) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+func (m *PaxosAccepted) Reset()                    { *m = PaxosAccepted{} }
+func (m *PaxosAccepted) String() string            { return proto.CompactTextString(m) }
+func (*PaxosAccepted) ProtoMessage()               {}
+func (*PaxosAccepted) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{57} }
 
-func (m *Peer) GetEnpoint() string {
+func (m *PaxosAccepted) GetBallot() uint64 {
 	if m != nil {
-		return m.Enpoint
+		return m.Ballot
 	}
-	return ""
+	return 0
 }
 
-// Header represents a very simple block header used for simulation.
-type Header struct {
-	// Index of the block.
-	Index uint32 `protobuf:"varint,1,opt,name=index" json:"index,omitempty"`
+func (m *PaxosAccepted) GetSlot() uint64 {
+	if m != nil {
+		return m.Slot
+	}
+	return 0
+}
+
+func (m *PaxosAccepted) GetAcceptor() uint32 {
+	if m != nil {
+		return m.Acceptor
+	}
+	return 0
+}
+
+func (m *PaxosAccepted) GetOk() bool {
+	if m != nil {
+		return m.Ok
+	}
+	return false
+}
+
+func (m *PaxosAccepted) GetPromised() uint64 {
+	if m != nil {
+		return m.Promised
+	}
+	return 0
+}
+
+// PaxosLearn is multicasted by the leader once a value is chosen for a slot
+// by a majority of the acceptors.
+type PaxosLearn struct {
+	Slot  uint64 `protobuf:"varint,1,opt,name=slot" json:"slot,omitempty"`
+	Value *Block `protobuf:"bytes,2,opt,name=value" json:"value,omitempty"`
+}
+
+func (m *PaxosLearn) Reset()                    { *m = PaxosLearn{} }
+func (m *PaxosLearn) String() string            { return proto.CompactTextString(m) }
+func (*PaxosLearn) ProtoMessage()               {}
+func (*PaxosLearn) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{58} }
+
+func (m *PaxosLearn) GetSlot() uint64 {
+	if m != nil {
+		return m.Slot
+	}
+	return 0
+}
+
+func (m *PaxosLearn) GetValue() *Block {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+// PaxosHeartbeat is multicasted by the leader to keep its leadership.
+type PaxosHeartbeat struct {
+	Ballot uint64 `protobuf:"varint,1,opt,name=ballot" json:"ballot,omitempty"`
 	// Nonce used to prevent hash collisions.
 	Nonce uint64 `protobuf:"varint,2,opt,name=nonce" json:"nonce,omitempty"`
+	// The slot following the last slot the leader knows to be decided.
+	Decided uint64 `protobuf:"varint,3,opt,name=decided" json:"decided,omitempty"`
 }
 
-func (m *Header) Reset()                    { *m = Header{} }
-func (m *Header) String() string            { return proto.CompactTextString(m) }
-func (*Header) ProtoMessage()               {}
-func (*Header) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+func (m *PaxosHeartbeat) Reset()                    { *m = PaxosHeartbeat{} }
+func (m *PaxosHeartbeat) String() string            { return proto.CompactTextString(m) }
+func (*PaxosHeartbeat) ProtoMessage()               {}
+func (*PaxosHeartbeat) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{59} }
 
-func (m *Header) GetIndex() uint32 {
+func (m *PaxosHeartbeat) GetBallot() uint64 {
 	if m != nil {
-		return m.Index
+		return m.Ballot
 	}
 	return 0
 }
 
-func (m *Header) GetNonce() uint64 {
+func (m *PaxosHeartbeat) GetNonce() uint64 {
 	if m != nil {
 		return m.Nonce
 	}
 	return 0
 }
 
-// Block represents a very simple Block used for simulation.
-type Block struct {
-	// Head of the block that also will be used for computing its hash.
-	Header *Header `protobuf:"bytes,1,opt,name=header" json:"header,omitempty"`
-	// List of recorded transactions.
-	Transactions []*Transaction `protobuf:"bytes,2,rep,name=transactions" json:"transactions,omitempty"`
+func (m *PaxosHeartbeat) GetDecided() uint64 {
+	if m != nil {
+		return m.Decided
+	}
+	return 0
 }
 
-func (m *Block) Reset()                    { *m = Block{} }
-func (m *Block) String() string            { return proto.CompactTextString(m) }
-func (*Block) ProtoMessage()               {}
-func (*Block) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+// PaxosCatchUp is multicasted by a node that missed the values decided for
+// some slots. Nodes that applied them answer with a PaxosLearn for each of
+// the slots from slot on.
+type PaxosCatchUp struct {
+	Slot uint64 `protobuf:"varint,1,opt,name=slot" json:"slot,omitempty"`
+}
 
-func (m *Block) GetHeader() *Header {
+func (m *PaxosCatchUp) Reset()                    { *m = PaxosCatchUp{} }
+func (m *PaxosCatchUp) String() string            { return proto.CompactTextString(m) }
+func (*PaxosCatchUp) ProtoMessage()               {}
+func (*PaxosCatchUp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{60} }
+
+func (m *PaxosCatchUp) GetSlot() uint64 {
 	if m != nil {
-		return m.Header
+		return m.Slot
+	}
+	return 0
+}
+
+// DagEvent is an event of the hashgraph, which is multicasted by its creator.
+// Events reference the last event of their creator and an event of another
+// node, the order of the events is agreed on by virtual voting over the
+// resulting graph.
+type DagEvent struct {
+	// Index of the creator in the validator set.
+	Creator uint32 `protobuf:"varint,1,opt,name=creator" json:"creator,omitempty"`
+	// Hashes of the parent events, empty for the first event of a creator.
+	SelfParent  []byte `protobuf:"bytes,2,opt,name=self_parent,json=selfParent,proto3" json:"self_parent,omitempty"`
+	OtherParent []byte `protobuf:"bytes,3,opt,name=other_parent,json=otherParent,proto3" json:"other_parent,omitempty"`
+	// Unix time in nanoseconds the creator created the event.
+	Timestamp    int64          `protobuf:"varint,4,opt,name=timestamp" json:"timestamp,omitempty"`
+	Transactions []*Transaction `protobuf:"bytes,5,rep,name=transactions" json:"transactions,omitempty"`
+	Signature    []byte         `protobuf:"bytes,6,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *DagEvent) Reset()                    { *m = DagEvent{} }
+func (m *DagEvent) String() string            { return proto.CompactTextString(m) }
+func (*DagEvent) ProtoMessage()               {}
+func (*DagEvent) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{61} }
+
+func (m *DagEvent) GetCreator() uint32 {
+	if m != nil {
+		return m.Creator
+	}
+	return 0
+}
+
+func (m *DagEvent) GetSelfParent() []byte {
+	if m != nil {
+		return m.SelfParent
 	}
 	return nil
 }
 
-func (m *Block) GetTransactions() []*Transaction {
+func (m *DagEvent) GetOtherParent() []byte {
 	if m != nil {
-		return m.Transactions
+		return m.OtherParent
 	}
 	return nil
 }
 
-// Transaction represents a very simple transaction used for simulation.
-type Transaction struct {
-	// Nonce used to prevent hash collisions.
-	Nonce uint64 `protobuf:"varint,1,opt,name=nonce" json:"nonce,omitempty"`
+func (m *DagEvent) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
 }
 
-func (m *Transaction) Reset()                    { *m = Transaction{} }
-func (m *Transaction) String() string            { return proto.CompactTextString(m) }
-func (*Transaction) ProtoMessage()               {}
-func (*Transaction) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+func (m *DagEvent) GetTransactions() []*Transaction {
+	if m != nil {
+		return m.Transactions
+	}
+	return nil
+}
 
-func (m *Transaction) GetNonce() uint64 {
+func (m *DagEvent) GetSignature() []byte {
 	if m != nil {
-		return m.Nonce
+		return m.Signature
 	}
-	return 0
+	return nil
 }
 
 func init() {
@@ -438,37 +4382,278 @@ func init() {
 	proto.RegisterType((*Header)(nil), "message.Header")
 	proto.RegisterType((*Block)(nil), "message.Block")
 	proto.RegisterType((*Transaction)(nil), "message.Transaction")
+	proto.RegisterType((*Stake)(nil), "message.Stake")
+	proto.RegisterType((*KVOp)(nil), "message.KVOp")
+	proto.RegisterType((*KVSnapshot)(nil), "message.KVSnapshot")
+	proto.RegisterType((*KVPair)(nil), "message.KVPair")
+	proto.RegisterType((*KVSequence)(nil), "message.KVSequence")
+	proto.RegisterType((*Hello)(nil), "message.Hello")
+	proto.RegisterType((*Identity)(nil), "message.Identity")
+	proto.RegisterType((*GetHeaders)(nil), "message.GetHeaders")
+	proto.RegisterType((*Headers)(nil), "message.Headers")
+	proto.RegisterType((*GetBlocks)(nil), "message.GetBlocks")
+	proto.RegisterType((*Blocks)(nil), "message.Blocks")
+	proto.RegisterType((*FbftPrepare)(nil), "message.FbftPrepare")
+	proto.RegisterType((*FbftCommit)(nil), "message.FbftCommit")
+	proto.RegisterType((*FbftReveal)(nil), "message.FbftReveal")
+	proto.RegisterType((*FbftViewChange)(nil), "message.FbftViewChange")
+	proto.RegisterType((*FbftCatchUp)(nil), "message.FbftCatchUp")
+	proto.RegisterType((*FbftCertificate)(nil), "message.FbftCertificate")
+	proto.RegisterType((*PbftPrePrepare)(nil), "message.PbftPrePrepare")
+	proto.RegisterType((*PbftPrepare)(nil), "message.PbftPrepare")
+	proto.RegisterType((*PbftCommit)(nil), "message.PbftCommit")
+	proto.RegisterType((*PbftPrepared)(nil), "message.PbftPrepared")
+	proto.RegisterType((*PbftViewChange)(nil), "message.PbftViewChange")
+	proto.RegisterType((*PbftNewView)(nil), "message.PbftNewView")
+	proto.RegisterType((*RaftEntry)(nil), "message.RaftEntry")
+	proto.RegisterType((*RaftRequestVote)(nil), "message.RaftRequestVote")
+	proto.RegisterType((*RaftVote)(nil), "message.RaftVote")
+	proto.RegisterType((*RaftAppendEntries)(nil), "message.RaftAppendEntries")
+	proto.RegisterType((*RaftAppendResponse)(nil), "message.RaftAppendResponse")
+	proto.RegisterType((*TendermintProposal)(nil), "message.TendermintProposal")
+	proto.RegisterType((*TendermintVote)(nil), "message.TendermintVote")
+	proto.RegisterType((*TendermintCommit)(nil), "message.TendermintCommit")
+	proto.RegisterType((*HotstuffProposal)(nil), "message.HotstuffProposal")
+	proto.RegisterType((*HotstuffVote)(nil), "message.HotstuffVote")
+	proto.RegisterType((*HotstuffQC)(nil), "message.HotstuffQC")
+	proto.RegisterType((*HotstuffNewView)(nil), "message.HotstuffNewView")
+	proto.RegisterType((*AvalancheQuery)(nil), "message.AvalancheQuery")
+	proto.RegisterType((*AvalancheResponse)(nil), "message.AvalancheResponse")
+	proto.RegisterType((*HoneybadgerCiphertext)(nil), "message.HoneybadgerCiphertext")
+	proto.RegisterType((*HoneybadgerBroadcast)(nil), "message.HoneybadgerBroadcast")
+	proto.RegisterType((*HoneybadgerAgreement)(nil), "message.HoneybadgerAgreement")
+	proto.RegisterType((*HoneybadgerDecryption)(nil), "message.HoneybadgerDecryption")
+	proto.RegisterType((*AlgorandProposal)(nil), "message.AlgorandProposal")
+	proto.RegisterType((*AlgorandVote)(nil), "message.AlgorandVote")
+	proto.RegisterType((*CasperVote)(nil), "message.CasperVote")
+	proto.RegisterType((*CasperSlashing)(nil), "message.CasperSlashing")
+	proto.RegisterType((*PaxosSlot)(nil), "message.PaxosSlot")
+	proto.RegisterType((*PaxosPrepare)(nil), "message.PaxosPrepare")
+	proto.RegisterType((*PaxosPromise)(nil), "message.PaxosPromise")
+	proto.RegisterType((*PaxosAccept)(nil), "message.PaxosAccept")
+	proto.RegisterType((*PaxosAccepted)(nil), "message.PaxosAccepted")
+	proto.RegisterType((*PaxosLearn)(nil), "message.PaxosLearn")
+	proto.RegisterType((*PaxosHeartbeat)(nil), "message.PaxosHeartbeat")
+	proto.RegisterType((*PaxosCatchUp)(nil), "message.PaxosCatchUp")
+	proto.RegisterType((*DagEvent)(nil), "message.DagEvent")
 	proto.RegisterEnum("message.Flag", Flag_name, Flag_value)
+	proto.RegisterEnum("message.KVOpType", KVOpType_name, KVOpType_value)
+	proto.RegisterEnum("message.TendermintVoteType", TendermintVoteType_name, TendermintVoteType_value)
+	proto.RegisterEnum("message.HoneybadgerBroadcastType", HoneybadgerBroadcastType_name, HoneybadgerBroadcastType_value)
+	proto.RegisterEnum("message.HoneybadgerAgreementType", HoneybadgerAgreementType_name, HoneybadgerAgreementType_value)
 }
 
 func init() { proto.RegisterFile("message.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 409 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x52, 0x4d, 0x6f, 0xd4, 0x30,
-	0x14, 0xdc, 0xa4, 0xc9, 0x46, 0xfb, 0xb2, 0x59, 0xaa, 0xa7, 0x22, 0xf9, 0xb8, 0xb8, 0x12, 0xac,
-	0x40, 0xea, 0x61, 0xcb, 0xa1, 0x48, 0x9c, 0x7a, 0x40, 0x7b, 0x41, 0xaa, 0x0c, 0x77, 0xe4, 0x26,
-	0x66, 0x09, 0x0d, 0xb6, 0x89, 0x5d, 0x01, 0x7f, 0x8d, 0x5f, 0x87, 0xfc, 0xbc, 0x1f, 0x66, 0xd5,
-	0x5b, 0xc6, 0x33, 0x13, 0xbf, 0x99, 0x67, 0x68, 0x7e, 0x28, 0xe7, 0xe4, 0x56, 0x5d, 0xd9, 0xd1,
-	0x78, 0x83, 0xd5, 0x0e, 0xf2, 0xbf, 0x39, 0x54, 0x1f, 0xe3, 0x37, 0xbe, 0x80, 0xe2, 0xeb, 0x20,
-	0xb7, 0x2c, 0x5b, 0x66, 0xab, 0xc5, 0xba, 0xb9, 0xda, 0x5b, 0x3e, 0x0c, 0x72, 0x2b, 0x88, 0xc2,
-	0x97, 0x50, 0x3a, 0x2f, 0xbd, 0x62, 0xf9, 0x32, 0x5b, 0xd5, 0xeb, 0xc5, 0x41, 0xf3, 0x29, 0x9c,
-	0x6e, 0x26, 0x22, 0xd2, 0xf8, 0x0e, 0xe6, 0x56, 0xa9, 0xf1, 0xcb, 0xa8, 0x7e, 0x3e, 0x2a, 0xe7,
-	0xd9, 0x19, 0xc9, 0x2f, 0x0e, 0xf2, 0x3b, 0xa5, 0x46, 0x11, 0xb9, 0xcd, 0x44, 0xd4, 0xf6, 0x08,
-	0xf1, 0x3d, 0x34, 0x3b, 0xab, 0xb3, 0x46, 0x3b, 0xc5, 0x0a, 0xf2, 0x3e, 0x3f, 0xf1, 0x46, 0x72,
-	0x33, 0x11, 0x73, 0x9b, 0x60, 0xbc, 0x81, 0xda, 0x8f, 0x52, 0x3b, 0xd9, 0xfa, 0xde, 0x68, 0x56,
-	0x9e, 0xdc, 0xfb, 0xf9, 0xc8, 0x85, 0x7b, 0x13, 0x69, 0x88, 0x76, 0x3f, 0x98, 0xf6, 0x81, 0x4d,
-	0x4f, 0xa2, 0xdd, 0x86, 0xd3, 0x10, 0x8d, 0xe8, 0xdb, 0x19, 0x54, 0x77, 0xf2, 0xcf, 0x60, 0x64,
-	0xc7, 0xdf, 0x40, 0x49, 0xb9, 0x71, 0x01, 0x79, 0xdf, 0x51, 0x6f, 0x85, 0xc8, 0xfb, 0x0e, 0x11,
-	0x0a, 0x6b, 0x46, 0x4f, 0x2d, 0x35, 0x82, 0xbe, 0xf9, 0x25, 0xd4, 0x49, 0x6a, 0xbc, 0x80, 0xf2,
-	0x41, 0x9b, 0x5f, 0x9a, 0x65, 0xcb, 0xb3, 0xd5, 0x4c, 0x44, 0xc0, 0xaf, 0x61, 0x9e, 0xc6, 0xc3,
-	0x4b, 0x28, 0x43, 0x3c, 0x47, 0xaa, 0x3a, 0xd9, 0x09, 0xa9, 0x22, 0xc7, 0x97, 0x50, 0x04, 0x88,
-	0x0c, 0x2a, 0xa5, 0xad, 0xe9, 0xb5, 0xa7, 0x51, 0x66, 0x62, 0x0f, 0xf9, 0x5b, 0x98, 0x6e, 0x94,
-	0xec, 0xd4, 0x18, 0xae, 0xed, 0x75, 0xa7, 0x7e, 0x93, 0xa2, 0x11, 0x11, 0x84, 0x53, 0x6d, 0x74,
-	0x1b, 0xd7, 0x5a, 0x88, 0x08, 0xf8, 0x77, 0x28, 0x29, 0x3b, 0xbe, 0x82, 0xe9, 0x37, 0xb2, 0x93,
-	0xab, 0x5e, 0x3f, 0x3b, 0x8c, 0x11, 0xff, 0x2a, 0x76, 0x34, 0xde, 0xc0, 0x3c, 0xa9, 0xd4, 0xb1,
-	0x9c, 0xa6, 0x7e, 0xb2, 0x7e, 0xf1, 0x9f, 0x32, 0xb4, 0x93, 0x90, 0xc7, 0x81, 0xb2, 0x64, 0xa0,
-	0xd7, 0x1c, 0x8a, 0xf0, 0x16, 0xb1, 0x81, 0x59, 0x1b, 0xea, 0xd1, 0xee, 0xd1, 0x9d, 0x4f, 0xb0,
-	0x86, 0xca, 0xc6, 0x8d, 0x9c, 0x67, 0xf7, 0x53, 0x7a, 0xe0, 0xd7, 0xff, 0x02, 0x00, 0x00, 0xff,
-	0xff, 0x89, 0x32, 0x02, 0xe9, 0xf1, 0x02, 0x00, 0x00,
+	// 3344 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x3a, 0x4d, 0x93, 0x14, 0xc7,
+	0xb1, 0xdb, 0xf3, 0x3d, 0x39, 0x33, 0xbb, 0xb3, 0xc5, 0x82, 0x5a, 0x20, 0xc4, 0xd2, 0x80, 0x04,
+	0x48, 0x42, 0x80, 0x42, 0x7a, 0x48, 0xf1, 0xf4, 0x9e, 0x01, 0x81, 0x56, 0x01, 0x96, 0x56, 0x0d,
+	0xc2, 0x76, 0x38, 0x1c, 0x13, 0xb5, 0x3d, 0x35, 0x33, 0x2d, 0x7a, 0xbb, 0x5b, 0xdd, 0x3d, 0x0b,
+	0xeb, 0x08, 0x9f, 0x7c, 0xb0, 0x0f, 0x96, 0x7f, 0x82, 0x23, 0x7c, 0xb5, 0xc2, 0x07, 0x5f, 0x6c,
+	0x9f, 0x7c, 0xb1, 0x4f, 0xf6, 0xc9, 0x57, 0x1f, 0x7c, 0xf6, 0xcf, 0x70, 0x64, 0x7d, 0x75, 0x75,
+	0x4f, 0xcf, 0x00, 0x2b, 0x87, 0x6f, 0x9d, 0x59, 0x99, 0x59, 0x99, 0x59, 0x59, 0x55, 0x99, 0x59,
+	0x0d, 0x83, 0x7d, 0x96, 0xa6, 0x74, 0xca, 0xae, 0xc4, 0x49, 0x94, 0x45, 0xa4, 0x2d, 0x41, 0xe7,
+	0x6f, 0x36, 0xb4, 0xbf, 0x2b, 0xbe, 0xc9, 0x59, 0x68, 0x4c, 0x02, 0x3a, 0xb5, 0xad, 0x6d, 0xeb,
+	0xe2, 0xfa, 0xf5, 0xc1, 0x15, 0xc5, 0x72, 0x37, 0xa0, 0x53, 0x97, 0x0f, 0x91, 0xd7, 0xa0, 0x99,
+	0x66, 0x34, 0x63, 0x76, 0x6d, 0xdb, 0xba, 0xd8, 0xbb, 0xbe, 0xae, 0x69, 0x1e, 0x20, 0x76, 0x67,
+	0xcd, 0x15, 0xc3, 0xe4, 0x7d, 0xe8, 0xc7, 0x8c, 0x25, 0xa3, 0x84, 0x7d, 0x35, 0x67, 0x69, 0x66,
+	0xd7, 0x39, 0xf9, 0x96, 0x26, 0xdf, 0x65, 0x2c, 0x71, 0xc5, 0xd8, 0xce, 0x9a, 0xdb, 0x8b, 0x73,
+	0x90, 0xfc, 0x2f, 0x0c, 0x24, 0x6b, 0x1a, 0x47, 0x61, 0xca, 0xec, 0x06, 0xe7, 0x3d, 0x5e, 0xe2,
+	0x15, 0x83, 0x3b, 0x6b, 0x6e, 0x3f, 0x36, 0x60, 0x72, 0x03, 0x7a, 0x59, 0x42, 0xc3, 0x94, 0x7a,
+	0x99, 0x1f, 0x85, 0x76, 0xb3, 0x34, 0xef, 0xc3, 0x7c, 0x0c, 0xe7, 0x35, 0x48, 0xd1, 0xb4, 0xbd,
+	0x20, 0xf2, 0x1e, 0xdb, 0xad, 0x92, 0x69, 0xb7, 0x10, 0x8b, 0xa6, 0xf1, 0x61, 0x34, 0x6d, 0xb2,
+	0x37, 0xc9, 0x46, 0x71, 0xc2, 0x62, 0x9a, 0x30, 0xbb, 0x5d, 0x9a, 0xe2, 0xee, 0xde, 0x24, 0xdb,
+	0x15, 0x63, 0x38, 0xc5, 0x24, 0x07, 0xc9, 0x7b, 0xc0, 0xc1, 0x91, 0x17, 0xed, 0xef, 0xfb, 0x99,
+	0xdd, 0xe1, 0x9c, 0xc7, 0x0a, 0x9c, 0xb7, 0xf9, 0xd0, 0xce, 0x9a, 0x0b, 0x13, 0x0d, 0x69, 0xbe,
+	0x84, 0x1d, 0x30, 0x1a, 0xd8, 0xdd, 0x0a, 0x3e, 0x97, 0x0f, 0x29, 0x3e, 0x01, 0x91, 0xdb, 0x30,
+	0xe4, 0x7c, 0x07, 0x3e, 0x7b, 0x32, 0xf2, 0x66, 0x34, 0x9c, 0x32, 0x1b, 0x38, 0xf3, 0x4b, 0x05,
+	0xe6, 0x47, 0x3e, 0x7b, 0x72, 0x9b, 0x0f, 0xef, 0xac, 0xb9, 0xeb, 0x93, 0x02, 0x06, 0x85, 0xc4,
+	0xd2, 0x5e, 0x6d, 0x73, 0xaf, 0x24, 0x64, 0x57, 0x18, 0x99, 0x9b, 0xbd, 0x1e, 0x17, 0x30, 0x3c,
+	0x1e, 0x4c, 0xa7, 0xf5, 0xcb, 0xf1, 0x50, 0x74, 0x5a, 0x5c, 0x74, 0x5a, 0x6c, 0x38, 0x6d, 0x50,
+	0x32, 0x7e, 0xb7, 0xe0, 0xb4, 0x58, 0x43, 0x5a, 0x6f, 0xd3, 0xf8, 0xf5, 0x0a, 0xbd, 0x8b, 0xc6,
+	0xc7, 0x45, 0xe3, 0x3f, 0x80, 0x01, 0x17, 0x12, 0xb2, 0x27, 0x5c, 0x90, 0xbd, 0x51, 0xa1, 0xf8,
+	0xa7, 0xec, 0x09, 0xb2, 0x28, 0xc5, 0x25, 0x48, 0xee, 0xc2, 0x66, 0x42, 0xf9, 0xaa, 0xf1, 0xc0,
+	0x1e, 0x1d, 0x44, 0x19, 0xb3, 0x87, 0x9c, 0xdf, 0xd6, 0xfc, 0x2e, 0xc5, 0xd5, 0xe2, 0x04, 0x8f,
+	0x22, 0xbe, 0x83, 0x36, 0x92, 0x22, 0x8a, 0x5c, 0x85, 0x2e, 0x97, 0xc3, 0xf9, 0x37, 0x39, 0xff,
+	0x66, 0x81, 0x5f, 0x32, 0x76, 0x12, 0xf9, 0x4d, 0xee, 0xc3, 0x31, 0xce, 0x41, 0xe3, 0x98, 0x85,
+	0xe3, 0x11, 0x0b, 0xb3, 0xc4, 0x67, 0xa9, 0x4d, 0x38, 0xef, 0xc9, 0x02, 0xef, 0x4d, 0x4e, 0x72,
+	0x47, 0x50, 0xec, 0xac, 0xb9, 0x9b, 0x49, 0x19, 0x49, 0x3e, 0x83, 0x2d, 0x53, 0x9a, 0xde, 0x97,
+	0xc7, 0xb8, 0xb8, 0x53, 0x15, 0xe2, 0x8c, 0xdd, 0x49, 0x92, 0x05, 0x2c, 0xf9, 0x14, 0x8e, 0x65,
+	0x2c, 0x1c, 0xb3, 0x64, 0xdf, 0x0f, 0x31, 0x24, 0xa2, 0x38, 0x4a, 0x69, 0x60, 0x6f, 0x95, 0xe4,
+	0x3d, 0xd4, 0x34, 0xbb, 0x92, 0x04, 0xe5, 0x65, 0x0b, 0x58, 0x72, 0x0b, 0x36, 0x0c, 0x79, 0xdc,
+	0x4d, 0xc7, 0x4b, 0x0b, 0x9d, 0xcb, 0x92, 0xce, 0x5a, 0xcf, 0x0a, 0x18, 0xb2, 0x03, 0x9b, 0xb3,
+	0x28, 0x4b, 0xb3, 0xf9, 0x64, 0x92, 0x6b, 0x74, 0x82, 0x4b, 0x79, 0x59, 0x4b, 0xd9, 0x91, 0x14,
+	0x86, 0x3e, 0xc3, 0x59, 0x09, 0x87, 0xe7, 0x97, 0x96, 0xc4, 0x75, 0x79, 0xa9, 0x74, 0x7e, 0x29,
+	0x29, 0x52, 0x93, 0xfe, 0xcc, 0x80, 0x31, 0x68, 0x34, 0xb7, 0x0e, 0x3a, 0xbb, 0x14, 0x34, 0x4a,
+	0x42, 0x1e, 0x78, 0x1b, 0xb3, 0x22, 0x0a, 0x7d, 0x42, 0x0f, 0x68, 0x40, 0x43, 0x6f, 0xc6, 0x46,
+	0x5f, 0xcd, 0x59, 0x72, 0x68, 0xbf, 0x5c, 0xf2, 0xc9, 0x4d, 0x35, 0xfe, 0x39, 0x0e, 0xa3, 0x4f,
+	0x68, 0x01, 0x43, 0xee, 0x01, 0xc9, 0x65, 0xe8, 0x65, 0x3f, 0x59, 0x8a, 0x22, 0x2d, 0xc6, 0x58,
+	0xf5, 0x4d, 0x5a, 0x46, 0x92, 0x87, 0x70, 0x7c, 0x16, 0x85, 0xec, 0x70, 0x8f, 0x8e, 0xa7, 0x2c,
+	0x19, 0xed, 0x25, 0x11, 0x1d, 0x7b, 0x34, 0xcd, 0xec, 0x53, 0x5c, 0xde, 0x69, 0xc3, 0x38, 0x4d,
+	0x75, 0x4b, 0x11, 0xed, 0xac, 0xb9, 0x5b, 0xb3, 0x0a, 0x7c, 0x59, 0x2a, 0x9d, 0x26, 0x8c, 0xed,
+	0xb3, 0x30, 0xb3, 0x5f, 0x59, 0x2e, 0xf5, 0xa6, 0x22, 0x2a, 0x49, 0xd5, 0x78, 0xf2, 0x3d, 0x38,
+	0x61, 0x4a, 0x1d, 0x33, 0x2f, 0x39, 0x8c, 0xf9, 0x7d, 0x72, 0x9a, 0x8b, 0x7d, 0xb5, 0x4a, 0xec,
+	0x47, 0x9a, 0x6a, 0x67, 0xcd, 0x3d, 0x3e, 0xab, 0x1a, 0xc0, 0x28, 0xa3, 0xc1, 0x34, 0x4a, 0x68,
+	0x38, 0xce, 0xa3, 0xec, 0xd5, 0x52, 0x94, 0xdd, 0x94, 0x14, 0x66, 0x94, 0xd1, 0x12, 0x0e, 0xa3,
+	0x4c, 0x4b, 0xe2, 0x51, 0x76, 0xa6, 0x14, 0x65, 0x4a, 0x8a, 0x8a, 0x32, 0x6a, 0xc0, 0x78, 0xa6,
+	0x7a, 0x34, 0x8d, 0x59, 0x22, 0x78, 0xb7, 0x4b, 0x67, 0xea, 0x6d, 0x3e, 0x26, 0x39, 0xc1, 0xd3,
+	0x10, 0x46, 0x95, 0xe4, 0x4b, 0x03, 0x9a, 0xce, 0xfc, 0x70, 0x6a, 0x9f, 0x2d, 0x45, 0x95, 0xe0,
+	0x7d, 0x20, 0x87, 0x31, 0xaa, 0xbc, 0x02, 0x86, 0xdf, 0xef, 0xf4, 0x69, 0x94, 0xea, 0xbb, 0xc0,
+	0x29, 0xdf, 0xef, 0x38, 0x9a, 0x5f, 0x06, 0xfd, 0xd8, 0x80, 0x4d, 0xee, 0x68, 0xdf, 0x4f, 0x99,
+	0x7d, 0xae, 0x9a, 0x9b, 0x0f, 0x1a, 0xdc, 0x1c, 0xe6, 0xd7, 0x10, 0xe7, 0xa6, 0x9e, 0xc7, 0xe2,
+	0xcc, 0x3e, 0x5f, 0x3e, 0xcd, 0x71, 0xf0, 0x26, 0x1f, 0xe3, 0xa7, 0x79, 0x0e, 0x92, 0xff, 0x87,
+	0x75, 0x93, 0x95, 0x8d, 0xed, 0x0b, 0x9c, 0xf9, 0x44, 0x15, 0x33, 0x1b, 0xef, 0xac, 0xb9, 0x83,
+	0xd8, 0x44, 0xf0, 0x7b, 0x8c, 0x0b, 0x08, 0x18, 0x4d, 0x42, 0xfb, 0xb5, 0xf2, 0x3d, 0x86, 0x63,
+	0xf7, 0x71, 0x88, 0xdf, 0x63, 0x1a, 0x42, 0x9f, 0x0b, 0xbe, 0x19, 0xa3, 0x49, 0xb6, 0xc7, 0x68,
+	0x66, 0xbf, 0x5e, 0xbe, 0xc6, 0x70, 0x7c, 0x47, 0x0d, 0xf3, 0x6b, 0xac, 0x80, 0xc1, 0x2b, 0x64,
+	0x4c, 0xa7, 0x23, 0x76, 0x80, 0x5b, 0xe3, 0x62, 0xe9, 0x0a, 0xf9, 0x88, 0x4e, 0xef, 0x1c, 0x88,
+	0xed, 0xd0, 0x19, 0xcb, 0x6f, 0xd4, 0x76, 0xca, 0x32, 0x9c, 0x73, 0xcc, 0x92, 0xd4, 0xbe, 0x54,
+	0xd2, 0xf6, 0x63, 0x96, 0xed, 0x88, 0x21, 0xd4, 0x76, 0xaa, 0x21, 0xf2, 0x26, 0xb4, 0x15, 0xcf,
+	0x65, 0xce, 0x33, 0xcc, 0xf7, 0x8a, 0x66, 0x50, 0x24, 0xe4, 0x1d, 0x40, 0xde, 0x11, 0x4f, 0xac,
+	0x52, 0xfb, 0x0d, 0xce, 0x40, 0xcc, 0x49, 0x78, 0xee, 0x85, 0x2c, 0xdd, 0xa9, 0x02, 0xc8, 0x25,
+	0x68, 0x49, 0x86, 0x37, 0x39, 0xc3, 0x46, 0x31, 0x53, 0x43, 0x6a, 0x49, 0x80, 0xfb, 0xcd, 0xb8,
+	0x19, 0x64, 0x06, 0xf1, 0x56, 0x69, 0xbf, 0xe5, 0x77, 0x83, 0xce, 0x23, 0x86, 0x59, 0x09, 0x87,
+	0xd9, 0xe1, 0x8c, 0x05, 0x41, 0x64, 0x5f, 0x29, 0x65, 0x87, 0x3b, 0x88, 0xc5, 0xec, 0x90, 0x0f,
+	0x93, 0xb7, 0xa1, 0xe3, 0x8f, 0x59, 0x98, 0xf9, 0xd9, 0xa1, 0xfd, 0x76, 0xc9, 0xd1, 0x9f, 0xc8,
+	0x01, 0x74, 0xb4, 0x22, 0x22, 0x1f, 0xaa, 0xb8, 0xf2, 0x68, 0xe6, 0xcd, 0x46, 0xf3, 0xd8, 0xbe,
+	0x5a, 0x15, 0xd1, 0xb7, 0x71, 0xf4, 0x8b, 0x58, 0x47, 0xb4, 0x84, 0x31, 0x41, 0x11, 0x29, 0xa5,
+	0xe2, 0xbe, 0x56, 0x91, 0x8e, 0xe6, 0xcc, 0xbd, 0x49, 0x0e, 0x92, 0x3b, 0x32, 0x3d, 0xf4, 0x58,
+	0x92, 0xf9, 0x13, 0xdf, 0xa3, 0x19, 0xb3, 0xaf, 0x97, 0xae, 0x1a, 0xce, 0x9e, 0x8f, 0xe3, 0x55,
+	0x33, 0x29, 0xa2, 0x6e, 0x75, 0xa1, 0xbd, 0x4b, 0x0f, 0x83, 0x88, 0x8e, 0x9d, 0x37, 0xa0, 0xc9,
+	0x0b, 0x01, 0xb2, 0x0e, 0x35, 0x7f, 0xcc, 0x0b, 0x89, 0x86, 0x5b, 0xf3, 0xc7, 0x84, 0x40, 0x23,
+	0x8e, 0x92, 0x8c, 0x97, 0x0d, 0x03, 0x97, 0x7f, 0x3b, 0xe7, 0xa0, 0x67, 0x94, 0x01, 0x64, 0x0b,
+	0x9a, 0x8f, 0xc3, 0xe8, 0x49, 0x68, 0x5b, 0xdb, 0xf5, 0x8b, 0x5d, 0x57, 0x00, 0xce, 0x3b, 0xd0,
+	0x37, 0xf3, 0x7d, 0x72, 0x0e, 0x9a, 0x31, 0xc3, 0xe8, 0x42, 0xaa, 0x9e, 0x51, 0xa4, 0x70, 0x2a,
+	0x31, 0xe6, 0x6c, 0x43, 0x03, 0x41, 0x62, 0x43, 0x9b, 0x85, 0x71, 0xe4, 0x87, 0x19, 0x57, 0xa5,
+	0xeb, 0x2a, 0xd0, 0xf9, 0x8b, 0x05, 0x2d, 0x11, 0x8f, 0x38, 0xaf, 0x1f, 0x8e, 0xd9, 0x53, 0x4e,
+	0x32, 0x70, 0x05, 0x80, 0xd8, 0x30, 0x0a, 0x3d, 0x51, 0xe8, 0x34, 0x5c, 0x01, 0x90, 0x53, 0xd0,
+	0x8d, 0x13, 0x76, 0x30, 0x9a, 0xd1, 0x74, 0xc6, 0x6b, 0x9a, 0xbe, 0xdb, 0x41, 0xc4, 0x0e, 0x4d,
+	0x67, 0xe4, 0x15, 0xe8, 0x66, 0xfe, 0x3e, 0x4b, 0x33, 0xba, 0x1f, 0xf3, 0xa2, 0xa5, 0xee, 0xe6,
+	0x08, 0xf2, 0x2a, 0xc0, 0xd8, 0x9f, 0x4c, 0x7c, 0x6f, 0x1e, 0x64, 0x87, 0xbc, 0x2e, 0x69, 0xb8,
+	0x06, 0x86, 0x9c, 0x84, 0x8e, 0xb8, 0x11, 0x58, 0x62, 0xb7, 0x94, 0x64, 0x01, 0x93, 0x97, 0xa0,
+	0x9d, 0x3d, 0x1d, 0x25, 0x51, 0x94, 0xf1, 0x6a, 0xa3, 0xef, 0xb6, 0xb2, 0xa7, 0x6e, 0x14, 0x65,
+	0xce, 0xcf, 0x2d, 0x68, 0xf2, 0xa0, 0x27, 0xaf, 0x43, 0x4b, 0x6c, 0x2a, 0xdb, 0x2a, 0x6d, 0x0a,
+	0x61, 0xa6, 0x2b, 0x87, 0xc9, 0x0d, 0xe8, 0x1b, 0x55, 0x4f, 0x6a, 0xd7, 0xb6, 0xeb, 0x85, 0x78,
+	0x31, 0x2a, 0x24, 0xb7, 0x40, 0x89, 0xf6, 0xa5, 0xfe, 0x34, 0xa4, 0xd9, 0x3c, 0x61, 0xd2, 0xf8,
+	0x1c, 0xe1, 0xfc, 0xcb, 0x82, 0x9e, 0xc1, 0x9b, 0x3b, 0xd0, 0x32, 0x1d, 0x78, 0x9e, 0xd7, 0x8f,
+	0x8f, 0x2b, 0xeb, 0xc7, 0xc7, 0xcc, 0x15, 0x83, 0xe4, 0x04, 0xb4, 0x52, 0xbe, 0x01, 0xe5, 0x34,
+	0x12, 0xc2, 0xf5, 0x8c, 0x45, 0xa4, 0x71, 0xff, 0xf6, 0x5d, 0x05, 0xa2, 0xf7, 0x52, 0x8c, 0x23,
+	0x9c, 0x50, 0xf8, 0x56, 0xc3, 0x45, 0xbd, 0x5b, 0x25, 0xbd, 0xc9, 0x10, 0xea, 0x13, 0x26, 0xaa,
+	0xb8, 0x86, 0x8b, 0x9f, 0xe4, 0x0c, 0xd4, 0xa3, 0x38, 0xb5, 0x3b, 0xa5, 0x00, 0xbb, 0xf7, 0xe8,
+	0xb3, 0xd8, 0xc5, 0x11, 0xe7, 0x43, 0x1e, 0xe5, 0x8f, 0xb9, 0xe4, 0x03, 0x1a, 0xf8, 0x63, 0x9a,
+	0x45, 0xc2, 0xef, 0x7d, 0x37, 0x47, 0xa0, 0x15, 0x74, 0x3f, 0x9a, 0x87, 0x22, 0xea, 0xeb, 0xae,
+	0x84, 0x9c, 0x2f, 0xa0, 0x81, 0xb2, 0xc8, 0x05, 0x68, 0x64, 0x87, 0x31, 0x93, 0xe5, 0xf6, 0x66,
+	0x61, 0xa2, 0x87, 0x87, 0x31, 0x73, 0xf9, 0x30, 0x2a, 0xf8, 0x98, 0x1d, 0x72, 0x19, 0x7d, 0x17,
+	0x3f, 0xd1, 0xb5, 0x07, 0x34, 0x98, 0xab, 0x45, 0x10, 0x80, 0x33, 0x01, 0xb8, 0xf7, 0xe8, 0x41,
+	0x48, 0xe3, 0x74, 0x16, 0x65, 0xe4, 0x02, 0x34, 0x63, 0xea, 0xeb, 0x7d, 0xb2, 0x61, 0x48, 0xdf,
+	0xa5, 0x7e, 0xe2, 0x8a, 0x51, 0x72, 0x0d, 0xba, 0xca, 0x4f, 0x2a, 0x14, 0x8e, 0x19, 0xa4, 0x0f,
+	0xe4, 0x98, 0x9b, 0x53, 0x39, 0x57, 0xa1, 0x25, 0x64, 0x28, 0xcd, 0xac, 0x0a, 0xcd, 0x6a, 0xa6,
+	0x66, 0xdf, 0xe1, 0x9a, 0xa9, 0xe5, 0xc8, 0x17, 0xd7, 0x2a, 0x2c, 0xae, 0xb9, 0x84, 0xb5, 0xe2,
+	0x12, 0x3a, 0x17, 0xa0, 0xc9, 0xcf, 0x59, 0xf4, 0xb8, 0x37, 0xa3, 0x41, 0xc0, 0xb0, 0x9a, 0x93,
+	0x1e, 0xd7, 0x08, 0xe7, 0x63, 0xe8, 0xa8, 0x33, 0x96, 0x9c, 0x06, 0x88, 0xe7, 0x7b, 0x81, 0xef,
+	0x8d, 0x72, 0x1d, 0xbb, 0x02, 0x73, 0x8f, 0x1d, 0x16, 0x83, 0xa2, 0x56, 0x0e, 0xe6, 0xf7, 0x00,
+	0xf2, 0x1b, 0x0e, 0x0f, 0xaf, 0x49, 0x12, 0xed, 0xcb, 0x03, 0x82, 0x7f, 0xa3, 0xa5, 0x9e, 0x5e,
+	0xdb, 0x81, 0x2b, 0x00, 0xe7, 0x3e, 0xb4, 0x15, 0xd3, 0x09, 0xdc, 0x90, 0xfe, 0x74, 0x96, 0x49,
+	0x36, 0x09, 0x91, 0x4b, 0xf9, 0x05, 0x59, 0x2b, 0x2d, 0x8d, 0xdc, 0xa9, 0x6a, 0xdc, 0x79, 0x17,
+	0xba, 0xfa, 0x0a, 0x7c, 0x01, 0x25, 0xae, 0x42, 0x4b, 0xf2, 0xbc, 0xa6, 0x6f, 0x4a, 0x11, 0x05,
+	0xa5, 0x9e, 0x86, 0xba, 0x26, 0x9d, 0x3f, 0x58, 0xd0, 0x33, 0xda, 0x16, 0x38, 0x17, 0xaf, 0x3b,
+	0xc4, 0xd6, 0xe5, 0xdf, 0xb8, 0xf7, 0xb8, 0x78, 0x96, 0xc8, 0xd5, 0x51, 0x20, 0xee, 0x69, 0x2e,
+	0x47, 0x36, 0x79, 0xca, 0x93, 0x88, 0x41, 0x3c, 0xff, 0xc4, 0xfd, 0xcb, 0xd3, 0x73, 0xb1, 0x7d,
+	0x0d, 0x0c, 0x0f, 0x8b, 0x19, 0x4d, 0x58, 0x6a, 0x37, 0xb7, 0xeb, 0x3c, 0x2c, 0x38, 0xb4, 0x7a,
+	0xf7, 0x3a, 0x5f, 0x02, 0xe4, 0x5d, 0x93, 0x17, 0xd4, 0xdb, 0x86, 0x76, 0xc2, 0xe2, 0xc0, 0xf7,
+	0x28, 0xd7, 0x7c, 0xe0, 0x2a, 0x10, 0xfd, 0xca, 0x67, 0x97, 0x6a, 0x0a, 0xc0, 0x89, 0xc5, 0x5c,
+	0xb2, 0xb7, 0xf2, 0x62, 0x73, 0xf1, 0xa0, 0xf7, 0x12, 0x96, 0xe5, 0x27, 0x1a, 0x42, 0x45, 0xeb,
+	0x1a, 0x65, 0xeb, 0xfe, 0x61, 0xc1, 0x7a, 0xb1, 0x3f, 0xb3, 0x6c, 0x5a, 0x65, 0x48, 0xad, 0x68,
+	0xc8, 0xca, 0x23, 0x9b, 0x5c, 0x87, 0xae, 0x58, 0x00, 0xcc, 0x66, 0x1b, 0xcb, 0xdb, 0x58, 0x6e,
+	0x4e, 0x66, 0x18, 0xd2, 0x2c, 0x18, 0x72, 0x15, 0xaf, 0x2f, 0x4e, 0x3d, 0xb6, 0x5b, 0x2b, 0x44,
+	0x69, 0x2a, 0xe7, 0x82, 0x88, 0x39, 0x95, 0x8c, 0x2c, 0xd9, 0x2f, 0xce, 0x0f, 0x60, 0xa3, 0x94,
+	0x83, 0x90, 0x2b, 0xd0, 0x96, 0x52, 0x6c, 0x6b, 0xc5, 0x54, 0x8a, 0xc8, 0xd0, 0xb9, 0x66, 0xea,
+	0xec, 0xfc, 0xde, 0x82, 0xf5, 0x62, 0xe7, 0xaa, 0xd2, 0xbd, 0x2b, 0x0e, 0x26, 0x14, 0x3d, 0xf6,
+	0xa7, 0xaa, 0xc3, 0xd9, 0x77, 0x25, 0x94, 0xef, 0x89, 0xc6, 0xaa, 0x3d, 0x61, 0x2c, 0x5c, 0x73,
+	0xc5, 0xc2, 0x2d, 0x44, 0xfd, 0xd7, 0x16, 0xf4, 0x76, 0x9f, 0xb1, 0x5f, 0x8f, 0xa2, 0xb5, 0xa1,
+	0x4f, 0x63, 0x85, 0x3e, 0xcd, 0xb2, 0x3e, 0xbf, 0xb0, 0x00, 0x76, 0x57, 0x6f, 0xc3, 0xff, 0xa6,
+	0x3a, 0x3f, 0x86, 0xbe, 0xe1, 0x9d, 0x31, 0xf6, 0x84, 0xcd, 0xe6, 0xa5, 0xb5, 0xb2, 0x79, 0xe9,
+	0x42, 0xac, 0xbf, 0x8d, 0xa8, 0x5e, 0x4c, 0x94, 0x76, 0x2b, 0xa2, 0x3a, 0x75, 0xbe, 0x91, 0x31,
+	0xf5, 0x8c, 0x2d, 0xbb, 0xca, 0x1d, 0xd7, 0x8c, 0xad, 0x54, 0xdf, 0xae, 0x17, 0x6b, 0x01, 0xc3,
+	0xae, 0x7c, 0x2f, 0x1d, 0xd9, 0x53, 0x7f, 0x97, 0x81, 0xa4, 0xba, 0x46, 0x55, 0xaa, 0x7e, 0x00,
+	0x7d, 0xa3, 0x85, 0xaa, 0xfc, 0xb0, 0xac, 0x87, 0xea, 0xf6, 0x0e, 0xf4, 0x77, 0x8a, 0xbc, 0x86,
+	0xe7, 0x53, 0xbb, 0x5e, 0xc1, 0x6b, 0xb8, 0xbe, 0x97, 0xbb, 0x3e, 0x3d, 0xb2, 0x4d, 0x3f, 0x84,
+	0x2e, 0x76, 0x22, 0xb1, 0x7b, 0x79, 0x88, 0x06, 0x65, 0x2c, 0xd9, 0x57, 0x06, 0xe1, 0x77, 0x9e,
+	0xf0, 0xcb, 0xd4, 0x9e, 0x03, 0xcf, 0x77, 0x8b, 0xe1, 0xce, 0xdb, 0x28, 0xb5, 0x6c, 0x2b, 0xe7,
+	0xc0, 0x3c, 0x85, 0x86, 0x63, 0xcc, 0x04, 0x99, 0x3c, 0x94, 0x73, 0x04, 0x39, 0x0f, 0xeb, 0x01,
+	0x4d, 0xb3, 0x51, 0x10, 0x4d, 0x47, 0x42, 0x95, 0x3a, 0xe7, 0xed, 0x23, 0xf6, 0x7e, 0x34, 0xfd,
+	0x84, 0x6b, 0xe4, 0xc0, 0x40, 0x53, 0xf1, 0x09, 0x1a, 0x9c, 0xa8, 0x27, 0x89, 0x1e, 0xb2, 0x64,
+	0xdf, 0x09, 0xa0, 0xa3, 0x3a, 0xc0, 0x47, 0xd0, 0x03, 0xd3, 0xb5, 0x28, 0x93, 0x69, 0xf6, 0xc0,
+	0x15, 0x00, 0x3a, 0x7e, 0x9a, 0xd0, 0x50, 0x5d, 0x0a, 0x1d, 0x57, 0x81, 0xce, 0x2f, 0x6b, 0xb0,
+	0xb9, 0xd0, 0x34, 0xae, 0x9c, 0xf7, 0x04, 0xb4, 0x02, 0x51, 0x8e, 0x88, 0x49, 0x25, 0x84, 0x71,
+	0x3f, 0x89, 0x82, 0x20, 0x7a, 0xa2, 0x27, 0xd5, 0x30, 0x7a, 0x85, 0x17, 0x57, 0xb9, 0x57, 0x84,
+	0xc1, 0x18, 0x42, 0x07, 0xa6, 0x57, 0x34, 0x15, 0x9f, 0x56, 0xa4, 0xfb, 0x3d, 0x49, 0x84, 0x5e,
+	0xc1, 0x26, 0x84, 0xea, 0x79, 0xb7, 0xb6, 0xeb, 0x85, 0x9e, 0x82, 0x0e, 0x0d, 0x57, 0x91, 0x90,
+	0x73, 0x30, 0x10, 0xda, 0xa9, 0x06, 0x41, 0x5b, 0x2e, 0x06, 0x47, 0xca, 0x33, 0x4d, 0x97, 0x33,
+	0x1d, 0xa3, 0x9c, 0x71, 0x7e, 0x6b, 0x01, 0x59, 0x6c, 0x7b, 0xff, 0xc7, 0x3c, 0x62, 0x43, 0x3b,
+	0x9d, 0x7b, 0x1e, 0x4b, 0x53, 0xb5, 0x12, 0x12, 0x24, 0x67, 0xa0, 0xb7, 0xcf, 0x2b, 0x7e, 0xe1,
+	0x28, 0x59, 0x4e, 0x72, 0xd4, 0x27, 0xc5, 0xfa, 0xb5, 0x65, 0xea, 0xfb, 0x67, 0x0b, 0xc8, 0x62,
+	0x5b, 0xbd, 0x74, 0xf7, 0x36, 0x74, 0xae, 0xba, 0x05, 0xcd, 0x24, 0x9a, 0x87, 0x63, 0xb5, 0x53,
+	0x38, 0xf0, 0x9c, 0xf9, 0xde, 0x19, 0xe8, 0xf1, 0x52, 0x68, 0x24, 0x24, 0x88, 0x7a, 0x18, 0x38,
+	0xca, 0xe5, 0x62, 0xcc, 0x82, 0x57, 0xdc, 0x7e, 0x1a, 0x7e, 0xc6, 0xf5, 0xf7, 0x57, 0x0b, 0xd6,
+	0x8b, 0x0d, 0x7d, 0xf2, 0x76, 0xa1, 0x96, 0x3a, 0xb5, 0xa4, 0xef, 0x6f, 0x54, 0x55, 0xb9, 0xc9,
+	0xb5, 0x6a, 0x93, 0xeb, 0xa6, 0xc9, 0xa7, 0x01, 0xb8, 0x55, 0xa2, 0xf0, 0x97, 0x79, 0x1a, 0xc7,
+	0xa8, 0xca, 0x3f, 0xaf, 0x03, 0x85, 0x2d, 0x39, 0xe2, 0x19, 0xc6, 0xfc, 0xcc, 0x82, 0x61, 0xb9,
+	0x03, 0x95, 0x6b, 0x61, 0x55, 0x3a, 0xbe, 0xb6, 0xca, 0xf1, 0xff, 0x03, 0x78, 0x83, 0x89, 0x50,
+	0x5e, 0x3c, 0x71, 0x8b, 0x0e, 0x71, 0x0d, 0x52, 0xe7, 0x77, 0x16, 0x0c, 0xcb, 0x2f, 0x1c, 0x95,
+	0x37, 0xc2, 0xf3, 0xe9, 0xf1, 0x16, 0xb4, 0xbf, 0x9c, 0xa7, 0x99, 0x3f, 0x39, 0x94, 0x81, 0x72,
+	0x6c, 0xe1, 0xfd, 0xe2, 0xf3, 0xdb, 0xae, 0xa2, 0x31, 0xf6, 0x47, 0xa3, 0xb0, 0x3f, 0x56, 0x1f,
+	0xf6, 0x21, 0xf4, 0xcd, 0xe7, 0x94, 0x4a, 0x75, 0x09, 0x34, 0xc2, 0x68, 0xac, 0xaa, 0x3c, 0xfe,
+	0xbd, 0x22, 0xf7, 0x5f, 0x9d, 0x91, 0x53, 0x80, 0x5c, 0xf9, 0xe7, 0x9e, 0xed, 0x0d, 0x71, 0xce,
+	0xa6, 0x0b, 0xd7, 0xb9, 0xa9, 0xbb, 0x38, 0x7e, 0x53, 0x4c, 0xa6, 0x36, 0x4a, 0x0f, 0x3c, 0x95,
+	0x13, 0x19, 0xfe, 0xad, 0x3d, 0x87, 0x7f, 0x8f, 0x6a, 0xf1, 0x6f, 0x2c, 0x58, 0x2f, 0xbe, 0x14,
+	0x2d, 0x34, 0xf7, 0x8e, 0xde, 0x52, 0xc2, 0xcc, 0x9c, 0xee, 0xc7, 0x01, 0xe3, 0x9e, 0x19, 0xb8,
+	0x12, 0x3a, 0xf2, 0xdd, 0xff, 0x13, 0xd8, 0x5c, 0x78, 0x8e, 0x5a, 0x50, 0x77, 0x9b, 0xa7, 0x83,
+	0x13, 0x96, 0xe4, 0x5d, 0x8f, 0xbe, 0x6b, 0xa2, 0x8e, 0xec, 0xab, 0xf7, 0xe1, 0xb8, 0xf1, 0x20,
+	0x74, 0xdb, 0x8f, 0x67, 0x2c, 0xc9, 0xd8, 0xd3, 0x8c, 0xf4, 0xc1, 0x9a, 0xcb, 0x1e, 0x84, 0x35,
+	0xc7, 0xd5, 0x1c, 0xd3, 0x8c, 0xaa, 0x10, 0xc1, 0x6f, 0xe7, 0xeb, 0x1a, 0x6c, 0x55, 0xbd, 0x7c,
+	0x91, 0x77, 0x0b, 0x27, 0xdb, 0xd9, 0x95, 0xcf, 0x64, 0xc6, 0xf9, 0xb6, 0x05, 0x4d, 0x16, 0x47,
+	0xde, 0x4c, 0x1d, 0xdd, 0x1c, 0x28, 0x9c, 0xb9, 0xf5, 0xd2, 0x99, 0xfb, 0x7f, 0x00, 0x9e, 0xd6,
+	0xd8, 0x6e, 0x2c, 0x7f, 0xe8, 0xca, 0xed, 0x72, 0x0d, 0x0e, 0x23, 0x8b, 0x6f, 0x16, 0xb2, 0xf8,
+	0xbc, 0xdf, 0xd3, 0x12, 0x1b, 0x5b, 0x40, 0x45, 0x57, 0xb6, 0x17, 0x4a, 0x9c, 0xa2, 0x3f, 0xf2,
+	0xb7, 0xb9, 0xe7, 0xf0, 0x87, 0x26, 0xfe, 0x56, 0xfe, 0xd0, 0x67, 0x70, 0xc3, 0x3c, 0x83, 0x75,
+	0x87, 0xab, 0xc9, 0x2f, 0x64, 0x01, 0xe4, 0x0d, 0x83, 0x96, 0xd1, 0x30, 0x40, 0x6c, 0x9c, 0x44,
+	0xd1, 0x44, 0x5a, 0x27, 0x00, 0xc3, 0x1f, 0x9d, 0xe5, 0xfe, 0xe8, 0x96, 0xfd, 0xf1, 0x8d, 0x55,
+	0x88, 0x2d, 0xe3, 0x4d, 0x51, 0x5b, 0x66, 0x2d, 0xb3, 0xac, 0xb6, 0x68, 0x99, 0xd0, 0xb6, 0x5e,
+	0xa9, 0x6d, 0xa3, 0x5a, 0xdb, 0xe6, 0x72, 0x6d, 0x17, 0x2e, 0xb5, 0x5f, 0x59, 0x30, 0x2c, 0x3f,
+	0x63, 0x7e, 0xab, 0x4b, 0x4d, 0x2b, 0x57, 0x37, 0x95, 0x33, 0x8d, 0x6c, 0xac, 0x4a, 0x21, 0x16,
+	0x0e, 0x8a, 0x5f, 0x5b, 0xd0, 0x37, 0x5f, 0x48, 0x97, 0x28, 0x47, 0xa0, 0x91, 0x66, 0x2c, 0x56,
+	0xcf, 0x16, 0xf8, 0x5d, 0xca, 0x05, 0xea, 0xe5, 0x5c, 0xa0, 0xda, 0x8d, 0x3a, 0xd3, 0x6e, 0x9a,
+	0x99, 0xf6, 0x6a, 0x27, 0xfe, 0xc9, 0x02, 0xc8, 0x5f, 0x62, 0xf9, 0x4a, 0x44, 0xf3, 0xc4, 0x63,
+	0xba, 0x6f, 0xca, 0x21, 0x72, 0x16, 0xfa, 0xe2, 0x6b, 0x64, 0x06, 0x78, 0x4f, 0xe0, 0xee, 0x20,
+	0x0a, 0x59, 0x33, 0x9a, 0x4c, 0xf3, 0xee, 0x93, 0x80, 0x90, 0x55, 0x7c, 0x49, 0x56, 0x59, 0x60,
+	0x08, 0x9c, 0x60, 0xfd, 0x36, 0xa9, 0xcd, 0x04, 0xd6, 0x8b, 0xaf, 0xc1, 0xe4, 0x92, 0x70, 0xc3,
+	0x35, 0xdb, 0x2a, 0xdd, 0x58, 0xb9, 0x9d, 0xc2, 0x37, 0xd7, 0x14, 0xe9, 0x75, 0xbb, 0xf6, 0x0c,
+	0xd2, 0xeb, 0xce, 0x8f, 0xa0, 0xcb, 0xdf, 0xc8, 0x1e, 0x04, 0x11, 0x6f, 0x3e, 0xa4, 0x41, 0xa4,
+	0x32, 0x59, 0xfe, 0x8d, 0xf6, 0xef, 0xd1, 0x00, 0xb1, 0x32, 0xd9, 0x13, 0x10, 0xc6, 0x5e, 0xde,
+	0x48, 0xaf, 0x88, 0x3d, 0x3e, 0xe8, 0x7c, 0x00, 0x7d, 0xf3, 0x49, 0xda, 0x90, 0x66, 0x15, 0xa4,
+	0xa9, 0x99, 0x6b, 0xf9, 0xcc, 0xce, 0x1f, 0x2d, 0xcd, 0x2c, 0x5e, 0xa0, 0x97, 0x31, 0x9f, 0x84,
+	0x8e, 0x78, 0x58, 0x8e, 0xf4, 0x7e, 0x55, 0x30, 0x5e, 0x60, 0x91, 0xc8, 0xb6, 0x3b, 0x6e, 0x2d,
+	0x7a, 0x2c, 0xc3, 0x1e, 0xc5, 0xa9, 0xc3, 0x49, 0xc3, 0xe4, 0x8a, 0x92, 0xc3, 0xc6, 0x76, 0xb3,
+	0x54, 0xfb, 0x68, 0x27, 0xb9, 0x9a, 0x06, 0xaf, 0x3a, 0x1a, 0xc7, 0x81, 0x2f, 0xdb, 0x76, 0x0d,
+	0x57, 0x81, 0xce, 0x08, 0x7a, 0xc6, 0x8b, 0xf6, 0x8b, 0x58, 0xfd, 0x9c, 0x7e, 0xfd, 0xa9, 0x05,
+	0x83, 0xc2, 0x9b, 0xf9, 0x0b, 0xcd, 0x61, 0x3a, 0xac, 0x5e, 0xe9, 0xb0, 0x46, 0xa5, 0xc3, 0x9a,
+	0x45, 0x87, 0x39, 0x77, 0x01, 0xf2, 0xa7, 0xf7, 0xca, 0xe8, 0x39, 0x6f, 0x3e, 0x6a, 0x2c, 0xb5,
+	0xe6, 0xfb, 0xb0, 0x5e, 0x7c, 0x86, 0x5f, 0x6a, 0x4d, 0xf5, 0xd3, 0xa2, 0x0d, 0xed, 0x31, 0xf3,
+	0xfc, 0x31, 0x53, 0xa5, 0x87, 0x02, 0x1d, 0x47, 0x86, 0x90, 0xea, 0x94, 0x56, 0xe8, 0xe8, 0xfc,
+	0xd3, 0x82, 0x8e, 0x7a, 0xc7, 0x47, 0x51, 0x5e, 0xc2, 0xf4, 0xa3, 0xd4, 0xc0, 0x55, 0x20, 0x16,
+	0x65, 0x29, 0x0b, 0x26, 0x23, 0x8c, 0xe3, 0x50, 0xb5, 0x43, 0x01, 0x51, 0xbb, 0x1c, 0x83, 0x27,
+	0x42, 0x94, 0xcd, 0x58, 0xa2, 0x28, 0xc4, 0x79, 0xd1, 0xe3, 0x38, 0x49, 0xb2, 0xfa, 0x99, 0xb3,
+	0x9c, 0x0b, 0x36, 0x8f, 0xf6, 0xbc, 0x58, 0x3e, 0x4b, 0x2e, 0xdf, 0x80, 0x06, 0xfe, 0x86, 0x4a,
+	0x06, 0xd8, 0xb3, 0x0e, 0x53, 0x16, 0xa6, 0xf3, 0x74, 0xb8, 0x46, 0x7a, 0xfa, 0x45, 0x70, 0x68,
+	0x91, 0x0e, 0x34, 0xd2, 0xc3, 0xd0, 0x1b, 0xd6, 0x08, 0x60, 0x2e, 0x92, 0x30, 0x2f, 0x1b, 0xd6,
+	0x2f, 0x9f, 0x81, 0x8e, 0x7a, 0x51, 0x23, 0x6d, 0xa8, 0xa7, 0x2c, 0x1b, 0xae, 0x71, 0x02, 0x16,
+	0xb0, 0x8c, 0x0d, 0xad, 0xcb, 0x57, 0xcd, 0x9a, 0x58, 0x95, 0x89, 0x5c, 0x72, 0xc2, 0xf0, 0x80,
+	0x19, 0xae, 0xe1, 0xac, 0xba, 0x50, 0x1a, 0x5a, 0x97, 0x6f, 0x80, 0xbd, 0x2c, 0xfd, 0xc2, 0x29,
+	0x0e, 0x68, 0x30, 0x5c, 0x43, 0x6d, 0x98, 0x37, 0x8b, 0x86, 0x16, 0xe9, 0x42, 0x33, 0x61, 0x74,
+	0x7c, 0x38, 0xac, 0x5d, 0xbe, 0x09, 0xf6, 0xb2, 0x44, 0x05, 0x19, 0xf6, 0x04, 0x6b, 0x1b, 0xea,
+	0x74, 0xfe, 0x54, 0x58, 0xe4, 0x45, 0x7e, 0x38, 0xac, 0xe1, 0x17, 0xb6, 0x11, 0x86, 0xf5, 0xbd,
+	0x16, 0xff, 0x83, 0xf7, 0x9d, 0x7f, 0x0f, 0x00, 0x99, 0x97, 0xce, 0x4c, 0xd2, 0x2b, 0x00, 0x00,
 }
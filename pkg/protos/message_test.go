@@ -0,0 +1,31 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/anthdm/consenter/pkg/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionSign(t *testing.T) {
+	priv := common.NewPrivateKey([]byte("client"))
+	tx, err := NewSignedTransaction(priv, 1, []byte("payload"))
+	assert.Nil(t, err)
+	pub, err := tx.Verify()
+	assert.Nil(t, err)
+	assert.Equal(t, priv.PublicKey, *pub)
+
+	// Any change of the transaction invalidates the signature.
+	tx.Sequence = 2
+	_, err = tx.Verify()
+	assert.Equal(t, errInvalidSignature, err)
+
+	// A signature of another sender.
+	tx.Sequence = 1
+	tx.Sender = common.MarshalPublicKey(&common.NewPrivateKey([]byte("other")).PublicKey)
+	_, err = tx.Verify()
+	assert.Equal(t, errInvalidSignature, err)
+
+	_, err = NewTransaction().Verify()
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,181 @@
+package state
+
+import (
+	"encoding/binary"
+	"sort"
+	"sync"
+
+	pb "github.com/anthdm/consenter/pkg/protos"
+	proto "github.com/golang/protobuf/proto"
+)
+
+// Leaves of the state root are prefixed by their kind.
+const (
+	pairLeaf     = 0x00
+	sequenceLeaf = 0x01
+)
+
+// KVStore is a key-value state machine. Transactions set and delete keys with
+// their ops, which are applied in the order of the block. Ops without a key
+// are ignored, as well as the transactions without a sender and the ones
+// that do not follow the sequence of their sender. The state root is the root
+// of a sparse Merkle tree of the pairs and the sequence numbers, which is
+// updated with each op and is nil for the empty state.
+type KVStore struct {
+	lock sync.RWMutex
+	data map[string][]byte
+	// Sequence numbers of the next transaction of each sender by its
+	// marshaled public key.
+	sequences map[string]uint64
+	tree      *sparseTree
+}
+
+// NewKVStore returns a new empty KVStore.
+func NewKVStore() *KVStore {
+	return &KVStore{
+		data:      make(map[string][]byte),
+		sequences: make(map[string]uint64),
+		tree:      &sparseTree{},
+	}
+}
+
+// Apply implements the StateMachine interface.
+func (s *KVStore) Apply(b *pb.Block) ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, tx := range b.Transactions {
+		sender := string(tx.Sender)
+		if len(sender) == 0 || tx.Sequence != s.sequences[sender] {
+			continue
+		}
+		s.sequences[sender]++
+		s.tree.set(leafKey(sequenceLeaf, sender), seqLeaf(sender, s.sequences[sender]))
+		for _, op := range tx.Ops {
+			if len(op.Key) == 0 {
+				continue
+			}
+			key := string(op.Key)
+			switch op.Type {
+			case pb.KVOpType_set:
+				s.data[key] = append([]byte{}, op.Value...)
+				s.tree.set(leafKey(pairLeaf, key), leaf(pairLeaf, key, s.data[key]))
+			case pb.KVOpType_delete:
+				delete(s.data, key)
+				s.tree.remove(leafKey(pairLeaf, key))
+			}
+		}
+	}
+	return s.tree.hash(), nil
+}
+
+// Query implements the StateMachine interface.
+func (s *KVStore) Query(key []byte) ([]byte, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	val, ok := s.data[string(key)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte{}, val...), nil
+}
+
+// Sequence implements the StateMachine interface.
+func (s *KVStore) Sequence(sender []byte) uint64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.sequences[string(sender)]
+}
+
+// Root returns the root of the current state.
+func (s *KVStore) Root() []byte {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.tree.hash()
+}
+
+// Snapshot implements the StateMachine interface.
+func (s *KVStore) Snapshot() ([]byte, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	snap := &pb.KVSnapshot{}
+	for _, key := range s.keys() {
+		snap.Pairs = append(snap.Pairs, &pb.KVPair{
+			Key:   []byte(key),
+			Value: s.data[key],
+		})
+	}
+	for _, sender := range s.senders() {
+		snap.Sequences = append(snap.Sequences, &pb.KVSequence{
+			Sender:   []byte(sender),
+			Sequence: s.sequences[sender],
+		})
+	}
+	return proto.Marshal(snap)
+}
+
+// Restore implements the StateMachine interface.
+func (s *KVStore) Restore(b []byte) error {
+	snap := &pb.KVSnapshot{}
+	if err := proto.Unmarshal(b, snap); err != nil {
+		return err
+	}
+	var (
+		data      = make(map[string][]byte, len(snap.Pairs))
+		sequences = make(map[string]uint64, len(snap.Sequences))
+		tree      = &sparseTree{}
+	)
+	for _, pair := range snap.Pairs {
+		key := string(pair.Key)
+		data[key] = pair.Value
+		tree.set(leafKey(pairLeaf, key), leaf(pairLeaf, key, pair.Value))
+	}
+	for _, seq := range snap.Sequences {
+		sender := string(seq.Sender)
+		sequences[sender] = seq.Sequence
+		tree.set(leafKey(sequenceLeaf, sender), seqLeaf(sender, seq.Sequence))
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.data = data
+	s.sequences = sequences
+	s.tree = tree
+	return nil
+}
+
+func (s *KVStore) keys() []string {
+	keys := make([]string, 0, len(s.data))
+	for key := range s.data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *KVStore) senders() []string {
+	senders := make([]string, 0, len(s.sequences))
+	for sender := range s.sequences {
+		senders = append(senders, sender)
+	}
+	sort.Strings(senders)
+	return senders
+}
+
+// leaf encodes a leaf of the state root of the given kind, the length
+// prefixed key followed by the value.
+func leaf(kind byte, key string, val []byte) []byte {
+	return append(leafKey(kind, key), val...)
+}
+
+// leafKey encodes the key of a leaf, which determines its path in the tree.
+func leafKey(kind byte, key string) []byte {
+	var n [binary.MaxVarintLen64]byte
+	b := append([]byte{kind}, n[:binary.PutUvarint(n[:], uint64(len(key)))]...)
+	return append(b, key...)
+}
+
+// seqLeaf encodes the leaf of the sequence number of the sender.
+func seqLeaf(sender string, seq uint64) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], seq)
+	return leaf(sequenceLeaf, sender, b[:])
+}
@@ -0,0 +1,134 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/anthdm/consenter/pkg/common"
+	pb "github.com/anthdm/consenter/pkg/protos"
+	"github.com/stretchr/testify/assert"
+)
+
+var client = common.NewPrivateKey([]byte("client"))
+
+// newKVTx returns a tx of the client with the given sequence and ops.
+func newKVTx(sequence uint64, ops ...*pb.KVOp) *pb.Transaction {
+	tx := &pb.Transaction{
+		Sequence: sequence,
+		Ops:      ops,
+	}
+	if err := tx.Sign(client); err != nil {
+		panic(err)
+	}
+	return tx
+}
+
+// newKVBlock returns the block with the given index holding a tx with the
+// given ops. The client sends a tx in every block, hence its sequence is the
+// index of the previous block.
+func newKVBlock(index uint32, ops ...*pb.KVOp) *pb.Block {
+	b := pb.NewBlock(index - 1)
+	b.Transactions = []*pb.Transaction{newKVTx(uint64(index-1), ops...)}
+	return b
+}
+
+func set(key, val string) *pb.KVOp {
+	return &pb.KVOp{Type: pb.KVOpType_set, Key: []byte(key), Value: []byte(val)}
+}
+
+func del(key string) *pb.KVOp {
+	return &pb.KVOp{Type: pb.KVOpType_delete, Key: []byte(key)}
+}
+
+func TestKVStoreApply(t *testing.T) {
+	s := NewKVStore()
+	root, err := s.Apply(newKVBlock(1, set("foo", "1"), set("bar", "2"), set("", "3")))
+	assert.Nil(t, err)
+	assert.NotNil(t, root)
+	assert.Equal(t, root, s.Root())
+
+	val, err := s.Query([]byte("foo"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("1"), val)
+	_, err = s.Query([]byte(""))
+	assert.Equal(t, ErrNotFound, err)
+
+	_, err = s.Apply(newKVBlock(2, del("foo")))
+	assert.Nil(t, err)
+	_, err = s.Query([]byte("foo"))
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestKVStoreRoot(t *testing.T) {
+	var (
+		a = NewKVStore()
+		b = NewKVStore()
+	)
+	assert.Nil(t, a.Root())
+	// The root does not depend on the order the keys are set in.
+	rootA, _ := a.Apply(newKVBlock(1, set("foo", "1"), set("bar", "2")))
+	rootB, _ := b.Apply(newKVBlock(1, set("bar", "2"), set("foo", "1")))
+	assert.Equal(t, rootA, rootB)
+
+	rootB, _ = b.Apply(newKVBlock(2, set("foo", "3")))
+	assert.NotEqual(t, rootA, rootB)
+	// Keys and values are not ambiguous.
+	c := NewKVStore()
+	rootC, _ := c.Apply(newKVBlock(1, set("fo", "o1"), set("bar", "2")))
+	assert.NotEqual(t, rootA, rootC)
+}
+
+func TestKVStoreSnapshot(t *testing.T) {
+	s := NewKVStore()
+	root, _ := s.Apply(newKVBlock(1, set("foo", "1"), set("bar", "2")))
+	snap, err := s.Snapshot()
+	assert.Nil(t, err)
+
+	s.Apply(newKVBlock(2, del("foo")))
+	assert.NotEqual(t, root, s.Root())
+	assert.Nil(t, s.Restore(snap))
+	assert.Equal(t, root, s.Root())
+
+	restored := NewKVStore()
+	assert.Nil(t, restored.Restore(snap))
+	assert.Equal(t, root, restored.Root())
+}
+
+func TestKVStoreSequence(t *testing.T) {
+	var (
+		s      = NewKVStore()
+		sender = common.MarshalPublicKey(&client.PublicKey)
+		b      = pb.NewBlock(0)
+	)
+	unsigned := pb.NewTransaction()
+	unsigned.Ops = []*pb.KVOp{set("unsigned", "1")}
+	b.Transactions = []*pb.Transaction{
+		unsigned,
+		newKVTx(1, set("early", "1")),
+		newKVTx(0, set("first", "1")),
+		newKVTx(0, set("replayed", "1")),
+		newKVTx(1, set("second", "1")),
+	}
+	_, err := s.Apply(b)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), s.Sequence(sender))
+	for _, key := range []string{"unsigned", "early", "replayed"} {
+		_, err := s.Query([]byte(key))
+		assert.Equal(t, ErrNotFound, err)
+	}
+	for _, key := range []string{"first", "second"} {
+		_, err := s.Query([]byte(key))
+		assert.Nil(t, err)
+	}
+
+	// The sequences are part of the root and the snapshot.
+	root := s.Root()
+	snap, err := s.Snapshot()
+	assert.Nil(t, err)
+	b = pb.NewBlock(1)
+	b.Transactions = []*pb.Transaction{newKVTx(2)}
+	s.Apply(b)
+	assert.NotEqual(t, root, s.Root())
+	assert.Nil(t, s.Restore(snap))
+	assert.Equal(t, root, s.Root())
+	assert.Equal(t, uint64(2), s.Sequence(sender))
+}
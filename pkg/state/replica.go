@@ -0,0 +1,131 @@
+package state
+
+import (
+	"fmt"
+	"sync"
+
+	pb "github.com/anthdm/consenter/pkg/protos"
+)
+
+const (
+	// snapshotDepth is the amount of heights below the current one the state
+	// can be rolled back to without replaying the chain from its start.
+	snapshotDepth = 64
+
+	// snapshotInterval is the amount of heights between two snapshots.
+	snapshotInterval = 16
+)
+
+// Replica applies the blocks of a chain to a state machine and keeps the
+// state root of every height. A snapshot is taken every snapshotInterval
+// heights, so the state can be rolled back to a snapshot below the common
+// ancestor on a reorg, from which the blocks are applied again.
+type Replica struct {
+	sm StateMachine
+
+	lock sync.RWMutex
+	// State roots by height, the initial state at height 0 has no root.
+	roots     [][]byte
+	snapshots map[uint32][]byte
+}
+
+// NewReplica returns a new Replica at height 0, of which the current state of
+// the state machine is the initial state.
+func NewReplica(sm StateMachine) (*Replica, error) {
+	snap, err := sm.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &Replica{
+		sm:        sm,
+		roots:     [][]byte{nil},
+		snapshots: map[uint32][]byte{0: snap},
+	}, nil
+}
+
+// Height returns the index of the last applied block.
+func (r *Replica) Height() uint32 {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.height()
+}
+
+// Apply applies the block following the last applied block to the state
+// machine and returns the new state root.
+func (r *Replica) Apply(b *pb.Block) ([]byte, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	height := r.height()
+	if b.Header == nil || b.Header.Index != height+1 {
+		return nil, fmt.Errorf("block does not follow the state at height %d", height)
+	}
+	root, err := r.sm.Apply(b)
+	if err != nil {
+		return nil, err
+	}
+	r.roots = append(r.roots, root)
+	if h := height + 1; h%snapshotInterval == 0 {
+		snap, err := r.sm.Snapshot()
+		if err != nil {
+			return nil, err
+		}
+		r.snapshots[h] = snap
+		// The initial state is kept to replay the chain from its start.
+		if h > snapshotDepth {
+			delete(r.snapshots, h-snapshotDepth)
+		}
+	}
+	return root, nil
+}
+
+// Rollback rolls the state back to the given height, or below to the last
+// snapshot before it. It returns the height the state is rolled back to,
+// after which the blocks above it need to be applied again.
+func (r *Replica) Rollback(height uint32) (uint32, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if height >= r.height() {
+		return r.height(), nil
+	}
+	height -= height % snapshotInterval
+	if _, ok := r.snapshots[height]; !ok {
+		height = 0
+	}
+	if err := r.sm.Restore(r.snapshots[height]); err != nil {
+		return 0, err
+	}
+	for h := height + 1; h <= r.height(); h++ {
+		delete(r.snapshots, h)
+	}
+	r.roots = r.roots[:height+1]
+	return height, nil
+}
+
+// Root returns the state root after applying the block at the given height.
+func (r *Replica) Root(height uint32) ([]byte, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if height > r.height() {
+		return nil, fmt.Errorf("no state at height %d", height)
+	}
+	return r.roots[height], nil
+}
+
+// Query returns the value of the key in the current state.
+func (r *Replica) Query(key []byte) ([]byte, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.sm.Query(key)
+}
+
+// Sequence returns the sequence number of the next transaction of the sender
+// in the current state.
+func (r *Replica) Sequence(sender []byte) uint64 {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.sm.Sequence(sender)
+}
+
+func (r *Replica) height() uint32 {
+	return uint32(len(r.roots) - 1)
+}
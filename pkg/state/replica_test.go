@@ -0,0 +1,59 @@
+package state
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplicaApply(t *testing.T) {
+	r, err := NewReplica(NewKVStore())
+	assert.Nil(t, err)
+	_, err = r.Apply(newKVBlock(2, set("foo", "1")))
+	assert.NotNil(t, err)
+
+	root, err := r.Apply(newKVBlock(1, set("foo", "1")))
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(1), r.Height())
+	found, err := r.Root(1)
+	assert.Nil(t, err)
+	assert.Equal(t, root, found)
+	_, err = r.Root(2)
+	assert.NotNil(t, err)
+}
+
+func TestReplicaRollback(t *testing.T) {
+	r, err := NewReplica(NewKVStore())
+	assert.Nil(t, err)
+	var roots [][]byte
+	for i := 1; i <= snapshotDepth+10; i++ {
+		root, err := r.Apply(newKVBlock(uint32(i), set(fmt.Sprintf("key %d", i), "1")))
+		assert.Nil(t, err)
+		roots = append(roots, root)
+	}
+	// Only the snapshots of the recent heights are kept.
+	assert.Equal(t, snapshotDepth/snapshotInterval+1, len(r.snapshots))
+
+	// The state is rolled back to the last snapshot before the height.
+	height, err := r.Rollback(20)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(16), height)
+	assert.Equal(t, uint32(16), r.Height())
+	_, err = r.Query([]byte("key 16"))
+	assert.Nil(t, err)
+	_, err = r.Query([]byte("key 17"))
+	assert.Equal(t, ErrNotFound, err)
+
+	// The state is rebuilt from the start once the snapshots are gone.
+	for i := 17; i <= snapshotDepth+10; i++ {
+		root, err := r.Apply(newKVBlock(uint32(i), set(fmt.Sprintf("key %d", i), "1")))
+		assert.Nil(t, err)
+		assert.Equal(t, roots[i-1], root)
+	}
+	height, err = r.Rollback(5)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(0), height)
+	_, err = r.Query([]byte("key 1"))
+	assert.Equal(t, ErrNotFound, err)
+}
@@ -0,0 +1,137 @@
+package state
+
+import (
+	"bytes"
+
+	"github.com/anthdm/consenter/pkg/common"
+)
+
+// Prefixes of the hashed leaves and inner nodes of the sparse tree, which
+// prevent an inner node from being passed off as a leaf.
+const (
+	sparseLeaf  byte = 0x00
+	sparseInner byte = 0x01
+)
+
+// emptyHash is the hash of an empty subtree.
+var emptyHash = make([]byte, 32)
+
+// sparseTree is a sparse Merkle tree, in which each leaf is placed at the
+// path of the hash of its key. A subtree holding a single leaf is replaced by
+// the leaf, hence the tree only grows as deep as the paths of its leaves have
+// common prefixes. The root does not depend on the order the leaves are
+// inserted in, and only the hashes on the path of a leaf are updated with it.
+type sparseTree struct {
+	root *sparseNode
+}
+
+type sparseNode struct {
+	left, right *sparseNode
+	// Path of the leaf, nil for inner nodes.
+	path []byte
+	hash []byte
+}
+
+// hash returns the root hash of the tree, which is nil if the tree is empty.
+func (t *sparseTree) hash() []byte {
+	if t.root == nil {
+		return nil
+	}
+	return t.root.hash
+}
+
+// set sets the leaf of the given key.
+func (t *sparseTree) set(key, leaf []byte) {
+	t.root = insertNode(t.root, &sparseNode{
+		path: common.Hash256(key),
+		hash: hashSparse(sparseLeaf, leaf),
+	}, 0)
+}
+
+// remove removes the leaf of the given key.
+func (t *sparseTree) remove(key []byte) {
+	t.root = removeNode(t.root, common.Hash256(key), 0)
+}
+
+func insertNode(n, leaf *sparseNode, depth int) *sparseNode {
+	if n == nil {
+		return leaf
+	}
+	if n.path != nil {
+		if bytes.Equal(n.path, leaf.path) {
+			return leaf
+		}
+		// Both leaves move down until their paths diverge.
+		inner := &sparseNode{}
+		inner.setChild(bit(n.path, depth), n)
+		n = inner
+	}
+	b := bit(leaf.path, depth)
+	n.setChild(b, insertNode(n.child(b), leaf, depth+1))
+	n.rehash()
+	return n
+}
+
+func removeNode(n *sparseNode, path []byte, depth int) *sparseNode {
+	if n == nil {
+		return nil
+	}
+	if n.path != nil {
+		if bytes.Equal(n.path, path) {
+			return nil
+		}
+		return n
+	}
+	b := bit(path, depth)
+	n.setChild(b, removeNode(n.child(b), path, depth+1))
+	// A single remaining leaf moves up.
+	switch {
+	case n.left == nil && n.right == nil:
+		return nil
+	case n.left == nil && n.right.path != nil:
+		return n.right
+	case n.right == nil && n.left.path != nil:
+		return n.left
+	}
+	n.rehash()
+	return n
+}
+
+func (n *sparseNode) child(b byte) *sparseNode {
+	if b == 0 {
+		return n.left
+	}
+	return n.right
+}
+
+func (n *sparseNode) setChild(b byte, c *sparseNode) {
+	if b == 0 {
+		n.left = c
+	} else {
+		n.right = c
+	}
+}
+
+func (n *sparseNode) rehash() {
+	left, right := emptyHash, emptyHash
+	if n.left != nil {
+		left = n.left.hash
+	}
+	if n.right != nil {
+		right = n.right.hash
+	}
+	n.hash = hashSparse(sparseInner, left, right)
+}
+
+// bit returns the bit of the path at the given depth.
+func bit(path []byte, depth int) byte {
+	return path[depth/8] >> uint(7-depth%8) & 1
+}
+
+func hashSparse(prefix byte, parts ...[]byte) []byte {
+	b := []byte{prefix}
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return common.Hash256(b)
+}
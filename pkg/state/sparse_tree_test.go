@@ -0,0 +1,48 @@
+package state
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSparseTree(t *testing.T) {
+	tree := &sparseTree{}
+	assert.Nil(t, tree.hash())
+	tree.set([]byte("foo"), []byte("1"))
+	assert.Equal(t, hashSparse(sparseLeaf, []byte("1")), tree.hash())
+	tree.remove([]byte("bar"))
+	assert.Equal(t, hashSparse(sparseLeaf, []byte("1")), tree.hash())
+	tree.remove([]byte("foo"))
+	assert.Nil(t, tree.hash())
+}
+
+func TestSparseTreeOrder(t *testing.T) {
+	var (
+		a    = &sparseTree{}
+		b    = &sparseTree{}
+		keys = make([][]byte, 100)
+	)
+	for i := range keys {
+		keys[i] = []byte(fmt.Sprintf("key %d", i))
+		a.set(keys[i], keys[i])
+	}
+	// The root does not depend on the order of the leaves, nor on the
+	// leaves that were removed again.
+	for _, i := range rand.Perm(len(keys)) {
+		b.set(keys[i], keys[i])
+		b.set([]byte(fmt.Sprintf("removed %d", i)), keys[i])
+	}
+	assert.NotEqual(t, a.hash(), b.hash())
+	for _, i := range rand.Perm(len(keys)) {
+		b.remove([]byte(fmt.Sprintf("removed %d", i)))
+	}
+	assert.Equal(t, a.hash(), b.hash())
+
+	b.set(keys[0], []byte("changed"))
+	assert.NotEqual(t, a.hash(), b.hash())
+	b.set(keys[0], keys[0])
+	assert.Equal(t, a.hash(), b.hash())
+}
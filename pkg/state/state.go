@@ -0,0 +1,31 @@
+package state
+
+import (
+	"errors"
+
+	pb "github.com/anthdm/consenter/pkg/protos"
+)
+
+// ErrNotFound is returned when querying a key that is not part of the state.
+var ErrNotFound = errors.New("key not found")
+
+// StateMachine is a deterministic application replicated by the nodes of the
+// network. Every node applies the same committed blocks in the same order,
+// hence honest nodes end up with the same state root at each height.
+type StateMachine interface {
+	// Apply executes the transactions of the block and returns the root of
+	// the resulting state.
+	Apply(*pb.Block) ([]byte, error)
+	// Query returns the value of the key in the current state.
+	Query(key []byte) ([]byte, error)
+	// Sequence returns the sequence number of the next transaction of the
+	// sender with the given marshaled public key. The transactions of a
+	// sender are applied in the order of their sequence starting at 0, the
+	// ones that do not follow it are ignored.
+	Sequence(sender []byte) uint64
+	// Snapshot returns the current state, which can be restored with
+	// Restore.
+	Snapshot() ([]byte, error)
+	// Restore replaces the current state with the snapshot.
+	Restore([]byte) error
+}
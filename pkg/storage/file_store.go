@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+)
+
+// maxRecordSize is the maximum size of a key or value, larger sizes can only
+// be read from a corrupted file.
+const maxRecordSize = 1 << 26
+
+var (
+	errCorrupted = errors.New("corrupted record")
+	errChecksum  = errors.New("checksum mismatch")
+)
+
+// FileStore is a Store persisted to an append only file. All entries are
+// held in memory as well, the file is replayed once the store is opened.
+type FileStore struct {
+	lock sync.RWMutex
+	mem  map[string][]byte
+	file *os.File
+}
+
+// NewFileStore opens the store at the given path, creating the file if it
+// does not exist.
+func NewFileStore(path string) (*FileStore, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	s := &FileStore{
+		mem:  make(map[string][]byte),
+		file: file,
+	}
+	if err := s.replay(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// replay reads the entries of the file. A partially written entry at the
+// end of the file, left by a crash, is cut off. Entries that are corrupted
+// anywhere else fail the replay, as cutting them off would lose the entries
+// following them.
+func (s *FileStore) replay() error {
+	var (
+		r      = bufio.NewReader(s.file)
+		offset int64
+	)
+	for {
+		key, val, n, err := readEntry(r)
+		// Either the end of the file or an entry that was partially written
+		// there.
+		if err == io.EOF || err == io.ErrUnexpectedEOF || (err == errChecksum && atEOF(r)) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("entry at offset %d: %s", offset, err)
+		}
+		s.mem[string(key)] = val
+		offset += int64(n)
+	}
+	if err := s.file.Truncate(offset); err != nil {
+		return err
+	}
+	_, err := s.file.Seek(offset, io.SeekStart)
+	return err
+}
+
+// Get implements the Store interface.
+func (s *FileStore) Get(key []byte) ([]byte, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if val, ok := s.mem[string(key)]; ok {
+		return copyBytes(val), nil
+	}
+	return nil, ErrNotFound
+}
+
+// Put implements the Store interface. The entry is synced to disk before
+// Put returns.
+func (s *FileStore) Put(key, val []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	buf := appendRecord(appendRecord(nil, key), val)
+	buf = appendChecksum(buf)
+	if _, err := s.file.Write(buf); err != nil {
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+	s.mem[string(key)] = copyBytes(val)
+	return nil
+}
+
+// Has implements the Store interface.
+func (s *FileStore) Has(key []byte) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	_, ok := s.mem[string(key)]
+	return ok
+}
+
+// Close closes the underlying file.
+func (s *FileStore) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.file.Close()
+}
+
+// appendRecord appends b prefixed with its length.
+func appendRecord(buf, b []byte) []byte {
+	var n [binary.MaxVarintLen64]byte
+	buf = append(buf, n[:binary.PutUvarint(n[:], uint64(len(b)))]...)
+	return append(buf, b...)
+}
+
+// appendChecksum appends the checksum of the entry in buf.
+func appendChecksum(buf []byte) []byte {
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc32.ChecksumIEEE(buf))
+	return append(buf, sum[:]...)
+}
+
+// readEntry reads the key and value of an entry and verifies its checksum.
+// It returns io.EOF only if the reader is at the end of the file, entries
+// that are cut off return io.ErrUnexpectedEOF.
+func readEntry(r *bufio.Reader) ([]byte, []byte, int, error) {
+	key, n, err := readRecord(r)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	val, m, err := readRecord(r)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	var sum [4]byte
+	if _, err := io.ReadFull(r, sum[:]); err != nil {
+		return nil, nil, 0, io.ErrUnexpectedEOF
+	}
+	buf := appendRecord(appendRecord(nil, key), val)
+	if binary.BigEndian.Uint32(sum[:]) != crc32.ChecksumIEEE(buf) {
+		return nil, nil, 0, errChecksum
+	}
+	return key, val, n + m + len(sum), nil
+}
+
+// atEOF reports whether the reader is at the end of the file.
+func atEOF(r *bufio.Reader) bool {
+	_, err := r.Peek(1)
+	return err == io.EOF
+}
+
+// readRecord reads a length prefixed record and returns it along with the
+// amount of bytes read.
+func readRecord(r *bufio.Reader) ([]byte, int, error) {
+	size, err := binary.ReadUvarint(r)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil, 0, err
+	}
+	if err != nil {
+		return nil, 0, errCorrupted
+	}
+	if size > maxRecordSize {
+		return nil, 0, errCorrupted
+	}
+	b := make([]byte, size)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	var n [binary.MaxVarintLen64]byte
+	return b, binary.PutUvarint(n[:], size) + int(size), nil
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "store.db")
+
+	s, err := NewFileStore(path)
+	assert.Nil(t, err)
+	for i := 0; i < 100; i++ {
+		key := []byte(fmt.Sprintf("%d", i))
+		assert.Nil(t, s.Put(key, key))
+	}
+	assert.Nil(t, s.Put([]byte("0"), []byte("overwritten")))
+	assert.Nil(t, s.Close())
+
+	// Append a partially written entry.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.Nil(t, err)
+	_, err = f.Write([]byte{5, 'k'})
+	assert.Nil(t, err)
+	assert.Nil(t, f.Close())
+
+	s, err = NewFileStore(path)
+	assert.Nil(t, err)
+	defer s.Close()
+	for i := 1; i < 100; i++ {
+		key := []byte(fmt.Sprintf("%d", i))
+		val, err := s.Get(key)
+		assert.Nil(t, err)
+		assert.Equal(t, key, val)
+	}
+	val, err := s.Get([]byte("0"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("overwritten"), val)
+	_, err = s.Get([]byte("k"))
+	assert.Equal(t, ErrNotFound, err)
+
+	// Entries written after the cut off one are kept.
+	assert.Nil(t, s.Put([]byte("k"), []byte("v")))
+	assert.True(t, s.Has([]byte("k")))
+}
+
+func TestFileStoreCorrupted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "store.db")
+
+	s, err := NewFileStore(path)
+	assert.Nil(t, err)
+	assert.Nil(t, s.Put([]byte("a"), []byte("1")))
+	assert.Nil(t, s.Put([]byte("b"), []byte("2")))
+	assert.Nil(t, s.Close())
+
+	// The value of the first entry is flipped, which is not at the end of
+	// the file and can not be cut off.
+	b, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	b[3] = '9'
+	assert.Nil(t, ioutil.WriteFile(path, b, 0644))
+	_, err = NewFileStore(path)
+	assert.NotNil(t, err)
+
+	// The same corruption of the last entry is cut off, as it was not
+	// completely written before a crash.
+	b[3] = '1'
+	b[len(b)-5] = '9'
+	assert.Nil(t, ioutil.WriteFile(path, b, 0644))
+	s, err = NewFileStore(path)
+	assert.Nil(t, err)
+	defer s.Close()
+	assert.True(t, s.Has([]byte("a")))
+	assert.False(t, s.Has([]byte("b")))
+}
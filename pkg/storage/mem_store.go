@@ -1,11 +1,6 @@
 package storage
 
-import (
-	"errors"
-	"sync"
-)
-
-var errNotFound = errors.New("not found")
+import "sync"
 
 // MemStore is an in-memory Store implementation.
 type MemStore struct {
@@ -28,7 +23,7 @@ func (s *MemStore) Get(key []byte) ([]byte, error) {
 	if val, ok := s.mem[string(key)]; ok {
 		return copyBytes(val), nil
 	}
-	return nil, errNotFound
+	return nil, ErrNotFound
 }
 
 // Put implements the Store interface.
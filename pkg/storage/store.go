@@ -0,0 +1,17 @@
+package storage
+
+import "errors"
+
+// ErrNotFound is returned by Get if the key is not in the store.
+var ErrNotFound = errors.New("not found")
+
+// Store is a key value store.
+type Store interface {
+	// Get returns the value of the key, ErrNotFound if the key is not in
+	// the store.
+	Get(key []byte) ([]byte, error)
+	// Put sets the value of the key.
+	Put(key, val []byte) error
+	// Has reports whether the key is in the store.
+	Has(key []byte) bool
+}